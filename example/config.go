@@ -0,0 +1,245 @@
+package example
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var (
+	ErrNoRoots         = errors.New("no roots")
+	ErrInvalidParallel = errors.New("parallel must be at least 1")
+	ErrUnknownAlgo     = errors.New("unknown hash algorithm")
+	ErrUnknownFormat   = errors.New("unknown output format")
+)
+
+const (
+	// FormatText строка "путь: хеш"
+	FormatText = "text"
+	// FormatJSON JSON-объект {"path", "algo", "hash"} на строку
+	FormatJSON = "json"
+)
+
+// hashers поддерживаемые алгоритмы хеширования
+var hashers = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Config параметры пайплайна подсчёта хешей файлов
+type Config struct {
+	// Roots директории для обхода
+	Roots []string
+	// Parallel количество параллельных узлов подсчёта хешей
+	Parallel int
+	// Algo алгоритм хеширования: md5 (по умолчанию), sha1, sha256, sha512
+	Algo string
+	// Include, Exclude шаблоны filepath.Match для имени файла: файл обрабатывается, если
+	// подходит под один из Include (или Include пуст) и не подходит ни под один из Exclude
+	Include []string
+	Exclude []string
+	// Timeout ограничение времени работы пайплайна, 0 — без ограничения (по умолчанию).
+	// Применяется вызывающим кодом к контексту Run.
+	Timeout time.Duration
+	// Output файл для результатов, пустая строка — стандартный вывод. Используется вызывающим кодом.
+	Output string
+	// Format формат строк результата: FormatText (по умолчанию) или FormatJSON
+	Format string
+	// Trace добавляет к строкам результата время прохождения файла через пайплайн (pipeline.Traced):
+	// суффикс " (<задержка>)" в формате FormatText и поле "latency" в FormatJSON
+	Trace bool
+}
+
+// Validate проверяет параметры: Parallel >= 1, корни существуют и являются директориями,
+// алгоритм, формат и шаблоны корректны
+func (c Config) Validate() error {
+	if c.Parallel < 1 {
+		return fmt.Errorf("%w: %d", ErrInvalidParallel, c.Parallel)
+	}
+	if len(c.Roots) == 0 {
+		return ErrNoRoots
+	}
+	for _, root := range c.Roots {
+		info, err := os.Stat(root)
+		if err != nil {
+			return fmt.Errorf("root: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("root: %s is not a directory", root)
+		}
+	}
+	if _, ok := hashers[c.algo()]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownAlgo, c.Algo)
+	}
+	if f := c.format(); f != FormatText && f != FormatJSON {
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, c.Format)
+	}
+	for _, pattern := range append(append([]string(nil), c.Include...), c.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// HashFilePipelineFromConfig проверяет cfg и строит пайплайн подсчёта хешей в cfg.Parallel копиях
+// обработчика с опциями opts. Возвращает пайплайн и канал строк результата в формате cfg.Format.
+func HashFilePipelineFromConfig(cfg Config, opts ...node.Option) (*pipeline.Pipeline, <-chan string, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.Trace {
+		hash := pipeline.TraceMap("Hasher", cfg.hashFile)
+		return hashPipeline(pipeline.TraceSource("Source", cfg.walkRoots), cfg.Parallel,
+			func(ctx context.Context, in pipeline.Traced[string], emit *node.Emitter[string]) error {
+				out, err := hash(ctx, in)
+				if err != nil {
+					return err
+				}
+				return cfg.traceReport(out, emit)
+			}, opts...)
+	}
+	return hashPipeline(cfg.walkRoots, cfg.Parallel, func(ctx context.Context, path string, emit *node.Emitter[string]) error {
+		line, err := cfg.hashFile(ctx, path)
+		if err != nil {
+			return err
+		}
+		return emit.Send(line)
+	}, opts...)
+}
+
+// traceReport для Trace извлекает строку результата из конверта и добавляет к ней задержку от
+// входа в пайплайн
+func (c Config) traceReport(in pipeline.Traced[string], emit *node.Emitter[string]) error {
+	latency := time.Since(in.EnteredAt).Round(time.Microsecond)
+	if c.format() != FormatJSON {
+		return emit.Send(fmt.Sprintf("%s (%s)", in.Val, latency))
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(in.Val), &fields); err != nil {
+		return err
+	}
+	fields["latency"] = latency.String()
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return emit.Send(string(b))
+}
+
+// walkRoots источник, обходящий cfg.Roots и отдающий пути обычных файлов, прошедших фильтры
+// (устройства, сокеты и символические ссылки пропускаются)
+func (c Config) walkRoots(ctx context.Context, output chan<- string, errChan chan<- error) {
+	emit := node.NewEmitter(ctx, output)
+	for _, root := range c.Roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errChan <- err
+				return nil
+			}
+			if !d.Type().IsRegular() || !c.match(d.Name()) {
+				return nil
+			}
+			return emit.Send(path)
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				errChan <- err
+			}
+			return
+		}
+	}
+}
+
+// match сообщает, проходит ли имя файла фильтры Include и Exclude
+func (c Config) match(name string) bool {
+	for _, pattern := range c.Exclude {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(c.Include) == 0 {
+		return true
+	}
+	for _, pattern := range c.Include {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hashFile подсчитывает хеш файла алгоритмом cfg.Algo и форматирует результат
+func (c Config) hashFile(ctx context.Context, path string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := hashers[c.algo()]()
+	if _, err := io.Copy(h, ctxReader{ctx: ctx, r: file}); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	if c.format() == FormatJSON {
+		line, err := json.Marshal(struct {
+			Path string `json:"path"`
+			Algo string `json:"algo"`
+			Hash string `json:"hash"`
+		}{path, c.algo(), sum})
+		return string(line), err
+	}
+	return fmt.Sprintf("%s: %s", path, sum), nil
+}
+
+// ctxReader прерывает чтение больших файлов при отмене контекста
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+func (c Config) algo() string {
+	if c.Algo == "" {
+		return "md5"
+	}
+	return c.Algo
+}
+
+func (c Config) format() string {
+	if c.Format == "" {
+		return FormatText
+	}
+	return c.Format
+}
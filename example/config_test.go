@@ -0,0 +1,68 @@
+package example
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "file.txt")
+	if err := os.WriteFile(file, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	valid := func(modify func(c *Config)) Config {
+		c := Config{Roots: []string{root}, Parallel: 2}
+		if modify != nil {
+			modify(&c)
+		}
+		return c
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+		ok   bool
+		// wantErr ошибка, которую оборачивает результат; nil при !ok — подходит любая ошибка
+		wantErr error
+	}{
+		{"defaults", valid(nil), true, nil},
+		{"all options", valid(func(c *Config) {
+			c.Algo, c.Format = "sha256", FormatJSON
+			c.Include, c.Exclude = []string{"*.txt"}, []string{"tmp*"}
+		}), true, nil},
+		{"zero parallel", valid(func(c *Config) { c.Parallel = 0 }), false, ErrInvalidParallel},
+		{"negative parallel", valid(func(c *Config) { c.Parallel = -1 }), false, ErrInvalidParallel},
+		{"no roots", valid(func(c *Config) { c.Roots = nil }), false, ErrNoRoots},
+		{"missing root", valid(func(c *Config) { c.Roots = append(c.Roots, filepath.Join(root, "missing")) }), false, fs.ErrNotExist},
+		{"root is a file", valid(func(c *Config) { c.Roots = []string{file} }), false, nil},
+		{"unknown algo", valid(func(c *Config) { c.Algo = "crc32" }), false, ErrUnknownAlgo},
+		{"unknown format", valid(func(c *Config) { c.Format = "xml" }), false, ErrUnknownFormat},
+		{"bad include", valid(func(c *Config) { c.Include = []string{"[a-"} }), false, filepath.ErrBadPattern},
+		{"bad exclude", valid(func(c *Config) { c.Exclude = []string{"["} }), false, filepath.ErrBadPattern},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			switch {
+			case tt.ok && err != nil:
+				t.Errorf("Validate: %v", err)
+			case !tt.ok && err == nil:
+				t.Error("Validate succeeded, want an error")
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("Validate = %v, want %v", err, tt.wantErr)
+			}
+
+			// конструктор не строит пайплайн по неверной конфигурации
+			p, out, err := HashFilePipelineFromConfig(tt.cfg)
+			if !tt.ok && (err == nil || p != nil || out != nil) {
+				t.Errorf("HashFilePipelineFromConfig = %v, %v, %v; want the validation error", p, out, err)
+			}
+			if tt.ok && err != nil {
+				t.Errorf("HashFilePipelineFromConfig: %v", err)
+			}
+		})
+	}
+}
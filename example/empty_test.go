@@ -0,0 +1,69 @@
+package example
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// TestHashEmptyDir прогон HashFilePipeline по пустой директории даёт пустой, но записанный манифест,
+// маркер готовности и явный ноль прочитанных элементов в сводке
+func TestHashEmptyDir(t *testing.T) {
+	paths := make(chan string, 1)
+	paths <- t.TempDir()
+	close(paths)
+	pipe, result, err := HashFilePipeline(2, []chan string{paths})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	manifestPath := filepath.Join(out, "manifest.txt")
+	markerPath := filepath.Join(out, "_SUCCESS")
+	manifest := node.AtomicFileSink("Manifest", manifestPath, func(w io.Writer, v string) error {
+		_, err := fmt.Fprintln(w, v)
+		return err
+	}, node.WithCompletionMarker(node.SuccessFileMarker(markerPath)))
+	if err := manifest.SetInput(0, result); err != nil {
+		t.Fatal(err)
+	}
+	if err := pipe.AddNode(manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan []error, 1)
+	go func() {
+		var all []error
+		for err := range pipe.ErrChan() {
+			all = append(all, err)
+		}
+		errs <- all
+	}()
+	if err := pipe.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	pipe.Wait()
+	if all := <-errs; len(all) > 0 {
+		t.Fatalf("errors: %v", all)
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("manifest %q, want empty", data)
+	}
+	if _, err := os.Stat(markerPath); err != nil {
+		t.Errorf("success marker not written: %v", err)
+	}
+	// узел со статистикой без единого элемента попадает в сводку с нулём
+	if n, ok := pipe.Summary().ItemsIn["Manifest"]; !ok || n != 0 {
+		t.Errorf("ItemsIn[Manifest] = %d, %v, want 0, true", n, ok)
+	}
+}
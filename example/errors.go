@@ -0,0 +1,36 @@
+package example
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/tom-lepsky/pipeline/pipeline"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// AttachErrorLog направляет поток ошибок пайплайна в ноду-приёмник, построчно пишущую ошибки в w
+// через буфер, который сбрасывается при завершении ноды. Ошибки самой ноды-приёмника остаются
+// в pipe.ErrChan()
+func AttachErrorLog(pipe *pipeline.Pipeline, w io.Writer) error {
+	errSource, err := pipe.ErrorSourceNode("Errors")
+	if err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriter(w)
+	errLog := node.NewSink("Error log", 1, func(ctx context.Context, e error) error {
+		_, err := fmt.Fprintln(buf, e)
+		return err
+	}, node.WithFlush(func(ctx context.Context) error {
+		return buf.Flush()
+	}))
+	err = node.Autowire(errSource, errLog)
+	if err != nil {
+		return err
+	}
+
+	pipe.AddErrorNode(errLog)
+	return nil
+}
@@ -3,91 +3,98 @@ package example
 import (
 	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
+	"iter"
 	"os"
 	"path/filepath"
 
 	"github.com/tom-lepsky/pipeline/pipeline"
 	"github.com/tom-lepsky/pipeline/pipeline/node"
+	"github.com/tom-lepsky/pipeline/pipeline/util"
 )
 
-// HashFilePipeline пайплайн для обхода заданных директорий и подсчета md5 хешей
-func HashFilePipeline(parallelHash int, paths []chan string, result []chan string) (*pipeline.Pipeline, error) {
-	// создаём узел для обхода директорий и привязываем к нему входы с потоком директорий
-	buffSize := make([]int, parallelHash)
-	for i := range buffSize {
-		buffSize[i] = 1
-	}
-	pathWalkerNode := node.New[string, string]("Path walker", 2, parallelHash, buffSize, PathReceiver)
-	err := pathWalkerNode.AutowireInput(paths...)
-	if err != nil {
-		return nil, err
-	}
+// HashFilePipeline пайплайн для обхода заданных директорий и подсчета md5 хешей в parallelHash
+// узлах-обработчиках, построенный на pipeline.MapReduce. Возвращает пайплайн и канал результатов
+func HashFilePipeline(parallelHash int, paths []chan string) (*pipeline.Pipeline, <-chan string, error) {
+	return pipeline.MapReduce(WalkPaths(paths), HashFile, parallelHash, node.LoopHandler(Demux))
+}
 
-	// создаем узел для объединения результатов параллельного подсчета хешей в 1 канал
-	// и привязываем результирующий канал(клиентский) к выходу ноды
-	demuxNode := node.New[string, string]("Demux", parallelHash, 1, []int{1}, Demux)
-	err = demuxNode.AutowireOutput(result...)
-	if err != nil {
-		return nil, err
-	}
+// HashFilePipelineFromSeq пайплайн подсчёта md5 хешей файлов, пути которых отдаёт paths
+// (например, slices.Values(paths)). Возвращает пайплайн и канал результатов
+func HashFilePipelineFromSeq(parallelHash int, paths iter.Seq[string]) (*pipeline.Pipeline, <-chan string, error) {
+	return hashPipeline(pipeline.SeqSource(paths), parallelHash, Hasher)
+}
 
-	// создаём узлы параллельно подсчитывающие хеши файлов и привязываем их выходы к демультиплексору
-	hasherNodes := make([]*node.Node[string, string], 0, parallelHash)
-	for i := 0; i < parallelHash; i++ {
-		h := node.New[string, string](fmt.Sprintf("Hasher %d", i), 1, 1, []int{1}, Hasher)
-		err := node.Autowire(&h, &demuxNode)
-		if err != nil {
-			return nil, err
-		}
-		hasherNodes = append(hasherNodes, &h)
+// hashPipeline соединяет источник source с узлом-пулом Hasher, выполняющим hash в workers копиях
+// (node.NewPool), и применяет opts к обоим узлам. Возвращает пайплайн и канал результатов
+func hashPipeline[I any](source node.SourceFn[I], workers int, hash node.LoopFn[I, string],
+	opts ...node.Option) (*pipeline.Pipeline, <-chan string, error) {
+	if workers < 1 {
+		return nil, nil, pipeline.ErrInvalidWorkers
 	}
 
-	// Привязываем ноды вычисляющие хеши к узлу, обходящему папки
-	err = node.Autowire(&pathWalkerNode, hasherNodes...)
-	if err != nil {
-		return nil, err
+	sourceNode := node.NewSource("Source", 1, []int{workers}, source, opts...)
+	hasherNode := node.NewPool("Hasher", workers, node.LoopHandler(hash), opts...)
+	result := make(chan string, workers)
+	if err := hasherNode.AutowireOutput(result); err != nil {
+		return nil, nil, err
 	}
-
-	runnable := make([]pipeline.Runnable, 0, parallelHash)
-	for i := 0; i < parallelHash; i++ {
-		runnable = append(runnable, hasherNodes[i])
+	if err := node.Autowire(sourceNode, hasherNode); err != nil {
+		return nil, nil, err
 	}
 
-	// Создаем пайплайн и добавляем в него все узлы
 	pipe := pipeline.New()
-	pipe.AddNode(&pathWalkerNode)
-	pipe.AddNode(runnable...)
-	pipe.AddNode(&demuxNode)
-
-	return &pipe, nil
+	if err := pipe.AddNode(sourceNode, hasherNode); err != nil {
+		return nil, nil, err
+	}
+	return pipe, result, nil
 }
 
-func PathReceiver(ctx context.Context, input <-chan string, output chan<- string, errChan chan<- error) {
-	defer close(output)
-	for path := range input {
-		select {
-		case <-ctx.Done():
-			errChan <- ctx.Err()
-			return
-		default:
-			dirWalk(ctx, path, output, errChan)
+// WalkPaths источник, обходящий директории из каналов paths и отдающий пути найденных файлов
+func WalkPaths(paths []chan string) node.SourceFn[string] {
+	inputs := make([]<-chan string, len(paths))
+	for i := range paths {
+		inputs[i] = paths[i]
+	}
+
+	return func(ctx context.Context, output chan<- string, errChan chan<- error) {
+		emit := node.NewEmitter(ctx, output)
+		for path := range util.FanIn(ctx, inputs...) {
+			if err := dirWalk(ctx, path, emit.Send); err != nil && ctx.Err() == nil {
+				errChan <- err
+			}
 		}
 	}
 }
 
-func dirWalk(ctx context.Context, path string, output chan<- string, errChan chan<- error) {
+// HashFile подсчитывает md5 хеш файла
+func HashFile(ctx context.Context, path string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s: %x", path, md5.Sum(file)), nil
+}
+
+// dirWalk обходит директорию path в ширину и отдаёт через emit пути найденных файлов.
+// Ошибки чтения вложенных директорий не прерывают обход и возвращаются вместе в конце
+func dirWalk(ctx context.Context, path string, emit func(string) error) error {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
-		errChan <- err
-		return
+		return err
 	}
 
 	if !fileInfo.IsDir() {
-		errChan <- fmt.Errorf("%s is not a directory", path)
-		return
+		return fmt.Errorf("%s is not a directory", path)
 	}
 
+	var errs []error
 	queue := make([]string, 0, 20)
 	queue = append(queue, path)
 	for len(queue) > 0 {
@@ -96,76 +103,36 @@ func dirWalk(ctx context.Context, path string, output chan<- string, errChan cha
 
 		directory, err := os.ReadDir(path)
 		if err != nil {
-			errChan <- err
+			errs = append(errs, err)
 			continue
 		}
 
 		for _, file := range directory {
-			select {
-			case <-ctx.Done():
-				errChan <- ctx.Err()
-				return
-			default:
-				fullPath := filepath.Join(path, file.Name())
-				if file.IsDir() {
-					queue = append(queue, fullPath)
-				} else {
-					output <- fullPath
-				}
+			fullPath := filepath.Join(path, file.Name())
+			if file.IsDir() {
+				queue = append(queue, fullPath)
+			} else if err := emit(fullPath); err != nil {
+				return err
 			}
 		}
 	}
+	return errors.Join(errs...)
 }
 
-func Hasher(ctx context.Context, input <-chan string, output chan<- string, errChan chan<- error) {
-	defer close(output)
-	for path := range input {
-		select {
-		case <-ctx.Done():
-			errChan <- ctx.Err()
-			return
-		default:
-			md5Hash(ctx, path, output, errChan)
-		}
-
-	}
-}
-
-func md5Hash(ctx context.Context, path string, output chan<- string, errChan chan<- error) {
-	var (
-		file []byte
-		err  error
-	)
-	select {
-	case <-ctx.Done():
-		errChan <- ctx.Err()
-		return
-	default:
-		file, err = os.ReadFile(path)
+// ListFiles рекурсивно обходит директорию dir и возвращает пути найденных файлов
+func ListFiles(ctx context.Context, dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
-			errChan <- err
+			return err
 		}
-	}
-
-	select {
-	case <-ctx.Done():
-		errChan <- ctx.Err()
-		return
-	default:
-		hash := md5.Sum(file)
-		output <- fmt.Sprintf("%s: %x", path, hash)
-	}
-}
-
-func Demux(ctx context.Context, input <-chan string, output chan<- string, errChan chan<- error) {
-	defer close(output)
-	for in := range input {
-		select {
-		case <-ctx.Done():
-			errChan <- ctx.Err()
-			return
-		default:
-			output <- in
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-	}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
 }
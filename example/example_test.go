@@ -0,0 +1,74 @@
+package example
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline"
+)
+
+// TestHashPipelines HashFilePipeline (pipeline.MapReduce) и HashFilePipelineFromSeq (узел-пул)
+// выдают хеши всех файлов дерева
+func TestHashPipelines(t *testing.T) {
+	const root = "../testdata"
+	files, err := ListFiles(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for _, f := range files {
+		line, err := HashFile(context.Background(), f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, line)
+	}
+	slices.Sort(want)
+
+	tests := []struct {
+		name  string
+		build func() (*pipeline.Pipeline, <-chan string, error)
+	}{
+		{"map reduce", func() (*pipeline.Pipeline, <-chan string, error) {
+			paths := make(chan string, 1)
+			paths <- root
+			close(paths)
+			return HashFilePipeline(3, []chan string{paths})
+		}},
+		{"pool", func() (*pipeline.Pipeline, <-chan string, error) {
+			return HashFilePipelineFromSeq(3, slices.Values(files))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipe, result, err := tt.build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := make(chan []error, 1)
+			go func() {
+				var all []error
+				for err := range pipe.ErrChan() {
+					all = append(all, err)
+				}
+				errs <- all
+			}()
+			if err := pipe.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for line := range result {
+				got = append(got, line)
+			}
+			pipe.Wait()
+			if all := <-errs; len(all) > 0 {
+				t.Fatalf("errors: %v", all)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("results %v, want %v", got, want)
+			}
+		})
+	}
+}
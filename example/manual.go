@@ -0,0 +1,62 @@
+package example
+
+import (
+	"context"
+
+	"github.com/tom-lepsky/pipeline/pipeline"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// HashFilePipelineManual пайплайн для обхода заданных директорий и подсчета md5 хешей,
+// собранный вручную из узлов. Эквивалентен HashFilePipeline
+func HashFilePipelineManual(parallelHash int, paths []chan string, result []chan string) (*pipeline.Pipeline, error) {
+	// создаём узел для обхода директорий и привязываем к нему входы с потоком директорий
+	pathWalkerNode := node.Loop[string, string]("Path walker", PathReceiver, node.WithPorts(2, 1),
+		node.WithOutputBuffers(parallelHash))
+	err := pathWalkerNode.AutowireInput(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	// создаём узел, параллельно подсчитывающий хеши файлов в parallelHash копиях обработчика,
+	// и привязываем результирующие каналы (клиентские) к его выходам
+	hasherNode := node.NewPool[string, string]("Hasher", parallelHash, node.LoopHandler(Hasher),
+		node.WithPorts(1, len(result)))
+	err = hasherNode.AutowireOutput(result...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = node.Autowire(pathWalkerNode, hasherNode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Создаем пайплайн и добавляем в него все узлы
+	pipe := pipeline.New()
+	err = pipe.AddNode(pathWalkerNode, hasherNode)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipe, nil
+}
+
+// PathReceiver обходит директорию path и отдаёт через emit пути найденных файлов
+func PathReceiver(ctx context.Context, path string, emit *node.Emitter[string]) error {
+	return dirWalk(ctx, path, emit.Send)
+}
+
+// Hasher подсчитывает md5 хеш файла path и отдаёт через emit строку "путь: хеш"
+func Hasher(ctx context.Context, path string, emit *node.Emitter[string]) error {
+	hash, err := HashFile(ctx, path)
+	if err != nil {
+		return err
+	}
+	return emit.Send(hash)
+}
+
+// Demux передаёт значения в выход без изменений, объединяя входы узла в один поток
+func Demux(_ context.Context, in string, emit *node.Emitter[string]) error {
+	return emit.Send(in)
+}
@@ -0,0 +1,81 @@
+// Периодический пайплайн: каждые interval заново обходит директории и выводит md5 хеши файлов.
+// Тик таймера разворачивается в список директорий, директории — в списки файлов.
+//
+//	go run ./example/periodic -interval 5s testdata/a testdata/c
+//
+// Завершается по Ctrl+C: источник тиков останавливается, уже выданные файлы дообрабатываются.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/example"
+	"github.com/tom-lepsky/pipeline/pipeline"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func main() {
+	interval := flag.Duration("interval", 5*time.Second, "rescan interval")
+	flag.Parse()
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	ticker := node.TickerSource("Ticker", *interval)
+	expand := node.NewFlatMap("Directories", func(ctx context.Context, tick time.Time) ([]string, error) {
+		fmt.Println("scan at", tick.Format(time.TimeOnly))
+		return dirs, nil
+	})
+	walk := node.NewFlatMap("Walker", example.ListFiles, node.WithOutputBuffers(10))
+	hash := node.NewMap("Hasher", example.HashFile, node.WithConcurrency(4))
+	printer := node.NewSink("Printer", 1, func(ctx context.Context, line string) error {
+		fmt.Println(line)
+		return nil
+	})
+
+	for _, err := range []error{
+		node.Autowire(ticker, expand),
+		node.Autowire(expand, walk),
+		node.Autowire(walk, hash),
+		node.Autowire(hash, printer),
+	} {
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	pipe := pipeline.New()
+	pipe.AddNode(ticker, expand, walk, hash, printer)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range pipe.ErrChan() {
+			fmt.Println(err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := pipe.Run(context.Background(), false); err != nil {
+		fmt.Println(err)
+		return
+	}
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := pipe.Shutdown(shutdownCtx); err != nil {
+		fmt.Println(err)
+	}
+	wg.Wait()
+}
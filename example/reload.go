@@ -0,0 +1,42 @@
+package example
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tom-lepsky/pipeline/pipeline/util"
+)
+
+// SuffixFilter обработчик для node.NewSelect с двумя входами: вход 0 поток путей, вход 1 сигнал
+// перезагрузки конфигурации с новым суффиксом. Пропускает пути с текущим суффиксом (пустой
+// суффикс пропускает все пути).
+func SuffixFilter(ctx context.Context, inputs []<-chan string, output chan<- string, errChan chan<- error) {
+	defer close(output)
+	data, reload := inputs[0], inputs[1]
+	suffix := ""
+	for !util.Closed(data, reload) {
+		select {
+		case s, ok := <-reload:
+			if !ok {
+				reload = nil
+				continue
+			}
+			suffix = s
+		case path, ok := <-data:
+			if !ok {
+				data = nil
+				continue
+			}
+			if !strings.HasSuffix(path, suffix) {
+				continue
+			}
+			select {
+			case output <- path:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package example
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestSuffixFilterReload(t *testing.T) {
+	// шаги выполняются по очереди: путь из data либо проходит (pass), либо отбрасывается
+	type step struct {
+		reload      string
+		closeReload bool
+		path        string
+		pass        bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		// до первого сигнала действует пустой суффикс: проходят все пути
+		{"default passes all", []step{{path: "a.txt", pass: true}, {path: "b.log", pass: true}}},
+		{"reload switches suffix", []step{
+			{path: "a.txt", pass: true},
+			{reload: ".log"},
+			{path: "b.txt"},
+			{path: "c.log", pass: true},
+			{reload: ".txt"},
+			{path: "d.log"},
+			{path: "e.txt", pass: true},
+		}},
+		// закрытие входа перезагрузки не завершает узел: действует последний суффикс
+		{"reload closed", []step{
+			{reload: ".log"},
+			{closeReload: true},
+			{path: "a.txt"},
+			{path: "b.log", pass: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, reload := make(chan string), make(chan string)
+			filter := node.NewSelect("Suffix filter", 2, 1, nil, SuffixFilter)
+			if err := filter.AutowireInput(data, reload); err != nil {
+				t.Fatal(err)
+			}
+			out := make(chan string)
+			if err := filter.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			errChan := make(chan error, 1)
+			var wg sync.WaitGroup
+			filter.Run(context.Background(), &wg, errChan, true)
+
+			var got, want []string
+			reloadClosed := false
+			for _, st := range tt.steps {
+				switch {
+				case st.closeReload:
+					close(reload)
+					reloadClosed = true
+				case st.reload != "":
+					reload <- st.reload
+				default:
+					data <- st.path
+				}
+				if st.pass {
+					want = append(want, st.path)
+					got = append(got, <-out)
+				}
+			}
+			close(data)
+			if !reloadClosed {
+				close(reload)
+			}
+			for path := range out {
+				got = append(got, path)
+			}
+			wg.Wait()
+			close(errChan)
+			for err := range errChan {
+				t.Error(err)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package example
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// hashReport HTML-таблица результатов HashFile (строк "путь: хеш")
+var hashReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"cut": func(line string) []string {
+		path, sum, _ := strings.Cut(line, ": ")
+		return []string{path, sum}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>File hashes</title></head>
+<body>
+<table>
+<tr><th>File</th><th>MD5</th></tr>
+{{- range .}}{{with cut .}}
+<tr><td>{{index . 0}}</td><td><code>{{index . 1}}</code></td></tr>
+{{- end}}{{end}}
+</table>
+<p>{{len .}} files</p>
+</body>
+</html>
+`))
+
+// HTMLReportSink приёмник, выводящий результаты HashFile в w одной HTML-таблицей после
+// завершения обхода (node.TemplateSink в сборном режиме)
+func HTMLReportSink(name string, w io.Writer, opts ...node.Option) *node.Node[string, struct{}] {
+	return node.TemplateSink[string](name, hashReport, w, append([]node.Option{node.WithCollect()}, opts...)...)
+}
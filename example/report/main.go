@@ -0,0 +1,64 @@
+// HTML-отчёт: обходит директории, считает md5 хеши файлов и выводит их одной HTML-таблицей.
+//
+//	go run ./example/report testdata/a testdata/c > report.html
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/tom-lepsky/pipeline/example"
+	"github.com/tom-lepsky/pipeline/pipeline"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func main() {
+	dirs := os.Args[1:]
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	source := node.NewSource("Dirs", 1, nil, func(ctx context.Context, output chan<- string, errChan chan<- error) {
+		emit := node.NewEmitter(ctx, output)
+		for _, dir := range dirs {
+			if emit.Send(dir) != nil {
+				return
+			}
+		}
+	})
+	walk := node.NewFlatMap("Walker", example.ListFiles, node.WithOutputBuffers(10))
+	hash := node.NewMap("Hasher", example.HashFile, node.WithConcurrency(4))
+	report := example.HTMLReportSink("Report", os.Stdout)
+
+	for _, err := range []error{
+		node.Autowire(source, walk),
+		node.Autowire(walk, hash),
+		node.Autowire(hash, report),
+	} {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	pipe := pipeline.New()
+	pipe.AddNode(source, walk, hash, report)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range pipe.ErrChan() {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+
+	if err := pipe.Run(context.Background(), false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	pipe.Wait()
+	wg.Wait()
+}
@@ -0,0 +1,53 @@
+// Хеши списка файлов: пути подаются в пайплайн из slices.Values, результаты читаются через
+// pipeline.Results.
+//
+//	go run ./example/seq testdata/a/file1 testdata/b/file2
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/tom-lepsky/pipeline/example"
+	"github.com/tom-lepsky/pipeline/pipeline"
+)
+
+func main() {
+	paths := os.Args[1:]
+	if len(paths) == 0 {
+		files, err := example.ListFiles(context.Background(), "testdata/a")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		paths = files
+	}
+
+	pipe, result, err := example.HashFilePipelineFromSeq(4, slices.Values(paths))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range pipe.ErrChan() {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+
+	if err := pipe.Run(context.Background(), false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for line := range pipeline.Results(pipe, result) {
+		fmt.Println(line)
+	}
+	pipe.Wait()
+	wg.Wait()
+}
@@ -0,0 +1,43 @@
+// Хеши файлов через pipeline.Simple: список путей проходит стадии без явной сборки графа.
+//
+//	go run ./example/simple testdata/a
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tom-lepsky/pipeline/example"
+	"github.com/tom-lepsky/pipeline/pipeline"
+)
+
+func main() {
+	root := "testdata/a"
+	if len(os.Args) > 1 {
+		root = os.Args[1]
+	}
+
+	paths, err := example.ListFiles(context.Background(), root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	hashes, errs := pipeline.Simple[string, string](context.Background(), paths, abs, example.HashFile)
+	for _, h := range hashes {
+		fmt.Println(h)
+	}
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// abs стадия, приводящая путь к абсолютному
+func abs(_ context.Context, path string) (string, error) {
+	return filepath.Abs(path)
+}
@@ -0,0 +1,116 @@
+//go:build soak
+
+package example
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline"
+)
+
+var (
+	soakDuration = flag.Duration("soak.duration", time.Minute, "how long to keep re-running the pipeline")
+	soakFiles    = flag.Int("soak.files", 200, "number of files in the generated tree")
+	soakParallel = flag.Int("soak.parallel", 4, "number of parallel hashers")
+	soakHeap     = flag.Uint64("soak.heap", 64<<20, "max heap after GC between runs, bytes")
+)
+
+// TestSoak нагрузочный прогон: пайплайн HashFilePipeline многократно обходит временное дерево файлов в
+// течение -soak.duration, после каждого прогона проверяется, что число горутин вернулось к
+// исходному, а куча после сборки мусора не превышает -soak.heap. Запускается явно, желательно с -race:
+//
+//	go test -race -tags soak -run TestSoak ./example -soak.duration 10m
+func TestSoak(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, *soakFiles)
+
+	baseline := runtime.NumGoroutine()
+	deadline := time.Now().Add(*soakDuration)
+	var stats runtime.MemStats
+	for run := 1; time.Now().Before(deadline); run++ {
+		n, err := hashTree(root, *soakParallel)
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if n != *soakFiles {
+			t.Fatalf("run %d: got %d results, want %d", run, n, *soakFiles)
+		}
+
+		if g := settle(baseline); g > baseline {
+			t.Fatalf("run %d: %d goroutines, want at most %d", run, g, baseline)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > *soakHeap {
+			t.Fatalf("run %d: heap %d bytes, limit %d", run, stats.HeapAlloc, *soakHeap)
+		}
+		if run%100 == 0 {
+			t.Logf("run %d: heap %d bytes", run, stats.HeapAlloc)
+		}
+	}
+}
+
+// makeTree создаёт в root дерево из files файлов по 10 в поддиректории
+func makeTree(t *testing.T, root string, files int) {
+	t.Helper()
+	for i := range files {
+		dir := filepath.Join(root, fmt.Sprintf("d%03d", i/10))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		data := []byte(fmt.Sprintf("file %d\n", i))
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", i)), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// hashTree один прогон HashFilePipeline по root; возвращает число результатов или первую ошибку
+func hashTree(root string, parallel int) (int, error) {
+	paths := make(chan string, 1)
+	paths <- root
+	close(paths)
+
+	pipe, result, err := HashFilePipeline(parallel, []chan string{paths})
+	if err != nil {
+		return 0, err
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		for err := range pipe.ErrChan() {
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+	}()
+
+	if err := pipe.Run(context.Background(), false); err != nil {
+		return 0, err
+	}
+	n := 0
+	for range pipeline.Results(pipe, result) {
+		n++
+	}
+	pipe.Wait()
+	return n, <-errs
+}
+
+// settle ждёт до секунды, пока число горутин не опустится до baseline, и возвращает его
+func settle(baseline int) int {
+	g := runtime.NumGoroutine()
+	for i := 0; i < 100 && g > baseline; i++ {
+		time.Sleep(10 * time.Millisecond)
+		g = runtime.NumGoroutine()
+	}
+	return g
+}
@@ -0,0 +1,328 @@
+// Проверка целостности дерева файлов: обходит -root, отбирает файлы по шаблонам имён, считает их
+// sha256, пакетами пишет манифест (атомарно, через node.AtomicFileSink) и сравнивает его с
+// манифестом -baseline предыдущего прогона. С -debug отдаёт согласованный снимок статистики нод
+// (Pipeline.StatsSnapshot) по HTTP. Ctrl+C прекращает обход и дожидается хешей уже найденных
+// файлов; манифест прерванного прогона содержит только их.
+//
+//	go run ./example/verify-tree -root testdata -manifest new.sha256 -baseline old.sha256
+//
+// Коды завершения: 0 — отличий нет, 1 — найдены отличия, 2 — были ошибки или прогон прерван.
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tom-lepsky/pipeline/pipeline"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+const (
+	exitClean       = 0
+	exitDifferences = 1
+	exitErrors      = 2
+)
+
+// entry файл дерева: путь относительно корня, размер и хеш
+type entry struct {
+	Path string
+	Size int64
+	Hash string
+}
+
+// config параметры прогона
+type config struct {
+	root     string
+	baseline string
+	manifest string
+	include  string
+	parallel int
+	batch    int
+	debug    string
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.root, "root", ".", "directory to verify")
+	flag.StringVar(&cfg.baseline, "baseline", "", "manifest of a previous run to compare with")
+	flag.StringVar(&cfg.manifest, "manifest", "manifest.sha256", "manifest file to write")
+	flag.StringVar(&cfg.include, "include", "*", "file name glob")
+	flag.IntVar(&cfg.parallel, "parallel", 4, "number of parallel hashers")
+	flag.IntVar(&cfg.batch, "batch", 64, "manifest entries per write")
+	flag.StringVar(&cfg.debug, "debug", "", "address for the stats endpoint, e.g. localhost:6060")
+	flag.Parse()
+
+	os.Exit(run(cfg))
+}
+
+// run выполняет прогон и возвращает код завершения
+func run(cfg config) int {
+	if _, err := filepath.Match(cfg.include, ""); err != nil {
+		fmt.Fprintln(os.Stderr, "include:", err)
+		return exitErrors
+	}
+	var baseline map[string]entry
+	if cfg.baseline != "" {
+		var err error
+		if baseline, err = readManifest(cfg.baseline); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitErrors
+		}
+	}
+
+	pipe, err := build(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitErrors
+	}
+	if cfg.debug != "" {
+		go serveStats(cfg.debug, pipe)
+	}
+
+	errDone := make(chan struct{})
+	go func() {
+		defer close(errDone)
+		for err := range pipe.ErrChan() {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	if err := pipe.Run(context.Background(), true); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitErrors
+	}
+
+	// Shutdown останавливает обход и дожидается хешей найденных файлов; второй Ctrl+C — Stop
+	done := make(chan struct{})
+	go func() {
+		pipe.Wait()
+		close(done)
+	}()
+	interrupted := false
+	select {
+	case <-done:
+	case <-interrupt:
+		interrupted = true
+		fmt.Fprintln(os.Stderr, "interrupted: hashing found files, press Ctrl+C again to stop now")
+		graceCtx, stopNow := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-interrupt:
+				stopNow()
+			case <-done:
+			}
+		}()
+		if err := pipe.Shutdown(graceCtx); err != nil {
+			fmt.Fprintln(os.Stderr, "stopped:", err)
+		}
+		stopNow()
+	}
+	<-errDone
+
+	summary := pipe.Summary()
+	snap := pipe.StatsSnapshot()
+	hashed, _ := snap.Stats("Hasher")
+	errs := summary.Item + summary.Node + summary.Infra
+	fmt.Fprintf(os.Stderr, "hashed %d files, %d errors\n", hashed.ItemsOut, errs)
+	if interrupted || errs > 0 {
+		return exitErrors
+	}
+	if cfg.baseline == "" {
+		return exitClean
+	}
+
+	current, err := readManifest(cfg.manifest)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitErrors
+	}
+	if diff := compare(baseline, current); len(diff) > 0 {
+		for _, line := range diff {
+			fmt.Println(line)
+		}
+		return exitDifferences
+	}
+	return exitClean
+}
+
+// build строит пайплайн Walker → Stat → Filter → Hasher → Batcher → Manifest
+func build(cfg config) (*pipeline.Pipeline, error) {
+	walker := node.NewSource("Walker", 1, []int{cfg.parallel}, walk(cfg.root),
+		node.WithInfiniteSource(), node.WithStats())
+	stat := node.NewFlatMap("Stat", statFile(cfg.root), node.WithStats())
+	filter := node.NewFlatMap("Filter", func(ctx context.Context, e entry) ([]entry, error) {
+		if ok, _ := filepath.Match(cfg.include, filepath.Base(e.Path)); !ok {
+			return nil, nil
+		}
+		return []entry{e}, nil
+	}, node.WithStats())
+	hasher := node.NewMap("Hasher", hashFile(cfg.root), node.WithOutputBuffers(cfg.batch),
+		node.WithConcurrency(cfg.parallel), node.WithStats())
+	batcher := node.NewBatch[entry]("Batcher", cfg.batch, node.WithStats())
+	manifest := node.AtomicFileSink("Manifest", cfg.manifest, writeEntries, node.WithStats())
+
+	for _, err := range []error{
+		node.Autowire(walker, stat),
+		node.Autowire(stat, filter),
+		node.Autowire(filter, hasher),
+		node.Autowire(hasher, batcher.Node),
+		node.Autowire(batcher.Node, manifest),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var opts []pipeline.Option
+	if cfg.debug != "" {
+		opts = append(opts, pipeline.WithConsistentStats())
+	}
+	pipe := pipeline.New(opts...)
+	if err := pipe.AddNode(walker, stat, filter, hasher, batcher, manifest); err != nil {
+		return nil, err
+	}
+	return pipe, nil
+}
+
+// walk источник путей файлов под root; останавливается при отмене контекста (Shutdown)
+func walk(root string) node.SourceFn[string] {
+	return func(ctx context.Context, output chan<- string, errChan chan<- error) {
+		emit := node.NewEmitter(ctx, output)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errChan <- err
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			return emit.Send(path)
+		})
+		if err != nil && ctx.Err() == nil {
+			errChan <- err
+		}
+	}
+}
+
+// statFile дополняет путь размером; не обычные файлы (ссылки, устройства) пропускаются
+func statFile(root string) node.FlatMapFn[string, entry] {
+	return func(ctx context.Context, path string) ([]entry, error) {
+		info, err := os.Lstat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil, err
+		}
+		return []entry{{Path: filepath.ToSlash(rel), Size: info.Size()}}, nil
+	}
+}
+
+// hashFile считает sha256 файла потоково, не читая его в память целиком
+func hashFile(root string) node.MapFn[entry, entry] {
+	return func(ctx context.Context, e entry) (entry, error) {
+		file, err := os.Open(filepath.Join(root, filepath.FromSlash(e.Path)))
+		if err != nil {
+			return entry{}, err
+		}
+		defer file.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, file); err != nil {
+			return entry{}, fmt.Errorf("%s: %w", e.Path, err)
+		}
+		e.Hash = hex.EncodeToString(h.Sum(nil))
+		return e, nil
+	}
+}
+
+// writeEntries пишет пакет строками манифеста "<sha256>  <размер>  <путь>"
+func writeEntries(w io.Writer, batch []entry) error {
+	for _, e := range batch {
+		if _, err := fmt.Fprintf(w, "%s  %d  %s\n", e.Hash, e.Size, e.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readManifest читает манифест в отображение путь → запись
+func readManifest(path string) (map[string]entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make(map[string]entry)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.SplitN(scanner.Text(), "  ", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: malformed entry", path, line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries[fields[2]] = entry{Path: fields[2], Size: size, Hash: fields[0]}
+	}
+	return entries, scanner.Err()
+}
+
+// compare возвращает отличия current от baseline по путям: "+ путь" — новый файл, "- путь" —
+// удалённый, "~ путь" — изменённый
+func compare(baseline, current map[string]entry) []string {
+	var diff []string
+	for path, e := range current {
+		old, ok := baseline[path]
+		switch {
+		case !ok:
+			diff = append(diff, "+ "+path)
+		case old != e:
+			diff = append(diff, "~ "+path)
+		}
+	}
+	for path := range baseline {
+		if _, ok := current[path]; !ok {
+			diff = append(diff, "- "+path)
+		}
+	}
+	slices.SortFunc(diff, func(a, b string) int { return strings.Compare(a[2:], b[2:]) })
+	return diff
+}
+
+// serveStats отдаёт по /debug/pipeline снимок статистики нод в JSON
+func serveStats(addr string, pipe *pipeline.Pipeline) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pipeline", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(pipe.StatsSnapshot())
+	})
+	if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, "debug:", err)
+	}
+}
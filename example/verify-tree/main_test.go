@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixture создаёт дерево файлов для проверки и возвращает его корень
+func fixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"a.txt":         "alpha",
+		"b.log":         "bravo",
+		"dir/c.txt":     "charlie",
+		"dir/sub/d.txt": "delta",
+	} {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestVerifyTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		include string
+		mutate  func(t *testing.T, root string)
+		want    int
+	}{
+		{"unchanged", "*", func(*testing.T, string) {}, exitClean},
+		{"modified", "*", func(t *testing.T, root string) {
+			if err := os.WriteFile(filepath.Join(root, "dir", "c.txt"), []byte("changed"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}, exitDifferences},
+		{"added", "*", func(t *testing.T, root string) {
+			if err := os.WriteFile(filepath.Join(root, "e.txt"), []byte("echo"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}, exitDifferences},
+		{"removed", "*", func(t *testing.T, root string) {
+			if err := os.Remove(filepath.Join(root, "dir", "sub", "d.txt")); err != nil {
+				t.Fatal(err)
+			}
+		}, exitDifferences},
+		// изменение файла, не попавшего под шаблон, не является отличием
+		{"filtered out", "*.txt", func(t *testing.T, root string) {
+			if err := os.WriteFile(filepath.Join(root, "b.log"), []byte("changed"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}, exitClean},
+		{"root removed", "*", func(t *testing.T, root string) {
+			if err := os.RemoveAll(root); err != nil {
+				t.Fatal(err)
+			}
+		}, exitErrors},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, out := fixture(t), t.TempDir()
+			cfg := config{
+				root:     root,
+				manifest: filepath.Join(out, "first.sha256"),
+				include:  tt.include,
+				parallel: 2,
+				batch:    2,
+			}
+			if code := run(cfg); code != exitClean {
+				t.Fatalf("first run exited with %d, want %d", code, exitClean)
+			}
+			first, err := readManifest(cfg.manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[string]int{"*": 4, "*.txt": 3}[tt.include]; len(first) != want {
+				t.Fatalf("manifest has %d entries, want %d", len(first), want)
+			}
+
+			tt.mutate(t, root)
+			cfg.baseline, cfg.manifest = cfg.manifest, filepath.Join(out, "second.sha256")
+			if code := run(cfg); code != tt.want {
+				t.Errorf("second run exited with %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyTreeBadInput(t *testing.T) {
+	root, out := fixture(t), t.TempDir()
+	malformed := filepath.Join(out, "malformed.sha256")
+	if err := os.WriteFile(malformed, []byte("not a manifest\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		cfg  config
+	}{
+		{"bad glob", config{include: "["}},
+		{"missing baseline", config{include: "*", baseline: filepath.Join(out, "missing.sha256")}},
+		{"malformed baseline", config{include: "*", baseline: malformed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.root, cfg.manifest, cfg.parallel, cfg.batch = root, filepath.Join(out, "manifest.sha256"), 1, 1
+			if code := run(cfg); code != exitErrors {
+				t.Errorf("exited with %d, want %d", code, exitErrors)
+			}
+		})
+	}
+}
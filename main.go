@@ -1,91 +1,170 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
-	"sync"
-	"time"
+	"strings"
 
 	"github.com/tom-lepsky/pipeline/example"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
 )
 
-func main() {
-	var wg sync.WaitGroup
-	paths := ProducePaths(&wg)
-	result := ConsumeResult(&wg)
+// stringList флаг, принимающий список значений через запятую или повторением флага
+type stringList []string
 
-	parallelHash := 10
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
 
-	pipe, err := example.HashFilePipeline(parallelHash, paths, result)
-	if err != nil {
-		fmt.Println(err)
-		return
+func (s *stringList) Set(val string) error {
+	*s = append(*s, strings.Split(val, ",")...)
+	return nil
+}
+
+func main() {
+	var cfg example.Config
+	flag.IntVar(&cfg.Parallel, "parallel", 10, "number of parallel hashers")
+	flag.StringVar(&cfg.Algo, "algo", "md5", "hash algorithm: md5, sha1, sha256, sha512")
+	flag.Var((*stringList)(&cfg.Include), "include", "file name globs to hash (comma-separated or repeated)")
+	flag.Var((*stringList)(&cfg.Exclude), "exclude", "file name globs to skip (comma-separated or repeated)")
+	flag.DurationVar(&cfg.Timeout, "timeout", 0, "pipeline timeout (default: run to completion)")
+	flag.StringVar(&cfg.Output, "o", "", "output file (default stdout)")
+	flag.StringVar(&cfg.Format, "format", example.FormatText, "output format: text, json")
+	flag.BoolVar(&cfg.Trace, "trace", false, "append per-file pipeline latency to results")
+	flag.Parse()
+
+	cfg.Roots = flag.Args()
+	if len(cfg.Roots) == 0 {
+		dataPath := path.Join(FindRoot(), "testdata")
+		cfg.Roots = []string{filepath.Join(dataPath, "a"), filepath.Join(dataPath, "b"), filepath.Join(dataPath, "c")}
 	}
-	errChan := pipe.ErrChan()
-	HandleError(&wg, errChan)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	pipe.Run(ctx, false)
-	pipe.Wait()
-	wg.Wait()
+	if err := run(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-func ProducePaths(wg *sync.WaitGroup) []chan string {
-	dataPath := path.Join(FindRoot(), "testdata")
-	data := make([]chan string, 2)
-	for i := 0; i < len(data); i++ {
-		data[i] = make(chan string, 10)
+// run запускает пайплайн до завершения. Поддерживаются два способа отмены: -timeout ограничивает
+// время работы через контекст Run, а Ctrl+C останавливает обход директорий, давая дообработать уже
+// найденные файлы; повторный Ctrl+C немедленно останавливает пайплайн через Stop. Возвращает
+// ошибку, если работа прервана или какая-либо нода сообщила об ошибке.
+func run(cfg example.Config) error {
+	// WithInfiniteSource позволяет Shutdown остановить источник, обходящий директории
+	pipe, result, err := example.HashFilePipelineFromConfig(cfg, node.WithInfiniteSource())
+	if err != nil {
+		return err
 	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for _, val := range []string{filepath.Join(dataPath, "a"), filepath.Join(dataPath, "b")} {
-			data[0] <- val
-		}
-		close(data[0])
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for _, val := range []string{filepath.Join(dataPath, "undefined"), filepath.Join(dataPath, "c")} {
-			data[1] <- val
+	var out io.Writer = os.Stdout
+	if cfg.Output != "" {
+		f, err := os.Create(cfg.Output)
+		if err != nil {
+			return err
 		}
-		close(data[1])
-	}()
+		defer f.Close()
+		out = f
+	}
 
-	return data
-}
+	buf := bufio.NewWriter(out)
+	defer buf.Flush()
+	resultDone := make(chan struct{})
+	pipe.Go(func(ctx context.Context) error {
+		defer close(resultDone)
+		return WriteResults(result, buf)
+	})
+
+	errLog, err := os.Create("errors.log")
+	if err != nil {
+		return err
+	}
+	defer errLog.Close()
+
+	err = example.AttachErrorLog(pipe, errLog)
+	if err != nil {
+		return err
+	}
+	pipe.Go(PrintErrors(pipe.ErrChan()))
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ctx := context.Background()
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	err = pipe.Run(ctx, false)
+	if err != nil {
+		return err
+	}
 
-func ConsumeResult(wg *sync.WaitGroup) []chan string {
-	result := make([]chan string, 1)
-	for i := 0; i < len(result); i++ {
-		result[i] = make(chan string, 1)
-		wg.Add(1)
-		go func(idx int) {
-			defer wg.Done()
-			for r := range result[idx] {
-				fmt.Println(r)
+	// Shutdown без сигнала дожидается завершения пайплайна, по первому Ctrl+C останавливает обход
+	// и дожидается хешей уже найденных файлов, а при отмене graceCtx (второй Ctrl+C) вызывает Stop
+	graceCtx, stopNow := context.WithCancel(context.Background())
+	defer stopNow()
+	interrupted := false
+	select {
+	case <-resultDone:
+	case <-interrupt:
+		interrupted = true
+		fmt.Fprintln(os.Stderr, "interrupted: finishing found files, press Ctrl+C again to stop now")
+		go func() {
+			select {
+			case <-interrupt:
+				stopNow()
+			case <-resultDone:
 			}
-		}(i)
+		}()
+	}
+	if err := pipe.Shutdown(graceCtx); err != nil {
+		fmt.Fprintln(os.Stderr, "stopped:", err)
 	}
 
-	return result
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s, results are incomplete", cfg.Timeout)
+	}
+	if interrupted {
+		return errors.New("interrupted, results are incomplete")
+	}
+	summary := pipe.Summary()
+	if total := summary.Item + summary.Node + summary.Infra; total > 0 {
+		return fmt.Errorf("%d errors (%d item, %d node, %d infra), see errors.log",
+			total, summary.Item, summary.Node, summary.Infra)
+	}
+	return nil
+}
+
+// WriteResults пишет результаты в w построчно до закрытия result. При ошибке записи продолжает
+// вычитывать result, чтобы не блокировать пайплайн, и возвращает первую ошибку.
+func WriteResults(result <-chan string, w io.Writer) error {
+	var firstErr error
+	for r := range result {
+		if _, err := fmt.Fprintln(w, r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
-func HandleError(wg *sync.WaitGroup, errChan <-chan error) {
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+// PrintErrors возвращает функцию для Pipeline.Go, печатающую ошибки errChan до его закрытия
+func PrintErrors(errChan <-chan error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
 		for err := range errChan {
 			fmt.Println(err)
 		}
-	}()
+		return nil
+	}
 }
 
 func FindRoot() string {
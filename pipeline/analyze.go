@@ -0,0 +1,307 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// Severity важность находки Analyze
+type Severity int
+
+const (
+	// SeverityWarning подозрительная, но работоспособная конфигурация
+	SeverityWarning Severity = iota + 1
+	// SeverityError конфигурация, при которой пайплайн не выполнит работу или не завершится
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// FindingKind вид находки Analyze
+type FindingKind string
+
+const (
+	// FindingFanOutSingleConsumer все подключённые выходы ноды (больше одного) ведут в одну ноду
+	FindingFanOutSingleConsumer FindingKind = "fan-out-single-consumer"
+	// FindingUnbufferedBatchInput ребро без буфера ведёт в пакетную ноду (node.NewBatch) с размером
+	// пакета больше 1: отправитель блокируется на каждом элементе, пока пакет собирается
+	FindingUnbufferedBatchInput FindingKind = "unbuffered-batch-input"
+	// FindingUnreachableNode нода не достижима ни из одного источника и не получит элементов
+	FindingUnreachableNode FindingKind = "unreachable-node"
+	// FindingUnreachableSink нода-приёмник не достижима ни из одного источника
+	FindingUnreachableSink FindingKind = "unreachable-sink"
+	// FindingSharedInput один канал подключён ко входам нескольких нод без node.SharedInput: ноды
+	// поочерёдно забирают из него элементы
+	FindingSharedInput FindingKind = "shared-input"
+	// FindingSharedOutput один канал подключён к нескольким выходам: каждый выход закрывается своей
+	// нодой, и повторное закрытие приводит к панике. Run отказывается запускать такой пайплайн.
+	FindingSharedOutput FindingKind = "shared-output"
+)
+
+// Finding находка Analyze. Edge задаётся для находок, относящихся к ребру, в формате
+// "<нода>[<выход>] -> <нода>[<вход>]".
+type Finding struct {
+	Severity Severity
+	Kind     FindingKind
+	Node     string
+	Edge     string
+	Message  string
+}
+
+func (f Finding) String() string {
+	where := "node " + f.Node
+	if f.Edge != "" {
+		where = "edge " + f.Edge
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Severity, where, f.Message, f.Kind)
+}
+
+// ported нода, сообщающая свои входы и выходы
+type ported interface {
+	Name() string
+	Ports() (inputs, outputs []node.Port)
+}
+
+// batchSizer пакетная нода (node.BatchNode)
+type batchSizer interface {
+	Size() int
+}
+
+// topoNode нода в топологии пайплайна
+type topoNode struct {
+	n       ported
+	inputs  []node.Port
+	outputs []node.Port
+}
+
+// consumer вход ноды, в который ведёт ребро
+type consumer struct {
+	node *topoNode
+	idx  int
+}
+
+// Analyze статически проверяет топологию нод пайплайна, соединённых через node.Connect и
+// node.Autowire, и возвращает находки в порядке добавления нод. Источниками считаются ноды без
+// входов и ноды, вход которых подключён к каналу вне пайплайна. Ноды, не сообщающие свои входы
+// и выходы (не node.Node), не анализируются. Вызывается до Run; для замороженного пайплайна
+// (Pipeline.Freeze) анализирует его план.
+func Analyze(p *Pipeline) []Finding {
+	nodes := p.planNodes()
+	produced := make(map[uintptr]bool)
+	for _, tn := range nodes {
+		for _, out := range tn.outputs {
+			if out.ID != 0 {
+				produced[out.ID] = true
+			}
+		}
+	}
+	consumers := topoConsumers(nodes)
+
+	var findings []Finding
+	for _, tn := range nodes {
+		findings = append(findings, analyzeOutputs(tn, consumers)...)
+	}
+	findings = append(findings, analyzeSharing(nodes, consumers)...)
+	return append(findings, analyzeReachability(nodes, produced, consumers)...)
+}
+
+// topoNodes возвращает ноды пайплайна, сообщающие свои входы и выходы, в порядке добавления
+func topoNodes(p *Pipeline) []*topoNode {
+	var nodes []*topoNode
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if pn, ok := n.(ported); ok {
+				inputs, outputs := pn.Ports()
+				if inputs == nil && outputs == nil {
+					// нода схлопнута в ребро
+					continue
+				}
+				nodes = append(nodes, &topoNode{n: pn, inputs: inputs, outputs: outputs})
+			}
+		}
+	}
+	return nodes
+}
+
+// topoConsumers возвращает входы нод, читающие каждый канал
+func topoConsumers(nodes []*topoNode) map[uintptr][]consumer {
+	consumers := make(map[uintptr][]consumer)
+	for _, tn := range nodes {
+		for i, in := range tn.inputs {
+			if in.ID != 0 {
+				consumers[in.ID] = append(consumers[in.ID], consumer{node: tn, idx: i})
+			}
+		}
+	}
+	return consumers
+}
+
+// analyzeOutputs проверяет рёбра, выходящие из ноды
+func analyzeOutputs(tn *topoNode, consumers map[uintptr][]consumer) []Finding {
+	var findings []Finding
+	targets := make(map[*topoNode]bool)
+	wired := 0
+	for i, out := range tn.outputs {
+		if out.ID == 0 {
+			continue
+		}
+		wired++
+		for _, c := range consumers[out.ID] {
+			targets[c.node] = true
+			if b, ok := c.node.n.(batchSizer); ok && out.Cap == 0 && b.Size() > 1 {
+				findings = append(findings, Finding{
+					Severity: SeverityWarning,
+					Kind:     FindingUnbufferedBatchInput,
+					Node:     c.node.n.Name(),
+					Edge:     fmt.Sprintf("%s[%d] -> %s[%d]", tn.n.Name(), i, c.node.n.Name(), c.idx),
+					Message:  fmt.Sprintf("unbuffered edge feeds batch of size %d", b.Size()),
+				})
+			}
+		}
+	}
+
+	if wired > 1 && len(targets) == 1 {
+		for target := range targets {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Kind:     FindingFanOutSingleConsumer,
+				Node:     tn.n.Name(),
+				Message:  fmt.Sprintf("all %d outputs lead to %s", wired, target.n.Name()),
+			})
+		}
+	}
+	return findings
+}
+
+// analyzeSharing ищет каналы, подключённые к нескольким входам или выходам
+func analyzeSharing(nodes []*topoNode, consumers map[uintptr][]consumer) []Finding {
+	var findings []Finding
+	seen := make(map[uintptr]bool)
+	for _, tn := range nodes {
+		for _, in := range tn.inputs {
+			cs := consumers[in.ID]
+			if in.ID == 0 || in.Shared || seen[in.ID] || len(cs) < 2 {
+				continue
+			}
+			seen[in.ID] = true
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Kind:     FindingSharedInput,
+				Node:     tn.n.Name(),
+				Message:  "channel is also read by " + portList(cs[1:]) + ", items are split between them",
+			})
+		}
+	}
+
+	for _, shared := range sharedOutputs(nodes) {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Kind:     FindingSharedOutput,
+			Node:     shared[0].node.n.Name(),
+			Message:  "channel is also written by " + portList(shared[1:]) + ", it would be closed twice",
+		})
+	}
+	return findings
+}
+
+// sharedOutputs возвращает группы выходов, подключённых к одному каналу, в порядке нод
+func sharedOutputs(nodes []*topoNode) [][]consumer {
+	producers := make(map[uintptr][]consumer)
+	var order []uintptr
+	for _, tn := range nodes {
+		for i, out := range tn.outputs {
+			if out.ID == 0 {
+				continue
+			}
+			if len(producers[out.ID]) == 0 {
+				order = append(order, out.ID)
+			}
+			producers[out.ID] = append(producers[out.ID], consumer{node: tn, idx: i})
+		}
+	}
+
+	var shared [][]consumer
+	for _, id := range order {
+		if len(producers[id]) > 1 {
+			shared = append(shared, producers[id])
+		}
+	}
+	return shared
+}
+
+// portList форматирует порты нод как "<нода>[<индекс>], ..."
+func portList(ports []consumer) string {
+	names := make([]string, len(ports))
+	for i, c := range ports {
+		names[i] = fmt.Sprintf("%s[%d]", c.node.n.Name(), c.idx)
+	}
+	return strings.Join(names, ", ")
+}
+
+// analyzeReachability ищет ноды, не достижимые ни из одного источника
+func analyzeReachability(nodes []*topoNode, produced map[uintptr]bool, consumers map[uintptr][]consumer) []Finding {
+	reached := make(map[*topoNode]bool)
+	var queue []*topoNode
+	for _, tn := range nodes {
+		if isSource(tn, produced) {
+			reached[tn] = true
+			queue = append(queue, tn)
+		}
+	}
+	for len(queue) > 0 {
+		tn := queue[0]
+		queue = queue[1:]
+		for _, out := range tn.outputs {
+			for _, c := range consumers[out.ID] {
+				if out.ID != 0 && !reached[c.node] {
+					reached[c.node] = true
+					queue = append(queue, c.node)
+				}
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, tn := range nodes {
+		if reached[tn] {
+			continue
+		}
+		f := Finding{
+			Severity: SeverityWarning,
+			Kind:     FindingUnreachableNode,
+			Node:     tn.n.Name(),
+			Message:  "no path from any source",
+		}
+		if len(tn.outputs) == 0 {
+			f.Severity = SeverityError
+			f.Kind = FindingUnreachableSink
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// isSource сообщает, является ли нода источником: у неё нет входов или вход подключён к каналу
+// вне пайплайна
+func isSource(tn *topoNode, produced map[uintptr]bool) bool {
+	if len(tn.inputs) == 0 {
+		return true
+	}
+	for _, in := range tn.inputs {
+		if in.ID != 0 && !produced[in.ID] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestAnalyze(t *testing.T) {
+	id := func(_ context.Context, v int) (int, error) { return v, nil }
+	buffered := node.WithOutputBuffers(4)
+	// finding значимые поля находки; Message не сравнивается
+	type finding struct {
+		Severity Severity
+		Kind     FindingKind
+		Node     string
+		Edge     string
+	}
+	tests := []struct {
+		name  string
+		build func(t *testing.T, p *Pipeline)
+		want  []finding
+	}{
+		{"clean", func(t *testing.T, p *Pipeline) {
+			src := sliceSource("src", ints(3), buffered)
+			m := node.NewMap("map", id, buffered)
+			sink, _ := sliceSink[int]("sink")
+			mustConnect(t, src, m)
+			mustConnect(t, m, sink)
+			mustAdd(t, p, src, m, sink)
+		}, nil},
+		{"fan-out into single consumer", func(t *testing.T, p *Pipeline) {
+			src := sliceSource("src", ints(3), buffered)
+			split := node.NewMap("split", id, node.WithPorts(1, 2), node.WithOutputBuffers(4, 4))
+			merge := node.NewSink("merge", 2, func(context.Context, int) error { return nil })
+			mustConnect(t, src, split)
+			for i := range 2 {
+				if err := node.Connect(split, i, merge, i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mustAdd(t, p, src, split, merge)
+		}, []finding{{SeverityWarning, FindingFanOutSingleConsumer, "split", ""}}},
+		{"unbuffered batch input", func(t *testing.T, p *Pipeline) {
+			src := sliceSource("src", ints(3))
+			batch := node.NewBatch[int]("batch", 3)
+			sink, _ := sliceSink[[]int]("sink")
+			mustConnect(t, src, batch.Node)
+			mustConnect(t, batch.Node, sink)
+			mustAdd(t, p, src, batch, sink)
+		}, []finding{{SeverityWarning, FindingUnbufferedBatchInput, "batch", "src[0] -> batch[0]"}}},
+		// вход map не подключён: ни map, ни приёмник за ней не получат элементов
+		{"unreachable", func(t *testing.T, p *Pipeline) {
+			m := node.NewMap("map", id, buffered)
+			sink, _ := sliceSink[int]("sink")
+			mustConnect(t, m, sink)
+			mustAdd(t, p, m, sink)
+		}, []finding{
+			{SeverityWarning, FindingUnreachableNode, "map", ""},
+			{SeverityError, FindingUnreachableSink, "sink", ""},
+		}},
+		{"shared input", func(t *testing.T, p *Pipeline) {
+			ch := make(chan int)
+			a, _ := sliceSink[int]("a")
+			b, _ := sliceSink[int]("b")
+			for _, n := range []*node.Node[int, struct{}]{a, b} {
+				if err := n.SetInput(0, ch); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mustAdd(t, p, a, b)
+		}, []finding{{SeverityWarning, FindingSharedInput, "a", ""}}},
+		{"shared output", func(t *testing.T, p *Pipeline) {
+			ch := make(chan int)
+			a := sliceSource("a", ints(3))
+			b := sliceSource("b", ints(3))
+			sink, _ := sliceSink[int]("sink")
+			for _, n := range []*node.Node[struct{}, int]{a, b} {
+				if err := n.SetOutput(0, ch); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := sink.SetInput(0, ch); err != nil {
+				t.Fatal(err)
+			}
+			mustAdd(t, p, a, b, sink)
+		}, []finding{{SeverityError, FindingSharedOutput, "a", ""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			tt.build(t, p)
+
+			var got []finding
+			for _, f := range Analyze(p) {
+				if f.Message == "" {
+					t.Errorf("finding without message: %v", f)
+				}
+				got = append(got, finding{f.Severity, f.Kind, f.Node, f.Edge})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Analyze = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrAuxiliary помечает ошибки вспомогательных горутин, запущенных через Go
+var ErrAuxiliary = errors.New("auxiliary")
+
+// Go запускает fn в отдельной горутине с контекстом пайплайна, например, для логирования ошибок
+// или вывода прогресса. До Run функция ставится в очередь и запускается вместе с пайплайном.
+// Wait и Stop после завершения нод закрывают каналы ошибок, отменяют контекст пайплайна и
+// дожидаются возврата всех fn, поэтому fn завершается при закрытии читаемых каналов или отмене ctx.
+// Ненулевая ошибка fn оборачивается в ErrAuxiliary, учитывается в Summary и отправляется в
+// ErrChan, если он ещё не закрыт; ошибка, которую никто не прочитал до завершения нод,
+// отбрасывается, не задерживая Wait и Stop.
+func (p *Pipeline) Go(fn func(ctx context.Context) error) {
+	p.auxMu.Lock()
+	defer p.auxMu.Unlock()
+	if p.auxCtx == nil {
+		p.auxFns = append(p.auxFns, fn)
+		return
+	}
+	p.goAux(p.auxCtx, fn)
+}
+
+// startAux запускает функции, поставленные в очередь Go до Run
+func (p *Pipeline) startAux(ctx context.Context) {
+	p.auxMu.Lock()
+	defer p.auxMu.Unlock()
+	p.auxCtx = ctx
+	for _, fn := range p.auxFns {
+		p.goAux(ctx, fn)
+	}
+	p.auxFns = nil
+}
+
+// goAux запускает вспомогательную горутину, вызывается под auxMu
+func (p *Pipeline) goAux(ctx context.Context, fn func(ctx context.Context) error) {
+	p.auxWg.Add(1)
+	go func() {
+		defer p.auxWg.Done()
+		if err := fn(ctx); err != nil {
+			p.auxError(fmt.Errorf("%w: %w", ErrAuxiliary, err))
+		}
+	}()
+}
+
+// auxError учитывает ошибку вспомогательной горутины и отправляет её в ErrChan, пока он открыт.
+// Отправка не удерживает auxMu и прекращается при завершении пайплайна (auxDone), так что
+// непрочитанный ErrChan не блокирует finish.
+func (p *Pipeline) auxError(err error) {
+	budgetErr := p.record(err)
+	p.auxMu.RLock()
+	if p.errChanClosed.Load() {
+		p.auxMu.RUnlock()
+		return
+	}
+	p.auxSends.Add(1)
+	p.auxMu.RUnlock()
+	defer p.auxSends.Done()
+
+	for _, err := range []error{err, budgetErr} {
+		if err == nil {
+			continue
+		}
+		select {
+		case p.errChan <- err:
+		case <-p.auxDone:
+			return
+		}
+	}
+}
+
+// stopAux отменяет контекст вспомогательных горутин и дожидается их завершения
+func (p *Pipeline) stopAux() {
+	p.auxMu.Lock()
+	p.auxCtx = nil
+	p.auxMu.Unlock()
+	if p.cancelFunc != nil {
+		p.cancelFunc()
+	}
+	p.auxWg.Wait()
+}
@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestGoErrorWithUnreadErrChan(t *testing.T) {
+	errAux := errors.New("logger failed")
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context) error
+	}{
+		// ошибка отправляется, пока ноды работают, и ждёт читателя ErrChan
+		{"while running", func(context.Context) error { return errAux }},
+		// ошибка возникает при отмене контекста во время завершения
+		{"at shutdown", func(ctx context.Context) error {
+			<-ctx.Done()
+			return errAux
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			src := node.NewSource("source", 1, nil, func(ctx context.Context, _ chan<- int, _ chan<- error) {
+				select {
+				case <-release:
+				case <-ctx.Done():
+				}
+			})
+			sink, _ := sliceSink[int]("sink")
+			mustConnect(t, src, sink)
+			p := New()
+			mustAdd(t, p, src, sink)
+			p.Go(tt.fn)
+
+			// ErrChan никто не читает
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			if tt.name == "while running" {
+				eventually(t, func() bool { return p.Summary().Item == 1 })
+			}
+			close(release)
+			waitTimeout(t, p)
+
+			if s := p.Summary(); s.Item != 1 {
+				t.Errorf("Summary.Item = %d, want the auxiliary error counted", s.Item)
+			}
+			if _, ok := <-p.ErrChan(); ok {
+				t.Error("ErrChan still open after Wait")
+			}
+		})
+	}
+}
+
+func TestGoErrorDelivered(t *testing.T) {
+	errAux := errors.New("logger failed")
+	release := make(chan struct{})
+	src := node.NewSource("source", 1, nil, func(ctx context.Context, _ chan<- int, _ chan<- error) {
+		<-release
+	})
+	sink, _ := sliceSink[int]("sink")
+	mustConnect(t, src, sink)
+	p := New()
+	mustAdd(t, p, src, sink)
+	p.Go(func(context.Context) error { return errAux })
+
+	collector := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool {
+		collector.mu.Lock()
+		defer collector.mu.Unlock()
+		return len(collector.errs) == 1
+	})
+	close(release)
+	waitTimeout(t, p)
+	errs := collector.wait(t)
+	if len(errs) != 1 || !errors.Is(errs[0], ErrAuxiliary) || !errors.Is(errs[0], errAux) {
+		t.Errorf("errors = %v, want one ErrAuxiliary wrapping %v", errs, errAux)
+	}
+}
@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// BottleneckChain узкое место пайплайна и цепочка заблокированных им нод (см. Bottlenecks)
+type BottleneckChain struct {
+	// Node нода — узкое место: её вход заполнен, а выходы пусты
+	Node string
+	// Chain ноды от самой дальней заблокированной до Node по заполненным рёбрам
+	Chain []string
+}
+
+// String возвращает цепочку в виде "walker blocked → hasher full → sink slow": первая нода
+// заблокирована на отправке, промежуточные заполнены, последняя — узкое место
+func (c BottleneckChain) String() string {
+	var b strings.Builder
+	for i, name := range c.Chain {
+		if i > 0 {
+			b.WriteString(" → ")
+		}
+		b.WriteString(name)
+		switch {
+		case i == len(c.Chain)-1:
+			b.WriteString(" slow")
+		case i == 0:
+			b.WriteString(" blocked")
+		default:
+			b.WriteString(" full")
+		}
+	}
+	return b.String()
+}
+
+// Bottleneck возвращает имена нод — узких мест (см. Bottlenecks)
+func (p *Pipeline) Bottleneck() []string {
+	var names []string
+	for _, c := range p.Bottlenecks() {
+		names = append(names, c.Node)
+	}
+	return names
+}
+
+// Bottlenecks находит по заполненности буферов рёбер ноды, тормозящие пайплайн: ноды, у которых
+// буфер хотя бы одного входа заполнен, а буферы всех выходов пусты. Из нод, лежащих на одном пути,
+// возвращаются самые дальние от источников: вышестоящие ноды с заполненными выходами — их жертвы,
+// они перечисляются в Chain. Учитываются только буферизованные рёбра нод, сообщающих свои входы и
+// выходы (node.Node); небуферизованное ребро не бывает заполненным. Можно вызывать во время работы
+// пайплайна, результат — снимок на момент вызова.
+func (p *Pipeline) Bottlenecks() []BottleneckChain {
+	nodes := topoNodes(p)
+	consumers := topoConsumers(nodes)
+	producers := make(map[uintptr]*topoNode)
+	for _, tn := range nodes {
+		for _, out := range tn.outputs {
+			if out.ID != 0 {
+				producers[out.ID] = tn
+			}
+		}
+	}
+
+	var candidates []*topoNode
+	for _, tn := range nodes {
+		if slices.ContainsFunc(tn.inputs, portFull) && !slices.ContainsFunc(tn.outputs, portBusy) {
+			candidates = append(candidates, tn)
+		}
+	}
+
+	var chains []BottleneckChain
+	for _, tn := range candidates {
+		if reachesAny(tn, candidates, consumers) {
+			continue
+		}
+		chains = append(chains, BottleneckChain{Node: tn.n.Name(), Chain: blockedChain(tn, producers)})
+	}
+	return chains
+}
+
+// portFull сообщает, что буфер канала заполнен
+func portFull(port node.Port) bool {
+	return port.ID != 0 && port.Cap > 0 && port.Len >= port.Cap
+}
+
+// portBusy сообщает, что в буфере канала есть элементы
+func portBusy(port node.Port) bool {
+	return port.Len > 0
+}
+
+// reachesAny сообщает, что из ноды from по рёбрам достижима другая нода из targets
+func reachesAny(from *topoNode, targets []*topoNode, consumers map[uintptr][]consumer) bool {
+	visited := map[*topoNode]bool{from: true}
+	queue := []*topoNode{from}
+	for len(queue) > 0 {
+		tn := queue[0]
+		queue = queue[1:]
+		for _, out := range tn.outputs {
+			for _, c := range consumers[out.ID] {
+				if visited[c.node] {
+					continue
+				}
+				if slices.Contains(targets, c.node) {
+					return true
+				}
+				visited[c.node] = true
+				queue = append(queue, c.node)
+			}
+		}
+	}
+	return false
+}
+
+// blockedChain возвращает цепочку нод, заблокированных нодой tn: от неё вверх по первому
+// заполненному входу каждой ноды, в порядке от самой дальней
+func blockedChain(tn *topoNode, producers map[uintptr]*topoNode) []string {
+	chain := []string{tn.n.Name()}
+	visited := map[*topoNode]bool{tn: true}
+	for {
+		i := slices.IndexFunc(tn.inputs, portFull)
+		if i == -1 {
+			break
+		}
+		up, ok := producers[tn.inputs[i].ID]
+		if !ok || visited[up] {
+			break
+		}
+		visited[up] = true
+		chain = append(chain, up.n.Name())
+		tn = up
+	}
+	slices.Reverse(chain)
+	return chain
+}
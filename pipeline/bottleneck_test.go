@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestBottlenecks(t *testing.T) {
+	tests := []struct {
+		name string
+		// full заполненные рёбра: 0 — src → walker, 1 — walker → hasher, 2 — hasher → sink
+		full      []int
+		wantNodes []string
+		wantChain []string
+	}{
+		{"idle", nil, nil, nil},
+		{"slow sink", []int{0, 1, 2}, []string{"sink"}, []string{"src blocked → walker full → hasher full → sink slow"}},
+		// выход hasher пуст: тормозит он, а не приёмник
+		{"slow hasher", []int{0, 1}, []string{"hasher"}, []string{"src blocked → walker full → hasher slow"}},
+		// из нод на одном пути возвращается самая дальняя; цепочка обрывается на незаполненном ребре
+		{"farthest on path", []int{0, 2}, []string{"sink"}, []string{"hasher blocked → sink slow"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relay := func(name string) *node.Node[int, int] {
+				return node.NewMap(name, func(_ context.Context, v int) (int, error) { return v, nil })
+			}
+			nodes := []*node.Node[int, int]{relay("src"), relay("walker"), relay("hasher"), relay("sink")}
+			p := New()
+			for i, n := range nodes {
+				mustAdd(t, p, n)
+				if i == 0 {
+					continue
+				}
+				// рёбра с буфером на один элемент: заполненное содержит элемент
+				edge := make(chan int, 1)
+				if err := nodes[i-1].SetOutput(0, edge); err != nil {
+					t.Fatal(err)
+				}
+				if err := n.SetInput(0, edge); err != nil {
+					t.Fatal(err)
+				}
+				if slices.Contains(tt.full, i-1) {
+					edge <- i
+				}
+			}
+
+			if got := p.Bottleneck(); !slices.Equal(got, tt.wantNodes) {
+				t.Errorf("Bottleneck = %v, want %v", got, tt.wantNodes)
+			}
+			var chains []string
+			for _, c := range p.Bottlenecks() {
+				chains = append(chains, c.String())
+			}
+			if !slices.Equal(chains, tt.wantChain) {
+				t.Errorf("Bottlenecks = %q, want %q", chains, tt.wantChain)
+			}
+		})
+	}
+}
+
+func TestBottlenecksRunning(t *testing.T) {
+	gate := make(chan struct{})
+	src := sliceSource("src", ints(10), node.WithOutputBuffers(2))
+	sink := node.NewSink("sink", 1, func(context.Context, int) error {
+		<-gate
+		return nil
+	})
+	mustConnect(t, src, sink)
+	p := New()
+	mustAdd(t, p, src, sink)
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// приёмник стоит, буфер его входа заполняется
+	eventually(t, func() bool { return slices.Equal(p.Bottleneck(), []string{"sink"}) })
+	close(gate)
+	waitTimeout(t, p)
+	if got := errs.wait(t); len(got) > 0 {
+		t.Errorf("errors: %v", got)
+	}
+	if got := p.Bottleneck(); got != nil {
+		t.Errorf("Bottleneck after the run = %v, want none", got)
+	}
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownBranch  = errors.New("unknown branch")
+	ErrBranchDisabled = errors.New("branch disabled")
+)
+
+// Branch помечает ноды как ветку с именем name. Нода может входить в несколько веток. Вызов
+// после Run возвращает ErrAlreadyRunning, после Freeze — ErrFrozen.
+func (p *Pipeline) Branch(name string, nodes ...Runnable) error {
+	if p.frozen.Load() {
+		return ErrFrozen
+	}
+	if p.run.Load() {
+		return ErrAlreadyRunning
+	}
+	if p.branches == nil {
+		p.branches = make(map[string][]Runnable)
+	}
+	p.branches[name] = append(p.branches[name], nodes...)
+	return nil
+}
+
+// Disable отключает ветку перед Run: её ноды не запускаются, выходы закрываются, а входы
+// дочитываются с отбрасыванием элементов (учитываются в node.Stats.Discarded), чтобы вышестоящие
+// ноды не блокировались. Пропуск нод записывается в Summary().Skipped. Возвращает
+// ErrUnknownBranch для неизвестной ветки и ErrAlreadyRunning после Run.
+func (p *Pipeline) Disable(name string) error {
+	if p.run.Load() {
+		return ErrAlreadyRunning
+	}
+	if _, ok := p.branches[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownBranch, name)
+	}
+	if p.disabled == nil {
+		p.disabled = make(map[string]bool)
+	}
+	p.disabled[name] = true
+	return nil
+}
+
+// Enable включает ранее отключённую ветку
+func (p *Pipeline) Enable(name string) error {
+	if p.run.Load() {
+		return ErrAlreadyRunning
+	}
+	if _, ok := p.branches[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownBranch, name)
+	}
+	delete(p.disabled, name)
+	return nil
+}
+
+// disabledBranch возвращает имя отключённой ветки, в которую входит нода, или пустую строку
+func (p *Pipeline) disabledBranch(n Runnable) (string, bool) {
+	for name := range p.disabled {
+		for _, bn := range p.branches[name] {
+			if bn == n {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
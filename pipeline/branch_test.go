@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestBranchDisable(t *testing.T) {
+	tests := []struct {
+		name string
+		// disable ветка отключается, enable — затем снова включается
+		disable, enable bool
+		wantGot         []int
+		wantDiscarded   uint64
+		wantSkipped     []string
+	}{
+		{"enabled", false, false, ints(5), 0, nil},
+		// входы отключённой ветки дочитываются, её выходы закрываются
+		{"disabled", true, false, nil, 5, []string{"sink", "upload"}},
+		{"enabled again", true, true, ints(5), 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := sliceSource("src", ints(5))
+			upload := node.NewMap("upload", func(_ context.Context, v int) (int, error) { return v, nil }, node.WithStats())
+			sink, got := sliceSink[int]("sink")
+			mustConnect(t, src, upload)
+			mustConnect(t, upload, sink)
+
+			p := New()
+			mustAdd(t, p, src, upload, sink)
+			if err := p.Branch("upload", upload, sink); err != nil {
+				t.Fatal(err)
+			}
+			if tt.disable {
+				if err := p.Disable("upload"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.enable {
+				if err := p.Enable("upload"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if errs := runAndWait(t, p); len(errs) > 0 {
+				t.Errorf("errors: %v", errs)
+			}
+			if !slices.Equal(*got, tt.wantGot) {
+				t.Errorf("sink got %v, want %v", *got, tt.wantGot)
+			}
+			if d := upload.Stats().Discarded; d != tt.wantDiscarded {
+				t.Errorf("Discarded = %d, want %d", d, tt.wantDiscarded)
+			}
+			skipped := p.Summary().Skipped
+			if names := slices.Sorted(maps.Keys(skipped)); !slices.Equal(names, tt.wantSkipped) {
+				t.Errorf("skipped %v, want %v", names, tt.wantSkipped)
+			}
+			for name, reason := range skipped {
+				if !errors.Is(reason, ErrBranchDisabled) {
+					t.Errorf("skip reason of %s = %v, want %v", name, reason, ErrBranchDisabled)
+				}
+			}
+		})
+	}
+}
+
+func TestBranchErrors(t *testing.T) {
+	p := New()
+	if err := p.Disable("upload"); !errors.Is(err, ErrUnknownBranch) {
+		t.Errorf("Disable = %v, want %v", err, ErrUnknownBranch)
+	}
+	if err := p.Enable("upload"); !errors.Is(err, ErrUnknownBranch) {
+		t.Errorf("Enable = %v, want %v", err, ErrUnknownBranch)
+	}
+
+	release := make(chan struct{})
+	task := node.Task("task", func(context.Context) error {
+		<-release
+		return nil
+	})
+	mustAdd(t, p, task)
+	if err := p.Branch("upload", task); err != nil {
+		t.Fatal(err)
+	}
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	branchErr, disableErr := p.Branch("other", task), p.Disable("upload")
+	close(release)
+	waitTimeout(t, p)
+	errs.wait(t)
+	if !errors.Is(branchErr, ErrAlreadyRunning) || !errors.Is(disableErr, ErrAlreadyRunning) {
+		t.Errorf("Branch, Disable while running = %v, %v; want %v", branchErr, disableErr, ErrAlreadyRunning)
+	}
+}
@@ -0,0 +1,23 @@
+package pipeline
+
+import "github.com/tom-lepsky/pipeline/pipeline/node"
+
+// bufferAdvisor нода, рекомендующая размеры буферов выходов (node.WithBufferAdvice)
+type bufferAdvisor interface {
+	BufferAdvice() []node.BufferAdvice
+}
+
+// BufferAdvice возвращает рекомендации размеров буферов выходов нод с node.WithBufferAdvice по
+// замерам последнего запуска, в порядке добавления нод. Вызывать после завершения пайплайна
+// (Wait или Stop).
+func (p *Pipeline) BufferAdvice() []node.BufferAdvice {
+	var advice []node.BufferAdvice
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if a, ok := n.(bufferAdvisor); ok {
+				advice = append(advice, a.BufferAdvice()...)
+			}
+		}
+	}
+	return advice
+}
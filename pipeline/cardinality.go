@@ -0,0 +1,22 @@
+package pipeline
+
+// cardinalityChecker нода с объявленным соотношением входов и выходов (node.WithCardinality)
+type cardinalityChecker interface {
+	CheckCardinality() error
+}
+
+// checkCardinality проверяет соотношение входов и выходов завершившихся нод группы и отправляет
+// нарушения в канал ошибок группы. Если контекст группы отменён, элементы могли быть потеряны
+// законно, и проверка не выполняется.
+func (g *group) checkCardinality() {
+	if g.ctx == nil || g.ctx.Err() != nil {
+		return
+	}
+	for _, n := range g.nodes {
+		if c, ok := n.(cardinalityChecker); ok {
+			if err := c.CheckCardinality(); err != nil {
+				g.errIn <- err
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestCardinalityReported(t *testing.T) {
+	tests := []struct {
+		name string
+		// cancel запуск отменяется на последнем элементе
+		cancel  bool
+		wantErr bool
+	}{
+		{"violated", false, true},
+		// после отмены элементы могли быть потеряны законно, проверка не выполняется
+		{"cancelled", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			// узел теряет нечётные элементы, хотя объявлен как 1:1
+			lossy := node.NewFlatMap("lossy", func(_ context.Context, v int) ([]int, error) {
+				if tt.cancel && v == 4 {
+					cancel()
+				}
+				if v%2 == 1 {
+					return nil, nil
+				}
+				return []int{v}, nil
+			}, node.WithCardinality(node.OneToOne))
+			src := sliceSource("src", ints(5))
+			sink, _ := sliceSink[int]("sink")
+			mustConnect(t, src, lossy)
+			mustConnect(t, lossy, sink)
+
+			p := New()
+			mustAdd(t, p, src, lossy, sink)
+			errs := collectErrors(p.ErrChan())
+			if err := p.Run(ctx, true); err != nil {
+				t.Fatal(err)
+			}
+			waitTimeout(t, p)
+			got := errs.wait(t)
+			switch {
+			case !tt.wantErr && len(got) > 0:
+				t.Errorf("errors: %v", got)
+			case tt.wantErr && (len(got) != 1 || !errors.Is(got[0], node.ErrCardinality) || node.ClassOf(got[0]) != node.ClassInfra):
+				t.Errorf("errors = %v, want one ClassInfra error wrapping %v", got, node.ErrCardinality)
+			}
+			if tt.wantErr && !errors.Is(p.WaitErr(), node.ErrCardinality) {
+				t.Errorf("WaitErr = %v, want %v", p.WaitErr(), node.ErrCardinality)
+			}
+		})
+	}
+}
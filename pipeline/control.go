@@ -0,0 +1,39 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var ErrUnknownNode = errors.New("unknown node")
+
+// Command управляющая команда узлу (см. node.Command)
+type Command = node.Command
+
+// Controllable узел, принимающий управляющие команды
+type Controllable interface {
+	Name() string
+	node.Controllable
+}
+
+// Command отправляет управляющую команду узлу с именем nodeName. Возвращает ErrUnknownNode,
+// если узла нет в пайплайне, и ошибку узла (например, node.ErrUnsupportedCommand), если
+// команда не поддерживается.
+func (p *Pipeline) Command(nodeName string, cmd Command) error {
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			c, ok := n.(Controllable)
+			if ok && c.Name() == nodeName {
+				err := c.Control(cmd)
+				if err == nil && (cmd.Kind == node.CmdPause || cmd.Kind == node.CmdResume) {
+					p.pauses.set(nodeName, cmd.Kind == node.CmdPause)
+				}
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownNode, nodeName)
+}
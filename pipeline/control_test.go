@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestCommandErrors(t *testing.T) {
+	relay := func(_ context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+		defer close(output)
+		for v := range input {
+			output <- v
+		}
+	}
+	tests := []struct {
+		name    string
+		target  Runnable
+		node    string
+		cmd     Command
+		wantErr error
+	}{
+		{"unknown node", node.NewMap("map", func(_ context.Context, v int) (int, error) { return v, nil }),
+			"missing", Command{Kind: node.CmdPause}, ErrUnknownNode},
+		{"flush unsupported by map", node.NewMap("map", func(_ context.Context, v int) (int, error) { return v, nil }),
+			"map", Command{Kind: node.CmdFlush}, node.ErrUnsupportedCommand},
+		{"pause without WithPausable", node.New("custom", 1, 1, nil, relay),
+			"custom", Command{Kind: node.CmdPause}, node.ErrUnsupportedCommand},
+		{"pause with WithPausable", node.New("custom", 1, 1, nil, relay, node.WithPausable()),
+			"custom", Command{Kind: node.CmdPause}, nil},
+		{"unknown param", node.NewThrottle[int]("throttle", 0),
+			"throttle", Command{Kind: node.CmdSetParam, Param: "burst", Value: 1}, node.ErrUnsupportedCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			mustAdd(t, p, tt.target)
+			// команды проверяются синхронно, в том числе до запуска пайплайна
+			if err := p.Command(tt.node, tt.cmd); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Command error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandPauseBackpressure(t *testing.T) {
+	const items = 100
+	relay := func(_ context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+		defer close(output)
+		for v := range input {
+			output <- v
+		}
+	}
+	tests := []struct {
+		name   string
+		paused Runnable
+	}{
+		{"map", node.NewMap("paused", func(_ context.Context, v int) (int, error) { return v, nil })},
+		{"custom handler", node.New("paused", 1, 1, nil, relay, node.WithPausable())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// источник считает значения, принятые нижестоящим узлом
+			var sent atomic.Int64
+			source := node.NewSource("source", 1, nil, func(ctx context.Context, output chan<- int, _ chan<- error) {
+				for i := range items {
+					select {
+					case output <- i:
+						sent.Add(1)
+					case <-ctx.Done():
+						return
+					}
+				}
+			})
+			paused := tt.paused.(*node.Node[int, int])
+			sink, got := sliceSink[int]("sink")
+			mustConnect(t, source, paused)
+			mustConnect(t, paused, sink)
+			p := New()
+			mustAdd(t, p, source, paused, sink)
+
+			errs := collectErrors(p.ErrChan())
+			if err := p.Command("paused", Command{Kind: node.CmdPause}); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+
+			// на паузе узел не читает вход, и источник блокируется на отправке
+			time.Sleep(20 * time.Millisecond)
+			stalled := sent.Load()
+			time.Sleep(50 * time.Millisecond)
+			if s := sent.Load(); s != stalled || s >= items {
+				t.Fatalf("source sent %d items while downstream paused (%d before)", s, stalled)
+			}
+
+			if err := p.Command("paused", Command{Kind: node.CmdResume}); err != nil {
+				t.Fatal(err)
+			}
+			waitTimeout(t, p)
+			if errs := errs.wait(t); len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+			if !slices.Equal(*got, ints(items)) {
+				t.Errorf("sink got %d items after resume, want %d in order", len(*got), items)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var (
+	ErrDependencyFailed = errors.New("dependency failed")
+	ErrDependencyCycle  = errors.New("dependency cycle")
+)
+
+// skipper нода, которую можно завершить без запуска обработчика
+type skipper interface {
+	Skip(ctx context.Context, wg *sync.WaitGroup)
+}
+
+// completion завершение ноды, от которой зависят другие ноды
+type completion struct {
+	done chan struct{}
+	// failed первая ошибка класса ClassNode или ClassInfra либо причина пропуска ноды
+	failed error
+}
+
+// After задаёт зависимость по завершению: нода then запускается только после того, как
+// обработчик first завершился без ошибок классов node.ClassNode и node.ClassInfra. Иначе then
+// не запускается (её выходы закрываются, входы дочитываются), а причина пропуска записывается
+// в Summary().Skipped. Обе ноды должны быть добавлены в пайплайн до Run. Вызов после Run
+// возвращает ErrAlreadyRunning, после Freeze — ErrFrozen.
+func (p *Pipeline) After(first, then Runnable) error {
+	if p.frozen.Load() {
+		return ErrFrozen
+	}
+	if p.run.Load() {
+		return ErrAlreadyRunning
+	}
+	if p.deps == nil {
+		p.deps = make(map[Runnable][]Runnable)
+	}
+	p.deps[then] = append(p.deps[then], first)
+	return nil
+}
+
+// prepareDeps проверяет зависимости и создаёт отметки завершения для нод, от которых зависят другие
+func (p *Pipeline) prepareDeps() error {
+	if len(p.deps) == 0 {
+		return nil
+	}
+
+	known := make(map[Runnable]bool)
+	for _, g := range p.groups {
+		for _, n := range g.nodes {
+			known[n] = true
+		}
+	}
+
+	for then, firsts := range p.deps {
+		if !known[then] {
+			return fmt.Errorf("%w: %s", ErrUnknownNode, nodeName(then))
+		}
+		for _, first := range firsts {
+			if !known[first] {
+				return fmt.Errorf("%w: %s", ErrUnknownNode, nodeName(first))
+			}
+		}
+	}
+	p.initCompletions()
+
+	// поиск цикла обходом в глубину
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[Runnable]int)
+	var visit func(n Runnable) error
+	visit = func(n Runnable) error {
+		switch state[n] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, nodeName(n))
+		case visited:
+			return nil
+		}
+		state[n] = visiting
+		for _, first := range p.deps[n] {
+			if err := visit(first); err != nil {
+				return err
+			}
+		}
+		state[n] = visited
+		return nil
+	}
+	for n := range p.deps {
+		if err := visit(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initCompletions создаёт отметки завершения для нод, от которых зависят другие
+func (p *Pipeline) initCompletions() {
+	if len(p.deps) == 0 {
+		return
+	}
+
+	p.completions = make(map[Runnable]*completion)
+	for _, firsts := range p.deps {
+		for _, first := range firsts {
+			p.completions[first] = &completion{done: make(chan struct{})}
+		}
+	}
+}
+
+// launch запускает ноду с учётом PreStart, зависимостей по завершению и отключённых веток
+func (p *Pipeline) launch(ctx context.Context, n Runnable, wg *sync.WaitGroup, errChan chan<- error, commonErrors bool) {
+	if _, disabled := p.disabledBranch(n); !disabled && p.awaitTrigger(ctx, n, wg, errChan, commonErrors) {
+		return
+	}
+	p.launchNow(ctx, n, wg, errChan, commonErrors)
+}
+
+// launchNow запускает ноду с учётом зависимостей по завершению и отключённых веток
+func (p *Pipeline) launchNow(ctx context.Context, n Runnable, wg *sync.WaitGroup, errChan chan<- error, commonErrors bool) {
+	firsts := p.deps[n]
+	c := p.completions[n]
+	if branch, ok := p.disabledBranch(n); ok {
+		p.skip(ctx, n, c, wg, fmt.Errorf("%w: %s", ErrBranchDisabled, branch))
+		return
+	}
+	if len(firsts) == 0 && c == nil {
+		n.Run(ctx, wg, errChan, commonErrors)
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for _, first := range firsts {
+			fc := p.completions[first]
+			<-fc.done
+			if fc.failed != nil {
+				p.skip(ctx, n, c, wg, fmt.Errorf("%w: %s: %w", ErrDependencyFailed, nodeName(first), fc.failed))
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			p.skip(ctx, n, c, wg, ctx.Err())
+			return
+		}
+
+		if c == nil {
+			n.Run(ctx, wg, errChan, commonErrors)
+			return
+		}
+
+		// отдельные WaitGroup и канал ошибок позволяют узнать завершение и результат ноды
+		nodeWg := &sync.WaitGroup{}
+		errProxy := make(chan error)
+		forwarded := make(chan struct{})
+		go func() {
+			defer close(forwarded)
+			for err := range errProxy {
+				if c.failed == nil && node.ClassOf(err) != node.ClassItem {
+					c.failed = err
+				}
+				errChan <- err
+			}
+		}()
+		n.Run(ctx, nodeWg, errProxy, commonErrors)
+		nodeWg.Wait()
+		close(errProxy)
+		<-forwarded
+		close(c.done)
+	}()
+}
+
+// skip завершает ноду без запуска (см. node.Node.Skip) и записывает причину
+func (p *Pipeline) skip(ctx context.Context, n Runnable, c *completion, wg *sync.WaitGroup, reason error) {
+	p.summary.skip(nodeName(n), reason)
+	if s, ok := n.(skipper); ok {
+		s.Skip(ctx, wg)
+	}
+	if c != nil {
+		c.failed = reason
+		close(c.done)
+	}
+}
+
+// nodeName возвращает имя ноды или её тип, если имени нет
+func nodeName(n Runnable) string {
+	if named, ok := n.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", n)
+}
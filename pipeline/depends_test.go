@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestAfter(t *testing.T) {
+	errStep := errors.New("step failed")
+	tests := []struct {
+		name string
+		// fail шаг, завершающийся ошибкой
+		fail    string
+		wantRun []string
+		// wantSkipped пропущенные шаги; причина каждого оборачивает ErrDependencyFailed
+		wantSkipped []string
+	}{
+		{"ordered", "", []string{"setup", "process", "finalize"}, nil},
+		// неудача шага пропускает все шаги, зависящие от него прямо или через другие шаги
+		{"setup fails", "setup", []string{"setup"}, []string{"finalize", "process"}},
+		{"process fails", "process", []string{"setup", "process"}, []string{"finalize"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var run []string
+			step := func(name string, delay time.Duration) *node.Node[struct{}, struct{}] {
+				return node.Task(name, func(context.Context) error {
+					// задержка выявила бы запуск следующего шага до завершения этого
+					time.Sleep(delay)
+					mu.Lock()
+					run = append(run, name)
+					mu.Unlock()
+					if name == tt.fail {
+						return errStep
+					}
+					return nil
+				})
+			}
+			setup, process, finalize := step("setup", 20*time.Millisecond), step("process", 10*time.Millisecond), step("finalize", 0)
+
+			p := New()
+			// порядок добавления обратен порядку выполнения
+			mustAdd(t, p, finalize, process, setup)
+			if err := p.After(setup, process); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.After(process, finalize); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := runAndWait(t, p)
+			if !slices.Equal(run, tt.wantRun) {
+				t.Errorf("run %v, want %v", run, tt.wantRun)
+			}
+			if tt.fail != "" && (len(errs) != 1 || !errors.Is(errs[0], errStep)) {
+				t.Errorf("errors = %v, want %v", errs, errStep)
+			}
+			skipped := p.Summary().Skipped
+			if got := slices.Sorted(maps.Keys(skipped)); !slices.Equal(got, tt.wantSkipped) {
+				t.Errorf("skipped %v, want %v", got, tt.wantSkipped)
+			}
+			for name, reason := range skipped {
+				if !errors.Is(reason, ErrDependencyFailed) || !errors.Is(reason, errStep) {
+					t.Errorf("skip reason of %s = %v, want %v wrapping %v", name, reason, ErrDependencyFailed, errStep)
+				}
+			}
+		})
+	}
+}
+
+func TestAfterSkippedDrainsInput(t *testing.T) {
+	errSetup := errors.New("setup failed")
+	src := sliceSource("src", ints(10))
+	sink, got := sliceSink[int]("sink")
+	mustConnect(t, src, sink)
+	setup := node.Task("setup", func(context.Context) error { return errSetup })
+
+	p := New()
+	mustAdd(t, p, src, sink, setup)
+	if err := p.After(setup, sink); err != nil {
+		t.Fatal(err)
+	}
+
+	// пропущенный приёмник дочитывает вход, источник не блокируется
+	runAndWait(t, p)
+	if len(*got) != 0 {
+		t.Errorf("skipped sink got %v", *got)
+	}
+	if reason := p.Summary().Skipped["sink"]; !errors.Is(reason, ErrDependencyFailed) {
+		t.Errorf("skip reason = %v, want %v", reason, ErrDependencyFailed)
+	}
+}
+
+func TestAfterErrors(t *testing.T) {
+	task := func(name string) *node.Node[struct{}, struct{}] {
+		return node.Task(name, func(context.Context) error { return nil })
+	}
+	tests := []struct {
+		name    string
+		build   func(p *Pipeline, a, b *node.Node[struct{}, struct{}]) error
+		wantErr error
+	}{
+		{"unknown node", func(p *Pipeline, a, _ *node.Node[struct{}, struct{}]) error {
+			return p.After(task("outside"), a)
+		}, ErrUnknownNode},
+		{"cycle", func(p *Pipeline, a, b *node.Node[struct{}, struct{}]) error {
+			return errors.Join(p.After(a, b), p.After(b, a))
+		}, ErrDependencyCycle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			a, b := task("a"), task("b")
+			mustAdd(t, p, a, b)
+			if err := tt.build(p, a, b); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Run(context.Background(), true); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("while running", func(t *testing.T) {
+		release := make(chan struct{})
+		p := New()
+		a := node.Task("a", func(context.Context) error {
+			<-release
+			return nil
+		})
+		b := task("b")
+		mustAdd(t, p, a, b)
+		errs := collectErrors(p.ErrChan())
+		if err := p.Run(context.Background(), true); err != nil {
+			t.Fatal(err)
+		}
+		err := p.After(a, b)
+		close(release)
+		waitTimeout(t, p)
+		errs.wait(t)
+		if !errors.Is(err, ErrAlreadyRunning) {
+			t.Errorf("After = %v, want %v", err, ErrAlreadyRunning)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var ErrDumpType = errors.New("dump codec type mismatch")
+
+// DumpUsage итог дампа ребра (DumpEdge) за последний запуск
+type DumpUsage struct {
+	Path string
+	// Items записанные элементы, Bytes размер файла дампа
+	Items uint64
+	Bytes int64
+	// Skipped элементы, которые не удалось закодировать
+	Skipped uint64
+	// Truncated запись остановлена по достижении maxBytes (или из-за ошибки записи файла)
+	Truncated bool
+}
+
+// edgeDump дамп ребра в файл (DumpEdge)
+type edgeDump struct {
+	edge     EdgeRef
+	path     string
+	maxBytes int64
+	encode   func(v any) ([]byte, error)
+
+	mu    sync.Mutex
+	f     *os.File
+	seq   uint64
+	usage DumpUsage
+}
+
+// DumpEdge записывает в файл path копии элементов, отправленных в выход outIdx ноды fromNode, в
+// формате FileRecordStore (JSON-объект Record на строку); файл читается через DumpStore, например
+// ReplaySource. Значения кодируются codec, для нулевого Codec — зарегистрированным кодеком типа
+// (RegisterCodec). Файл пересоздаётся при каждом запуске. Когда очередная запись не помещается в
+// maxBytes, запись дампа прекращается, поток при этом не затрагивается; файл содержит только целые
+// записи. Элементы, которые не удалось закодировать, пропускаются с подсчётом. Итог доступен в
+// Summary().Dumps. Ребро можно одновременно записывать через WithRecording.
+//
+// Вызывается до Run и Freeze (иначе ErrAlreadyRunning и ErrFrozen). Возвращает ErrUnknownNode,
+// ErrDumpType, если тип codec не совпадает с типом выходов ноды, и ErrNoCodec; неверный outIdx
+// обнаруживается при запуске.
+func DumpEdge[T any](p *Pipeline, fromNode string, outIdx int, path string, maxBytes int64, codec Codec[T]) error {
+	if p.frozen.Load() {
+		return ErrFrozen
+	}
+	if p.run.Load() {
+		return ErrAlreadyRunning
+	}
+
+	edge := Edge(fromNode, outIdx)
+	t, err := p.tapperOf(fromNode)
+	if err != nil {
+		return err
+	}
+	if t.OutputType() != reflect.TypeFor[T]() {
+		return fmt.Errorf("dump %s: %w: %s, node outputs %s", edge, ErrDumpType, reflect.TypeFor[T](), t.OutputType())
+	}
+
+	var encode func(v any) ([]byte, error)
+	if codec.Encode != nil {
+		encode = func(v any) ([]byte, error) {
+			return codec.Encode(v.(T))
+		}
+	} else {
+		c, err := codecFor(reflect.TypeFor[T]())
+		if err != nil {
+			return fmt.Errorf("dump %s: %w", edge, err)
+		}
+		encode = c.encode
+	}
+
+	p.dumps = append(p.dumps, &edgeDump{edge: edge, path: path, maxBytes: maxBytes, encode: encode})
+	return nil
+}
+
+// openDumps пересоздаёт файлы дампов перед запуском
+func (p *Pipeline) openDumps() error {
+	for i, d := range p.dumps {
+		f, err := os.Create(d.path)
+		if err != nil {
+			for _, opened := range p.dumps[:i] {
+				opened.close()
+			}
+			return fmt.Errorf("dump %s: %w", d.edge, err)
+		}
+		d.mu.Lock()
+		d.f = f
+		d.seq = 0
+		d.usage = DumpUsage{Path: d.path}
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// closeDumps закрывает файлы дампов после завершения нод
+func (p *Pipeline) closeDumps() {
+	for _, d := range p.dumps {
+		if err := d.close(); err != nil {
+			_ = p.record(node.Classify(node.ClassInfra, fmt.Errorf("dump %s: %w", d.edge, err)))
+		}
+	}
+}
+
+// dumpUsage собирает итоги дампов по рёбрам
+func (p *Pipeline) dumpUsage() map[string]DumpUsage {
+	if len(p.dumps) == 0 {
+		return nil
+	}
+
+	usage := make(map[string]DumpUsage, len(p.dumps))
+	for _, d := range p.dumps {
+		d.mu.Lock()
+		usage[d.edge.String()] = d.usage
+		d.mu.Unlock()
+	}
+	return usage
+}
+
+// tap возвращает функцию, дописывающую значения ребра в файл дампа
+func (d *edgeDump) tap(p *Pipeline) func(v any) {
+	return func(v any) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.f == nil || d.usage.Truncated {
+			return
+		}
+
+		data, err := d.encode(v)
+		if err != nil {
+			d.usage.Skipped++
+			return
+		}
+		line, err := json.Marshal(Record{Seq: d.seq + 1, At: p.clock().Now(), Data: data})
+		if err != nil {
+			d.usage.Skipped++
+			return
+		}
+		line = append(line, '\n')
+		if d.usage.Bytes+int64(len(line)) > d.maxBytes {
+			d.usage.Truncated = true
+			return
+		}
+		if _, err := d.f.Write(line); err != nil {
+			d.usage.Truncated = true
+			_ = p.record(node.Classify(node.ClassInfra, fmt.Errorf("dump %s: %w", d.edge, err)))
+			return
+		}
+		d.seq++
+		d.usage.Items++
+		d.usage.Bytes += int64(len(line))
+	}
+}
+
+// close закрывает файл дампа
+func (d *edgeDump) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.f == nil {
+		return nil
+	}
+	err := d.f.Close()
+	d.f = nil
+	return err
+}
+
+// dumpStore RecordStore только для чтения над файлом дампа
+type dumpStore struct {
+	path string
+}
+
+// DumpStore возвращает RecordStore только для чтения, отдающий записи файла дампа DumpEdge для
+// любого ребра; Append возвращает errors.ErrUnsupported
+func DumpStore(path string) RecordStore {
+	return dumpStore{path: path}
+}
+
+func (s dumpStore) Append(EdgeRef, Record) error {
+	return errors.ErrUnsupported
+}
+
+func (s dumpStore) Records(EdgeRef) ([]Record, error) {
+	return readRecords(s.path)
+}
@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestDumpEdge(t *testing.T) {
+	errOddItem := errors.New("odd item")
+	items := make([]recItem, 5)
+	for i := range items {
+		items[i] = recItem{N: i, Name: "item"}
+	}
+	// skipOdd кодек, не кодирующий нечётные элементы
+	skipOdd := JSONCodec[recItem]()
+	encode := skipOdd.Encode
+	skipOdd.Encode = func(v recItem) ([]byte, error) {
+		if v.N%2 == 1 {
+			return nil, errOddItem
+		}
+		return encode(v)
+	}
+	tests := []struct {
+		name     string
+		codec    Codec[recItem]
+		maxBytes int64
+		// wantN номера записанных элементов; при Truncated — их начало
+		wantN         []int
+		wantSkipped   uint64
+		wantTruncated bool
+	}{
+		{"explicit codec", JSONCodec[recItem](), 1 << 20, []int{0, 1, 2, 3, 4}, 0, false},
+		// нулевой Codec: используется зарегистрированный кодек типа
+		{"registered codec", Codec[recItem]{}, 1 << 20, []int{0, 1, 2, 3, 4}, 0, false},
+		{"encode errors", skipOdd, 1 << 20, []int{0, 2, 4}, 2, false},
+		// запись, не помещающаяся в maxBytes, прекращает дамп; файл содержит только целые записи
+		{"truncated", JSONCodec[recItem](), 200, []int{0}, 0, true},
+		{"nothing fits", JSONCodec[recItem](), 1, nil, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dump.jsonl")
+			src := sliceSource("src", items)
+			sink, got := sliceSink[recItem]("sink")
+			mustConnect(t, src, sink)
+			p := New()
+			mustAdd(t, p, src, sink)
+			if err := DumpEdge(p, "src", 0, path, tt.maxBytes, tt.codec); err != nil {
+				t.Fatal(err)
+			}
+
+			if errs := runAndWait(t, p); len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+			// дамп не затрагивает поток
+			if !slices.Equal(*got, items) {
+				t.Errorf("sink got %v, want %v", *got, items)
+			}
+
+			records, err := DumpStore(path).Records(Edge("src", 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotN []int
+			for i, rec := range records {
+				if rec.Seq != uint64(i+1) {
+					t.Errorf("record %d seq %d, want %d", i, rec.Seq, i+1)
+				}
+				var v recItem
+				if err := json.Unmarshal(rec.Data, &v); err != nil {
+					t.Fatal(err)
+				}
+				gotN = append(gotN, v.N)
+			}
+			if tt.wantTruncated && len(gotN) > len(tt.wantN) {
+				gotN = gotN[:len(tt.wantN)]
+			}
+			if !slices.Equal(gotN, tt.wantN) {
+				t.Errorf("dumped items %v, want %v", gotN, tt.wantN)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := p.Summary().Dumps["src[0]"]
+			if u.Path != path || u.Items != uint64(len(records)) || u.Bytes != info.Size() ||
+				u.Skipped != tt.wantSkipped || u.Truncated != tt.wantTruncated {
+				t.Errorf("DumpUsage = %+v, want %d items of %d bytes, %d skipped, truncated %v", u,
+					len(records), info.Size(), tt.wantSkipped, tt.wantTruncated)
+			}
+			if tt.wantTruncated && len(records) == len(items) {
+				t.Error("truncated dump holds every item")
+			}
+		})
+	}
+}
+
+// plainItem тип без зарегистрированного кодека
+type plainItem int
+
+func TestDumpEdgeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dump    func(t *testing.T, p *Pipeline) error
+		wantErr error
+	}{
+		{"unknown node", func(t *testing.T, p *Pipeline) error {
+			return DumpEdge(p, "missing", 0, "dump", 1, JSONCodec[recItem]())
+		}, ErrUnknownNode},
+		{"type mismatch", func(t *testing.T, p *Pipeline) error {
+			return DumpEdge(p, "src", 0, "dump", 1, JSONCodec[string]())
+		}, ErrDumpType},
+		{"no codec", func(t *testing.T, p *Pipeline) error {
+			return DumpEdge(p, "plain", 0, "dump", 1, Codec[plainItem]{})
+		}, ErrNoCodec},
+		{"running", func(t *testing.T, p *Pipeline) error {
+			if err := p.Run(context.Background(), true); err != nil {
+				return err
+			}
+			t.Cleanup(func() {
+				p.Stop()
+				waitTimeout(t, p)
+			})
+			return DumpEdge(p, "src", 0, "dump", 1, JSONCodec[recItem]())
+		}, ErrAlreadyRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			src := node.NewSource("src", 1, nil, func(ctx context.Context, _ chan<- recItem, _ chan<- error) {
+				<-ctx.Done()
+			})
+			plain := node.NewSource("plain", 1, nil, func(context.Context, chan<- plainItem, chan<- error) {})
+			if err := src.MarkOutputUnused(0); err != nil {
+				t.Fatal(err)
+			}
+			if err := plain.MarkOutputUnused(0); err != nil {
+				t.Fatal(err)
+			}
+			mustAdd(t, p, src, plain)
+			if err := tt.dump(t, p); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DumpEdge = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
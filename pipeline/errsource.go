@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// ErrorGroup группа нод, обрабатывающих поток ошибок пайплайна (см. ErrorSourceNode)
+const ErrorGroup = "errors"
+
+var ErrErrorSourceExists = errors.New("error source node already exists")
+
+// ErrorSourceNode создаёт ноду-источник, выдающую ошибки нод пайплайна как обычный поток данных,
+// и добавляет её в группу ErrorGroup. После создания ошибки всех групп, кроме ErrorGroup, направляются
+// в этот поток вместо каналов групп. Выход ноды закрывается, когда завершились все ноды вне ErrorGroup.
+//
+// Ноды, обрабатывающие поток ошибок, нужно добавлять через AddErrorNode: их собственные ошибки
+// (и ошибки, не доставленные после отмены контекста) направляются в ErrChan, а не обратно в поток,
+// что исключает цикл. Вызывается до Run и Freeze, повторный вызов возвращает ErrErrorSourceExists.
+func (p *Pipeline) ErrorSourceNode(name string) (*node.Node[struct{}, error], error) {
+	if p.frozen.Load() {
+		return nil, ErrFrozen
+	}
+	if p.run.Load() {
+		return nil, ErrAlreadyRunning
+	}
+	if p.errStream != nil {
+		return nil, ErrErrorSourceExists
+	}
+
+	stream := make(chan error)
+	src := node.NewSource(name, 1, []int{1}, func(ctx context.Context, output chan<- error, errChan chan<- error) {
+		for err := range stream {
+			select {
+			case output <- err:
+			case <-ctx.Done():
+				errChan <- err
+			}
+		}
+	})
+
+	p.errStream = stream
+	p.AddErrorNode(src)
+	return src, nil
+}
+
+// AddErrorNode добавляет ноды, обрабатывающие поток ошибок, в группу ErrorGroup (см. AddNode)
+func (p *Pipeline) AddErrorNode(n ...Runnable) error {
+	return p.AddNodeGroup(ErrorGroup, n...)
+}
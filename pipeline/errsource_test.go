@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestErrorSourceNode(t *testing.T) {
+	errSink := errors.New("error sink failed")
+	failing := func(name string, n int) *node.Node[struct{}, struct{}] {
+		return node.New(name, 0, 0, nil, func(_ context.Context, _ <-chan struct{}, _ chan<- struct{}, errChan chan<- error) {
+			for i := range n {
+				errChan <- fmt.Errorf("%s error %d", name, i)
+			}
+		})
+	}
+
+	p := New()
+	source, err := p.ErrorSourceNode("errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ошибки в потоке обрабатываются обычной нодой; её собственная ошибка уходит в ErrChan,
+	// а не обратно в поток
+	var streamed []string
+	sink := node.NewSink("error sink", 1, func(_ context.Context, e error) error {
+		streamed = append(streamed, e.Error())
+		if len(streamed) == 1 {
+			return errSink
+		}
+		return nil
+	})
+	mustConnect(t, source, sink)
+	if err := p.AddErrorNode(sink); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddNodeGroup("ingest", failing("ingest", 2)); err != nil {
+		t.Fatal(err)
+	}
+	mustAdd(t, p, failing("serve", 3))
+
+	groupErrs := collectErrors(p.ErrChanFor("ingest"))
+	errs := runAndWait(t, p)
+
+	if len(errs) != 1 || !errors.Is(errs[0], errSink) {
+		t.Errorf("ErrChan errors = %v, want only the error sink's own error", errs)
+	}
+	if errs := groupErrs.wait(t); len(errs) != 0 {
+		t.Errorf("ingest group channel got %v, want nothing: errors go to the stream", errs)
+	}
+	// поток завершается после всех нод вне ErrorGroup, так что приёмник видит все ошибки
+	want := []string{"ingest error 0", "ingest error 1", "serve error 0", "serve error 1", "serve error 2"}
+	slices.Sort(streamed)
+	if !slices.Equal(streamed, want) {
+		t.Errorf("streamed errors %q, want %q", streamed, want)
+	}
+}
+
+func TestErrorSourceNodeErrors(t *testing.T) {
+	block := func() *node.Node[struct{}, struct{}] {
+		return node.Task("block", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+	}
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, p *Pipeline)
+		wantErr error
+	}{
+		{"second call", func(t *testing.T, p *Pipeline) {
+			if _, err := p.ErrorSourceNode("errors"); err != nil {
+				t.Fatal(err)
+			}
+		}, ErrErrorSourceExists},
+		{"frozen", func(t *testing.T, p *Pipeline) {
+			mustAdd(t, p, block())
+			if err := p.Freeze(); err != nil {
+				t.Fatal(err)
+			}
+		}, ErrFrozen},
+		{"running", func(t *testing.T, p *Pipeline) {
+			mustAdd(t, p, block())
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(p.Stop)
+		}, ErrAlreadyRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			tt.prepare(t, p)
+			if _, err := p.ErrorSourceNode("late"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ErrorSourceNode error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
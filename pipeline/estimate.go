@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"math"
+	"time"
+)
+
+// Topology модель графа пайплайна для Estimate: ноды и рёбра между ними
+type Topology struct {
+	Nodes []string
+	Edges []TopologyEdge
+}
+
+// TopologyEdge ребро графа. Share доля элементов ноды From, уходящая по ребру: выходы ноды
+// распределяют элементы (RoundRobin и другие стратегии), а не дублируют их, поэтому доли рёбер
+// одной ноды в сумме дают 1.
+type TopologyEdge struct {
+	From  string
+	To    string
+	Share float64
+}
+
+// TopologyOf строит модель графа нод пайплайна, соединённых через node.Connect и node.Autowire.
+// Элементы ноды делятся поровну между подключёнными выходами, а элементы выхода — между читающими
+// его нодами; для взвешенного распределения (node.WithFanOutWeights) доли можно поправить вручную.
+// Для замороженного пайплайна (Pipeline.Freeze) возвращает модель его плана.
+func TopologyOf(p *Pipeline) Topology {
+	if topo, ok := p.planTopology(); ok {
+		return topo
+	}
+	return topologyOf(topoNodes(p))
+}
+
+// topologyOf строит модель графа нод
+func topologyOf(nodes []*topoNode) Topology {
+	consumers := topoConsumers(nodes)
+
+	var topo Topology
+	for _, tn := range nodes {
+		topo.Nodes = append(topo.Nodes, tn.n.Name())
+	}
+	for _, tn := range nodes {
+		var wired []uintptr
+		for _, out := range tn.outputs {
+			if out.ID != 0 && len(consumers[out.ID]) > 0 {
+				wired = append(wired, out.ID)
+			}
+		}
+		for _, id := range wired {
+			for _, c := range consumers[id] {
+				topo.Edges = append(topo.Edges, TopologyEdge{
+					From:  tn.n.Name(),
+					To:    c.node.n.Name(),
+					Share: 1 / float64(len(wired)*len(consumers[id])),
+				})
+			}
+		}
+	}
+	return topo
+}
+
+// StageEstimate оценка одной ноды
+type StageEstimate struct {
+	Node string
+	// Load элементов, обрабатываемых нодой на один элемент каждого источника
+	Load float64
+	// Capacity элементов в секунду, которые нода способна обработать (parallelism / cost),
+	// +Inf для нод без стоимости
+	Capacity float64
+	// Utilization доля Capacity, занятая при Throughput
+	Utilization float64
+	// ReplicaGain прирост Throughput при добавлении ноде одной реплики
+	ReplicaGain float64
+}
+
+// EstimateReport результат Estimate
+type EstimateReport struct {
+	// Bottleneck нода, ограничивающая пропускную способность; пустая, если ни у одной ноды нет стоимости
+	Bottleneck string
+	// Throughput элементов в секунду, которые способен выдавать каждый источник, +Inf без стоимостей
+	Throughput float64
+	Stages     []StageEstimate
+}
+
+// Estimate оценивает пропускную способность пайплайна по модели topo, средней стоимости обработки
+// одного элемента нодами costs и количеству параллельных обработчиков нод parallelism (по умолчанию 1).
+// Источники (ноды без входящих рёбер) выдают элементы с одинаковой частотой, каждая нода выдаёт
+// по элементу на входной (соотношение 1:1), ноды без стоимости не ограничивают поток. Ноды,
+// входящие в цикл, не получают нагрузки. RoundRobin на деле пропускает заполненные выходы, поэтому
+// для неравных по скорости получателей оценка по долям рёбер занижена. Вычисление не запускает ноды.
+func Estimate(topo Topology, costs map[string]time.Duration, parallelism map[string]int) EstimateReport {
+	load := estimateLoad(topo)
+	capacity := func(name string, extra int) float64 {
+		cost := costs[name]
+		if cost <= 0 {
+			return math.Inf(1)
+		}
+		return float64(max(parallelism[name], 1)+extra) / cost.Seconds()
+	}
+	throughput := func(extraFor string) (float64, string) {
+		best, bottleneck := math.Inf(1), ""
+		for _, name := range topo.Nodes {
+			extra := 0
+			if name == extraFor {
+				extra = 1
+			}
+			if l := load[name]; l > 0 {
+				if t := capacity(name, extra) / l; t < best {
+					best, bottleneck = t, name
+				}
+			}
+		}
+		return best, bottleneck
+	}
+
+	report := EstimateReport{}
+	report.Throughput, report.Bottleneck = throughput("")
+	for _, name := range topo.Nodes {
+		stage := StageEstimate{Node: name, Load: load[name], Capacity: capacity(name, 0)}
+		if !math.IsInf(stage.Capacity, 1) && !math.IsInf(report.Throughput, 1) {
+			stage.Utilization = report.Throughput * stage.Load / stage.Capacity
+			t, _ := throughput(name)
+			stage.ReplicaGain = t - report.Throughput
+		}
+		report.Stages = append(report.Stages, stage)
+	}
+	return report
+}
+
+// estimateLoad распространяет нагрузку от источников по рёбрам в топологическом порядке
+func estimateLoad(topo Topology) map[string]float64 {
+	indegree := make(map[string]int, len(topo.Nodes))
+	out := make(map[string][]TopologyEdge)
+	for _, e := range topo.Edges {
+		indegree[e.To]++
+		out[e.From] = append(out[e.From], e)
+	}
+
+	load := make(map[string]float64, len(topo.Nodes))
+	var queue []string
+	for _, name := range topo.Nodes {
+		if indegree[name] == 0 {
+			load[name] = 1
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, e := range out[name] {
+			load[e.To] += load[name] * e.Share
+			if indegree[e.To]--; indegree[e.To] == 0 {
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	for name, d := range indegree {
+		if d > 0 {
+			load[name] = 0
+		}
+	}
+	return load
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestEstimate(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		name           string
+		topo           Topology
+		costs          map[string]time.Duration
+		parallelism    map[string]int
+		wantBottleneck string
+		wantThroughput float64
+		wantStages     []StageEstimate
+	}{
+		// hash: 2 / 10ms = 200/с, sink: 1 / 4ms = 250/с; третья реплика hash упирается в sink
+		{"linear", Topology{
+			Nodes: []string{"src", "hash", "sink"},
+			Edges: []TopologyEdge{{"src", "hash", 1}, {"hash", "sink", 1}},
+		}, map[string]time.Duration{"hash": 10 * time.Millisecond, "sink": 4 * time.Millisecond},
+			map[string]int{"hash": 2}, "hash", 200, []StageEstimate{
+				{Node: "src", Load: 1, Capacity: inf},
+				{Node: "hash", Load: 1, Capacity: 200, Utilization: 1, ReplicaGain: 50},
+				{Node: "sink", Load: 1, Capacity: 250, Utilization: 0.8},
+			}},
+		// источник делит элементы поровну: b (50/с) получает половину потока и ограничивает его сотней
+		{"fan-out and fan-in", Topology{
+			Nodes: []string{"src", "a", "b", "sink"},
+			Edges: []TopologyEdge{{"src", "a", 0.5}, {"src", "b", 0.5}, {"a", "sink", 1}, {"b", "sink", 1}},
+		}, map[string]time.Duration{"a": 10 * time.Millisecond, "b": 20 * time.Millisecond, "sink": 2 * time.Millisecond},
+			nil, "b", 100, []StageEstimate{
+				{Node: "src", Load: 1, Capacity: inf},
+				{Node: "a", Load: 0.5, Capacity: 100, Utilization: 0.5},
+				{Node: "b", Load: 0.5, Capacity: 50, Utilization: 1, ReplicaGain: 100},
+				{Node: "sink", Load: 1, Capacity: 500, Utilization: 0.2},
+			}},
+		{"no costs", Topology{
+			Nodes: []string{"src", "sink"},
+			Edges: []TopologyEdge{{"src", "sink", 1}},
+		}, nil, nil, "", inf, []StageEstimate{
+			{Node: "src", Load: 1, Capacity: inf},
+			{Node: "sink", Load: 1, Capacity: inf},
+		}},
+		// ноды цикла не получают нагрузки и не ограничивают поток
+		{"cycle", Topology{
+			Nodes: []string{"src", "a", "b"},
+			Edges: []TopologyEdge{{"src", "a", 1}, {"a", "b", 1}, {"b", "a", 1}},
+		}, map[string]time.Duration{"a": time.Second}, nil, "", inf, []StageEstimate{
+			{Node: "src", Load: 1, Capacity: inf},
+			{Node: "a", Load: 0, Capacity: 1},
+			{Node: "b", Load: 0, Capacity: inf},
+		}},
+	}
+	near := func(a, b float64) bool {
+		return a == b || math.Abs(a-b) < 1e-9
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Estimate(tt.topo, tt.costs, tt.parallelism)
+			if r.Bottleneck != tt.wantBottleneck || !near(r.Throughput, tt.wantThroughput) {
+				t.Errorf("bottleneck %q, throughput %v; want %q, %v", r.Bottleneck, r.Throughput,
+					tt.wantBottleneck, tt.wantThroughput)
+			}
+			if len(r.Stages) != len(tt.wantStages) {
+				t.Fatalf("stages %+v, want %+v", r.Stages, tt.wantStages)
+			}
+			for i, s := range r.Stages {
+				w := tt.wantStages[i]
+				if s.Node != w.Node || !near(s.Load, w.Load) || !near(s.Capacity, w.Capacity) ||
+					!near(s.Utilization, w.Utilization) || !near(s.ReplicaGain, w.ReplicaGain) {
+					t.Errorf("stage %+v, want %+v", s, w)
+				}
+			}
+		})
+	}
+}
+
+func TestTopologyOf(t *testing.T) {
+	id := func(_ context.Context, v int) (int, error) { return v, nil }
+	src := sliceSource("src", ints(3))
+	split := node.NewMap("split", id, node.WithPorts(1, 2))
+	a, _ := sliceSink[int]("a")
+	b, _ := sliceSink[int]("b")
+	mustConnect(t, src, split)
+	if err := node.Autowire(split, a, b); err != nil {
+		t.Fatal(err)
+	}
+	p := New()
+	mustAdd(t, p, src, split, a, b)
+
+	// элементы split делятся поровну между двумя выходами
+	topo := TopologyOf(p)
+	if want := []string{"src", "split", "a", "b"}; !slices.Equal(topo.Nodes, want) {
+		t.Errorf("nodes %v, want %v", topo.Nodes, want)
+	}
+	want := []TopologyEdge{{"src", "split", 1}, {"split", "a", 0.5}, {"split", "b", 0.5}}
+	if !slices.Equal(topo.Edges, want) {
+		t.Errorf("edges %v, want %v", topo.Edges, want)
+	}
+}
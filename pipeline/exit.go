@@ -0,0 +1,23 @@
+package pipeline
+
+import "github.com/tom-lepsky/pipeline/pipeline/node"
+
+// exitReporter нода, сообщающая причину своего завершения
+type exitReporter interface {
+	Name() string
+	ExitReason() node.ExitReason
+}
+
+// ExitReport возвращает причины завершения нод по именам (см. node.ExitReason). До завершения
+// пайплайна работающие ноды имеют причину node.ExitRunning, не запущенные — node.ExitNone.
+func (p *Pipeline) ExitReport() map[string]node.ExitReason {
+	report := make(map[string]node.ExitReason)
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if r, ok := n.(exitReporter); ok {
+				report[r.Name()] = r.ExitReason()
+			}
+		}
+	}
+	return report
+}
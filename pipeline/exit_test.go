@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// eventually ждёт выполнения cond и проваливает тест по истечении времени
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestExitReport(t *testing.T) {
+	p := New()
+
+	// вход закрыт: источник исчерпан, приёмник дочитал вход
+	finite := sliceSource("finite", ints(3))
+	sink, _ := sliceSink[int]("sink")
+	mustConnect(t, finite, sink)
+
+	// досрочное завершение: обработчик читает один элемент, его источник остаётся заблокированным
+	// до Stop и завершается отменой
+	blocked := sliceSource("blocked", ints(3))
+	early := node.New("early", 1, 0, nil, func(_ context.Context, input <-chan int, _ chan<- struct{}, _ chan<- error) {
+		<-input
+	})
+	mustConnect(t, blocked, early)
+
+	// паника, преобразованная в ошибку
+	panicSource := sliceSource("panic source", ints(1))
+	panicky := node.NewMap("panicky", func(context.Context, int) (int, error) {
+		panic("boom")
+	}, node.WithPanicPolicy(node.PanicToError))
+	panicSink, _ := sliceSink[int]("panic sink")
+	mustConnect(t, panicSource, panicky)
+	mustConnect(t, panicky, panicSink)
+
+	// ошибка init
+	initSource := sliceSource("init source", ints(1))
+	initFailed := node.NewSink("init failed", 1, func(context.Context, int) error { return nil },
+		node.WithInit(func(context.Context) error { return errors.New("no connection") }))
+	mustConnect(t, initSource, initFailed)
+
+	mustAdd(t, p, finite, sink, blocked, early, panicSource, panicky, panicSink, initSource, initFailed)
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool {
+		for _, name := range []string{"sink", "early", "panicky", "init failed"} {
+			if p.ExitReport()[name] == node.ExitRunning {
+				return false
+			}
+		}
+		return true
+	})
+	p.Stop()
+	errs.wait(t)
+
+	want := map[string]node.ExitReason{
+		"finite":       node.ExitInputClosed,
+		"sink":         node.ExitInputClosed,
+		"blocked":      node.ExitCancelled,
+		"early":        node.ExitEarly,
+		"panic source": node.ExitInputClosed,
+		"panicky":      node.ExitPanicked,
+		"panic sink":   node.ExitInputClosed,
+		"init source":  node.ExitCancelled,
+		"init failed":  node.ExitInitFailed,
+	}
+	report := p.ExitReport()
+	if !maps.Equal(report, want) {
+		t.Errorf("ExitReport = %v, want %v", report, want)
+	}
+	if s := p.Summary(); !maps.Equal(s.Exits, report) {
+		t.Errorf("Summary.Exits = %v, want %v", s.Exits, report)
+	}
+}
+
+func TestExitReportBeforeRun(t *testing.T) {
+	p := New()
+	src := sliceSource("source", ints(1))
+	sink, _ := sliceSink[int]("sink")
+	mustConnect(t, src, sink)
+	mustAdd(t, p, src, sink)
+
+	for name, r := range p.ExitReport() {
+		if r != node.ExitNone {
+			t.Errorf("%s: ExitReason before Run = %v, want %v", name, r, node.ExitNone)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestFilterBetweenSourceAndSink(t *testing.T) {
+	const perSource = 100
+	errTenth := errors.New("tenth")
+	odd := func(_ context.Context, v int) (bool, error) { return v%2 == 1, nil }
+	tests := []struct {
+		name           string
+		sources, sinks int
+		pred           node.FilterFn[int]
+		opts           []node.Option
+		kept, errs     int
+	}{
+		{"single", 1, 1, odd, nil, 50, 0},
+		{"predicate errors", 1, 1, func(_ context.Context, v int) (bool, error) {
+			if v%10 == 0 {
+				return false, errTenth
+			}
+			return v%2 == 1, nil
+		}, nil, 50, 10},
+		{"fan-in fan-out", 3, 2, odd, nil, 150, 0},
+		{"concurrent", 2, 1, odd, []node.Option{node.WithConcurrency(4)}, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			var want []int
+			p := New()
+			filter := node.NewFilter("filter", tt.pred, append(tt.opts, node.WithPorts(tt.sources, tt.sinks), node.WithStats())...)
+			mustAdd(t, p, filter)
+			for i := range tt.sources {
+				items := make([]int, perSource)
+				for k := range items {
+					items[k] = i*perSource + k
+					if ok, err := tt.pred(context.Background(), items[k]); ok && err == nil {
+						want = append(want, items[k])
+					}
+				}
+				source := sliceSource(fmt.Sprintf("source %d", i), items)
+				if err := node.Connect(source, 0, filter, i); err != nil {
+					t.Fatal(err)
+				}
+				mustAdd(t, p, source)
+			}
+			var got []*[]int
+			for i := range tt.sinks {
+				sink, items := sliceSink[int](fmt.Sprintf("sink %d", i))
+				if err := node.Connect(filter, i, sink, 0); err != nil {
+					t.Fatal(err)
+				}
+				mustAdd(t, p, sink)
+				got = append(got, items)
+			}
+
+			errs := runAndWait(t, p)
+			if len(errs) != tt.errs {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), tt.errs, errs)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, errTenth) {
+					t.Errorf("unexpected error %v", err)
+				}
+			}
+			var kept []int
+			for _, items := range got {
+				kept = append(kept, *items...)
+			}
+			slices.Sort(kept)
+			if !slices.Equal(kept, want) {
+				t.Errorf("kept %v, want %v", kept, want)
+			}
+
+			// отброшенные предикатом значения не доходят до выхода и не считаются ошибками
+			total := tt.sources * perSource
+			if dropped := total - len(kept) - len(errs); dropped != total-tt.kept-tt.errs {
+				t.Errorf("dropped %d items, want %d", dropped, total-tt.kept-tt.errs)
+			}
+			s := filter.Stats()
+			if int(s.ItemsIn) != total || int(s.ItemsOut) != tt.kept || int(s.Errors) != tt.errs {
+				t.Errorf("stats in %d, out %d, errors %d; want in %d, out %d, errors %d",
+					s.ItemsIn, s.ItemsOut, s.Errors, total, tt.kept, tt.errs)
+			}
+			settleGoroutines(t, base)
+		})
+	}
+}
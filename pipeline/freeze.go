@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// ErrFrozen топология замороженного пайплайна (Freeze) или ноды не может быть изменена
+var ErrFrozen = node.ErrFrozen
+
+// freezer нода, подключение которой можно заморозить
+type freezer interface {
+	Freeze()
+}
+
+// plan план запуска замороженного пайплайна: топология, проверенная и вычисленная в Freeze
+type plan struct {
+	nodes    []*topoNode
+	topology Topology
+}
+
+// Freeze проверяет пайплайн так же, как Run (ErrNoNodes, ErrUnknownNode, ErrDependencyCycle,
+// ErrNoCodec, node.ErrUnwired, ErrSharedOutput), устанавливает записывающие отводы WithRecording и фиксирует
+// топологию: после успешного вызова AddNode, After, Branch и подключение нод (node.Connect,
+// SetInput и т.п.) возвращают ErrFrozen. Run замороженного пайплайна не повторяет проверки, а
+// Analyze и TopologyOf используют вычисленный здесь план. Повторный вызов ничего не делает;
+// вызов во время работы возвращает ErrAlreadyRunning.
+func (p *Pipeline) Freeze() error {
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+	if p.frozen.Load() {
+		return nil
+	}
+	if p.run.Load() {
+		return ErrAlreadyRunning
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	nodes := topoNodes(p)
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if f, ok := n.(freezer); ok {
+				f.Freeze()
+			}
+		}
+	}
+	p.plan = &plan{nodes: nodes, topology: topologyOf(nodes)}
+	p.frozen.Store(true)
+	return nil
+}
+
+// Frozen сообщает, что топология пайплайна заморожена (Freeze)
+func (p *Pipeline) Frozen() bool {
+	return p.frozen.Load()
+}
+
+// validate выполняет проверки перед запуском: зависимостей, записываемых рёбер и общих выходов
+func (p *Pipeline) validate() error {
+	if p.empty() {
+		return ErrNoNodes
+	}
+	if err := p.prepareDeps(); err != nil {
+		return err
+	}
+	if err := p.prepareRecording(); err != nil {
+		return err
+	}
+	if err := p.validateNodes(); err != nil {
+		return err
+	}
+	if shared := sharedOutputs(topoNodes(p)); len(shared) > 0 {
+		return fmt.Errorf("%w: %s", ErrSharedOutput, portList(shared[0]))
+	}
+	return nil
+}
+
+// validator нода, проверяющая своё подключение перед запуском
+type validator interface {
+	Validate() error
+}
+
+// validateNodes проверяет подключение всех нод и возвращает все найденные ошибки (node.ErrUnwired)
+func (p *Pipeline) validateNodes() error {
+	var errs []error
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if v, ok := n.(validator); ok {
+				if err := v.Validate(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// planNodes возвращает ноды плана замороженного пайплайна или текущие ноды (topoNodes)
+func (p *Pipeline) planNodes() []*topoNode {
+	if p.frozen.Load() {
+		return p.plan.nodes
+	}
+	return topoNodes(p)
+}
+
+// planTopology возвращает копию топологии плана замороженного пайплайна
+func (p *Pipeline) planTopology() (Topology, bool) {
+	if !p.frozen.Load() {
+		return Topology{}, false
+	}
+	return Topology{Nodes: slices.Clone(p.plan.topology.Nodes), Edges: slices.Clone(p.plan.topology.Edges)}, true
+}
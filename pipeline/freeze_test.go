@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestFreeze(t *testing.T) {
+	src := sliceSource("src", ints(5))
+	double := node.NewMap("double", func(_ context.Context, v int) (int, error) { return 2 * v, nil })
+	sink, got := sliceSink[int]("sink")
+	mustConnect(t, src, double)
+	mustConnect(t, double, sink)
+	p := New()
+	mustAdd(t, p, src, double, sink)
+	if err := p.Freeze(); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Frozen() {
+		t.Fatal("Frozen = false after Freeze")
+	}
+	// повторный вызов ничего не делает
+	if err := p.Freeze(); err != nil {
+		t.Errorf("second Freeze = %v", err)
+	}
+
+	extra := node.NewMap("extra", func(_ context.Context, v int) (int, error) { return v, nil })
+	tests := []struct {
+		name   string
+		mutate func() error
+	}{
+		{"AddNode", func() error { return p.AddNode(extra) }},
+		{"AddNodeGroup", func() error { return p.AddNodeGroup("other", extra) }},
+		{"After", func() error { return p.After(src, sink) }},
+		{"Branch", func() error { return p.Branch("branch", double) }},
+		{"ErrorSourceNode", func() error {
+			_, err := p.ErrorSourceNode("errors")
+			return err
+		}},
+		{"DumpEdge", func() error {
+			return DumpEdge(p, "src", 0, filepath.Join(t.TempDir(), "dump"), 0, JSONCodec[int]())
+		}},
+		{"Connect to frozen node", func() error { return node.Connect(double, 0, extra, 0) }},
+		{"SetInput", func() error { return double.SetInput(0, make(chan int)) }},
+		{"ForceSetInput", func() error { return double.ForceSetInput(0, make(chan int)) }},
+		{"SetOutput", func() error { return double.SetOutput(0, make(chan int)) }},
+		{"ForceSetOutput", func() error { return double.ForceSetOutput(0, make(chan int)) }},
+		{"MarkOutputUnused", func() error { return double.MarkOutputUnused(0) }},
+		{"TapOutput", func() error { return double.TapOutput(0, func(any) {}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.mutate(); !errors.Is(err, ErrFrozen) {
+				t.Errorf("error = %v, want %v", err, ErrFrozen)
+			}
+		})
+	}
+
+	// отказы не изменили топологию: запускается то, что было заморожено
+	if errs := runAndWait(t, p); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if want := []int{0, 2, 4, 6, 8}; !slices.Equal(*got, want) {
+		t.Errorf("sink got %v, want %v", *got, want)
+	}
+	if wired, _ := extra.InputWired(0); wired {
+		t.Error("frozen node connected to extra")
+	}
+}
+
+func TestFreezeFailed(t *testing.T) {
+	// непрошедшая проверку топология не замораживается и может быть исправлена
+	src := sliceSource("src", ints(3))
+	sink, got := sliceSink[int]("sink")
+	p := New()
+	mustAdd(t, p, src, sink)
+	if err := p.Freeze(); !errors.Is(err, node.ErrUnwired) {
+		t.Fatalf("Freeze = %v, want %v", err, node.ErrUnwired)
+	}
+	if p.Frozen() {
+		t.Fatal("Frozen = true after a failed Freeze")
+	}
+	mustConnect(t, src, sink)
+	if err := p.Freeze(); err != nil {
+		t.Fatal(err)
+	}
+	if errs := runAndWait(t, p); len(errs) > 0 || !slices.Equal(*got, ints(3)) {
+		t.Errorf("sink got %v, errors %v; want %v", *got, errs, ints(3))
+	}
+}
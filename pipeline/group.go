@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// DefaultGroup имя группы узлов, добавленных через AddNode
+const DefaultGroup = ""
+
+// group именованная группа узлов с собственным каналом ошибок и политикой fail-fast
+type group struct {
+	name     string
+	nodes    []Runnable
+	failFast bool
+	// errIn канал, в который пишут узлы группы; errChan публичный канал группы;
+	// dest канал, в который пересылаются ошибки (errChan либо поток ошибок пайплайна)
+	errIn   chan error
+	errChan chan error
+	dest    chan error
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func newGroup(name string, errChan chan error) *group {
+	return &group{
+		name:    name,
+		errIn:   make(chan error),
+		errChan: errChan,
+	}
+}
+
+// start запускает пересылку ошибок и ноды группы в собственном контексте
+func (g *group) start(ctx context.Context, wg, forwardWg *sync.WaitGroup, commonErrors bool, cancelPipeline func(),
+	record func(error) error, launch launchFunc) {
+	groupCtx, cancel := context.WithCancel(ctx)
+	g.ctx, g.cancel = groupCtx, cancel
+	g.forward(forwardWg, cancelPipeline, record)
+
+	for i := 0; i < len(g.nodes); i++ {
+		launch(groupCtx, g.nodes[i], wg, g.errIn, commonErrors)
+	}
+}
+
+// launchFunc запускает ноду группы
+type launchFunc func(ctx context.Context, n Runnable, wg *sync.WaitGroup, errChan chan<- error, commonErrors bool)
+
+// forward пересылает ошибки узлов группы в dest, применяя политики fail-fast и учитывая их
+// через record. Ошибка превышения бюджета, возвращённая record, пересылается следом.
+func (g *group) forward(wg *sync.WaitGroup, cancelPipeline func(), record func(error) error) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range g.errIn {
+			if g.failFast && g.cancel != nil {
+				g.cancel()
+			}
+			if cancelPipeline != nil {
+				cancelPipeline()
+			}
+			budgetErr := record(err)
+			g.dest <- err
+			if budgetErr != nil {
+				g.dest <- budgetErr
+			}
+		}
+	}()
+}
+
+// stats суммирует статистику узлов группы, поддерживающих Stats
+func (g *group) stats() node.Stats {
+	var total node.Stats
+	for _, n := range g.nodes {
+		s, ok := n.(interface{ Stats() node.Stats })
+		if !ok {
+			continue
+		}
+		ns := s.Stats()
+		total.ItemsIn += ns.ItemsIn
+		total.ItemsOut += ns.ItemsOut
+		total.Errors += ns.Errors
+		total.Discarded += ns.Discarded
+		total.Suppressed += ns.Suppressed
+		total.InFlight += ns.InFlight
+		total.Busy += ns.Busy
+		total.Shed += ns.Shed
+		total.BytesIn += ns.BytesIn
+		total.BytesOut += ns.BytesOut
+		total.Bypassed += ns.Bypassed
+		total.Bypass = total.Bypass || ns.Bypass
+		total.StartedAt = earliest(total.StartedAt, ns.StartedAt)
+		if ns.FinishedAt.After(total.FinishedAt) {
+			total.FinishedAt = ns.FinishedAt
+		}
+		if ns.LastActivity.After(total.LastActivity) {
+			total.LastActivity = ns.LastActivity
+		}
+	}
+	return total
+}
+
+// earliest возвращает меньшее из ненулевых значений времени
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
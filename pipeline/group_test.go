@@ -0,0 +1,252 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestGroupErrorRouting(t *testing.T) {
+	p := New()
+	failing := func(name string, n int) *node.Node[struct{}, struct{}] {
+		return node.New(name, 0, 0, nil, func(_ context.Context, _ <-chan struct{}, _ chan<- struct{}, errChan chan<- error) {
+			for i := range n {
+				errChan <- fmt.Errorf("%s error %d", name, i)
+			}
+		}, node.WithStats())
+	}
+	ingest, parse := failing("ingest", 3), failing("parse", 2)
+	serve := failing("serve", 4)
+	if err := p.AddNodeGroup("ingest", ingest); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddNodeGroup("ingest", parse); err != nil {
+		t.Fatal(err)
+	}
+	mustAdd(t, p, serve)
+	if p.ErrChanFor("unknown") != nil {
+		t.Error("ErrChanFor of an unknown group is not nil")
+	}
+
+	groupErrs := collectErrors(p.ErrChanFor("ingest"))
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	defaultErrs := collectErrors(p.ErrChan())
+	waitTimeout(t, p)
+
+	// ошибки каждой группы приходят только в её канал, и все каналы закрываются после Wait
+	for _, c := range []struct {
+		group  string
+		errs   []error
+		prefix []string
+		want   int
+	}{
+		{"ingest", groupErrs.wait(t), []string{"ingest ", "parse "}, 5},
+		{"default", defaultErrs.wait(t), []string{"serve "}, 4},
+	} {
+		if len(c.errs) != c.want {
+			t.Errorf("%s: got %d errors, want %d: %v", c.group, len(c.errs), c.want, c.errs)
+		}
+		for _, err := range c.errs {
+			if !slices.ContainsFunc(c.prefix, func(prefix string) bool { return strings.HasPrefix(err.Error(), prefix) }) {
+				t.Errorf("%s: error %q from another group", c.group, err)
+			}
+		}
+	}
+	if s := p.GroupStats("ingest"); s.Errors != 5 {
+		t.Errorf("ingest group stats count %d errors, want 5", s.Errors)
+	}
+	if s := p.GroupStats(DefaultGroup); s.Errors != 4 {
+		t.Errorf("default group stats count %d errors, want 4", s.Errors)
+	}
+}
+
+func TestAddNodeGroupDuplicateName(t *testing.T) {
+	task := func(name string) *node.Node[struct{}, struct{}] {
+		return node.Task(name, func(context.Context) error { return nil })
+	}
+	tests := []struct {
+		name  string
+		first []Runnable
+		// group группа второго добавления, first добавляется в "ingest"
+		group  string
+		second []Runnable
+	}{
+		{"same group", []Runnable{task("a")}, "ingest", []Runnable{task("a")}},
+		{"another group", []Runnable{task("a")}, "serve", []Runnable{task("a")}},
+		{"default group", []Runnable{task("a")}, DefaultGroup, []Runnable{task("a")}},
+		{"same call", nil, "ingest", []Runnable{task("b"), task("b")}},
+		{"same node twice", nil, "ingest", func() []Runnable {
+			n := task("c")
+			return []Runnable{n, n}
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			if err := p.AddNodeGroup("ingest", tt.first...); err != nil {
+				t.Fatal(err)
+			}
+			// отклонённый вызов не добавляет ни одной ноды, включая ноды с уникальными именами
+			fresh := task("fresh")
+			err := p.AddNodeGroup(tt.group, append([]Runnable{fresh}, tt.second...)...)
+			if !errors.Is(err, ErrDuplicateNode) {
+				t.Fatalf("AddNodeGroup error = %v, want ErrDuplicateNode", err)
+			}
+			if err := p.AddNode(fresh); err != nil {
+				t.Errorf("node of the rejected call was added: %v", err)
+			}
+		})
+	}
+}
+
+func TestGroupFailFast(t *testing.T) {
+	errIngest := errors.New("ingest failed")
+	tests := []struct {
+		name          string
+		opts          []Option
+		groupFailFast bool
+		// wantServeCancelled отменяется ли нода другой группы
+		wantServeCancelled bool
+	}{
+		{"group", nil, true, false},
+		{"pipeline", []Option{WithFailFast()}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.opts...)
+			ingestDone := make(chan struct{})
+			fail := node.Task("fail", func(context.Context) error { return errIngest })
+			wait := node.Task("wait", func(ctx context.Context) error {
+				defer close(ingestDone)
+				select {
+				case <-ctx.Done():
+				case <-time.After(5 * time.Second):
+					return errors.New("ingest group not cancelled")
+				}
+				return nil
+			})
+			var serveCancelled bool
+			serve := node.Task("serve", func(ctx context.Context) error {
+				<-ingestDone
+				select {
+				case <-ctx.Done():
+					serveCancelled = true
+				case <-time.After(50 * time.Millisecond):
+				}
+				return nil
+			})
+			if err := p.AddNodeGroup("ingest", fail, wait); err != nil {
+				t.Fatal(err)
+			}
+			p.SetGroupFailFast("ingest", tt.groupFailFast)
+			mustAdd(t, p, serve)
+
+			groupErrs := collectErrors(p.ErrChanFor("ingest"))
+			if errs := runAndWait(t, p); len(errs) != 0 {
+				t.Errorf("default group errors: %v", errs)
+			}
+			if errs := groupErrs.wait(t); len(errs) != 1 || !errors.Is(errs[0], errIngest) {
+				t.Errorf("ingest group errors = %v, want only %v", errs, errIngest)
+			}
+			if serveCancelled != tt.wantServeCancelled {
+				t.Errorf("serve cancelled = %v, want %v", serveCancelled, tt.wantServeCancelled)
+			}
+		})
+	}
+}
+
+func TestGroupStatsCounters(t *testing.T) {
+	src := sliceSource("src", ints(5), node.WithOutputBuffers(5))
+	// обработчик читает один элемент и завершается, остаток входа дочитывается и отбрасывается
+	early := node.New("early", 1, 0, nil, func(_ context.Context, input <-chan int, _ chan<- struct{}, _ chan<- error) {
+		<-input
+	}, node.WithEarlyExit(node.EarlyExitDrain), node.WithStats())
+	mustConnect(t, src, early)
+	// ошибки всех элементов подавляются преобразованием
+	quietSrc := sliceSource("quiet src", ints(3))
+	quiet := node.NewSink("quiet", 1, func(context.Context, int) error {
+		return errors.New("ignored")
+	}, node.WithErrorTransform(func(error) error { return nil }), node.WithStats())
+	mustConnect(t, quietSrc, quiet)
+	p := New()
+	if err := p.AddNodeGroup("ingest", src, early, quietSrc, quiet); err != nil {
+		t.Fatal(err)
+	}
+	// два сбоя подряд включают обход, следующие три элемента обходят функцию; ошибки и переход
+	// в режим обхода приходят в канал группы "bypass"
+	rateSrc := sliceSource("rate src", []int{-1, -2, 1, 2, 3})
+	rate := node.NewMap("rate", func(_ context.Context, v int) (int, error) {
+		if v < 0 {
+			return 0, errors.New("fail")
+		}
+		return v, nil
+	}, node.WithErrorRateBreaker(0.5, 2, node.BypassForward))
+	rateSink, _ := sliceSink[int]("rate sink")
+	mustConnect(t, rateSrc, rate)
+	mustConnect(t, rate, rateSink)
+	if err := p.AddNodeGroup("bypass", rateSrc, rate, rateSink); err != nil {
+		t.Fatal(err)
+	}
+
+	bypassErrs := collectErrors(p.ErrChanFor("bypass"))
+	if errs := runAndWait(t, p); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if errs := bypassErrs.wait(t); len(errs) != 3 {
+		t.Errorf("bypass group errors = %v, want two failures and %v", errs, node.ErrBypassEngaged)
+	}
+	if s := p.GroupStats("bypass"); !s.Bypass || s.Bypassed != 3 {
+		t.Errorf("group Bypass = %v, Bypassed = %d, want true, 3", s.Bypass, s.Bypassed)
+	}
+	s := p.GroupStats("ingest")
+	// 0 прочитан обработчиком, остальные отброшены
+	if s.Discarded != 4 {
+		t.Errorf("group Discarded = %d, want 4", s.Discarded)
+	}
+	if s.Suppressed != 3 {
+		t.Errorf("group Suppressed = %d, want 3", s.Suppressed)
+	}
+	if s.Bypass || s.Bypassed != 0 {
+		t.Errorf("group without bypassing nodes: Bypass = %v, Bypassed = %d", s.Bypass, s.Bypassed)
+	}
+}
+
+func TestGroupStatsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	src := sliceSource("src", ints(4))
+	held := node.NewMap("held", func(_ context.Context, v int) (int, error) {
+		<-release
+		return v, nil
+	}, node.WithConcurrency(2))
+	sink, _ := sliceSink[int]("sink")
+	mustConnect(t, src, held)
+	mustConnect(t, held, sink)
+	p := New()
+	if err := p.AddNodeGroup("ingest", src, held); err != nil {
+		t.Fatal(err)
+	}
+	mustAdd(t, p, sink)
+
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	// InFlight суммируется по нодам группы, пока они заняты
+	eventually(t, func() bool { return p.GroupStats("ingest").InFlight == 2 })
+	close(release)
+	waitTimeout(t, p)
+	if errs := errs.wait(t); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if n := p.GroupStats("ingest").InFlight; n != 0 {
+		t.Errorf("group InFlight = %d after completion, want 0", n)
+	}
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// errCollector собирает ошибки из канала до его закрытия
+type errCollector struct {
+	mu   sync.Mutex
+	errs []error
+	done chan struct{}
+}
+
+// collectErrors начинает читать ошибки из ch
+func collectErrors(ch <-chan error) *errCollector {
+	c := &errCollector{done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		for err := range ch {
+			c.mu.Lock()
+			c.errs = append(c.errs, err)
+			c.mu.Unlock()
+		}
+	}()
+	return c
+}
+
+// wait дожидается закрытия канала и возвращает собранные ошибки
+func (c *errCollector) wait(t *testing.T) []error {
+	t.Helper()
+	select {
+	case <-c.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("error channel was not closed")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.errs
+}
+
+// runAndWait запускает пайплайн с общим каналом ошибок, дожидается его завершения и возвращает ошибки
+func runAndWait(t *testing.T, p *Pipeline) []error {
+	t.Helper()
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	waitTimeout(t, p)
+	return errs.wait(t)
+}
+
+// waitTimeout вызывает Wait и проваливает тест, если пайплайн не завершился за разумное время
+func waitTimeout(t *testing.T, p *Pipeline) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return")
+	}
+}
+
+// sliceSource источник, отдающий items по порядку
+func sliceSource[T any](name string, items []T, opts ...node.Option) *node.Node[struct{}, T] {
+	return node.NewSource(name, 1, nil, SeqSource(slices.Values(items)), opts...)
+}
+
+// sliceSink приёмник, собирающий значения; результат доступен после завершения пайплайна
+func sliceSink[T any](name string, opts ...node.Option) (*node.Node[T, struct{}], *[]T) {
+	var got []T
+	sink := node.NewSink(name, 1, func(_ context.Context, v T) error {
+		got = append(got, v)
+		return nil
+	}, opts...)
+	return sink, &got
+}
+
+// ints возвращает числа 0..n-1
+func ints(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+// mustConnect соединяет выход from[0] со входом to[0]
+func mustConnect[I, O, T any](t *testing.T, from *node.Node[I, O], to *node.Node[O, T]) {
+	t.Helper()
+	if err := node.Connect(from, 0, to, 0); err != nil {
+		t.Fatalf("Connect %s -> %s: %v", from.Name(), to.Name(), err)
+	}
+}
+
+// mustAdd добавляет ноды в пайплайн
+func mustAdd(t *testing.T, p *Pipeline, nodes ...Runnable) {
+	t.Helper()
+	if err := p.AddNode(nodes...); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+}
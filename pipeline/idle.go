@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var (
+	// ErrIdleTimeout пайплайн завершён после WithIdleTimeout без входных элементов
+	ErrIdleTimeout = errors.New("idle timeout")
+	// ErrIdleUntracked у источника нет статистики, по которой WithIdleTimeout определяет активность
+	ErrIdleUntracked = errors.New("idle timeout needs source stats")
+)
+
+// idleChecks количество проверок бездействия за время WithIdleTimeout
+const idleChecks = 4
+
+// WithIdleTimeout завершает пайплайн, когда за время d ни одна нода не прочитала и не отправила
+// ни одного элемента (node.Stats.LastActivity), буферы рёбер пусты и ни одна нода Map-стиля не
+// обрабатывает элемент (node.Stats.InFlight). Тогда все источники node.NewSource останавливаются,
+// ноды получают MaxRuntimeGrace на завершение, после чего пайплайн останавливается через Stop;
+// причина ErrIdleTimeout записывается в ErrorSummary.Terminated. Активность видна только у нод со
+// статистикой, поэтому все источники должны быть созданы с node.WithStats, иначе Run возвращает
+// ErrIdleUntracked. Отсчёт начинается при запуске по часам WithClock; при подмене часов ноды
+// должны использовать те же (node.WithClock). Нулевое или отрицательное d отключает проверку.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = max(d, 0)
+	}
+}
+
+// statsReporter нода, сообщающая, ведёт ли она статистику
+type statsReporter interface {
+	HasStats() bool
+}
+
+// checkIdleTracking проверяет, что активность всех источников видна WithIdleTimeout
+func (p *Pipeline) checkIdleTracking() error {
+	if p.opts.idleTimeout == 0 {
+		return nil
+	}
+
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if !isSourceNode(n) {
+				continue
+			}
+			if s, ok := n.(statsReporter); !ok || !s.HasStats() {
+				return fmt.Errorf("%w: %s", ErrIdleUntracked, nodeName(n))
+			}
+		}
+	}
+	return nil
+}
+
+// guardIdle проверяет бездействие пайплайна до завершения нод или отмены ctx. При бездействии
+// запускает завершение пайплайна в отдельной горутине, так как Stop дожидается вспомогательных.
+func (p *Pipeline) guardIdle(ctx context.Context) error {
+	clock, done := p.clock(), p.monitorDone
+	timeout := p.opts.idleTimeout
+	started := clock.Now()
+	for {
+		select {
+		case <-clock.After(timeout / idleChecks):
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+
+		last, busy := p.activity()
+		if busy {
+			continue
+		}
+		if last.Before(started) {
+			last = started
+		}
+		if clock.Now().Sub(last) >= timeout {
+			p.summary.terminate(ErrIdleTimeout)
+			go p.drainAndStop(clock, done, p.drainSources)
+			return nil
+		}
+	}
+}
+
+// activity возвращает время последней активности нод и сообщает, есть ли элементы в буферах рёбер
+// или в обработке
+func (p *Pipeline) activity() (last time.Time, busy bool) {
+	if len(p.BufferUsage()) > 0 {
+		return time.Time{}, true
+	}
+
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			s, ok := n.(interface{ Stats() node.Stats })
+			if !ok {
+				continue
+			}
+			st := s.Stats()
+			if st.InFlight > 0 {
+				return time.Time{}, true
+			}
+			if st.LastActivity.After(last) {
+				last = st.LastActivity
+			}
+		}
+	}
+	return last, false
+}
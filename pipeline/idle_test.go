@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestWithIdleTimeout(t *testing.T) {
+	const timeout = 4 * time.Minute
+	const check = timeout / idleChecks
+	tests := []struct {
+		name string
+		// sendAt номер проверки, перед которой источник выдаёт элемент (0 — не выдаёт)
+		sendAt int
+		// wantChecks проверка, на которой пайплайн признаётся бездействующим
+		wantChecks int
+	}{
+		// отсчёт от запуска
+		{"idle from start", 0, 4},
+		// элемент откладывает завершение на timeout от момента его чтения
+		{"activity", 3, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			trigger := make(chan int)
+			source := node.NewSource("source", 1, nil, func(ctx context.Context, output chan<- int, _ chan<- error) {
+				for {
+					select {
+					case v := <-trigger:
+						select {
+						case output <- v:
+						case <-ctx.Done():
+							return
+						}
+					case <-ctx.Done():
+						return
+					}
+				}
+			}, node.WithStats(), node.WithClock(clock))
+			var received atomic.Int64
+			sink := node.NewSink("sink", 1, func(context.Context, int) error {
+				received.Add(1)
+				return nil
+			})
+			mustConnect(t, source, sink)
+			p := New(WithIdleTimeout(timeout), WithClock(clock))
+			mustAdd(t, p, source, sink)
+
+			errs := collectErrors(p.ErrChan())
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			for i := 1; i <= tt.wantChecks; i++ {
+				if i == tt.sendAt {
+					trigger <- i
+					eventually(t, func() bool { return received.Load() == 1 })
+				}
+				// проверка бездействия ждёт очередного срока
+				eventually(t, func() bool { return clock.waiting() == 1 })
+				if p.Summary().Terminated != nil {
+					t.Fatalf("terminated before check %d", i)
+				}
+				clock.Advance(check)
+			}
+
+			err := p.WaitErr()
+			if got := errs.wait(t); len(got) > 0 {
+				t.Errorf("errors: %v", got)
+			}
+			if terminated := p.Summary().Terminated; !errors.Is(terminated, ErrIdleTimeout) ||
+				!errors.Is(err, ErrIdleTimeout) {
+				t.Errorf("terminated %v, WaitErr %v; want %v", terminated, err, ErrIdleTimeout)
+			}
+			// источник остановлен, приёмник завершился после закрытия входа
+			if r := sink.ExitReason(); r != node.ExitInputClosed {
+				t.Errorf("sink ExitReason = %v, want %v", r, node.ExitInputClosed)
+			}
+		})
+	}
+}
+
+func TestWithIdleTimeoutUntracked(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr error
+	}{
+		{"source without stats", time.Minute, ErrIdleUntracked},
+		// нулевое время отключает проверку и требование статистики
+		{"disabled", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := sliceSource("src", ints(3))
+			sink, got := sliceSink[int]("sink")
+			mustConnect(t, src, sink)
+			p := New(WithIdleTimeout(tt.timeout))
+			mustAdd(t, p, src, sink)
+			err := p.Run(context.Background(), true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				waitTimeout(t, p)
+				if len(*got) != 3 {
+					t.Errorf("sink got %v, want 3 items", *got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// walkStage ноды пайплайна "walk": источник путей и фильтр по расширению
+func walkStage(paths []string) (*node.Node[struct{}, string], *node.Node[string, string]) {
+	walker := sliceSource("walker", paths)
+	filter := node.NewFilter("filter", func(_ context.Context, path string) (bool, error) {
+		return strings.HasSuffix(path, ".go"), nil
+	})
+	return walker, filter
+}
+
+// hashStage ноды пайплайна "hash": хешер и приёмник результатов
+func hashStage() (*node.Node[string, string], *node.Node[string, struct{}], *[]string) {
+	hasher := node.NewMap("hasher", func(_ context.Context, path string) (string, error) {
+		return fmt.Sprintf("%x  %s", sha256.Sum256([]byte(path)), path), nil
+	}, node.WithConcurrency(3))
+	sink, got := sliceSink[string]("sink")
+	return hasher, sink, got
+}
+
+func TestJoinPipelines(t *testing.T) {
+	var paths []string
+	for i := range 50 {
+		paths = append(paths, fmt.Sprintf("dir/file%d.go", i), fmt.Sprintf("dir/file%d.txt", i))
+	}
+
+	// монолитный пайплайн
+	walker, filter := walkStage(paths)
+	hasher, sink, want := hashStage()
+	mustConnect(t, walker, filter)
+	mustConnect(t, filter, hasher)
+	mustConnect(t, hasher, sink)
+	mono := New()
+	mustAdd(t, mono, walker, filter, hasher, sink)
+	if errs := runAndWait(t, mono); len(errs) > 0 {
+		t.Fatalf("monolithic errors: %v", errs)
+	}
+
+	// те же стадии, собранные отдельными пайплайнами и соединённые каналом
+	walker, filter = walkStage(paths)
+	mustConnect(t, walker, filter)
+	walk := New()
+	mustAdd(t, walk, walker, filter)
+	hasher, sink, got := hashStage()
+	mustConnect(t, hasher, sink)
+	hash := New()
+	mustAdd(t, hash, hasher, sink)
+
+	link := make(chan string)
+	if err := filter.SetOutput(0, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := hasher.SetInput(0, link); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	walkErrs, hashErrs := collectErrors(walk.ErrChan()), collectErrors(hash.ErrChan())
+	for _, p := range []*Pipeline{hash, walk} {
+		if err := p.Run(ctx, true); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+	// закрытие выхода filter по завершении walk закрывает вход hasher
+	waitTimeout(t, walk)
+	waitTimeout(t, hash)
+	if errs := append(walkErrs.wait(t), hashErrs.wait(t)...); len(errs) > 0 {
+		t.Fatalf("joined errors: %v", errs)
+	}
+
+	slices.Sort(*want)
+	slices.Sort(*got)
+	if len(*got) != 50 || !slices.Equal(*got, *want) {
+		t.Errorf("joined results differ from monolithic: got %d, want %d", len(*got), len(*want))
+	}
+}
@@ -0,0 +1,210 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// fanGraph строит source -> (fan-out) два обработчика -> (fan-in) приёмник со статистикой, чтобы
+// задействовать горутины распределения, слияния, счётчиков и пересылки ошибок
+func fanGraph(t *testing.T, src *node.Node[struct{}, int]) *Pipeline {
+	t.Helper()
+	sink := node.NewSink("sink", 2, func(context.Context, int) error { return nil }, node.WithStats())
+	p := New()
+	mustAdd(t, p, src, sink)
+	for i := range 2 {
+		worker := node.NewMap("worker "+string(rune('a'+i)), func(_ context.Context, v int) (int, error) {
+			return v, nil
+		}, node.WithStats(), node.WithConcurrency(2))
+		if err := node.Connect(src, i, worker, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := node.Connect(worker, 0, sink, i); err != nil {
+			t.Fatal(err)
+		}
+		mustAdd(t, p, worker)
+	}
+	return p
+}
+
+// stopTimeout вызывает Stop и проваливает тест, если пайплайн не остановился за разумное время
+func stopTimeout(t *testing.T, p *Pipeline) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Stop()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
+
+func TestNoGoroutinesAfterWait(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"wait", func(t *testing.T) {
+			p := fanGraph(t, node.NewSource("source", 2, nil, SeqSource(func(yield func(int) bool) {
+				for i := range 100 {
+					if !yield(i) {
+						return
+					}
+				}
+			}), node.WithStats()))
+			if err := p.Run(context.Background(), false); err != nil {
+				t.Fatal(err)
+			}
+			p.Wait()
+		}},
+		{"stop", func(t *testing.T) {
+			started := make(chan struct{})
+			p := fanGraph(t, node.NewSource("source", 2, nil, func(ctx context.Context, output chan<- int,
+				_ chan<- error) {
+				close(started)
+				for i := 0; ; i++ {
+					select {
+					case output <- i:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}, node.WithStats()))
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			<-started
+			p.Stop()
+		}},
+		// обработчик с двумя выходами завершается по отмене, не закрыв выход: распределение выходов
+		// узла не ждёт закрытия и не держит Stop
+		{"stop, output left open", func(t *testing.T) {
+			started := make(chan struct{})
+			p := fanGraph(t, node.New("source", 0, 2, nil, func(ctx context.Context, _ <-chan struct{},
+				output chan<- int, _ chan<- error) {
+				close(started)
+				for i := 0; ; i++ {
+					select {
+					case output <- i:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}))
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			<-started
+			stopTimeout(t, p)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			for i := range 50 {
+				tt.run(t)
+				// после Wait и Stop горутин пакета не остаётся: проверка без ожидания
+				if n := runtime.NumGoroutine(); n > base {
+					buf := make([]byte, 1<<16)
+					t.Fatalf("iteration %d: %d goroutines, %d before\n%s", i, n, base, buf[:runtime.Stack(buf, true)])
+				}
+			}
+		})
+	}
+}
+
+func TestRunStopRace(t *testing.T) {
+	n := 2000
+	if testing.Short() {
+		n = 200
+	}
+	base := runtime.NumGoroutine()
+	for i := range n {
+		p := fanGraph(t, node.NewSource("source", 2, nil, func(ctx context.Context, output chan<- int,
+			_ chan<- error) {
+			for i := 0; ; i++ {
+				select {
+				case output <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}))
+		// Stop, пришедший во время запуска, дожидается его и останавливает все ноды; пришедший до
+		// запуска ничего не делает, и пайплайн останавливается повторным Stop
+		runErr := make(chan error, 1)
+		go func() { runErr <- p.Run(context.Background(), true) }()
+		go p.Stop()
+		if err := <-runErr; err != nil {
+			t.Fatalf("iteration %d: Run: %v", i, err)
+		}
+		p.Stop()
+		waitTimeout(t, p)
+		settleGoroutines(t, base)
+	}
+}
+
+func TestStopWaitRace(t *testing.T) {
+	errAux := errors.New("aux")
+	for i := range 200 {
+		var sent atomic.Int32
+		source := node.NewSource("source", 1, []int{5}, func(ctx context.Context, output chan<- int, _ chan<- error) {
+			for i := 0; ; i++ {
+				select {
+				case output <- i:
+					sent.Add(1)
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+		// обработчик не читает вход: элементы остаются в буфере ребра
+		idle := node.New("idle", 1, 0, nil, func(ctx context.Context, _ <-chan int, _ chan<- struct{}, _ chan<- error) {
+			<-ctx.Done()
+		})
+		mustConnect(t, source, idle)
+		p := New()
+		mustAdd(t, p, source, idle)
+		// вспомогательная горутина завершается с задержкой после отмены, её ошибка попадает в Summary
+		p.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(time.Millisecond)
+			return errAux
+		})
+		if err := p.Run(context.Background(), false); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, func() bool { return sent.Load() >= 5 })
+
+		// кто бы ни выиграл гонку за finish, оба вызова возвращаются после его окончания
+		var wg sync.WaitGroup
+		for _, f := range []func(){p.Stop, p.Wait} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f()
+				stranded := 0
+				for _, n := range p.Stranded() {
+					stranded += n
+				}
+				if want := int(sent.Load()); stranded != want {
+					t.Errorf("iteration %d: stranded %d items, want %d", i, stranded, want)
+				}
+				if s := p.Summary(); s.Item+s.Node+s.Infra != 1 {
+					t.Errorf("iteration %d: summary %+v, want the auxiliary error", i, s)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var ErrInvalidWorkers = errors.New("workers must be positive")
+
+// MapReduce строит пайплайн из источника, workers параллельных узлов-преобразователей и
+// узла-свёртки: source -> mapper x workers -> reducer. Возвращает пайплайн и канал результатов,
+// который закрывается после завершения reducer (reducer обязан закрыть свой output).
+// Опции применяются ко всем узлам; WithRetry и WithTimeout действуют только на mapper.
+// С node.WithOrderedOutput результаты mapper поступают в reducer в порядке выдачи источника
+// (см. node.NewOrderedStage и node.WithReorderWindow).
+func MapReduce[I, M, O any](source node.SourceFn[I], mapper node.MapFn[I, M], workers int, reducer node.Handler[M, O],
+	opts ...node.Option) (*Pipeline, <-chan O, error) {
+	if workers < 1 {
+		return nil, nil, ErrInvalidWorkers
+	}
+
+	sourceOutputs, reducerInputs := workers, workers
+	if node.HasOrderedOutput(opts...) {
+		sourceOutputs, reducerInputs = 1, 1
+	}
+
+	buffSize := make([]int, sourceOutputs)
+	for i := range buffSize {
+		buffSize[i] = 1
+	}
+	sourceNode := node.NewSource("Source", sourceOutputs, buffSize, source, opts...)
+
+	result := make(chan O, workers)
+	reducerNode := node.New("Reducer", reducerInputs, 1, nil, reducer, opts...)
+	err := reducerNode.AutowireOutput(result)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if node.HasOrderedOutput(opts...) {
+		return orderedMapReduce(sourceNode, mapper, workers, reducerNode, result, opts)
+	}
+
+	mapperNodes := make([]*node.Node[I, M], 0, workers)
+	for i := 0; i < workers; i++ {
+		m := node.NewMap(fmt.Sprintf("Mapper %d", i), mapper, append([]node.Option{node.WithOutputBuffers(1)}, opts...)...)
+		err := node.Autowire(m, reducerNode)
+		if err != nil {
+			return nil, nil, err
+		}
+		mapperNodes = append(mapperNodes, m)
+	}
+
+	err = node.Autowire(sourceNode, mapperNodes...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pipe := New()
+	err = pipe.AddNode(sourceNode)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range mapperNodes {
+		err = pipe.AddNode(m)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err = pipe.AddNode(reducerNode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pipe, result, nil
+}
+
+// orderedMapReduce соединяет source -> упорядоченная стадия mapper -> reducer
+func orderedMapReduce[I, M, O any](sourceNode *node.Node[struct{}, I], mapper node.MapFn[I, M], workers int,
+	reducerNode *node.Node[M, O], result <-chan O, opts []node.Option) (*Pipeline, <-chan O, error) {
+	stage := node.NewOrderedStage("Mapper", workers, mapper, opts...)
+	err := node.Autowire(sourceNode, stage.Sequencer)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = node.Autowire(stage.Reorder, reducerNode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pipe := New()
+	err = pipe.AddNode(sourceNode, stage.Sequencer)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range stage.Workers {
+		err = pipe.AddNode(stage.Workers[i])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err = pipe.AddNode(stage.Reorder, reducerNode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pipe, result, nil
+}
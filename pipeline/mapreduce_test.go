@@ -0,0 +1,181 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestMapReduce(t *testing.T) {
+	errOdd := errors.New("odd")
+	double := func(_ context.Context, v int) (int, error) {
+		return v * 2, nil
+	}
+	doubled := func(n int) []int {
+		want := make([]int, n)
+		for i := range want {
+			want[i] = i * 2
+		}
+		return want
+	}
+
+	tests := []struct {
+		name    string
+		workers int
+		mapper  func() node.MapFn[int, int]
+		reducer node.Handler[int, int]
+		opts    []node.Option
+		// sorted сравнивать результат без учёта порядка
+		sorted  bool
+		want    []int
+		wantErr error
+		// wantErrs количество ошибок в ErrChan, каждая должна содержать wantErr
+		wantErrs int
+	}{
+		{
+			name: "unordered", workers: 3, sorted: true, want: doubled(20),
+			mapper: func() node.MapFn[int, int] { return jitter(double) },
+		},
+		{
+			name: "ordered", workers: 3, opts: []node.Option{node.WithOrderedOutput()}, want: doubled(20),
+			mapper: func() node.MapFn[int, int] { return jitter(double) },
+		},
+		{
+			name: "single worker", workers: 1, want: doubled(20),
+			mapper: func() node.MapFn[int, int] { return double },
+		},
+		{
+			// reducer сворачивает поток в одно значение, и оно попадает в возвращённый канал
+			name: "reducer output", workers: 3, want: []int{380},
+			mapper: func() node.MapFn[int, int] { return double },
+			reducer: func(_ context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+				defer close(output)
+				sum := 0
+				for v := range input {
+					sum += v
+				}
+				output <- sum
+			},
+		},
+		{
+			name: "mapper errors", workers: 3, sorted: true, want: []int{0, 4, 8, 12, 16, 20, 24, 28, 32, 36},
+			wantErr: errOdd, wantErrs: 10,
+			mapper: func() node.MapFn[int, int] {
+				return func(ctx context.Context, v int) (int, error) {
+					if v%2 == 1 {
+						return 0, errOdd
+					}
+					return double(ctx, v)
+				}
+			},
+		},
+		{
+			// каждый элемент падает при первой попытке: без повтора на любом из mapper появилась бы ошибка
+			name: "retry on every mapper", workers: 3, sorted: true, want: doubled(20),
+			opts:   []node.Option{node.WithRetry(1, nil)},
+			mapper: func() node.MapFn[int, int] { return failOnce(double) },
+		},
+		{
+			name: "no retry", workers: 3, sorted: true, want: nil, wantErr: errFirstAttempt, wantErrs: 20,
+			mapper: func() node.MapFn[int, int] { return failOnce(double) },
+		},
+		{
+			// mapper ждёт отмены вызова: каждый элемент завершается по тайм-ауту
+			name: "timeout on every mapper", workers: 3, want: nil,
+			opts: []node.Option{node.WithTimeout(10 * time.Millisecond)}, wantErr: context.DeadlineExceeded, wantErrs: 20,
+			mapper: func() node.MapFn[int, int] {
+				return func(ctx context.Context, _ int) (int, error) {
+					<-ctx.Done()
+					return 0, ctx.Err()
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reducer := tt.reducer
+			if reducer == nil {
+				reducer = node.LoopHandler(func(_ context.Context, v int, emit *node.Emitter[int]) error {
+					return emit.Send(v)
+				})
+			}
+			p, result, err := MapReduce(SeqSource(slices.Values(ints(20))), tt.mapper(), tt.workers, reducer, tt.opts...)
+			if err != nil {
+				t.Fatalf("MapReduce: %v", err)
+			}
+
+			var got []int
+			collected := make(chan struct{})
+			go func() {
+				defer close(collected)
+				for v := range result {
+					got = append(got, v)
+				}
+			}()
+			errs := runAndWait(t, p)
+			select {
+			case <-collected:
+			case <-time.After(5 * time.Second):
+				t.Fatal("result channel was not closed")
+			}
+
+			if tt.sorted {
+				slices.Sort(got)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+			if len(errs) != tt.wantErrs {
+				t.Errorf("got %d errors, want %d: %v", len(errs), tt.wantErrs, errs)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error %v, want %v", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestMapReduceInvalidWorkers(t *testing.T) {
+	for _, workers := range []int{0, -1} {
+		p, result, err := MapReduce(SeqSource(slices.Values(ints(3))), func(_ context.Context, v int) (int, error) {
+			return v, nil
+		}, workers, node.LoopHandler(func(_ context.Context, v int, emit *node.Emitter[int]) error {
+			return emit.Send(v)
+		}))
+		if !errors.Is(err, ErrInvalidWorkers) {
+			t.Errorf("workers %d: err = %v, want ErrInvalidWorkers", workers, err)
+		}
+		if p != nil || result != nil {
+			t.Errorf("workers %d: got non-nil pipeline or result", workers)
+		}
+	}
+}
+
+var errFirstAttempt = errors.New("first attempt")
+
+// jitter добавляет к f случайную задержку, перемешивающую порядок завершения у параллельных mapper
+func jitter(f node.MapFn[int, int]) node.MapFn[int, int] {
+	return func(ctx context.Context, v int) (int, error) {
+		time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+		return f(ctx, v)
+	}
+}
+
+// failOnce возвращает errFirstAttempt при первом вызове для каждого значения
+func failOnce(f node.MapFn[int, int]) node.MapFn[int, int] {
+	var seen sync.Map
+	return func(ctx context.Context, v int) (int, error) {
+		if _, loaded := seen.LoadOrStore(v, struct{}{}); !loaded {
+			return 0, errFirstAttempt
+		}
+		return f(ctx, v)
+	}
+}
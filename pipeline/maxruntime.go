@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// ErrMaxRuntime пайплайн остановлен по истечении WithMaxRuntime
+var ErrMaxRuntime = errors.New("max runtime exceeded")
+
+// MaxRuntimeGrace время, которое ноды получают на дообработку уже выданных элементов после
+// истечения WithMaxRuntime, прежде чем пайплайн будет остановлен через Stop
+const MaxRuntimeGrace = 5 * time.Second
+
+// WithMaxRuntime ограничивает время работы пайплайна независимо от контекста Run. Отсчёт начинается
+// при Run или PreStart. По истечении d
+// бесконечные источники останавливаются, как в Shutdown, ноды получают MaxRuntimeGrace на
+// дообработку, после чего пайплайн останавливается через Stop; причина завершения ErrMaxRuntime
+// записывается в ErrorSummary.Terminated. Если ноды завершились раньше, таймер снимается.
+// Нулевое или отрицательное d снимает ограничение.
+func WithMaxRuntime(d time.Duration) Option {
+	return func(o *options) {
+		o.maxRuntime = max(d, 0)
+	}
+}
+
+// WithMaxRuntimePauseAware исключает из отсчёта WithMaxRuntime время, пока хотя бы одна нода
+// приостановлена через Command (node.CmdPause). По умолчанию время идёт и во время паузы.
+func WithMaxRuntimePauseAware() Option {
+	return func(o *options) {
+		o.maxRuntimePauseAware = true
+	}
+}
+
+// WithClock подменяет источник времени пайплайна (таймер WithMaxRuntime), например, в тестах
+func WithClock(clock node.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// clock возвращает источник времени пайплайна
+func (p *Pipeline) clock() node.Clock {
+	if p.opts.clock != nil {
+		return p.opts.clock
+	}
+	return node.SystemClock
+}
+
+// pauses ноды, приостановленные через Command; changed получает сигнал при каждом изменении
+type pauses struct {
+	mu      sync.Mutex
+	paused  map[string]bool
+	changed chan struct{}
+}
+
+func newPauses() *pauses {
+	return &pauses{paused: make(map[string]bool), changed: make(chan struct{}, 1)}
+}
+
+// set отмечает паузу или возобновление ноды name
+func (s *pauses) set(name string, paused bool) {
+	s.mu.Lock()
+	if paused {
+		s.paused[name] = true
+	} else {
+		delete(s.paused, name)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// any сообщает, приостановлена ли хотя бы одна нода
+func (s *pauses) any() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.paused) > 0
+}
+
+// guardRuntime ждёт истечения WithMaxRuntime, завершения нод или отмены ctx. По истечении
+// запускает остановку пайплайна в отдельной горутине, так как Stop дожидается вспомогательных.
+func (p *Pipeline) guardRuntime(ctx context.Context) error {
+	clock, done := p.clock(), p.monitorDone
+	remaining := p.opts.maxRuntime
+	for {
+		paused := p.opts.maxRuntimePauseAware && p.pauses.any()
+		var expired <-chan time.Time
+		start := clock.Now()
+		if !paused {
+			expired = clock.After(remaining)
+		}
+
+		select {
+		case <-expired:
+			p.summary.terminate(ErrMaxRuntime)
+			go p.drainAndStop(clock, done, p.stopSources)
+			return nil
+		case <-p.pauses.changed:
+			if !paused {
+				remaining -= clock.Now().Sub(start)
+			}
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// drainAndStop останавливает источники через stopSources, ждёт завершения нод не дольше
+// MaxRuntimeGrace и останавливает пайплайн
+func (p *Pipeline) drainAndStop(clock node.Clock, done <-chan struct{}, stopSources func()) {
+	stopSources()
+	select {
+	case <-done:
+	case <-clock.After(MaxRuntimeGrace):
+	}
+	p.Stop()
+}
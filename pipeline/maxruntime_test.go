@@ -0,0 +1,144 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// fakeClock Clock, время которого сдвигает тест через Advance; канал After срабатывает, когда
+// время доходит до срока
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// waiting возвращает количество ожидающих каналов After
+func (c *fakeClock) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Advance сдвигает время на d и срабатывает наступившие сроки After
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+func TestWithMaxRuntime(t *testing.T) {
+	tests := []struct {
+		name string
+		// infinite источник не завершается сам
+		infinite bool
+		// stuck приёмник не возвращается из обработки элемента до отмены контекста
+		stuck bool
+		// wantTerminated пайплайн остановлен по истечении WithMaxRuntime
+		wantTerminated bool
+	}{
+		// источник завершился раньше срока: таймер снимается
+		{"natural completion", false, false, false},
+		// по истечении срока источник останавливается, приёмник дообрабатывает выданное
+		{"expired", true, false, true},
+		// приёмник не успевает за MaxRuntimeGrace: пайплайн останавливается через Stop
+		{"expired past grace", true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			var sent atomic.Int64
+			source := node.NewSource("source", 1, nil, func(ctx context.Context, output chan<- int, _ chan<- error) {
+				for i := 0; tt.infinite || i < 3; i++ {
+					select {
+					case output <- i:
+						sent.Add(1)
+					case <-ctx.Done():
+						return
+					}
+				}
+			}, node.WithInfiniteSource())
+			sink := node.NewSink("sink", 1, func(ctx context.Context, _ int) error {
+				if tt.stuck {
+					<-ctx.Done()
+				}
+				return nil
+			})
+			mustConnect(t, source, sink)
+			p := New(WithMaxRuntime(time.Minute), WithClock(clock))
+			mustAdd(t, p, source, sink)
+
+			errs := collectErrors(p.ErrChan())
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			if tt.infinite {
+				// таймер взведён при Run; до срока пайплайн работает
+				eventually(t, func() bool { return clock.waiting() == 1 && sent.Load() > 0 })
+				clock.Advance(time.Minute - time.Second)
+				if p.Summary().Terminated != nil {
+					t.Fatal("terminated before max runtime")
+				}
+				clock.Advance(time.Second)
+			}
+			if tt.stuck {
+				// после истечения срока ожидается MaxRuntimeGrace
+				eventually(t, func() bool { return clock.waiting() == 1 })
+				clock.Advance(MaxRuntimeGrace)
+			}
+
+			err := p.WaitErr()
+			if got := errs.wait(t); len(got) > 0 {
+				t.Errorf("errors: %v", got)
+			}
+			terminated := p.Summary().Terminated
+			switch {
+			case tt.wantTerminated && (!errors.Is(terminated, ErrMaxRuntime) || !errors.Is(err, ErrMaxRuntime)):
+				t.Errorf("terminated %v, WaitErr %v; want %v", terminated, err, ErrMaxRuntime)
+			case !tt.wantTerminated && (terminated != nil || err != nil):
+				t.Errorf("terminated %v, WaitErr %v; want natural completion", terminated, err)
+			}
+			wantExit := node.ExitInputClosed
+			if tt.stuck {
+				wantExit = node.ExitCancelled
+			}
+			if r := sink.ExitReason(); r != wantExit {
+				t.Errorf("sink ExitReason = %v, want %v", r, wantExit)
+			}
+		})
+	}
+}
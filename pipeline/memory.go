@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// ErrMemoryLimit куча процесса превысила лимит WithMemoryGuard
+var ErrMemoryLimit = errors.New("memory limit exceeded")
+
+// memoryReportBuffers количество крупнейших буферов в тексте MemoryLimitError
+const memoryReportBuffers = 5
+
+// memoryGuard параметры WithMemoryGuard
+type memoryGuard struct {
+	limit uint64
+	check time.Duration
+}
+
+// WithMemoryGuard включает проверку размера кучи (runtime.MemStats.HeapAlloc) с периодом check
+// во время работы пайплайна. При превышении limitBytes в ErrChan отправляется *MemoryLimitError
+// с классом node.ClassInfra и заполненностью буферов нод (см. node.BufferUsage, node.WithItemSize).
+// Повторно ошибка отправляется только после того, как куча опустится ниже лимита. Нулевой
+// limitBytes или неположительный check отключают проверку.
+func WithMemoryGuard(limitBytes uint64, check time.Duration) Option {
+	return func(o *options) {
+		o.memoryGuard = nil
+		if limitBytes > 0 && check > 0 {
+			o.memoryGuard = &memoryGuard{limit: limitBytes, check: check}
+		}
+	}
+}
+
+// MemoryLimitError превышение лимита WithMemoryGuard
+type MemoryLimitError struct {
+	HeapAlloc uint64
+	Limit     uint64
+	// Buffers непустые буферы нод по убыванию оценки памяти, затем количества элементов
+	Buffers []node.BufferUsage
+}
+
+func (e *MemoryLimitError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: heap %d bytes, limit %d bytes", ErrMemoryLimit, e.HeapAlloc, e.Limit)
+	for i, u := range e.Buffers {
+		if i == memoryReportBuffers {
+			fmt.Fprintf(&b, ", %d more", len(e.Buffers)-i)
+			break
+		}
+		sep := ", "
+		if i == 0 {
+			sep = "; buffers: "
+		}
+		fmt.Fprintf(&b, "%s%s %d items", sep, u.Buffer, u.Items)
+		if u.Bytes > 0 {
+			fmt.Fprintf(&b, " (%d bytes)", u.Bytes)
+		}
+	}
+	return b.String()
+}
+
+func (e *MemoryLimitError) Unwrap() error {
+	return ErrMemoryLimit
+}
+
+// bufferUser нода, сообщающая о заполненности своих буферов
+type bufferUser interface {
+	BufferUsage() []node.BufferUsage
+}
+
+// BufferUsage возвращает заполненность буферов всех нод пайплайна в порядке добавления нод.
+// Можно вызывать во время работы пайплайна.
+func (p *Pipeline) BufferUsage() []node.BufferUsage {
+	var usage []node.BufferUsage
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if u, ok := n.(bufferUser); ok {
+				usage = append(usage, u.BufferUsage()...)
+			}
+		}
+	}
+	return usage
+}
+
+// guardMemory проверяет размер кучи до отмены ctx и сообщает о превышении лимита
+func (p *Pipeline) guardMemory(ctx context.Context) error {
+	g := p.opts.memoryGuard
+	ticker := time.NewTicker(g.check)
+	defer ticker.Stop()
+
+	var stats runtime.MemStats
+	exceeded := false
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc <= g.limit {
+			exceeded = false
+			continue
+		}
+		if exceeded {
+			continue
+		}
+		exceeded = true
+
+		buffers := p.BufferUsage()
+		slices.SortStableFunc(buffers, func(a, b node.BufferUsage) int {
+			return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(b.Items, a.Items))
+		})
+		p.auxError(node.Classify(node.ClassInfra, &MemoryLimitError{HeapAlloc: stats.HeapAlloc, Limit: g.limit, Buffers: buffers}))
+	}
+}
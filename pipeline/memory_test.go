@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestWithMemoryGuard(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit uint64
+		check time.Duration
+		// wantErr куча превышает лимит: ошибка отправляется один раз, пока куча не опустится
+		wantErr bool
+	}{
+		{"exceeded", 1, 5 * time.Millisecond, true},
+		{"within limit", 1 << 50, 5 * time.Millisecond, false},
+		{"disabled", 0, 5 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// приёмник ждёт release, поэтому элементы источника копятся в буфере выхода
+			release := make(chan struct{})
+			src := sliceSource("src", ints(10), node.WithOutputBuffers(5), node.WithItemSize(8))
+			sink := node.NewSink("sink", 1, func(context.Context, int) error {
+				<-release
+				return nil
+			})
+			mustConnect(t, src, sink)
+			p := New(WithMemoryGuard(tt.limit, tt.check))
+			mustAdd(t, p, src, sink)
+
+			errs := collectErrors(p.ErrChan())
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			// за время нескольких проверок превышение сообщается однажды
+			time.Sleep(20 * tt.check)
+			close(release)
+			waitTimeout(t, p)
+			got := errs.wait(t)
+
+			if !tt.wantErr {
+				if len(got) > 0 {
+					t.Errorf("errors: %v", got)
+				}
+				return
+			}
+			var mle *MemoryLimitError
+			if len(got) != 1 || !errors.As(got[0], &mle) || !errors.Is(got[0], ErrMemoryLimit) ||
+				node.ClassOf(got[0]) != node.ClassInfra {
+				t.Fatalf("errors = %v, want one ClassInfra MemoryLimitError", got)
+			}
+			if mle.HeapAlloc <= mle.Limit || mle.Limit != tt.limit {
+				t.Errorf("heap %d, limit %d; want heap above %d", mle.HeapAlloc, mle.Limit, tt.limit)
+			}
+			// в разбивке — заполненный буфер ребра с оценкой памяти по WithItemSize
+			if len(mle.Buffers) == 0 || mle.Buffers[0].Items == 0 || mle.Buffers[0].Bytes != uint64(8*mle.Buffers[0].Items) {
+				t.Errorf("buffers %+v, want the filled src edge with 8 bytes per item", mle.Buffers)
+			}
+		})
+	}
+}
+
+func TestMemoryLimitErrorText(t *testing.T) {
+	buffers := func(n int) []node.BufferUsage {
+		var usage []node.BufferUsage
+		for i := range n {
+			usage = append(usage, node.BufferUsage{Buffer: string(rune('a' + i)), Items: n - i})
+		}
+		return usage
+	}
+	tests := []struct {
+		name    string
+		buffers []node.BufferUsage
+		want    string
+	}{
+		{"no buffers", nil, "memory limit exceeded: heap 200 bytes, limit 100 bytes"},
+		{"bytes", []node.BufferUsage{{Buffer: "src[0] -> sink[0]", Items: 4, Bytes: 32}},
+			"memory limit exceeded: heap 200 bytes, limit 100 bytes; buffers: src[0] -> sink[0] 4 items (32 bytes)"},
+		// выводятся только крупнейшие буферы
+		{"truncated", buffers(7), "memory limit exceeded: heap 200 bytes, limit 100 bytes; buffers: " +
+			"a 7 items, b 6 items, c 5 items, d 4 items, e 3 items, 2 more"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &MemoryLimitError{HeapAlloc: 200, Limit: 100, Buffers: tt.buffers}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+			if !strings.HasPrefix(err.Error(), ErrMemoryLimit.Error()) || !errors.Is(err, ErrMemoryLimit) {
+				t.Errorf("%v does not wrap %v", err, ErrMemoryLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package pipeline
+
+// noCopy запрещает копирование Pipeline: go vet (copylocks) сообщает о копиях значений
+// с методами Lock и Unlock
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
@@ -0,0 +1,130 @@
+package node
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// BatchNode узел, собирающий элементы в пакеты
+type BatchNode[T any] struct {
+	*Node[T, []T]
+	size  atomic.Int64
+	flush chan struct{}
+}
+
+// NewBatch создаёт узел, выдающий пакеты по size элементов. Неполный пакет выдаётся при закрытии
+// входа и по команде CmdFlush. Размер меняется на ходу через SetSize или команду CmdSetParam с Param
+// "size" и значением int. Количество входов и выходов задаёт WithPorts, буферы выходов —
+// WithOutputBuffers. Поддерживает паузу и WithEmitOnEmpty с фабрикой func() []T.
+func NewBatch[T any](name string, size int, opts ...Option) *BatchNode[T] {
+	cfg := newConfig(opts)
+	cfg.gated = true
+	var onEmpty func() []T
+	if cfg.emitOnEmpty != nil {
+		f, ok := cfg.emitOnEmpty.(func() []T)
+		if !ok {
+			panic("emit on empty type mismatch")
+		}
+		onEmpty = f
+	}
+
+	b := &BatchNode[T]{flush: make(chan struct{}, 1)}
+	b.SetSize(size)
+	cfg.controller = controlFunc(func(cmd Command) error {
+		switch {
+		case cmd.Kind == CmdFlush:
+			b.Flush()
+			return nil
+		case cmd.Kind == CmdSetParam && cmd.Param == "size":
+			size, err := paramValue[int](cmd)
+			if err != nil {
+				return err
+			}
+			b.SetSize(size)
+			return nil
+		default:
+			return ErrUnsupportedCommand
+		}
+	})
+
+	inputNum, outputNum := cfg.portCounts()
+	b.Node = newNode[T, []T](name, inputNum, outputNum, nil, cfg)
+	b.handler = func(ctx context.Context, input <-chan T, output chan<- []T, errChan chan<- error) {
+		defer close(output)
+
+		var batch []T
+		limit := 0
+		seen := false
+		emit := func() bool {
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case output <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for cfg.gate.wait(ctx) {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					if !seen && onEmpty != nil {
+						batch = onEmpty()
+						select {
+						case output <- batch:
+						case <-ctx.Done():
+						}
+						return
+					}
+					emit()
+					return
+				}
+				if Yield(ctx) != nil {
+					return
+				}
+				seen = true
+
+				if len(batch) == 0 {
+					limit = int(b.size.Load())
+					batch = make([]T, 0, limit)
+				}
+				batch = append(batch, val)
+				if len(batch) >= limit && !emit() {
+					return
+				}
+			case <-b.flush:
+				if !emit() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	return b
+}
+
+// SetSize меняет размер пакета (минимум 1). Текущий пакет собирается по прежнему размеру,
+// новый размер действует со следующего пакета. Безопасен для вызова во время работы узла.
+func (b *BatchNode[T]) SetSize(size int) {
+	b.size.Store(int64(max(size, 1)))
+}
+
+// Size возвращает текущий размер пакета
+func (b *BatchNode[T]) Size() int {
+	return int(b.size.Load())
+}
+
+// Flush требует выдать текущий неполный пакет
+func (b *BatchNode[T]) Flush() {
+	select {
+	case b.flush <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,170 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchSetSize(t *testing.T) {
+	setParam := func(v any) func(*BatchNode[int]) error {
+		return func(n *BatchNode[int]) error {
+			return n.Control(Command{Kind: CmdSetParam, Param: "size", Value: v})
+		}
+	}
+	setSize := func(size int) func(*BatchNode[int]) error {
+		return func(n *BatchNode[int]) error {
+			n.SetSize(size)
+			return nil
+		}
+	}
+	flush := func(n *BatchNode[int]) error {
+		return n.Control(Command{Kind: CmdFlush})
+	}
+	// шаги выполняются по порядку на одном узле: change применяется после отправки send,
+	// want — пакеты, выданные к концу шага. Вход без буфера, поэтому отправка значения
+	// означает, что предыдущее уже добавлено в пакет
+	steps := []struct {
+		name     string
+		send     []int
+		change   func(*BatchNode[int]) error
+		wantErr  error
+		wantSize int
+		want     [][]int
+	}{
+		{"initial size", []int{0, 1}, nil, nil, 2, [][]int{{0, 1}}},
+		{"SetSize", nil, setSize(3), nil, 3, nil},
+		{"new size", []int{2, 3, 4}, nil, nil, 3, [][]int{{2, 3, 4}}},
+		// текущий пакет собирается по прежнему размеру
+		{"mid-batch change", []int{5, 6}, setParam(1), nil, 1, nil},
+		{"finish batch", []int{7}, nil, nil, 1, [][]int{{5, 6, 7}}},
+		{"size one", []int{8}, nil, nil, 1, [][]int{{8}}},
+		{"clamped to one", nil, setSize(0), nil, 1, nil},
+		{"command wrong type", nil, setParam("4"), ErrParamType, 1, nil},
+		{"command", nil, setParam(4), nil, 4, nil},
+		{"flush", []int{9, 10}, flush, nil, 4, [][]int{{9, 10}}},
+		{"flush empty", nil, flush, nil, 4, nil},
+		{"unknown param", nil, func(n *BatchNode[int]) error {
+			return n.Control(Command{Kind: CmdSetParam, Param: "rate", Value: 1.0})
+		}, ErrUnsupportedCommand, 4, nil},
+	}
+
+	n := NewBatch[int]("batch", 2)
+	input := make(chan int)
+	if err := n.SetInput(0, input); err != nil {
+		t.Fatal(err)
+	}
+	output := make(chan []int, len(steps))
+	if err := n.SetOutput(0, output); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errChan := make(chan error)
+	n.Run(context.Background(), &wg, errChan, true)
+
+	for _, st := range steps {
+		for _, v := range st.send {
+			select {
+			case input <- v:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: node does not read %d", st.name, v)
+			}
+		}
+		if st.change != nil {
+			if err := st.change(n); !errors.Is(err, st.wantErr) {
+				t.Fatalf("%s: error = %v, want %v", st.name, err, st.wantErr)
+			}
+		}
+		// лишний пакет шага обнаружится на следующем шаге или после закрытия входа
+		var got [][]int
+		for range st.want {
+			select {
+			case b := <-output:
+				got = append(got, b)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: got batches %v, want %v", st.name, got, st.want)
+			}
+		}
+		if !slices.EqualFunc(got, st.want, slices.Equal) {
+			t.Errorf("%s: batches %v, want %v", st.name, got, st.want)
+		}
+		if size := n.Size(); size != st.wantSize {
+			t.Errorf("%s: Size() = %d, want %d", st.name, size, st.wantSize)
+		}
+	}
+
+	// закрытие входа без накопленных элементов не выдаёт пустой пакет
+	close(input)
+	got := drain(output)
+	waitGroup(t, &wg)
+	if b := got(); len(b) != 0 {
+		t.Errorf("batches after close %v, want none", b)
+	}
+}
+
+func TestBatchEmitOnEmpty(t *testing.T) {
+	empty := func() []int { return []int{} }
+	tests := []struct {
+		name  string
+		items []int
+		opts  []Option
+		// cancel вход не закрывается, узел останавливается отменой контекста
+		cancel bool
+		want   [][]int
+	}{
+		{"empty input", nil, []Option{WithEmitOnEmpty(empty)}, false, [][]int{{}}},
+		// фабрика вызывается только для входа без единого элемента
+		{"items", []int{1, 2, 3}, []Option{WithEmitOnEmpty(empty)}, false, [][]int{{1, 2}, {3}}},
+		{"without option", nil, nil, false, nil},
+		{"cancelled", nil, []Option{WithEmitOnEmpty(empty)}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewBatch[int]("batch", 2, tt.opts...)
+			input := make(chan int)
+			if !tt.cancel {
+				input = feed(tt.items...)
+			}
+			if err := n.SetInput(0, input); err != nil {
+				t.Fatal(err)
+			}
+			output := make(chan []int)
+			if err := n.SetOutput(0, output); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			got := drain(output)
+			var wg sync.WaitGroup
+			errChan := make(chan error, 1)
+			n.Run(ctx, &wg, errChan, true)
+			if tt.cancel {
+				cancel()
+			}
+			waitGroup(t, &wg)
+
+			batches := got()
+			if !slices.EqualFunc(batches, tt.want, slices.Equal) {
+				t.Errorf("batches %v, want %v", batches, tt.want)
+			}
+			// пустой результат отличим от отсутствия результата: пакет не nil
+			for _, b := range batches {
+				if b == nil {
+					t.Error("nil batch")
+				}
+			}
+		})
+	}
+}
+
+func TestBatchEmitOnEmptyTypeMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewBatch with a mismatched factory did not panic")
+		}
+	}()
+	NewBatch[int]("batch", 2, WithEmitOnEmpty(func() []string { return nil }))
+}
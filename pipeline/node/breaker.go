@@ -0,0 +1,170 @@
+package node
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitState состояние автоматического выключателя узла
+type CircuitState int32
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// WithCircuitBreaker включает автоматический выключатель для узлов Map-стиля: после failureThreshold
+// ошибок в пределах window выключатель размыкается и элементы без вызова функции отправляются
+// в dead-letter (или в канал ошибок) с ErrCircuitOpen. По истечении cooldown пропускается один
+// пробный элемент: успех замыкает выключатель, ошибка снова размыкает его.
+func WithCircuitBreaker(failureThreshold int, window, cooldown time.Duration) Option {
+	return func(c *config) {
+		c.breaker = &breakerConfig{
+			threshold: max(failureThreshold, 1),
+			window:    window,
+			cooldown:  cooldown,
+		}
+	}
+}
+
+// circuitEvents события, соответствующие состояниям выключателя
+var circuitEvents = map[CircuitState]EventKind{
+	CircuitOpen:     EventCircuitOpen,
+	CircuitHalfOpen: EventCircuitHalfOpen,
+	CircuitClosed:   EventCircuitClosed,
+}
+
+// breakerConfig параметры выключателя
+type breakerConfig struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+}
+
+// breaker автоматический выключатель
+type breaker struct {
+	cfg      *breakerConfig
+	clock    Clock
+	onChange func(CircuitState)
+
+	mu       sync.Mutex
+	failures []time.Time
+	openedAt time.Time
+	probing  bool
+	// gen поколение выключателя, увеличивается при каждом размыкании: результаты вызовов,
+	// разрешённых до размыкания, не влияют на состояние
+	gen   uint64
+	state atomic.Int32
+}
+
+// breakerToken разрешение allow на вызов: поколение выключателя и признак пробного вызова
+type breakerToken struct {
+	gen   uint64
+	probe bool
+}
+
+func newBreaker(cfg *breakerConfig, clock Clock, onChange func(CircuitState)) *breaker {
+	return &breaker{cfg: cfg, clock: clock, onChange: onChange}
+}
+
+// State возвращает текущее состояние выключателя
+func (b *breaker) State() CircuitState {
+	return CircuitState(b.state.Load())
+}
+
+// allow сообщает, можно ли вызвать функцию для очередного элемента, и возвращает разрешение,
+// которое передаётся в done вместе с результатом вызова
+func (b *breaker) allow() (breakerToken, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.State() {
+	case CircuitOpen:
+		if b.clock.Now().Sub(b.openedAt) < b.cfg.cooldown {
+			return breakerToken{}, false
+		}
+		b.setState(CircuitHalfOpen)
+		b.probing = true
+		return breakerToken{gen: b.gen, probe: true}, true
+	case CircuitHalfOpen:
+		if b.probing {
+			return breakerToken{}, false
+		}
+		b.probing = true
+		return breakerToken{gen: b.gen, probe: true}, true
+	default:
+		return breakerToken{gen: b.gen}, true
+	}
+}
+
+// done фиксирует результат вызова, разрешённого allow с разрешением t. Результаты вызовов прежних
+// поколений отбрасываются, а в полуоткрытом состоянии состояние меняет только пробный вызов:
+// медленный вызов, разрешённый ещё до размыкания (WithConcurrency), не считается пробой.
+func (b *breaker) done(t breakerToken, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t.gen != b.gen {
+		return
+	}
+	now := b.clock.Now()
+	if b.State() == CircuitHalfOpen {
+		if !t.probe {
+			return
+		}
+		b.probing = false
+		if err != nil {
+			b.open(now)
+		} else {
+			b.failures = b.failures[:0]
+			b.setState(CircuitClosed)
+		}
+		return
+	}
+
+	if err == nil {
+		return
+	}
+
+	i := 0
+	for i < len(b.failures) && now.Sub(b.failures[i]) > b.cfg.window {
+		i++
+	}
+	b.failures = append(b.failures[i:], now)
+	if len(b.failures) >= b.cfg.threshold {
+		b.open(now)
+	}
+}
+
+// open размыкает выключатель
+func (b *breaker) open(now time.Time) {
+	b.gen++
+	b.openedAt = now
+	b.failures = b.failures[:0]
+	b.setState(CircuitOpen)
+}
+
+// setState меняет состояние и уведомляет об изменении
+func (b *breaker) setState(s CircuitState) {
+	if CircuitState(b.state.Swap(int32(s))) != s && b.onChange != nil {
+		b.onChange(s)
+	}
+}
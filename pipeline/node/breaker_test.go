@@ -0,0 +1,234 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stepper запускает узел и передаёт ему значения по одному, дожидаясь результата каждого
+type stepper[I, O any] struct {
+	t       *testing.T
+	input   chan I
+	output  chan O
+	errChan chan error
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newStepper[I, O any](t *testing.T, n *Node[I, O]) *stepper[I, O] {
+	t.Helper()
+	s := &stepper[I, O]{t: t, input: make(chan I), output: make(chan O), errChan: make(chan error)}
+	if err := n.SetInput(0, s.input); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetOutput(0, s.output); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	n.Run(ctx, &s.wg, s.errChan, true)
+	t.Cleanup(s.stop)
+	return s
+}
+
+// step отправляет v и возвращает выходное значение или ошибку, порождённые им
+func (s *stepper[I, O]) step(v I) (O, error) {
+	s.t.Helper()
+	var zero O
+	select {
+	case s.input <- v:
+	case <-time.After(5 * time.Second):
+		s.t.Fatal("node does not read its input")
+	}
+	select {
+	case out := <-s.output:
+		return out, nil
+	case err := <-s.errChan:
+		return zero, err
+	case <-time.After(5 * time.Second):
+		s.t.Fatal("no result for the item")
+	}
+	return zero, nil
+}
+
+// stop закрывает вход и дожидается завершения узла
+func (s *stepper[I, O]) stop() {
+	close(s.input)
+	go func() {
+		for range s.output {
+		}
+	}()
+	waitGroup(s.t, &s.wg)
+	s.cancel()
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	errFail := errors.New("fail")
+	const (
+		passed   = "passed"
+		failed   = "failed"
+		rejected = "rejected"
+	)
+	// отрицательные значения завершаются ошибкой функции
+	steps := []struct {
+		advance time.Duration
+		item    int
+		want    string
+		state   CircuitState
+	}{
+		{0, 1, passed, CircuitClosed},
+		{0, -1, failed, CircuitClosed},
+		// первая ошибка вышла из окна: порог снова не достигнут
+		{2 * time.Minute, -2, failed, CircuitClosed},
+		{0, -3, failed, CircuitOpen},
+		{0, 2, rejected, CircuitOpen},
+		// после cooldown пробный элемент с ошибкой снова размыкает выключатель
+		{10 * time.Second, -4, failed, CircuitOpen},
+		{5 * time.Second, 3, rejected, CircuitOpen},
+		// успешный пробный элемент замыкает выключатель
+		{5 * time.Second, 4, passed, CircuitClosed},
+		{0, -5, failed, CircuitClosed},
+		{0, 5, passed, CircuitClosed},
+	}
+
+	clock := newFakeClock()
+	var mu sync.Mutex
+	var events []EventKind
+	n := NewMap("breaker", func(_ context.Context, v int) (int, error) {
+		if v < 0 {
+			return 0, errFail
+		}
+		return v, nil
+	}, WithCircuitBreaker(2, time.Minute, 10*time.Second), WithClock(clock), WithObserver(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e.Kind)
+	}))
+	s := newStepper(t, n)
+
+	for i, st := range steps {
+		clock.Advance(st.advance)
+		out, err := s.step(st.item)
+		var got string
+		switch {
+		case err == nil && out == st.item:
+			got = passed
+		case errors.Is(err, ErrCircuitOpen):
+			got = rejected
+		case errors.Is(err, errFail):
+			got = failed
+		default:
+			t.Fatalf("step %d: unexpected result %v, %v", i, out, err)
+		}
+		if got != st.want {
+			t.Errorf("step %d: item %d %s, want %s", i, st.item, got, st.want)
+		}
+		if state := n.Stats().Circuit; state != st.state {
+			t.Errorf("step %d: state %v, want %v", i, state, st.state)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []EventKind{EventCircuitOpen, EventCircuitHalfOpen, EventCircuitOpen, EventCircuitHalfOpen, EventCircuitClosed}
+	if !slices.Equal(events, want) {
+		t.Errorf("events %v, want %v", events, want)
+	}
+}
+
+func TestCircuitRejectedDeadLetter(t *testing.T) {
+	n := NewMap("breaker", func(context.Context, int) (int, error) {
+		return 0, errors.New("fail")
+	}, WithCircuitBreaker(1, time.Minute, time.Hour), WithClock(newFakeClock()))
+	s := newStepper(t, n)
+	if _, err := s.step(1); err == nil {
+		t.Fatal("failing item passed")
+	}
+	// отклонённый элемент не вызывает функцию и сообщается как DeadLetter с самим элементом
+	_, err := s.step(2)
+	var dl *DeadLetter
+	if !errors.As(err, &dl) || dl.Node != "breaker" || dl.Item != 2 || !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("error %v, want a DeadLetter of item 2 with ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitStaleCallInHalfOpen(t *testing.T) {
+	errFail := errors.New("fail")
+	// release[v] отпускает вызов для значения v с результатом ошибки; без записи вызов завершается сразу
+	release := map[int]chan error{1: make(chan error), 3: make(chan error)}
+	// started получает v задерживаемого вызова, когда выключатель уже разрешил его
+	started := make(chan int, len(release))
+	clock := newFakeClock()
+	n := NewMap("breaker", func(_ context.Context, v int) (int, error) {
+		if ch, ok := release[v]; ok {
+			started <- v
+			return v, <-ch
+		}
+		if v < 0 {
+			return 0, errFail
+		}
+		return v, nil
+	}, WithCircuitBreaker(1, time.Minute, 10*time.Second), WithClock(clock), WithConcurrency(2))
+
+	input := make(chan int)
+	output := make(chan int, 10)
+	errChan := make(chan error, 10)
+	if err := n.SetInput(0, input); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetOutput(0, output); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	n.Run(context.Background(), &wg, errChan, true)
+	state := func(want CircuitState) {
+		t.Helper()
+		eventually(t, func() bool { return n.Stats().Circuit == want })
+	}
+	nextErr := func(want error) {
+		t.Helper()
+		select {
+		case err := <-errChan:
+			if !errors.Is(err, want) {
+				t.Fatalf("error %v, want %v", err, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no error, want %v", want)
+		}
+	}
+
+	// 1 разрешён при замкнутом выключателе и задерживается; ошибка -1 размыкает выключатель
+	input <- 1
+	<-started
+	input <- -1
+	nextErr(errFail)
+	state(CircuitOpen)
+	// после cooldown 3 — пробный вызов
+	clock.Advance(10 * time.Second)
+	input <- 3
+	<-started
+	state(CircuitHalfOpen)
+
+	// успех старого вызова 1 не замыкает выключатель и не освобождает место пробы
+	release[1] <- nil
+	if v := <-output; v != 1 {
+		t.Fatalf("output %d, want 1", v)
+	}
+	input <- 4
+	nextErr(ErrCircuitOpen)
+	if s := n.Stats().Circuit; s != CircuitHalfOpen {
+		t.Errorf("state after the stale call %v, want %v", s, CircuitHalfOpen)
+	}
+
+	// результат решает только проба
+	release[3] <- errFail
+	nextErr(errFail)
+	state(CircuitOpen)
+
+	close(input)
+	waitGroup(t, &wg)
+}
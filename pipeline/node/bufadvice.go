@@ -0,0 +1,146 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BufferSampleInterval интервал замеров заполненности выходов узла с WithBufferAdvice
+const BufferSampleInterval = 5 * time.Millisecond
+
+// BufferAdvice рекомендация размера буфера выхода узла по замерам его заполненности за запуск
+type BufferAdvice struct {
+	// Edge имя ребра (см. StrandedEdge)
+	Edge string
+	Cap  int
+	// Recommended рекомендуемый размер буфера в пределах WithBufferAdvice
+	Recommended int
+	Samples     int
+	// Full доля замеров с заполненным буфером (отправитель блокировался), Empty — с пустым
+	// (получатель ждал), Peak наибольшая замеренная заполненность
+	Full  float64
+	Empty float64
+	Peak  int
+}
+
+func (a BufferAdvice) String() string {
+	return fmt.Sprintf("%s: buffer %d -> %d (full %.0f%%, empty %.0f%%, peak %d, %d samples)",
+		a.Edge, a.Cap, a.Recommended, a.Full*100, a.Empty*100, a.Peak, a.Samples)
+}
+
+// WithBufferAdvice включает замеры заполненности буферов выходов узла во время работы (каждые
+// BufferSampleInterval по Clock узла) и рекомендацию размеров в пределах [min, max] по итогам
+// запуска (см. Node.BufferAdvice, Pipeline.BufferAdvice, pipeline.ErrorSummary.Buffers). Режим
+// только отчётный: размеры буферов во время работы не меняются, рекомендации применяются при
+// следующей сборке пайплайна. Замена канала ребра на ходу (адаптивные буферы) не поддерживается:
+// канал закрывает отправитель, и на него ссылаются ответвления (TapOutput), дампы рёбер и учёт
+// застрявших элементов, которые подмена оставила бы на старом канале. Буфер увеличивается вдвое,
+// если он бывал и полон, и пуст (всплески отправителя); сохраняется, если он почти не опустошался
+// (узкое место — получатель, буфер не поможет); иначе уменьшается до наибольшей заполненности.
+// Небуферизованные выходы не замеряются, для них рекомендуется min.
+func WithBufferAdvice(min, max int) Option {
+	return func(c *config) {
+		c.bufAdvisor = &bufAdvisor{min: min, max: max}
+	}
+}
+
+// bufAdvisor замеры заполненности выходов узла для рекомендаций; каналы выходов не заменяет
+type bufAdvisor struct {
+	min, max int
+	mu       sync.Mutex
+	samples  int
+	full     []int
+	empty    []int
+	peak     []int
+}
+
+// sample запускает замеры выходов outputs до закрытия done
+func sample[T any](ctx context.Context, wg *sync.WaitGroup, clock Clock, a *bufAdvisor, outputs []chan<- T, done <-chan struct{}) {
+	a.mu.Lock()
+	a.samples = 0
+	a.full, a.empty, a.peak = make([]int, len(outputs)), make([]int, len(outputs)), make([]int, len(outputs))
+	a.mu.Unlock()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-clock.After(BufferSampleInterval):
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+
+			a.mu.Lock()
+			a.samples++
+			for i, output := range outputs {
+				if output == nil || cap(output) == 0 {
+					continue
+				}
+				l := len(output)
+				if l == cap(output) {
+					a.full[i]++
+				}
+				if l == 0 {
+					a.empty[i]++
+				}
+				a.peak[i] = max(a.peak[i], l)
+			}
+			a.mu.Unlock()
+		}
+	}()
+}
+
+// advise возвращает рекомендацию для выхода idx с буфером capacity
+func (a *bufAdvisor) advise(idx int, edge string, capacity int) BufferAdvice {
+	adv := BufferAdvice{Edge: edge, Cap: capacity, Recommended: a.min}
+	if capacity == 0 || a.samples == 0 || idx >= len(a.full) {
+		return adv
+	}
+
+	adv.Samples = a.samples
+	adv.Full = float64(a.full[idx]) / float64(a.samples)
+	adv.Empty = float64(a.empty[idx]) / float64(a.samples)
+	adv.Peak = a.peak[idx]
+
+	// доля замеров, начиная с которой буфер считается часто полным или пустым
+	const often = 0.05
+	switch {
+	case adv.Full >= often && adv.Empty >= often:
+		adv.Recommended = capacity * 2
+	case adv.Full >= often:
+		adv.Recommended = capacity
+	default:
+		adv.Recommended = adv.Peak
+	}
+	adv.Recommended = min(max(adv.Recommended, a.min), a.max)
+	return adv
+}
+
+// BufferAdvice возвращает рекомендации размеров буферов выходов узла с WithBufferAdvice по
+// замерам последнего запуска. Вызывать после завершения узла.
+func (n *Node[I, O]) BufferAdvice() []BufferAdvice {
+	a := n.cfg.bufAdvisor
+	if a == nil || n.collapsed {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	advice := make([]BufferAdvice, 0, len(n.outputs))
+	for i, output := range n.outputs {
+		if output == nil {
+			continue
+		}
+		name := fmt.Sprintf("%s[%d]", n.name, i)
+		if i < len(n.edges) && n.edges[i].ch != nil {
+			name = n.edges[i].name
+		}
+		advice = append(advice, a.advise(i, name, cap(output)))
+	}
+	return advice
+}
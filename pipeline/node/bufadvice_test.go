@@ -0,0 +1,90 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tickClock Clock, замеры по которому выполняются по команде теста: каждый вызов After отмечается
+// в waits, срабатывание — отправкой в ticks
+type tickClock struct {
+	waits chan struct{}
+	ticks chan time.Time
+}
+
+func newTickClock() *tickClock {
+	return &tickClock{waits: make(chan struct{}, 64), ticks: make(chan time.Time)}
+}
+
+func (c *tickClock) Now() time.Time {
+	return time.Time{}
+}
+
+func (c *tickClock) After(time.Duration) <-chan time.Time {
+	c.waits <- struct{}{}
+	return c.ticks
+}
+
+func TestBufferAdvice(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int
+		// fills заполненность буфера выхода (ёмкость 4) в моменты замеров
+		fills []int
+		want  BufferAdvice
+	}{
+		// всплески отправителя: буфер то полон, то пуст
+		{"bursty", 1, 64, []int{4, 0, 4, 0, 4, 0}, BufferAdvice{Cap: 4, Recommended: 8, Samples: 6, Full: 0.5,
+			Empty: 0.5, Peak: 4}},
+		{"bursty capped", 1, 6, []int{4, 0, 4, 0}, BufferAdvice{Cap: 4, Recommended: 6, Samples: 4, Full: 0.5,
+			Empty: 0.5, Peak: 4}},
+		// медленный получатель: буфер не опустошается, увеличение не поможет
+		{"slow consumer", 1, 64, []int{4, 4, 3, 4}, BufferAdvice{Cap: 4, Recommended: 4, Samples: 4, Full: 0.75,
+			Peak: 4}},
+		// буфер с запасом уменьшается до наибольшей заполненности
+		{"oversized", 1, 64, []int{1, 2, 1, 1}, BufferAdvice{Cap: 4, Recommended: 2, Samples: 4, Peak: 2}},
+		{"idle", 3, 64, []int{0, 0}, BufferAdvice{Cap: 4, Recommended: 3, Samples: 2, Empty: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newTickClock()
+			release := make(chan struct{})
+			n := New("burst", 0, 1, nil, func(ctx context.Context, _ <-chan struct{}, _ chan<- int, _ chan<- error) {
+				<-release
+			}, WithBufferAdvice(tt.min, tt.max), WithClock(clock))
+			out := make(chan int, 4)
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			errChan := make(chan error)
+			n.Run(context.Background(), &wg, errChan, true)
+			<-clock.waits
+			for _, fill := range tt.fills {
+				// буфер заполняет тест, как это сделал бы отправитель
+				for len(out) < fill {
+					out <- 0
+				}
+				for len(out) > fill {
+					<-out
+				}
+				clock.ticks <- time.Time{}
+				<-clock.waits
+			}
+			close(release)
+			waitGroup(t, &wg)
+
+			advice := n.BufferAdvice()
+			if len(advice) != 1 {
+				t.Fatalf("advice = %v, want one output", advice)
+			}
+			tt.want.Edge = "burst[0]"
+			if advice[0] != tt.want {
+				t.Errorf("advice = %+v, want %+v", advice[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package node
+
+import "errors"
+
+var (
+	ErrNilHandler         = errors.New("nil handler")
+	ErrOutputBuffMismatch = errors.New("mismatch output buff size")
+	ErrInputBuffMismatch  = errors.New("mismatch input buff size")
+	ErrIOOutOfRange       = errors.New("I/O out of range")
+	ErrSizeFuncType       = errors.New("size func type mismatch")
+	ErrInputLabels        = errors.New("mismatch input labels")
+	ErrInputWeight        = errors.New("non-positive input weight")
+	ErrMiddlewareType     = errors.New("middleware type mismatch")
+)
+
+// WithOutputBuffers задаёт буферы выходных каналов узла: выход i получает буфер sizes[i].
+// Конструкторы с параметром outputBuffSize используют опцию, только если параметр nil.
+func WithOutputBuffers(sizes ...int) Option {
+	return func(c *config) {
+		c.outputBuffSize = sizes
+	}
+}
+
+// WithPorts задаёт количество входов и выходов узлов, конструкторы которых не принимают их
+// параметрами (NewMap, NewFilter, NewFlatMap, Loop, NewPool, NewBatch, NewThrottle, Delay, Sample,
+// Quota, TimeoutGuard); по умолчанию один вход и один выход.
+func WithPorts(inputNum int, outputNum int) Option {
+	return func(c *config) {
+		c.ports = &[2]int{inputNum, outputNum}
+	}
+}
+
+// portCounts возвращает количество входов и выходов, заданное WithPorts, или один вход и один выход
+func (c *config) portCounts() (int, int) {
+	if c.ports == nil {
+		return 1, 1
+	}
+	return c.ports[0], c.ports[1]
+}
+
+// Build создаёт узел с inputNum входами и outputNum выходами так же, как New, но принимает буферы
+// и имя опциями (WithOutputBuffers, WithInputBuffers, WithName) и вместо паники возвращает ошибку:
+// ErrNilHandler, ErrIOOutOfRange, ErrOutputBuffMismatch, ErrInputBuffMismatch и т.п. (кроме
+// ErrNilHandler, обёрнутые в NodeError).
+func Build[I, O any](inputNum int, outputNum int, handler Handler[I, O], opts ...Option) (*Node[I, O], error) {
+	if handler == nil {
+		return nil, ErrNilHandler
+	}
+
+	cfg := newConfig(opts)
+	name := autoName("", funcName(handler), cfg)
+	if err := checkNode[I, O](inputNum, outputNum, cfg.outputBuffSize, cfg); err != nil {
+		return nil, &NodeError{Node: name, Err: err}
+	}
+	n := newNode[I, O](name, inputNum, outputNum, nil, cfg)
+	n.handler = handler
+	return n, nil
+}
+
+// checkNode проверяет параметры узла, о которых иначе сообщает паника конструктора
+func checkNode[I, O any](inputNum int, outputNum int, outputBuffSize []int, cfg *config) error {
+	if inputNum < 0 || outputNum < 0 || inputNum > maxIO || outputNum > maxIO {
+		return ErrIOOutOfRange
+	}
+	if outputBuffSize != nil && len(outputBuffSize) != outputNum {
+		return ErrOutputBuffMismatch
+	}
+	if cfg.inputBuffSize != nil && len(cfg.inputBuffSize) != inputNum {
+		return ErrInputBuffMismatch
+	}
+
+	if cfg.sizeFunc != nil {
+		_, in := cfg.sizeFunc.(func(I) int)
+		_, out := cfg.sizeFunc.(func(O) int)
+		if !in && !out {
+			return ErrSizeFuncType
+		}
+	}
+
+	if cfg.inputLabels != nil && len(cfg.inputLabels) != inputNum {
+		return ErrInputLabels
+	}
+	for _, w := range cfg.inputWeights {
+		if w <= 0 {
+			return ErrInputWeight
+		}
+	}
+	for _, m := range cfg.middleware {
+		if _, ok := m.(Middleware[I, O]); !ok {
+			return ErrMiddlewareType
+		}
+	}
+	return nil
+}
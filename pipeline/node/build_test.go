@@ -0,0 +1,125 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// relay обработчик, пересылающий вход в выход до закрытия входа
+func relay(_ context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+	defer close(output)
+	for v := range input {
+		output <- v
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		handler         Handler[int, int]
+		inputs, outputs int
+		opts            []Option
+		want            error
+	}{
+		{"nil handler", nil, 1, 1, nil, ErrNilHandler},
+		{"negative inputs", relay, -1, 1, nil, ErrIOOutOfRange},
+		{"too many outputs", relay, 1, maxIO + 1, nil, ErrIOOutOfRange},
+		{"output buffers", relay, 1, 2, []Option{WithOutputBuffers(1)}, ErrOutputBuffMismatch},
+		{"input buffers", relay, 2, 1, []Option{WithInputBuffers(1, 2, 3)}, ErrInputBuffMismatch},
+		{"size func", relay, 1, 1, []Option{WithSizeFunc(func(string) int { return 0 })}, ErrSizeFuncType},
+		{"input labels", relay, 2, 1, []Option{WithFairFanIn(map[string]int{"a": 1}, "a")}, ErrInputLabels},
+		{"input weight", relay, 2, 1, []Option{WithFairFanIn(map[string]int{"a": 0}, "a", "a")}, ErrInputWeight},
+		{"middleware", relay, 1, 1, []Option{WithMiddleware[string, int]()}, nil},
+		{"middleware type", relay, 1, 1, []Option{WithMiddleware(func(h Handler[string, int]) Handler[string, int] {
+			return h
+		})}, ErrMiddlewareType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithName("built")}, tt.opts...)
+			n, err := Build(tt.inputs, tt.outputs, tt.handler, opts...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Build error = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil {
+				return
+			}
+			if n != nil {
+				t.Error("Build returned a node with an error")
+			}
+			var ne *NodeError
+			if isNodeErr := errors.As(err, &ne); isNodeErr != (tt.want != ErrNilHandler) {
+				t.Errorf("error %v: NodeError = %v", err, isNodeErr)
+			} else if isNodeErr && ne.Node != "built" {
+				t.Errorf("NodeError.Node = %q, want built", ne.Node)
+			}
+
+			// New сообщает о тех же ошибках паникой
+			defer func() {
+				if recover() == nil {
+					t.Error("New did not panic")
+				}
+			}()
+			New("new", tt.inputs, tt.outputs, nil, tt.handler, tt.opts...)
+		})
+	}
+}
+
+func TestBuildOptions(t *testing.T) {
+	n, err := Build(1, 2, relay, WithName("relay"), WithInputBuffers(3), WithOutputBuffers(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Name() != "relay" || n.InputCount() != 1 || n.OutputCount() != 2 {
+		t.Errorf("node = %s %d/%d, want relay 1/2", n.Name(), n.InputCount(), n.OutputCount())
+	}
+	if !slices.Equal(n.outputBuffSize, []int{1, 2}) {
+		t.Errorf("output buffers = %v, want [1 2]", n.outputBuffSize)
+	}
+
+	// вход с буфером принадлежит узлу, в него можно писать до запуска
+	in, err := n.Input(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cap(in) != 3 {
+		t.Errorf("input buffer = %d, want 3", cap(in))
+	}
+	for _, v := range []int{1, 2, 3} {
+		in <- v
+	}
+	close(in)
+
+	outs := []chan int{make(chan int), make(chan int)}
+	var got []func() []int
+	for i, out := range outs {
+		if err := n.SetOutput(i, out); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, drain(out))
+	}
+	if errs := runNodes(t, context.Background(), n); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	all := append(got[0](), got[1]()...)
+	slices.Sort(all)
+	if !slices.Equal(all, []int{1, 2, 3}) {
+		t.Errorf("output = %v, want [1 2 3]", all)
+	}
+}
+
+func TestBuildAutoName(t *testing.T) {
+	a, err := Build(1, 1, relay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Build(1, 1, relay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name() == "" || a.Name() == b.Name() {
+		t.Errorf("names %q and %q, want unique non-empty names", a.Name(), b.Name())
+	}
+}
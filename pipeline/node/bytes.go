@@ -0,0 +1,185 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrNoSizeFunc = errors.New("no size func")
+
+// WithSizeFunc задаёт функцию размера элемента в байтах для учёта объёма данных в Stats.BytesIn и
+// Stats.BytesOut; включает статистику узла (WithStats). Размер считается для входов, если T совпадает
+// с типом входа узла, и для выходов, если с типом выхода; если тип не совпадает ни с одним,
+// конструктор узла паникует. Используется также ConnectWithByteLimit.
+func WithSizeFunc[T any](size func(T) int) Option {
+	return func(c *config) {
+		c.sizeFunc = size
+		c.stats = true
+	}
+}
+
+// ConnectWithByteLimit подключает выход from[outIdx] к входу to[inIdx] (см. Connect) с ограничением
+// объёма неполученных элементов ребра: отправка ждёт, пока суммарный размер элементов в буфере ребра
+// и ещё не прочитанных to превышает limit байт. Элемент, больший limit, отправляется, когда ребро
+// пусто. Размер считает size (nil — WithSizeFunc узла from), он должен быть детерминированным.
+// Возвращает ErrNoSizeFunc, если функции размера нет. limit <= 0 равносилен Connect. Встраиваемые
+// узлы (WithInline) на таком ребре не схлопываются.
+func ConnectWithByteLimit[I, O, T any](from *Node[I, O], outIdx int, to *Node[O, T], inIdx int, limit int64,
+	size func(O) int) error {
+	if limit <= 0 {
+		return Connect(from, outIdx, to, inIdx)
+	}
+	if size == nil {
+		size, _ = from.cfg.sizeFunc.(func(O) int)
+		if size == nil {
+			return from.wrapError(ErrNoSizeFunc)
+		}
+	}
+
+	wiring.Lock()
+	defer wiring.Unlock()
+	if err := checkFrozen(from, to); err != nil {
+		return err
+	}
+	if outIdx < 0 || outIdx >= len(from.outputs) {
+		return from.wrapError(ErrOutputIdxOutOfRange)
+	}
+
+	if inIdx < 0 || inIdx >= len(to.inputs) {
+		return to.wrapError(ErrInputIdxOutOfRange)
+	}
+	if err := checkSlots(from, outIdx, to, inIdx); err != nil {
+		return err
+	}
+
+	connectChan(from, outIdx, to, inIdx)
+	b := &byteLimit[O]{limit: limit, size: size, freed: make(chan struct{}, 1)}
+	if from.outLimits == nil {
+		from.outLimits = make([]*byteLimit[O], len(from.outputs))
+	}
+	from.outLimits[outIdx] = b
+	if to.inLimits == nil {
+		to.inLimits = make([]*byteLimit[O], len(to.inputs))
+	}
+	to.inLimits[inIdx] = b
+	to.occupyInput(inIdx)
+	from.occupyOutput(outIdx)
+	return nil
+}
+
+// byteLimit ограничение объёма неполученных элементов ребра (ConnectWithByteLimit): used растёт при
+// отправке в ребро и уменьшается, когда получатель прочитал элемент
+type byteLimit[T any] struct {
+	limit int64
+	size  func(T) int
+	used  atomic.Int64
+	// freed получает сигнал при каждом уменьшении used
+	freed chan struct{}
+}
+
+// acquire ждёт, пока элемент размера size уместится в ограничение, и учитывает его. Возвращает
+// false при отмене контекста.
+func (b *byteLimit[T]) acquire(ctx context.Context, size int64) bool {
+	for {
+		used := b.used.Load()
+		if used == 0 || used+size <= b.limit {
+			b.used.Add(size)
+			return true
+		}
+		select {
+		case <-b.freed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// release освобождает size байт и будит ожидающего отправителя
+func (b *byteLimit[T]) release(size int64) {
+	b.used.Add(-size)
+	select {
+	case b.freed <- struct{}{}:
+	default:
+	}
+}
+
+// limitOutputs возвращает выходы узла, где выходы с ConnectWithByteLimit обёрнуты limitOutput
+func (n *Node[I, O]) limitOutputs(ctx context.Context, wg *sync.WaitGroup, outputs []chan<- O) []chan<- O {
+	limited := append([]chan<- O(nil), outputs...)
+	for i, b := range n.outLimits {
+		if b != nil && limited[i] != nil {
+			limited[i] = limitOutput(ctx, wg, limited[i], b)
+		}
+	}
+	return limited
+}
+
+// limitInputs возвращает входы узла, где входы с ConnectWithByteLimit обёрнуты limitInput
+func (n *Node[I, O]) limitInputs(ctx context.Context, wg *sync.WaitGroup) []<-chan I {
+	if n.inLimits == nil {
+		return n.inputs
+	}
+
+	limited := append([]<-chan I(nil), n.inputs...)
+	for i, b := range n.inLimits {
+		if b != nil {
+			limited[i] = limitInput(ctx, wg, limited[i], b)
+		}
+	}
+	return limited
+}
+
+// limitOutput ретранслирует output, ожидая места в ограничении ребра перед каждой отправкой.
+// После отмены контекста элементы отбрасываются.
+func limitOutput[T any](ctx context.Context, wg *sync.WaitGroup, output chan<- T, b *byteLimit[T]) chan<- T {
+	proxy := make(chan T)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(output)
+		for val := range proxy {
+			size := int64(b.size(val))
+			if !b.acquire(ctx, size) {
+				continue
+			}
+			select {
+			case output <- val:
+			case <-ctx.Done():
+				b.release(size)
+			}
+		}
+	}()
+
+	return proxy
+}
+
+// limitInput ретранслирует input, освобождая место в ограничении ребра после передачи элемента
+// получателю. Канал-обёртка закрывается при закрытии input или отмене контекста.
+func limitInput[T any](ctx context.Context, wg *sync.WaitGroup, input <-chan T, b *byteLimit[T]) <-chan T {
+	proxy := make(chan T)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(proxy)
+		for {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					return
+				}
+				select {
+				case proxy <- val:
+					b.release(int64(b.size(val)))
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return proxy
+}
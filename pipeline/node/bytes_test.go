@@ -0,0 +1,125 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnectWithByteLimit(t *testing.T) {
+	const limit = 10
+	size := func(v string) int { return len(v) }
+	items := slices.Repeat([]string{"abcd"}, 20)
+	tests := []struct {
+		name  string
+		opts  []Option
+		size  func(string) int
+		items []string
+		// wantMaxUsed наибольший объём неполученных элементов ребра, замеченный получателем
+		wantMaxUsed int64
+	}{
+		{"size func option", []Option{WithSizeFunc(size)}, nil, items, limit},
+		{"explicit size", nil, size, items, limit},
+		// элемент больше ограничения отправляется в пустое ребро и не блокирует узел навсегда
+		{"oversized item", []Option{WithSizeFunc(size)}, nil, []string{strings.Repeat("x", 3*limit), "a", "b"},
+			3 * limit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var processed atomic.Int64
+			from := NewMap("from", func(_ context.Context, v string) (string, error) {
+				processed.Add(1)
+				return v, nil
+			}, append(tt.opts, WithOutputBuffers(len(tt.items)))...)
+			gate := make(chan struct{})
+			var got []string
+			var maxUsed int64
+			var b *byteLimit[string]
+			to := NewSink("to", 1, func(_ context.Context, v string) error {
+				<-gate
+				maxUsed = max(maxUsed, b.used.Load())
+				got = append(got, v)
+				return nil
+			})
+			if err := from.SetInput(0, feed(tt.items...)); err != nil {
+				t.Fatal(err)
+			}
+			if err := ConnectWithByteLimit(from, 0, to, 0, limit, tt.size); err != nil {
+				t.Fatal(err)
+			}
+			b = from.outLimits[0]
+
+			var wg sync.WaitGroup
+			errChan := make(chan error, 1)
+			from.Run(context.Background(), &wg, errChan, true)
+			to.Run(context.Background(), &wg, errChan, true)
+
+			// получатель стоит: буфер ребра вмещает все элементы, но отправитель ждёт освобождения
+			// места, и объём в ребре не превышает ограничения
+			if len(tt.items) > 3 {
+				eventually(t, func() bool { return b.used.Load() > 0 })
+				for deadline := time.Now().Add(20 * time.Millisecond); time.Now().Before(deadline); {
+					if used := b.used.Load(); used > limit {
+						t.Fatalf("%d bytes in the edge, limit %d", used, limit)
+					}
+				}
+				if p := processed.Load(); p == int64(len(tt.items)) {
+					t.Errorf("all %d items processed while the receiver is blocked", p)
+				}
+			}
+			close(gate)
+			waitGroup(t, &wg)
+
+			if !slices.Equal(got, tt.items) {
+				t.Errorf("received %q, want %q", got, tt.items)
+			}
+			if maxUsed > tt.wantMaxUsed {
+				t.Errorf("up to %d bytes in flight, want at most %d", maxUsed, tt.wantMaxUsed)
+			}
+			if used := b.used.Load(); used != 0 {
+				t.Errorf("%d bytes left in the edge", used)
+			}
+			if len(errChan) > 0 {
+				t.Errorf("unexpected error %v", <-errChan)
+			}
+		})
+	}
+}
+
+func TestConnectWithByteLimitWiring(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		limit   int64
+		wantErr error
+		// wantLimited ребро получает ограничение
+		wantLimited bool
+	}{
+		{"limited", []Option{WithSizeFunc(func(v string) int { return len(v) })}, 10, nil, true},
+		{"no size func", nil, 10, ErrNoSizeFunc, false},
+		// limit <= 0 равносилен Connect и функции размера не требует
+		{"no limit", nil, 0, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := NewMap("from", func(_ context.Context, v string) (string, error) { return v, nil }, tt.opts...)
+			to := NewSink("to", 1, func(context.Context, string) error { return nil })
+			err := ConnectWithByteLimit(from, 0, to, 0, tt.limit, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConnectWithByteLimit error = %v, want %v", err, tt.wantErr)
+			}
+			if limited := from.outLimits != nil; limited != tt.wantLimited {
+				t.Errorf("edge limited = %v, want %v", limited, tt.wantLimited)
+			}
+			wired, _ := to.InputWired(0)
+			if wired != (tt.wantErr == nil) {
+				t.Errorf("InputWired = %v, want %v", wired, tt.wantErr == nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,467 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/util"
+)
+
+// ported узел с входами int и выходами O
+type ported[O any] interface {
+	runner
+	SetInput(idx int, input <-chan int) error
+	SetOutput(idx int, output chan<- O) error
+}
+
+// attachInputs подключает ins ко входам узла
+func attachInputs[O any](t *testing.T, n ported[O], ins []chan int) {
+	t.Helper()
+	for i, ch := range ins {
+		if err := n.SetInput(i, ch); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// attachOutputs подключает outputNum выходов узла и возвращает функцию, дожидающуюся их закрытия и
+// возвращающую выданные значения, приведённые к int через conv
+func attachOutputs[O any](t *testing.T, n interface {
+	SetOutput(idx int, output chan<- O) error
+}, outputNum int, conv func(O) []int) func() []int {
+	t.Helper()
+	var drains []func() []O
+	for i := 0; i < outputNum; i++ {
+		ch := make(chan O)
+		if err := n.SetOutput(i, ch); err != nil {
+			t.Fatal(err)
+		}
+		drains = append(drains, drain(ch))
+	}
+	return func() []int {
+		var got []int
+		for _, d := range drains {
+			for _, v := range d() {
+				got = append(got, conv(v)...)
+			}
+		}
+		return got
+	}
+}
+
+// attach подключает входы и выходы одного узла
+func attach[O any](t *testing.T, n ported[O], ins []chan int, outputNum int, conv func(O) []int) func() []int {
+	t.Helper()
+	attachInputs(t, n, ins)
+	return attachOutputs(t, n, outputNum, conv)
+}
+
+// same приводит int к срезу из одного значения
+func same(v int) []int {
+	return []int{v}
+}
+
+// recorder значения, записанные приёмником
+type recorder struct {
+	mu   sync.Mutex
+	vals []int
+}
+
+func (r *recorder) add(vals ...int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.vals = append(r.vals, vals...)
+}
+
+func (r *recorder) got() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.vals)
+}
+
+// encodeInt записывает значение строкой и отмечает его в r
+func (r *recorder) encodeInt(w io.Writer, v int) error {
+	r.add(v)
+	_, err := fmt.Fprintln(w, v)
+	return err
+}
+
+// Execute реализует Template: записывает элемент или срез элементов (WithCollect)
+func (r *recorder) Execute(_ io.Writer, data any) error {
+	switch v := data.(type) {
+	case int:
+		r.add(v)
+	case []int:
+		r.add(v...)
+	}
+	return nil
+}
+
+// nopCloser io.WriteCloser, отбрасывающий запись
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// matrixCase конструктор узла в матрице отмены
+type matrixCase struct {
+	name string
+	// ports конструктор принимает количество входов и выходов, иначе проверяется только форма 1:1
+	ports bool
+	// sink узел без выходов: значения берутся из записанных приёмником
+	sink bool
+	// want значения, выдаваемые узлом без отмены для входа 0..n-1; nil — не проверяется (узел
+	// отбрасывает часть элементов по своей логике)
+	want func(n int) []int
+	// build создаёт узлы с входами ins и outputNum выходами; got возвращает выданные значения
+	// после закрытия выходов
+	build func(t *testing.T, ins []chan int, outputNum int) (nodes []runner, got func() []int)
+}
+
+// identity ожидаемый результат узлов, передающих элементы без изменений
+func identity(n int) []int {
+	return seq(n)
+}
+
+var matrixCases = []matrixCase{
+	{name: "Map", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewMap("map", func(_ context.Context, v int) (int, error) { return v, nil }, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Map concurrent", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewMap("map", func(_ context.Context, v int) (int, error) { return v, nil }, WithPorts(len(ins), out),
+				WithConcurrency(4))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Map middleware", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewMap("map", func(_ context.Context, v int) (int, error) { return v, nil }, WithPorts(len(ins), out),
+				WithMiddleware(LogLifecycle[int, int](func(string, ...any) {})))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Filter", ports: true,
+		want: func(n int) []int {
+			return slices.DeleteFunc(seq(n), func(v int) bool { return v%2 == 1 })
+		},
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewFilter("filter", func(_ context.Context, v int) (bool, error) {
+				return v%2 == 0, nil
+			}, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "FlatMap", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewFlatMap("flat", func(_ context.Context, v int) ([]int, error) {
+				return []int{v}, nil
+			}, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Loop", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := Loop("loop", func(_ context.Context, v int, emit *Emitter[int]) error {
+				return emit.Send(v)
+			}, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Pool", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewPool("pool", 3, LoopHandler(func(_ context.Context, v int, emit *Emitter[int]) error {
+				return emit.Send(v)
+			}), WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Batch", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewBatch[int]("batch", 3, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, func(b []int) []int { return b })
+		}},
+	{name: "Throttle", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewThrottle[int]("throttle", 1e6, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Delay", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := Delay[int]("delay", 10*time.Microsecond, 0, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Sample", ports: true,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := Sample[int]("sample", 1e6, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "Quota", ports: true,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := Quota("quota", 10, func(int) int64 { return 1 }, QuotaStopAccepting, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "TimeoutGuard", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := TimeoutGuard("guard", time.Hour, func() (int, bool) { return 0, false }, WithPorts(len(ins), out))
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "DiskBuffer", ports: true, want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			codec := util.Codec[int]{
+				Encode: func(v int) ([]byte, error) { return []byte(strconv.Itoa(v)), nil },
+				Decode: func(b []byte) (int, error) { return strconv.Atoi(string(b)) },
+			}
+			n := DiskBuffer("disk", len(ins), out, nil, t.TempDir(), codec, 4, 1<<20)
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "PassThrough", want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			n := NewPassThrough[int]("pass")
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "ScatterGather", want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			branch := func(_ context.Context, v int) (int, error) { return v, nil }
+			n := ScatterGather("scatter", []func(context.Context, int) (int, error){branch, branch},
+				func(in int, _ []int, _ []error) (int, error) { return in, nil })
+			return []runner{n}, attach(t, n, ins, out, same)
+		}},
+	{name: "OrderedStage", want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			stage := NewOrderedStage("ordered", 3, func(_ context.Context, v int) (int, error) { return v, nil })
+			attachInputs(t, stage.Sequencer, ins)
+			nodes := []runner{stage.Sequencer, stage.Reorder}
+			for _, w := range stage.Workers {
+				nodes = append(nodes, w)
+			}
+			return nodes, attachOutputs(t, stage.Reorder, out, same)
+		}},
+	{name: "JSON", want: identity,
+		build: func(t *testing.T, ins []chan int, out int) ([]runner, func() []int) {
+			enc := JSONEncode[int]("encode")
+			dec := JSONDecode[int]("decode")
+			if err := Connect(enc, 0, dec, 0); err != nil {
+				t.Fatal(err)
+			}
+			attachInputs(t, enc, ins)
+			return []runner{enc, dec}, attachOutputs(t, dec, out, same)
+		}},
+	{name: "Sink", ports: true, sink: true, want: identity,
+		build: func(t *testing.T, ins []chan int, _ int) ([]runner, func() []int) {
+			var r recorder
+			n := NewSink("sink", len(ins), func(_ context.Context, v int) error {
+				r.add(v)
+				return nil
+			})
+			attachInputs(t, n, ins)
+			return []runner{n}, r.got
+		}},
+	{name: "AtomicFileSink", sink: true, want: identity,
+		build: func(t *testing.T, ins []chan int, _ int) ([]runner, func() []int) {
+			var r recorder
+			n := AtomicFileSink("atomic", filepath.Join(t.TempDir(), "out.txt"), r.encodeInt)
+			attachInputs(t, n, ins)
+			return []runner{n}, r.got
+		}},
+	{name: "RotatingFileSink", sink: true, want: identity,
+		build: func(t *testing.T, ins []chan int, _ int) ([]runner, func() []int) {
+			var r recorder
+			n := RotatingFileSink("rotating", filepath.Join(t.TempDir(), "out.txt"), 16, 0, r.encodeInt)
+			attachInputs(t, n, ins)
+			return []runner{n}, r.got
+		}},
+	{name: "ShardedSink", sink: true, want: identity,
+		build: func(t *testing.T, ins []chan int, _ int) ([]runner, func() []int) {
+			var r recorder
+			n := ShardedSink("sharded", func(v int) int { return v % 3 },
+				func(int) (io.WriteCloser, error) { return nopCloser{io.Discard}, nil }, r.encodeInt, 2)
+			attachInputs(t, n, ins)
+			return []runner{n}, r.got
+		}},
+	{name: "TemplateSink", sink: true, want: identity,
+		build: func(t *testing.T, ins []chan int, _ int) ([]runner, func() []int) {
+			r := &recorder{}
+			n := TemplateSink[int]("template", r, io.Discard)
+			attachInputs(t, n, ins)
+			return []runner{n}, r.got
+		}},
+	{name: "TemplateSink collect", sink: true, want: identity,
+		build: func(t *testing.T, ins []chan int, _ int) ([]runner, func() []int) {
+			r := &recorder{}
+			n := TemplateSink[int]("report", r, io.Discard, WithCollect())
+			attachInputs(t, n, ins)
+			return []runner{n}, r.got
+		}},
+}
+
+// cancelPoint момент отмены контекста относительно подачи входа
+type cancelPoint int
+
+const (
+	noCancel cancelPoint = iota
+	beforeFirst
+	midStream
+	afterClose
+)
+
+func (p cancelPoint) String() string {
+	return [...]string{"no cancel", "before first item", "mid-stream", "after input close"}[p]
+}
+
+// shape количество входов и выходов узла
+type shape struct {
+	name    string
+	inputs  int
+	outputs int
+}
+
+// matrixItems количество элементов, подаваемых на вход
+const matrixItems = 40
+
+// TestCancellationMatrix проверяет контракт отмены из документации пакета для всех конструкторов,
+// форм 1:1, слияния и распределения и трёх моментов отмены: узел завершается и освобождает wg,
+// выходы закрываются, ctx.Err() не отправляется в канал ошибок, значения не дублируются и не
+// появляются из ниоткуда; без отмены и при отмене после полной выдачи результата выдаются все
+// элементы.
+func TestCancellationMatrix(t *testing.T) {
+	for _, c := range matrixCases {
+		shapes := []shape{{"1:1", 1, 1}}
+		if c.ports {
+			shapes = append(shapes, shape{"fan-in", 3, 1}, shape{"fan-out", 1, 3})
+		}
+		for _, s := range shapes {
+			if c.sink {
+				s.outputs = 0
+				if s.inputs == 1 && s.name != "1:1" {
+					continue
+				}
+			}
+			for _, point := range []cancelPoint{noCancel, beforeFirst, midStream, afterClose} {
+				t.Run(fmt.Sprintf("%s/%s/%s", c.name, s.name, point), func(t *testing.T) {
+					runCancelCase(t, c, s, point)
+				})
+			}
+		}
+	}
+}
+
+func runCancelCase(t *testing.T, c matrixCase, s shape, point cancelPoint) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ins := make([]chan int, s.inputs)
+	for i := range ins {
+		ins[i] = make(chan int)
+	}
+	nodes, got := c.build(t, ins, s.outputs)
+
+	var errs recorderErrs
+	errChan := make(chan error)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for err := range errChan {
+			errs.add(err)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		n.Run(ctx, &wg, errChan, true)
+	}
+
+	// входы остаются открытыми до завершения узла, если отмена происходит раньше их закрытия
+	fed := make(chan struct{})
+	go func() {
+		defer close(fed)
+		if point == beforeFirst {
+			cancel()
+			return
+		}
+		for v := 0; v < matrixItems; v++ {
+			if point == midStream && v == matrixItems/2 {
+				cancel()
+				return
+			}
+			select {
+			case ins[v%len(ins)] <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for _, ch := range ins {
+			close(ch)
+		}
+		if point == afterClose {
+			cancel()
+		}
+	}()
+
+	waitGroup(t, &wg)
+	close(errChan)
+	<-collected
+	<-fed
+	if point == beforeFirst || point == midStream {
+		for _, ch := range ins {
+			close(ch)
+		}
+	}
+
+	values := make(chan []int, 1)
+	go func() { values <- got() }()
+	var vals []int
+	select {
+	case vals = <-values:
+	case <-time.After(5 * time.Second):
+		t.Fatal("outputs were not closed")
+	}
+
+	for _, err := range errs.get() {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("context error reported: %v", err)
+		}
+	}
+
+	seen := make(map[int]bool)
+	limit := matrixItems
+	if point == midStream {
+		limit = matrixItems / 2
+	}
+	for _, v := range vals {
+		if v < 0 || v >= limit || seen[v] {
+			t.Fatalf("unexpected or duplicate value %d in %v", v, vals)
+		}
+		seen[v] = true
+	}
+	if point == beforeFirst && len(vals) != 0 {
+		t.Errorf("values delivered after cancel before the first item: %v", vals)
+	}
+	if point == noCancel && c.want != nil {
+		slices.Sort(vals)
+		if want := c.want(matrixItems); !slices.Equal(vals, want) {
+			t.Errorf("got %v, want %v", vals, want)
+		}
+	}
+}
+
+// recorderErrs ошибки, полученные из канала ошибок
+type recorderErrs struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *recorderErrs) add(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+func (r *recorderErrs) get() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.errs)
+}
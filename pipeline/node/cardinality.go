@@ -0,0 +1,77 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrCardinality = errors.New("cardinality violated")
+
+// Cardinality соотношение количества входных и выходных элементов узла
+type Cardinality int
+
+const (
+	// CardinalityUnknown соотношение не задано, проверка не выполняется (по умолчанию)
+	CardinalityUnknown Cardinality = iota
+	// OneToOne каждый входной элемент даёт ровно один выходной или ошибку
+	OneToOne
+	// OneToMany каждый входной элемент даёт хотя бы один выходной или ошибку
+	OneToMany
+	// ManyToOne выходных элементов не больше, чем входных (свёртка, фильтр)
+	ManyToOne
+)
+
+func (c Cardinality) String() string {
+	switch c {
+	case OneToOne:
+		return "1:1"
+	case OneToMany:
+		return "1:N"
+	case ManyToOne:
+		return "N:1"
+	default:
+		return "unknown"
+	}
+}
+
+// WithCardinality объявляет соотношение входных и выходных элементов узла и включает WithStats.
+// Пайплайн после завершения всех нод, если запуск не был отменён, сравнивает счётчики узла
+// с объявленным соотношением (см. CheckCardinality). Ошибки и отброшенные элементы (Stats.Errors,
+// Stats.Discarded) считаются обработанными входными элементами.
+func WithCardinality(c Cardinality) Option {
+	return func(cfg *config) {
+		cfg.cardinality = c
+		cfg.stats = true
+	}
+}
+
+// CheckCardinality сравнивает счётчики узла с объявленным WithCardinality соотношением и возвращает
+// ошибку ErrCardinality класса ClassInfra при нарушении. Вызывается после завершения узла.
+func (n *Node[I, O]) CheckCardinality() error {
+	c := n.cfg.cardinality
+	if c == CardinalityUnknown || n.counters == nil {
+		return nil
+	}
+
+	s := n.counters.snapshot()
+	// handled входные элементы, для которых выход не ожидается
+	handled := s.Errors + s.Suppressed + s.Discarded
+	var ok bool
+	switch c {
+	case OneToOne:
+		ok = s.ItemsOut <= s.ItemsIn && s.ItemsOut+handled >= s.ItemsIn
+	case OneToMany:
+		ok = s.ItemsOut+handled >= s.ItemsIn
+	case ManyToOne:
+		ok = s.ItemsOut <= s.ItemsIn
+	}
+	if ok {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: %d in, %d out", ErrCardinality, s.ItemsIn, s.ItemsOut)
+	if handled > 0 {
+		err = fmt.Errorf("%w (%d errors, %d suppressed, %d discarded)", err, s.Errors, s.Suppressed, s.Discarded)
+	}
+	return Classify(ClassInfra, n.wrapError(fmt.Errorf("%w, expected %s", err, c)))
+}
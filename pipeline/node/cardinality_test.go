@@ -0,0 +1,61 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheckCardinality(t *testing.T) {
+	errBad := errors.New("bad item")
+	// fanOut выходные значения для каждого входного: 0 — элемент теряется, -1 — ошибка
+	flatMap := func(fanOut map[int]int) FlatMapFn[int, int] {
+		return func(_ context.Context, v int) ([]int, error) {
+			k, ok := fanOut[v]
+			switch {
+			case !ok:
+				return []int{v}, nil
+			case k < 0:
+				return nil, errBad
+			}
+			out := make([]int, k)
+			for i := range out {
+				out[i] = v
+			}
+			return out, nil
+		}
+	}
+	tests := []struct {
+		name        string
+		cardinality Cardinality
+		fanOut      map[int]int
+		wantErr     bool
+	}{
+		{"one to one", OneToOne, nil, false},
+		// элемент с ошибкой считается обработанным
+		{"one to one with error", OneToOne, map[int]int{2: -1}, false},
+		{"one to one lost", OneToOne, map[int]int{2: 0}, true},
+		{"one to one duplicated", OneToOne, map[int]int{2: 2}, true},
+		{"one to many", OneToMany, map[int]int{1: 3, 2: 2}, false},
+		// проверяются суммарные счётчики: лишние выходы одного элемента покрыли бы потерю другого
+		{"one to many lost", OneToMany, map[int]int{2: 0}, true},
+		{"many to one", ManyToOne, map[int]int{1: 0, 2: 0}, false},
+		{"many to one duplicated", ManyToOne, map[int]int{2: 2}, true},
+		{"unknown", CardinalityUnknown, map[int]int{1: 0, 2: 5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewFlatMap("stage", flatMap(tt.fanOut), WithCardinality(tt.cardinality))
+			process(t, n, seq(5)...)
+
+			err := n.CheckCardinality()
+			switch {
+			case !tt.wantErr && err != nil:
+				t.Errorf("CheckCardinality: %v", err)
+			case tt.wantErr && (!errors.Is(err, ErrCardinality) || ClassOf(err) != ClassInfra ||
+				!errors.As(err, new(*NodeError))):
+				t.Errorf("CheckCardinality = %v, want a ClassInfra NodeError wrapping %v", err, ErrCardinality)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package node
+
+import "time"
+
+// Clock источник времени для узлов. Подменяется в тестах через WithClock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock Clock на основе пакета time, например, для ReplaySource и nodetest.NewPlayer
+var SystemClock Clock = realClock{}
+
+// realClock Clock на основе пакета time
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
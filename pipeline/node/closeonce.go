@@ -0,0 +1,50 @@
+package node
+
+import (
+	"errors"
+	"runtime"
+)
+
+var (
+	ErrOutputClosedTwice = errors.New("output closed more than once")
+	ErrSendAfterClose    = errors.New("send on closed output, item dropped")
+)
+
+// outputMisuse проверяет, вызвана ли паника r повторным закрытием выхода output или отправкой
+// в уже закрытый выход, и возвращает соответствующую ошибку (ErrOutputClosedTwice,
+// ErrSendAfterClose). Паника, относящаяся к другому каналу, не считается нарушением: выход
+// при проверке закрывается, как и при обработке любой паники.
+func outputMisuse[T any](r any, output chan<- T) error {
+	if pe, ok := r.(*PanicError); ok {
+		r = pe.Value
+	}
+	re, ok := r.(runtime.Error)
+	if !ok || output == nil {
+		return nil
+	}
+
+	var err error
+	switch re.Error() {
+	case "close of closed channel":
+		err = ErrOutputClosedTwice
+	case "send on closed channel":
+		err = ErrSendAfterClose
+	default:
+		return nil
+	}
+	if tryClose(output) {
+		return nil
+	}
+	return err
+}
+
+// tryClose закрывает канал и сообщает, был ли он открыт
+func tryClose[T any](ch chan<- T) (closed bool) {
+	defer func() {
+		if recover() != nil {
+			closed = false
+		}
+	}()
+	close(ch)
+	return true
+}
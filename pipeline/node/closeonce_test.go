@@ -0,0 +1,80 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// misuse действие обработчика после закрытия выхода
+type misuse int
+
+const (
+	noMisuse misuse = iota
+	closeAgain
+	sendAfterClose
+)
+
+func TestOutputMisuseRandomized(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		outputs := 1 + 2*rng.Intn(2)
+		sent := rng.Intn(5)
+		// кто закрывает повторно: обработчик закрывает выход, затем, возможно, снова закрывает его
+		// или пишет в него, в случайном порядке
+		actions := []misuse{noMisuse, closeAgain, sendAfterClose}
+		rng.Shuffle(len(actions), func(i, j int) { actions[i], actions[j] = actions[j], actions[i] })
+
+		var want error
+		for _, a := range actions {
+			if a == closeAgain {
+				want = ErrOutputClosedTwice
+			} else if a == sendAfterClose {
+				want = ErrSendAfterClose
+			}
+			if a != noMisuse {
+				break
+			}
+		}
+
+		n := New("sloppy", 0, outputs, nil, func(_ context.Context, _ <-chan struct{}, output chan<- int, _ chan<- error) {
+			for i := range sent {
+				output <- i
+			}
+			close(output)
+			for _, a := range actions {
+				switch a {
+				case closeAgain:
+					close(output)
+				case sendAfterClose:
+					output <- -1
+				}
+			}
+		})
+		var got []func() []int
+		for i := range outputs {
+			out := make(chan int, sent)
+			if err := n.SetOutput(i, out); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, drain(out))
+		}
+
+		errs := runNodes(t, context.Background(), n)
+		if len(errs) != 1 || !errors.Is(errs[0], want) {
+			t.Fatalf("seed %d (actions %v): errors = %v, want %v", seed, actions, errs, want)
+		}
+		// выходы закрыты, значения до закрытия доставлены, значение после закрытия отброшено
+		delivered := 0
+		for _, g := range got {
+			delivered += len(g())
+		}
+		if delivered != sent {
+			t.Fatalf("seed %d: delivered %d of %d", seed, delivered, sent)
+		}
+		if r := n.ExitReason(); r == ExitPanicked {
+			t.Fatalf("seed %d: misuse reported as a panic", seed)
+		}
+	}
+}
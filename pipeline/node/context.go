@@ -0,0 +1,74 @@
+package node
+
+import "context"
+
+// cancelKey ключ контекста для функции отмены пайплайна
+type cancelKey struct{}
+
+// ContextWithCancel сохраняет в контексте функцию отмены пайплайна. Узлы вызывают её, когда
+// их политика требует остановить весь пайплайн (например, при исчерпании перезапусков).
+func ContextWithCancel(ctx context.Context, cancel context.CancelFunc) context.Context {
+	return context.WithValue(ctx, cancelKey{}, cancel)
+}
+
+// cancelPipeline отменяет пайплайн, если функция отмены сохранена в контексте.
+// Возвращает false, если функции нет.
+func cancelPipeline(ctx context.Context) bool {
+	cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc)
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// panicKey ключ контекста для политики паники пайплайна
+type panicKey struct{}
+
+// ContextWithPanicPolicy сохраняет в контексте политику паники пайплайна. Она действует для
+// узлов, у которых не задана собственная политика (WithPanicPolicy).
+func ContextWithPanicPolicy(ctx context.Context, policy PanicPolicy) context.Context {
+	return context.WithValue(ctx, panicKey{}, policy)
+}
+
+// runIDKey ключ контекста для идентификатора запуска пайплайна
+type runIDKey struct{}
+
+// ContextWithRunID сохраняет в контексте идентификатор запуска пайплайна. Узлы добавляют его
+// в NodeError, обработчики получают его через RunID.
+func ContextWithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// RunID возвращает идентификатор запуска пайплайна из контекста или "", если он не задан
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// drainKey ключ контекста для функции корректного завершения пайплайна
+type drainKey struct{}
+
+// ContextWithDrain сохраняет в контексте функцию, останавливающую источники пайплайна так, что он
+// завершается после дообработки уже выданных элементов (например, при исчерпании Quota)
+func ContextWithDrain(ctx context.Context, drain func()) context.Context {
+	return context.WithValue(ctx, drainKey{}, drain)
+}
+
+// drainPipeline вызывает функцию корректного завершения пайплайна, если она сохранена в контексте.
+// Возвращает false, если функции нет.
+func drainPipeline(ctx context.Context) bool {
+	drain, ok := ctx.Value(drainKey{}).(func())
+	if ok {
+		drain()
+	}
+	return ok
+}
+
+// nodeNameKey ключ контекста для имени узла
+type nodeNameKey struct{}
+
+// NodeName возвращает имя узла, в обработчике которого вызвана функция, или "" вне узла
+func NodeName(ctx context.Context) string {
+	name, _ := ctx.Value(nodeNameKey{}).(string)
+	return name
+}
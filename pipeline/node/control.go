@@ -0,0 +1,189 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrUnsupportedCommand = errors.New("unsupported command")
+	ErrParamType          = errors.New("unexpected value type")
+)
+
+// CommandKind вид управляющей команды
+type CommandKind int
+
+const (
+	// CmdPause прекращает чтение входа узлом до CmdResume
+	CmdPause CommandKind = iota
+	// CmdResume возобновляет чтение входа
+	CmdResume
+	// CmdFlush требует немедленно выдать накопленные данные
+	CmdFlush
+	// CmdSetParam меняет параметр узла Param на Value
+	CmdSetParam
+)
+
+func (k CommandKind) String() string {
+	switch k {
+	case CmdPause:
+		return "pause"
+	case CmdResume:
+		return "resume"
+	case CmdFlush:
+		return "flush"
+	case CmdSetParam:
+		return "set-param"
+	default:
+		return "unknown"
+	}
+}
+
+// Command управляющая команда узлу
+type Command struct {
+	Kind  CommandKind
+	Param string
+	Value any
+}
+
+// Controllable принимает управляющие команды. Возвращает ErrUnsupportedCommand для
+// неподдерживаемых команд.
+type Controllable interface {
+	Control(cmd Command) error
+}
+
+// WithController задаёт получателя команд, которые узел не обрабатывает сам
+// (для пользовательских обработчиков, поддерживающих CmdFlush, CmdSetParam и т.п.)
+func WithController(c Controllable) Option {
+	return func(cfg *config) {
+		cfg.controller = c
+	}
+}
+
+// WithPausable включает поддержку CmdPause/CmdResume для узла с произвольным обработчиком:
+// вход оборачивается и не читается, пока узел на паузе. Узлы Map-стиля поддерживают паузу всегда.
+func WithPausable() Option {
+	return func(cfg *config) {
+		cfg.pausable = true
+	}
+}
+
+// Control выполняет управляющую команду. Пауза не прерывает обработку уже прочитанных элементов,
+// но прекращает чтение входа, поэтому вышестоящие узлы блокируются на отправке.
+func (n *Node[I, O]) Control(cmd Command) error {
+	if (n.cfg.pausable || n.cfg.gated) && (cmd.Kind == CmdPause || cmd.Kind == CmdResume) {
+		n.cfg.gate.set(cmd.Kind == CmdPause)
+		return nil
+	}
+
+	if n.cfg.controller != nil {
+		if err := n.cfg.controller.Control(cmd); err != nil {
+			return n.wrapError(err)
+		}
+		return nil
+	}
+
+	return n.wrapError(ErrUnsupportedCommand)
+}
+
+// Quiesced сообщает, что узел на паузе (CmdPause) и не обрабатывает ни одного элемента: после
+// паузы узел Map-стиля дообрабатывает уже прочитанные элементы, и Quiesced позволяет дождаться этого
+func (n *Node[I, O]) Quiesced() bool {
+	return n.cfg.gate.isPaused() && n.cfg.inFlight.Load() == 0
+}
+
+// gate пропускает чтение входа, пока узел не на паузе
+type gate struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+}
+
+// set ставит или снимает паузу
+func (g *gate) set(paused bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if paused == g.paused {
+		return
+	}
+	g.paused = paused
+	if paused {
+		g.resume = make(chan struct{})
+	} else {
+		close(g.resume)
+	}
+}
+
+// isPaused сообщает, что узел на паузе
+func (g *gate) isPaused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// wait блокируется, пока узел на паузе. Возвращает false, если контекст отменён.
+func (g *gate) wait(ctx context.Context) bool {
+	if g == nil {
+		return true
+	}
+
+	g.mu.Lock()
+	paused, resume := g.paused, g.resume
+	g.mu.Unlock()
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-resume:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// gateInput оборачивает вход так, что элементы не читаются, пока узел на паузе
+func gateInput[T any](ctx context.Context, wg *sync.WaitGroup, input <-chan T, g *gate) <-chan T {
+	proxy := make(chan T)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(proxy)
+		for g.wait(ctx) {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					return
+				}
+				select {
+				case proxy <- val:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return proxy
+}
+
+// controlFunc адаптер функции к Controllable
+type controlFunc func(cmd Command) error
+
+func (f controlFunc) Control(cmd Command) error {
+	return f(cmd)
+}
+
+// paramValue приводит значение параметра команды к типу T
+func paramValue[T any](cmd Command) (T, error) {
+	v, ok := cmd.Value.(T)
+	if !ok {
+		return v, fmt.Errorf("param %s: %w %T", cmd.Param, ErrParamType, cmd.Value)
+	}
+	return v, nil
+}
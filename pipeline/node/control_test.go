@@ -0,0 +1,84 @@
+package node
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestInFlightAndQuiesced(t *testing.T) {
+	const items, workers = 10, 3
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"without stats", nil},
+		{"with stats", []Option{WithStats()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// функция держит элементы, пока тест не отпустит их по одному
+			release := make(chan struct{})
+			n := NewMap("held", func(_ context.Context, v int) (int, error) {
+				<-release
+				return v, nil
+			}, append(tt.opts, WithConcurrency(workers))...)
+			// вход без буфера: после паузы узел не успевает прочитать лишний элемент
+			input := make(chan int)
+			if err := n.SetInput(0, input); err != nil {
+				t.Fatal(err)
+			}
+			out := make(chan int, items)
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			done := make(chan []error, 1)
+			go func() { done <- runNodes(t, context.Background(), n) }()
+
+			for i := range workers {
+				input <- i
+			}
+			eventually(t, func() bool { return n.Stats().InFlight == workers })
+			if err := n.Control(Command{Kind: CmdPause}); err != nil {
+				t.Fatal(err)
+			}
+			// на паузе уже прочитанные элементы дообрабатываются: узел не затихает, пока они в работе
+			if n.Quiesced() {
+				t.Fatal("Quiesced with items in flight")
+			}
+			for i := workers; i > 0; i-- {
+				if got := n.Stats().InFlight; got != int64(i) {
+					t.Fatalf("InFlight = %d, want %d", got, i)
+				}
+				release <- struct{}{}
+				eventually(t, func() bool { return n.Stats().InFlight == int64(i-1) })
+			}
+			if !n.Quiesced() {
+				t.Error("not Quiesced after in-flight items finished")
+			}
+
+			if err := n.Control(Command{Kind: CmdResume}); err != nil {
+				t.Fatal(err)
+			}
+			if n.Quiesced() {
+				t.Error("Quiesced after resume")
+			}
+			close(release)
+			for i := workers; i < items; i++ {
+				input <- i
+			}
+			close(input)
+			if errs := <-done; len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+			got := drain(out)()
+			slices.Sort(got)
+			if !slices.Equal(got, seq(items)) {
+				t.Errorf("got %v, want %v", got, seq(items))
+			}
+			if s := n.Stats(); s.InFlight != 0 {
+				t.Errorf("InFlight = %d after completion", s.InFlight)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package node
+
+import (
+	"syscall"
+	"time"
+)
+
+// rusageThread RUSAGE_THREAD: ресурсы только вызывающего потока ОС
+const rusageThread = 1
+
+// threadCPU возвращает процессорное время текущего потока ОС. Вызывающий должен закрепить
+// горутину за потоком (runtime.LockOSThread).
+func threadCPU() (time.Duration, bool) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(rusageThread, &ru); err != nil {
+		return 0, false
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano()), true
+}
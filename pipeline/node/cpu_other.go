@@ -0,0 +1,11 @@
+//go:build !linux
+
+package node
+
+import "time"
+
+// threadCPU на системах без учёта времени отдельного потока недоступна: WithCPUAccounting
+// измеряет время вызова по часам
+func threadCPU() (time.Duration, bool) {
+	return 0, false
+}
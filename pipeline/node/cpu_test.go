@@ -0,0 +1,57 @@
+package node
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// spin занимает процессор на d
+func spin(d time.Duration) {
+	for start := time.Now(); time.Since(start) < d; {
+	}
+}
+
+func TestWithCPUAccounting(t *testing.T) {
+	const work = 10 * time.Millisecond
+	// ожидание не занимает поток, но без учёта времени потока считается по часам
+	sleepMin, sleepMax := time.Duration(0), work
+	if _, ok := threadCPU(); !ok {
+		sleepMin, sleepMax = 3*work, 0
+	}
+	tests := []struct {
+		name string
+		f    func()
+		opts []Option
+		// wantMin, wantMax границы Busy для трёх элементов; wantMax 0 — без верхней границы
+		wantMin, wantMax time.Duration
+	}{
+		{"disabled", func() { spin(work) }, nil, 0, 0},
+		{"spin", func() { spin(work) }, []Option{WithCPUAccounting()}, 2 * work, 0},
+		// время параллельных вызовов суммируется
+		{"concurrent spin", func() { spin(work) }, []Option{WithCPUAccounting(), WithConcurrency(3)}, 2 * work, 0},
+		{"sleep", func() { time.Sleep(work) }, []Option{WithCPUAccounting()}, sleepMin, sleepMax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewMap("map", func(_ context.Context, v int) (int, error) {
+				tt.f()
+				return v, nil
+			}, tt.opts...)
+			got, errs := process(t, n, seq(3)...)
+			if len(got) != 3 || len(errs) > 0 {
+				t.Fatalf("output %v, errors %v", got, errs)
+			}
+			busy := n.Stats().Busy
+			switch {
+			case tt.opts == nil && busy != 0:
+				t.Errorf("Busy = %v without WithCPUAccounting", busy)
+			case busy < tt.wantMin:
+				t.Errorf("Busy = %v, want at least %v", busy, tt.wantMin)
+			case tt.wantMax > 0 && busy >= tt.wantMax:
+				t.Errorf("Busy = %v, want below %v (%s)", busy, tt.wantMax, runtime.GOOS)
+			}
+		})
+	}
+}
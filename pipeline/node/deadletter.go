@@ -0,0 +1,40 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DeadLetter элемент, который узел не смог обработать, вместе с причиной. Отправляется в канал,
+// заданный WithDeadLetter, а при его отсутствии — в канал ошибок как error.
+type DeadLetter struct {
+	Node string
+	Item any
+	Err  error
+}
+
+func (d *DeadLetter) Error() string {
+	return fmt.Sprintf("item %v: %v", d.Item, d.Err)
+}
+
+func (d *DeadLetter) Unwrap() error {
+	return d.Err
+}
+
+// sendError отправляет ошибку обработки элемента: DeadLetter уходит в канал dead-letter, если он
+// задан, остальные ошибки — в errChan. Возвращает false, если контекст отменён.
+func (c *config) sendError(ctx context.Context, errChan chan<- error, err error) bool {
+	var dl *DeadLetter
+	if c.deadLetter != nil && errors.As(err, &dl) {
+		select {
+		case c.deadLetter <- *dl:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	errChan <- err
+	return true
+}
@@ -0,0 +1,46 @@
+package node
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// WithPerItemDelay задаёт задержку узла Delay в зависимости от значения: delay(v) заменяет базовую
+// задержку d. Тип T должен совпадать с типом элементов узла, иначе Delay паникует.
+func WithPerItemDelay[T any](delay func(T) time.Duration) Option {
+	return func(c *config) {
+		c.itemDelay = delay
+	}
+}
+
+// Delay создаёт узел, пересылающий элементы без изменений с задержкой перед каждым элементом:
+// d плюс случайное отклонение, равномерно распределённое в [-jitter, jitter] (итоговая задержка не
+// меньше 0). Подходит для ограничения темпа обращений к внешней системе и имитации медленных
+// этапов в тестах. Задержка отсчитывается по часам узла (WithClock) и прерывается отменой
+// контекста, поэтому остановка ждёт не дольше остатка задержки текущего элемента; после закрытия
+// входа текущий элемент пересылается по истечении своей задержки. Поддерживает WithConcurrency
+// (задержки элементов перекрываются), WithPerItemDelay, WithPorts и WithOutputBuffers.
+func Delay[T any](name string, d time.Duration, jitter time.Duration, opts ...Option) *Node[T, T] {
+	cfg := newConfig(opts)
+	base := func(T) time.Duration { return d }
+	if cfg.itemDelay != nil {
+		f, ok := cfg.itemDelay.(func(T) time.Duration)
+		if !ok {
+			panic("per-item delay type mismatch")
+		}
+		base = f
+	}
+
+	return Loop(autoName(name, "Delay", cfg),
+		func(ctx context.Context, item T, emit *Emitter[T]) error {
+			delay := base(item)
+			if jitter > 0 {
+				delay += rand.N(2*jitter+1) - jitter
+			}
+			if !sleep(ctx, cfg.clock, delay) {
+				return nil
+			}
+			return emit.Send(item)
+		}, opts...)
+}
@@ -0,0 +1,105 @@
+package node
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// delayClock Clock, запоминающий запрошенные задержки и не ждущий их
+type delayClock struct {
+	mu    sync.Mutex
+	waits []time.Duration
+}
+
+func (c *delayClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *delayClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		d, jitter time.Duration
+		opts      []Option
+		// wantMin, wantMax границы каждой выдержанной задержки
+		wantMin, wantMax time.Duration
+		// wantWaits точные задержки, если отклонения нет
+		wantWaits []time.Duration
+	}{
+		{"fixed", time.Second, 0, nil, 0, 0, []time.Duration{time.Second, time.Second, time.Second, time.Second}},
+		// нулевая задержка часами не выдерживается
+		{"per item", time.Second, 0, []Option{WithPerItemDelay(func(v int) time.Duration {
+			return time.Duration(v) * time.Second
+		})}, 0, 0, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}},
+		{"jitter", 10 * time.Second, 2 * time.Second, nil, 8 * time.Second, 12 * time.Second, nil},
+		// отклонение больше задержки: отрицательные задержки не выдерживаются вовсе
+		{"jitter below zero", time.Second, 5 * time.Second, nil, time.Nanosecond, 6 * time.Second, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &delayClock{}
+			n := Delay[int]("delay", tt.d, tt.jitter, append(tt.opts, WithClock(clock))...)
+			got, errs := process(t, n, seq(4)...)
+			if len(errs) > 0 {
+				t.Errorf("errors: %v", errs)
+			}
+			if !slices.Equal(got, seq(4)) {
+				t.Errorf("output %v, want %v", got, seq(4))
+			}
+			if tt.wantWaits != nil && !slices.Equal(clock.waits, tt.wantWaits) {
+				t.Errorf("waits %v, want %v", clock.waits, tt.wantWaits)
+			}
+			for _, w := range clock.waits {
+				if tt.wantWaits == nil && (w < tt.wantMin || w > tt.wantMax) {
+					t.Errorf("wait %v outside [%v, %v]", w, tt.wantMin, tt.wantMax)
+				}
+			}
+		})
+	}
+}
+
+func TestDelayCancel(t *testing.T) {
+	clock := newFakeClock()
+	n := Delay[int]("delay", time.Hour, 0, WithClock(clock))
+	out := make(chan int, 1)
+	if err := n.SetInput(0, feed(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	n.Run(ctx, &wg, make(chan error), true)
+
+	// отмена прерывает выдержку: узел завершается, не дожидаясь часа и не пересылая элемент
+	eventually(t, func() bool { return clock.waiting() == 1 })
+	cancel()
+	waitGroup(t, &wg)
+	if got := drain(out)(); len(got) > 0 {
+		t.Errorf("output %v after cancel", got)
+	}
+	if r := n.ExitReason(); r != ExitCancelled {
+		t.Errorf("ExitReason = %v, want %v", r, ExitCancelled)
+	}
+}
+
+func TestDelayTypeMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Delay with a per-item delay of another type did not panic")
+		}
+	}()
+	Delay[int]("delay", time.Second, 0, WithPerItemDelay(func(string) time.Duration { return 0 }))
+}
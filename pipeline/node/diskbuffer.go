@@ -0,0 +1,49 @@
+package node
+
+import (
+	"context"
+
+	"github.com/tom-lepsky/pipeline/pipeline/util"
+)
+
+// DiskBuffer создаёт узел, пересылающий элементы без изменений через util.DiskBufferOnError: при
+// остановке нижестоящего узла до memLimit элементов ждут в памяти, остальные сбрасываются в
+// сегменты в dir (не более diskLimit байт), так что вышестоящие узлы не блокируются. Ошибки
+// открытия dir, повреждённые сегменты прошлого запуска и ошибки во время работы отправляются в
+// канал ошибок; если буфер не удалось открыть, узел завершается, не читая вход. Паникует, если
+// у codec нет Encode или Decode.
+func DiskBuffer[T any](name string, inputNum int, outputNum int, outputBuffSize []int, dir string,
+	codec util.Codec[T], memLimit int, diskLimit int64, opts ...Option) *Node[T, T] {
+	if codec.Encode == nil || codec.Decode == nil {
+		panic("nil codec func")
+	}
+
+	cfg := newConfig(opts)
+	n := newNode[T, T](autoName(name, "DiskBuffer", cfg), inputNum, outputNum, outputBuffSize, cfg)
+	n.handler = func(ctx context.Context, input <-chan T, output chan<- T, errChan chan<- error) {
+		defer closeOutput(output)
+		report := func(err error) {
+			select {
+			case errChan <- err:
+			case <-ctx.Done():
+			}
+		}
+
+		buffered, err := util.DiskBufferOnError(ctx, input, dir, codec, memLimit, diskLimit, report)
+		if err != nil {
+			report(err)
+			return
+		}
+		for val := range buffered {
+			if output == nil {
+				continue
+			}
+			select {
+			case output <- val:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,38 @@
+// Package node содержит узлы пайплайна: обобщённый Node с произвольным обработчиком и готовые
+// конструкторы (Map, FlatMap, Sink, Source, Batch, Throttle, ScatterGather, JSON, Exec, файловые
+// приёмники и др.), а также соединение узлов через Connect и Autowire.
+//
+// # Отмена
+//
+// Отмена контекста Run действует одинаково для всех конструкторов пакета, в том числе для узлов
+// с несколькими входами (слияние util.FanIn) и выходами (распределение util.FanOut):
+//
+//   - узел прекращает чтение входов и не вызывает функцию узла для новых элементов; уже начатые
+//     вызовы получают отменённый контекст, обработчик завершается без блокировки на отправке;
+//   - элемент, прочитанный до отмены, но не отправленный в выход, отбрасывается. Элементы, уже
+//     принятые выходом, остаются в буферах рёбер (см. Stranded); распределитель нескольких выходов
+//     при отмене без блокировки передаёт принятые им элементы в выходы со свободным местом;
+//   - накопленные, но не выданные данные (неполный пакет NewBatch, отчёт TemplateSink с
+//     WithCollect, файл AtomicFileSink без WithKeepPartial) отбрасываются; финальный сброс
+//     WithFlush выполняется при любом завершении;
+//   - все выходы узла закрываются;
+//   - отмена не считается ошибкой: узлы не отправляют ctx.Err() в канал ошибок, а ошибки функции
+//     узла, возвращённые после отмены, отбрасываются. Ошибки хуков (WithInit, WithFlush, WithClose)
+//     отправляются как обычно;
+//   - после отмены завершаются все горутины узла, и wg, переданный в Run, освобождается.
+//
+// Если вход закрыт до отмены, узел обрабатывает и выдаёт все прочитанные элементы (неполный пакет
+// NewBatch выдаётся), и только затем закрывает выходы. Отмена после закрытия входа, пока узел ещё
+// выдаёт результаты, действует по правилам выше.
+//
+// Обработчики New, NewSelect и функции NewSource, Task должны соблюдать те же правила сами:
+// отправлять в выход через select с ctx.Done() и не сообщать ctx.Err() как ошибку.
+//
+// # Ошибки
+//
+// Ошибки, по которым вызывающий код может принимать решения, экспортируются как переменные Err*
+// (ErrCircuitOpen, ErrQuotaExceeded, ErrFrozen и т.д.) и сравниваются через errors.Is. Обёртки
+// пакета — NodeError (имя узла и идентификатор запуска), ClassifiedError (класс ошибки), DeadLetter
+// и PanicError (если паника вызвана с ошибкой) — поддерживают Unwrap, поэтому сравнение работает
+// через любое их сочетание.
+package node
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"context"
+)
+
+// EarlyExitPolicy действие, когда обработчик завершился, а вход узла ещё не закрыт
+type EarlyExitPolicy int
+
+const (
+	// EarlyExitKeep ничего не делает: вход после возврата обработчика не читается, вышестоящие узлы
+	// могут заблокироваться на отправке. Элемент, уже полученный пересылкой входа (см.
+	// WithExitTracking), но не принятый обработчиком, сообщается Stranded.
+	EarlyExitKeep EarlyExitPolicy = iota
+	// EarlyExitDrain читает и отбрасывает остаток входа до его закрытия (учитывается в Stats.Discarded)
+	EarlyExitDrain
+	// EarlyExitCancel сообщает ErrHandlerExited и отменяет пайплайн, чтобы вышестоящие узлы завершились
+	EarlyExitCancel
+)
+
+// WithEarlyExit задаёт действие при завершении обработчика до закрытия входа (по умолчанию
+// EarlyExitKeep). Рекомендуется EarlyExitDrain для обработчиков, которые могут завершиться досрочно
+// (например, ограничивающих количество элементов), иначе Wait может не вернуться. После паники,
+// перехваченной политикой паники (WithPanicPolicy), EarlyExitKeep действует как EarlyExitDrain:
+// вход дочитывается до закрытия, чтобы вышестоящие узлы не заблокировались. Не применяется
+// к узлам с SelectHandler и с политикой перезапуска.
+func WithEarlyExit(policy EarlyExitPolicy) Option {
+	return func(c *config) {
+		c.earlyExit = policy
+	}
+}
+
+// afterExit записывает причину завершения и применяет политику досрочного завершения к входу
+// после возврата обработчика. После перехваченной паники вход дочитывается и при EarlyExitKeep.
+func (n *Node[I, O]) afterExit(ctx context.Context, input <-chan I, errChan chan<- error) {
+	input = n.finishRelay(input)
+	reason := n.exitAfter(ctx, input)
+	n.setExit(reason)
+	policy := n.cfg.earlyExit
+	if policy == EarlyExitKeep && n.ExitReason() == ExitPanicked {
+		policy = EarlyExitDrain
+	}
+	if reason != ExitEarly || policy == EarlyExitKeep {
+		return
+	}
+
+	switch policy {
+	case EarlyExitDrain:
+		if n.held != nil {
+			n.held = nil
+			n.discard()
+		}
+		for {
+			select {
+			case _, ok := <-input:
+				if !ok {
+					return
+				}
+				n.discard()
+			case <-ctx.Done():
+				return
+			}
+		}
+	case EarlyExitCancel:
+		errChan <- ErrHandlerExited
+		cancelPipeline(ctx)
+	}
+}
+
+// discard учитывает отброшенный элемент в статистике
+func (n *Node[I, O]) discard() {
+	if n.counters != nil {
+		n.counters.discarded.Add(1)
+	}
+}
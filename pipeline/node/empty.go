@@ -0,0 +1,22 @@
+package node
+
+// WithEmitOnEmpty задаёт результат узлов-агрегаторов для пустого входа: если вход закрылся, не
+// дав ни одного элемента, узел отправляет в выход factory() вместо того, чтобы ничего не выдать,
+// так что нижестоящие узлы отличают пустой результат от невыполненной работы. Тип factory должен
+// соответствовать выходу узла (для NewBatch — func() []T), иначе конструктор паникует. При отмене
+// контекста результат не отправляется.
+func WithEmitOnEmpty[T any](factory func() T) Option {
+	return func(c *config) {
+		c.emitOnEmpty = factory
+	}
+}
+
+// WithWriteEmptyResult включает для файловых приёмников создание пустого результата, если вход
+// закрылся, не дав ни одного элемента: RotatingFileSink создаёт пустой первый файл. AtomicFileSink
+// и TemplateSink с WithCollect выдают пустой результат (пустой файл, отчёт по пустому срезу) и без
+// опции; маркер WithCompletionMarker при пустом входе записывается как при любом успешном завершении.
+func WithWriteEmptyResult() Option {
+	return func(c *config) {
+		c.writeEmpty = true
+	}
+}
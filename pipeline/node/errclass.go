@@ -0,0 +1,90 @@
+package node
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorClass класс ошибки узла
+type ErrorClass int
+
+const (
+	// ClassItem ошибка обработки отдельного элемента (например, отсутствующий файл). Класс по
+	// умолчанию для ошибок, отправленных обработчиком без классификации.
+	ClassItem ErrorClass = iota
+	// ClassNode отказ узла целиком: ошибки init/close, досрочное завершение, перезапуски
+	ClassNode
+	// ClassInfra сбой механизмов пайплайна, например паника обработчика
+	ClassInfra
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ClassItem:
+		return "item"
+	case ClassNode:
+		return "node"
+	case ClassInfra:
+		return "infra"
+	default:
+		return "unknown"
+	}
+}
+
+// ClassifiedError ошибка с явно заданным классом. Текст ошибки совпадает с текстом Err.
+type ClassifiedError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// Classify задаёт класс ошибки err
+func Classify(class ErrorClass, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ClassifiedError{Class: class, Err: err}
+}
+
+// ClassOf определяет класс ошибки: явно заданный через Classify (ближайший к началу цепочки),
+// ClassInfra для паники, ClassItem для DeadLetter, ClassNode для ErrHandlerExited и
+// ErrRestartLimit, иначе ClassItem.
+func ClassOf(err error) ErrorClass {
+	var ce *ClassifiedError
+	if errors.As(err, &ce) {
+		return ce.Class
+	}
+
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		return ClassInfra
+	}
+
+	var dl *DeadLetter
+	if errors.As(err, &dl) {
+		return ClassItem
+	}
+
+	if errors.Is(err, ErrHandlerExited) || errors.Is(err, ErrRestartLimit) {
+		return ClassNode
+	}
+	return ClassItem
+}
+
+// SendError отправляет в errChan ошибку err с классом class. Возвращает false, если контекст
+// отменён раньше, чем ошибка принята.
+func SendError(ctx context.Context, errChan chan<- error, class ErrorClass, err error) bool {
+	select {
+	case errChan <- Classify(class, err):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithErrorTransform(t *testing.T) {
+	errDisk := errors.New("disk full")
+	errBad := errors.New("bad item")
+	// 1 — отсутствующий файл, 2 — ошибка инфраструктуры, 3 — ошибка элемента
+	stat := func(_ context.Context, v int) (int, error) {
+		switch v {
+		case 1:
+			return 0, &fs.PathError{Op: "stat", Path: "missing", Err: fs.ErrNotExist}
+		case 2:
+			return 0, Classify(ClassInfra, errDisk)
+		case 3:
+			return 0, errBad
+		}
+		return v, nil
+	}
+	// отсутствующие файлы подавляются, остальные ошибки оборачиваются доменной
+	transform := func(err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("domain: %w", err)
+	}
+	n := NewMap("stat", stat, WithErrorTransform(transform), WithStats(), WithOutputBuffers(5))
+
+	got, errs := process(t, n, seq(5)...)
+	if want := []int{0, 4}; !slices.Equal(got, want) {
+		t.Errorf("output = %v, want %v", got, want)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("errors = %v, want 2", errs)
+	}
+	for _, err := range errs {
+		if !strings.Contains(err.Error(), "domain: ") {
+			t.Errorf("error %v was not transformed", err)
+		}
+	}
+	// класс сохраняется при обёртке через %w
+	if !errors.Is(errs[0], errDisk) || ClassOf(errs[0]) != ClassInfra {
+		t.Errorf("error %v, want ClassInfra wrapping %v", errs[0], errDisk)
+	}
+	if !errors.Is(errs[1], errBad) || ClassOf(errs[1]) != ClassItem {
+		t.Errorf("error %v, want ClassItem wrapping %v", errs[1], errBad)
+	}
+	if s := n.Stats(); s.Suppressed != 1 || s.Errors != 2 {
+		t.Errorf("stats suppressed %d, errors %d; want 1, 2", s.Suppressed, s.Errors)
+	}
+}
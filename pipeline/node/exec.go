@@ -0,0 +1,119 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// ExecWaitDelay время, которое узел Exec ждёт закрытия потоков вывода после завершения или
+// уничтожения процесса (потомки процесса могут удерживать их открытыми)
+const ExecWaitDelay = time.Second
+
+var (
+	ErrEmptyArgv = errors.New("empty argv")
+	ErrExitCode  = errors.New("exit code")
+)
+
+// ExecResult результат выполнения команды для элемента
+type ExecResult struct {
+	Item   string
+	Stdout []byte
+	Stderr []byte
+	// ExitCode код завершения процесса; -1, если процесс не запустился или был уничтожен
+	ExitCode int
+	// Err ошибка запуска или ожидания процесса (в том числе по таймауту); nil при любом коде завершения
+	Err error
+}
+
+// WithExitErrors включает для узла Exec отправку неудачных запусков (ненулевой код завершения
+// или ExecResult.Err) в канал ошибок вместо выхода. Ошибка содержит stderr процесса; при ненулевом
+// коде завершения она оборачивает ErrExitCode.
+func WithExitErrors() Option {
+	return func(c *config) {
+		c.exitErrors = true
+	}
+}
+
+// Exec создаёт узел, запускающий для каждого элемента внешнюю команду argv(item) (argv[0] — путь
+// или имя программы) и выдающий ExecResult. Одновременно работает не больше maxConc процессов
+// (WithConcurrency не действует), порядок результатов не сохраняется без WithOrderedOutput.
+// timeout ограничивает время одного процесса, 0 — без ограничения. Ненулевой код завершения
+// по умолчанию считается данными (см. WithExitErrors). При отмене контекста, по таймауту и при
+// остановке пайплайна процесс уничтожается вместе с группой его потомков (на unix-системах) и
+// ожидается, так что зомби-процессы не остаются.
+func Exec(name string, argv func(item string) []string, maxConc int, timeout time.Duration, opts ...Option) *Node[string, ExecResult] {
+	if argv == nil {
+		panic("nil argv func")
+	}
+
+	cfg := newConfig(opts)
+	cfg.gated = true
+	cfg.concurrency = max(maxConc, 1)
+	handler := func(ctx context.Context, input <-chan string, output chan<- ExecResult, errChan chan<- error) {
+		defer closeOutput(output)
+		runItems(ctx, cfg, input, output, errChan, func(ctx context.Context, item string) (ExecResult, error) {
+			res := runCommand(ctx, item, argv(item), timeout)
+			if cfg.exitErrors && (res.Err != nil || res.ExitCode != 0) {
+				return res, fmt.Errorf("exec %q: %w", item, exitError(res))
+			}
+			return res, nil
+		})
+	}
+
+	n := newNode[string, ExecResult](name, 1, 1, nil, cfg)
+	n.handler = handler
+	return n
+}
+
+// runCommand запускает команду и ожидает её завершения
+func runCommand(ctx context.Context, item string, argv []string, timeout time.Duration) ExecResult {
+	res := ExecResult{Item: item, ExitCode: -1}
+	if len(argv) == 0 {
+		res.Err = ErrEmptyArgv
+		return res
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	cmd.WaitDelay = ExecWaitDelay
+	killGroup(cmd)
+
+	err := cmd.Run()
+	res.Stdout, res.Stderr = stdout.Bytes(), stderr.Bytes()
+	if cmd.ProcessState != nil {
+		res.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		res.Err = ctx.Err()
+	case errors.As(err, &exitErr):
+	default:
+		res.Err = err
+	}
+	return res
+}
+
+// exitError возвращает ошибку неудачного запуска со stderr процесса
+func exitError(res ExecResult) error {
+	err := res.Err
+	if err == nil {
+		err = fmt.Errorf("%w %d", ErrExitCode, res.ExitCode)
+	}
+	if stderr := bytes.TrimSpace(res.Stderr); len(stderr) > 0 {
+		err = fmt.Errorf("%w: %s", err, stderr)
+	}
+	return err
+}
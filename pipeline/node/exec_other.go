@@ -0,0 +1,9 @@
+//go:build !unix
+
+package node
+
+import "os/exec"
+
+// killGroup на системах без групп процессов ничего не делает: при отмене уничтожается только
+// сам процесс
+func killGroup(*exec.Cmd) {}
@@ -0,0 +1,99 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shArgv запускает item как команду /bin/sh; элемент "empty" даёт пустой argv
+func shArgv(item string) []string {
+	if item == "empty" {
+		return nil
+	}
+	return []string{"/bin/sh", "-c", item}
+}
+
+func TestExec(t *testing.T) {
+	if _, err := exec.LookPath("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+	const (
+		echo  = "echo hello"
+		fail  = "echo oops >&2; exit 3"
+		sleep = "sleep 5"
+	)
+	tests := []struct {
+		name       string
+		item       string
+		exitErrors bool
+		want       ExecResult
+		// wantErr ошибка в канале ошибок вместо результата
+		wantErr error
+	}{
+		{"echo", echo, false, ExecResult{Item: echo, Stdout: []byte("hello\n")}, nil},
+		// ненулевой код завершения по умолчанию — данные
+		{"non-zero exit", fail, false, ExecResult{Item: fail, Stderr: []byte("oops\n"), ExitCode: 3}, nil},
+		{"non-zero exit as error", fail, true, ExecResult{}, ErrExitCode},
+		{"echo with exit errors", echo, true, ExecResult{Item: echo, Stdout: []byte("hello\n")}, nil},
+		// процесс уничтожается по таймауту
+		{"timeout", sleep, false, ExecResult{Item: sleep, ExitCode: -1, Err: context.DeadlineExceeded}, nil},
+		{"empty argv", "empty", false, ExecResult{Item: "empty", ExitCode: -1, Err: ErrEmptyArgv}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.exitErrors {
+				opts = append(opts, WithExitErrors())
+			}
+			start := time.Now()
+			got, errs := process(t, Exec("exec", shArgv, 2, 200*time.Millisecond, opts...), tt.item)
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("took %v, the process was not killed", elapsed)
+			}
+
+			if tt.wantErr != nil {
+				if len(got) != 0 || len(errs) != 1 || !errors.Is(errs[0], tt.wantErr) || !strings.Contains(errs[0].Error(), "oops") {
+					t.Errorf("output %v, errors %v; want one error wrapping %v with stderr", got, errs, tt.wantErr)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Errorf("errors: %v", errs)
+			}
+			if len(got) != 1 {
+				t.Fatalf("output %v, want one result", got)
+			}
+			res := got[0]
+			if res.Item != tt.want.Item || string(res.Stdout) != string(tt.want.Stdout) ||
+				string(res.Stderr) != string(tt.want.Stderr) || res.ExitCode != tt.want.ExitCode ||
+				!errors.Is(res.Err, tt.want.Err) {
+				t.Errorf("result %+v, want %+v", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecConcurrency(t *testing.T) {
+	if _, err := exec.LookPath("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+	items := []string{"echo 1", "echo 2", "echo 3", "echo 4", "echo 5"}
+	got, errs := process(t, Exec("exec", shArgv, 3, 0), items...)
+	if len(errs) > 0 {
+		t.Errorf("errors: %v", errs)
+	}
+	// порядок результатов не сохраняется
+	var outs []string
+	for _, res := range got {
+		outs = append(outs, strings.TrimSpace(string(res.Stdout)))
+	}
+	slices.Sort(outs)
+	if want := []string{"1", "2", "3", "4", "5"}; !slices.Equal(outs, want) {
+		t.Errorf("stdout %v, want %v", outs, want)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package node
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killGroup запускает процесс в собственной группе, чтобы при отмене уничтожались и его потомки
+func killGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
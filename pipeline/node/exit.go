@@ -0,0 +1,194 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ExitReason причина завершения узла
+type ExitReason int32
+
+const (
+	// ExitNone узел не запускался (или схлопнут в ребро, см. WithInline)
+	ExitNone ExitReason = iota
+	// ExitRunning узел работает
+	ExitRunning
+	// ExitInputClosed обработчик завершился после закрытия входа; для источника — после того,
+	// как он исчерпал данные или был остановлен StopSource
+	ExitInputClosed
+	// ExitCancelled узел завершился из-за отмены контекста (Stop, отмена ctx Run, WithFailFast)
+	ExitCancelled
+	// ExitEarly обработчик вернулся, когда вход ещё не был закрыт
+	ExitEarly
+	// ExitPanicked обработчик запаниковал (паника преобразована в ошибку политикой паники)
+	ExitPanicked
+	// ExitInitFailed хук WithInit вернул ошибку, обработчик не запускался
+	ExitInitFailed
+	// ExitRestartLimit исчерпаны перезапуски WithRestartPolicy
+	ExitRestartLimit
+	// ExitSkipped узел пропущен пайплайном (неудача зависимости или отключённая ветка) или не
+	// запущен из-за неподключённого входа
+	ExitSkipped
+)
+
+func (r ExitReason) String() string {
+	switch r {
+	case ExitNone:
+		return "not run"
+	case ExitRunning:
+		return "running"
+	case ExitInputClosed:
+		return "input closed"
+	case ExitCancelled:
+		return "cancelled"
+	case ExitEarly:
+		return "handler returned early"
+	case ExitPanicked:
+		return "panicked"
+	case ExitInitFailed:
+		return "init failed"
+	case ExitRestartLimit:
+		return "restart limit exceeded"
+	case ExitSkipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
+
+// ExitReason возвращает причину завершения последнего запуска узла. Для узла с SelectHandler
+// досрочное завершение не отличается от закрытия входов. Закрытие входа узлы пакета отмечают сами;
+// вход узла с произвольным обработчиком (New, Build, NewPool, New2) при EarlyExitKeep после возврата
+// обработчика не читается, чтобы не потерять элемент, поэтому такой узел сообщает ExitEarly, даже
+// если вход был закрыт, — кроме узлов с WithExitTracking или WithStats. При EarlyExitDrain и
+// EarlyExitCancel закрытие определяется пробным чтением входа.
+func (n *Node[I, O]) ExitReason() ExitReason {
+	return ExitReason(n.exit.Load())
+}
+
+// setExit записывает причину завершения, если она ещё не записана в этом запуске
+func (n *Node[I, O]) setExit(reason ExitReason) {
+	n.exit.CompareAndSwap(int32(ExitRunning), int32(reason))
+}
+
+// exitAfter определяет причину завершения после возврата обработчика. Если закрытие входа не
+// отмечено, при EarlyExitKeep вход не читается и завершение считается досрочным; при остальных
+// политиках вход проверяется чтением, и прочитанный элемент отбрасывается (учитывается в
+// Stats.Discarded) так же, как остаток входа при EarlyExitDrain.
+func (n *Node[I, O]) exitAfter(ctx context.Context, input <-chan I) ExitReason {
+	if ctx.Err() != nil {
+		return ExitCancelled
+	}
+	if input == nil || n.cfg.inputClosed.Load() {
+		return ExitInputClosed
+	}
+	if n.cfg.earlyExit == EarlyExitKeep {
+		return ExitEarly
+	}
+
+	select {
+	case _, ok := <-input:
+		if !ok {
+			return ExitInputClosed
+		}
+		n.discard()
+	default:
+	}
+	return ExitEarly
+}
+
+// WithExitTracking пересылает вход узла с произвольным обработчиком (New, Build, NewPool, New2)
+// через промежуточный канал, отмечающий закрытие входа, чтобы ExitReason различал закрытие входа и
+// досрочное завершение и при EarlyExitKeep. Пересылка стоит горутины и лишней передачи через канал
+// на каждый элемент; с WithStats она включена всегда. Элемент, уже полученный пересылкой, но не
+// принятый обработчиком, не теряется: он сообщается Stranded как ребро "<узел> input" (при
+// EarlyExitDrain и после паники отбрасывается вместе с остатком входа).
+func WithExitTracking() Option {
+	return func(c *config) {
+		c.trackExit = true
+	}
+}
+
+// inputRelay пересылка входа обработчику текущего запуска (relayInput); held элемент, прочитанный
+// из source, но не принятый обработчиком
+type inputRelay[T any] struct {
+	source <-chan T
+	stop   chan struct{}
+	done   chan struct{}
+	held   *T
+}
+
+// relayInput пересылает input обработчику через канал-обёртку и отмечает закрытие input, чтобы
+// причину завершения обработчика, не отмечающего закрытие сам, можно было определить без чтения
+// входа (WithExitTracking). При включённой статистике элементы учитываются так же, как в countInput.
+// Пересылка прекращается finishRelay после возврата обработчика или отменой ctx: элемент, уже
+// прочитанный из input, но не принятый обработчиком, сохраняется в held и не учитывается в ItemsIn.
+func (n *Node[I, O]) relayInput(ctx context.Context, wg *sync.WaitGroup, input <-chan I,
+	size func(I) int) (<-chan I, *inputRelay[I]) {
+	r := &inputRelay[I]{source: input, stop: make(chan struct{}), done: make(chan struct{})}
+	proxy := make(chan I)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(r.done)
+		defer close(proxy)
+		for {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					n.cfg.inputClosed.Store(true)
+					return
+				}
+				itemSize := sizeOf(size, val)
+				if n.counters != nil {
+					n.counters.countIn(n.cfg.clock.Now(), itemSize)
+				}
+				select {
+				case proxy <- val:
+					continue
+				case <-ctx.Done():
+				case <-r.stop:
+				}
+				if n.counters != nil {
+					n.counters.uncountIn(itemSize)
+				}
+				r.held = &val
+				return
+			case <-ctx.Done():
+				return
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+
+	return proxy, r
+}
+
+// finishRelay прекращает пересылку входа текущего запуска, дожидается её завершения, запоминает
+// удержанный ею элемент (см. Stranded) и возвращает исходный вход. Без пересылки возвращает input.
+func (n *Node[I, O]) finishRelay(input <-chan I) <-chan I {
+	r := n.relay
+	if r == nil {
+		return input
+	}
+	n.relay = nil
+	close(r.stop)
+	<-r.done
+	n.held = r.held
+	return r.source
+}
+
+// exitOf возвращает причину завершения запуска supervise с ошибкой последнего запуска err
+func exitOf(ctx context.Context, err error) ExitReason {
+	var pe *PanicError
+	switch {
+	case errors.As(err, &pe):
+		return ExitPanicked
+	case ctx.Err() != nil:
+		return ExitCancelled
+	default:
+		return ExitInputClosed
+	}
+}
@@ -0,0 +1,248 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// takeOne обработчик, читающий один элемент и завершающийся до закрытия входа
+func takeOne(_ context.Context, input <-chan int, _ chan<- struct{}, _ chan<- error) {
+	<-input
+}
+
+// readAll обработчик, читающий вход до закрытия
+func readAll(_ context.Context, input <-chan int, _ chan<- struct{}, _ chan<- error) {
+	for range input {
+	}
+}
+
+func TestExitKeepDoesNotConsume(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"exit tracking", []Option{WithExitTracking()}},
+		{"stats", []Option{WithStats()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan int, 3)
+			in <- 1
+			in <- 2
+			in <- 3
+			n := New("early", 1, 0, nil, takeOne, tt.opts...)
+			if err := n.SetInput(0, in); err != nil {
+				t.Fatal(err)
+			}
+
+			runNodes(t, context.Background(), n)
+			if r := n.ExitReason(); r != ExitEarly {
+				t.Errorf("ExitReason = %v, want %v", r, ExitEarly)
+			}
+			// элемент, полученный пересылкой входа до возврата обработчика, не теряется: он
+			// сообщается Stranded, остальные остаются во входе по порядку
+			var rest []int
+			for _, e := range n.Stranded(1) {
+				if e.Edge != "early input" || e.Count != 1 || len(e.Items) != 1 {
+					t.Fatalf("stranded %+v, want the held input item", e)
+				}
+				rest = append(rest, e.Items[0].(int))
+			}
+			for len(in) > 0 {
+				rest = append(rest, <-in)
+			}
+			if !slices.Equal(rest, []int{2, 3}) {
+				t.Errorf("items after the consumed one: %v, want [2 3]", rest)
+			}
+			if d := n.Stats().Discarded; d != 0 {
+				t.Errorf("Discarded = %d, want 0", d)
+			}
+		})
+	}
+}
+
+func TestExitReasonByPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   Handler[int, struct{}]
+		policy    EarlyExitPolicy
+		want      ExitReason
+		opts      []Option
+		discarded uint64
+	}{
+		{"keep early", takeOne, EarlyExitKeep, ExitEarly, []Option{WithStats()}, 0},
+		{"drain early", takeOne, EarlyExitDrain, ExitEarly, []Option{WithStats()}, 2},
+		{"drain closed", readAll, EarlyExitDrain, ExitInputClosed, []Option{WithStats()}, 0},
+		{"keep closed", readAll, EarlyExitKeep, ExitInputClosed, []Option{WithExitTracking()}, 0},
+		// без пересылки закрытие входа при EarlyExitKeep не проверяется чтением
+		{"keep closed untracked", readAll, EarlyExitKeep, ExitEarly, nil, 0},
+		{"cancel closed", readAll, EarlyExitCancel, ExitInputClosed, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := New("node", 1, 0, nil, tt.handler, append(tt.opts, WithEarlyExit(tt.policy))...)
+			if err := n.SetInput(0, feed(1, 2, 3)); err != nil {
+				t.Fatal(err)
+			}
+			errs := runNodes(t, context.Background(), n)
+			if len(errs) != 0 {
+				t.Errorf("errors = %v", errs)
+			}
+			if r := n.ExitReason(); r != tt.want {
+				t.Errorf("ExitReason = %v, want %v", r, tt.want)
+			}
+			if d := n.Stats().Discarded; d != tt.discarded {
+				t.Errorf("Discarded = %d, want %d", d, tt.discarded)
+			}
+		})
+	}
+}
+
+func TestEarlyExitUpstream(t *testing.T) {
+	const items = 10
+	tests := []struct {
+		name   string
+		policy EarlyExitPolicy
+		// wantStalled источник не завершился сам, и узлы остановила отмена теста
+		wantStalled bool
+		wantCancel  bool
+		wantErr     error
+		// discarded допустимый диапазон Stats.Discarded
+		discarded [2]uint64
+	}{
+		// вход не читается, и источник блокируется на отправке до отмены
+		{"keep", EarlyExitKeep, true, false, nil, [2]uint64{0, 0}},
+		// остаток входа вычитывается, источник отправляет всё и завершается сам
+		{"drain", EarlyExitDrain, false, false, nil, [2]uint64{items - 1, items - 1}},
+		// пайплайн отменяется, источник завершается по отмене; проверка закрытия входа могла
+		// прочитать и отбросить один элемент
+		{"cancel", EarlyExitCancel, false, true, ErrHandlerExited, [2]uint64{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent atomic.Int32
+			source := NewSource("source", 1, nil, func(ctx context.Context, output chan<- int, _ chan<- error) {
+				for i := range items {
+					select {
+					case output <- i:
+						sent.Add(1)
+					case <-ctx.Done():
+						return
+					}
+				}
+			})
+			n := New("early", 1, 0, nil, takeOne, WithEarlyExit(tt.policy), WithStats())
+			if err := Connect(source, 0, n, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var cancelled, stalled atomic.Bool
+			ctx = ContextWithCancel(ctx, func() {
+				cancelled.Store(true)
+				cancel()
+			})
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-done:
+				case <-time.After(100 * time.Millisecond):
+					stalled.Store(true)
+					cancel()
+				}
+			}()
+			errs := runNodes(t, ctx, source, n)
+			close(done)
+
+			if stalled.Load() != tt.wantStalled {
+				t.Errorf("stalled = %v, want %v (source sent %d)", stalled.Load(), tt.wantStalled, sent.Load())
+			}
+			if s := int(sent.Load()); tt.policy == EarlyExitDrain && s != items {
+				t.Errorf("source sent %d items, want %d", s, items)
+			}
+			if cancelled.Load() != tt.wantCancel {
+				t.Errorf("pipeline cancelled = %v, want %v", cancelled.Load(), tt.wantCancel)
+			}
+			if tt.wantErr == nil && len(errs) > 0 || tt.wantErr != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.wantErr)) {
+				t.Errorf("errors = %v, want %v", errs, tt.wantErr)
+			}
+			if r := n.ExitReason(); r != ExitEarly {
+				t.Errorf("ExitReason = %v, want %v", r, ExitEarly)
+			}
+			if d := n.Stats().Discarded; d < tt.discarded[0] || d > tt.discarded[1] {
+				t.Errorf("Discarded = %d, want %d..%d", d, tt.discarded[0], tt.discarded[1])
+			}
+		})
+	}
+}
+
+func TestExitInputClosedMapStyle(t *testing.T) {
+	mapped := NewMap("map", func(_ context.Context, v int) (int, error) { return v, nil }, WithOutputBuffers(3))
+	sink := NewSink("sink", 1, func(context.Context, int) error { return nil })
+	if err := mapped.SetInput(0, feed(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Connect(mapped, 0, sink, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	runNodes(t, context.Background(), mapped, sink)
+	for _, n := range []interface {
+		Name() string
+		ExitReason() ExitReason
+	}{mapped, sink} {
+		if r := n.ExitReason(); r != ExitInputClosed {
+			t.Errorf("%s: ExitReason = %v, want %v", n.Name(), r, ExitInputClosed)
+		}
+	}
+}
+
+func TestExitCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	n := NewSink("sink", 1, func(context.Context, int) error { return nil })
+	if err := n.SetInput(0, in); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	runNodes(t, ctx, n)
+	if r := n.ExitReason(); r != ExitCancelled {
+		t.Errorf("ExitReason = %v, want %v", r, ExitCancelled)
+	}
+}
+
+func TestExitPanickedDrainsByDefault(t *testing.T) {
+	in := make(chan int)
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		defer close(in)
+		for i := range 100 {
+			in <- i
+		}
+	}()
+	n := NewSink("sink", 1, func(context.Context, int) error { panic("boom") })
+	if err := n.SetInput(0, in); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := runNodes(t, context.Background(), n)
+	select {
+	case <-produced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("producer blocked on the panicked node's input")
+	}
+	var pe *PanicError
+	if len(errs) != 1 || !errors.As(errs[0], &pe) {
+		t.Fatalf("errors = %v, want one *PanicError", errs)
+	}
+	if r := n.ExitReason(); r != ExitPanicked {
+		t.Errorf("ExitReason = %v, want %v", r, ExitPanicked)
+	}
+}
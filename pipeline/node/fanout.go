@@ -0,0 +1,107 @@
+package node
+
+import (
+	"context"
+
+	"github.com/tom-lepsky/pipeline/pipeline/util"
+)
+
+const (
+	// DefaultFanInTreeThreshold количество входов узла, начиная с которого (не включительно)
+	// входы сливаются деревом
+	DefaultFanInTreeThreshold = 32
+	// DefaultFanInBranching ветвление дерева слияния входов по умолчанию
+	DefaultFanInBranching = 8
+)
+
+// FanOutStrategy способ распределения выходных значений узла по нескольким выходам
+type FanOutStrategy int
+
+const (
+	// RoundRobin поочерёдное распределение с пропуском заполненных выходов (util.FanOut)
+	RoundRobin FanOutStrategy = iota
+	// RoundRobinStrict строго поочерёдное распределение без пропусков (util.FanOutStrict).
+	// Пропускная способность ограничена самым медленным выходом.
+	RoundRobinStrict
+	// Weighted взвешенное распределение (util.FanOutWeighted), задаётся WithFanOutWeights
+	Weighted
+)
+
+// WithFanOutStrategy задаёт способ распределения значений по выходам узла (по умолчанию RoundRobin)
+func WithFanOutStrategy(s FanOutStrategy) Option {
+	return func(c *config) {
+		c.fanOut = s
+	}
+}
+
+// WithFanOutWeights включает взвешенное распределение значений по выходам узла: weights задаёт
+// вес каждого выхода, нулевой вес означает резервный выход (см. util.FanOutWeighted).
+// Количество весов должно совпадать с количеством выходов.
+func WithFanOutWeights(weights ...int) Option {
+	return func(c *config) {
+		c.fanOut = Weighted
+		c.fanOutWeights = weights
+	}
+}
+
+// WithFanInTree задаёт слияние входов узла деревом (util.FanInTree) с ветвлением branching, если
+// входов больше threshold (по умолчанию DefaultFanInTreeThreshold и DefaultFanInBranching).
+// threshold <= 0 отключает дерево: входы всегда сливаются util.FanIn.
+func WithFanInTree(threshold, branching int) Option {
+	return func(c *config) {
+		c.fanInTree = threshold
+		c.fanInBranch = branching
+	}
+}
+
+// fanIn сливает входы узла в один канал: деревом, если входов больше порога WithFanInTree
+func fanIn[T any](ctx context.Context, cfg *config, inputs []<-chan T) <-chan T {
+	if cfg.inputLabels != nil {
+		return fairFanIn(ctx, cfg, inputs)
+	}
+	if cfg.fanInTree > 0 && len(inputs) > cfg.fanInTree {
+		return util.FanInTree(ctx, cfg.fanInBranch, inputs...)
+	}
+	return util.FanIn(ctx, inputs...)
+}
+
+// WithFairFanIn помечает входы узла метками labels (labels[i] — метка входа i) и сливает их
+// справедливо по весам weights (util.FairFanIn): например, входы разных клиентов общей дорогой
+// стадии, чтобы всплеск одного не вытеснял остальных. Входы с одной меткой сначала сливаются
+// util.FanIn и делят её вес. Количество меток должно совпадать с количеством входов; узел с одним
+// входом не сливает входы, и опция на него не влияет. Заменяет WithFanInTree.
+func WithFairFanIn(weights map[string]int, labels ...string) Option {
+	return func(c *config) {
+		c.inputLabels = labels
+		c.inputWeights = weights
+	}
+}
+
+// fairFanIn сливает входы узла по меткам WithFairFanIn
+func fairFanIn[T any](ctx context.Context, cfg *config, inputs []<-chan T) <-chan T {
+	grouped := make(map[string][]<-chan T)
+	for i, input := range inputs {
+		grouped[cfg.inputLabels[i]] = append(grouped[cfg.inputLabels[i]], input)
+	}
+
+	labelled := make(map[string]<-chan T, len(grouped))
+	for label, group := range grouped {
+		labelled[label] = group[0]
+		if len(group) > 1 {
+			labelled[label] = util.FanIn(ctx, group...)
+		}
+	}
+	return util.FairFanIn(ctx, cfg.inputWeights, labelled)
+}
+
+// fanOut объединяет выходы узла в один канал согласно стратегии
+func fanOut[T any](ctx context.Context, cfg *config, outputs []chan<- T) chan<- T {
+	switch cfg.fanOut {
+	case RoundRobinStrict:
+		return util.FanOutStrict(ctx, outputs...)
+	case Weighted:
+		return util.FanOutWeighted(ctx, cfg.fanOutWeights, outputs...)
+	default:
+		return util.FanOut(ctx, outputs...)
+	}
+}
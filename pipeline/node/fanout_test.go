@@ -0,0 +1,83 @@
+package node
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithFanOutWeights(t *testing.T) {
+	const items = 800
+	n := New("weighted", 1, 3, nil, relay, WithFanOutWeights(5, 2, 1))
+	if err := n.SetInput(0, feed(seq(items)...)); err != nil {
+		t.Fatal(err)
+	}
+	// буферы вмещают все значения, поэтому распределение определяется только весами
+	var got []func() []int
+	for i := range 3 {
+		out := make(chan int, items)
+		if err := n.SetOutput(i, out); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, drain(out))
+	}
+	if errs := runNodes(t, context.Background(), n); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+
+	for i, want := range []int{500, 200, 100} {
+		if l := len(got[i]()); l != want {
+			t.Errorf("output %d got %d items, want %d", i, l, want)
+		}
+	}
+}
+
+func TestFanInTreeThreshold(t *testing.T) {
+	const perInput = 20
+	tests := []struct {
+		name   string
+		inputs int
+		opts   []Option
+	}{
+		{"default flat", DefaultFanInTreeThreshold, nil},
+		{"default tree", DefaultFanInTreeThreshold + 8, nil},
+		{"custom tree", 10, []Option{WithFanInTree(4, 2)}},
+		{"tree disabled", maxIO, []Option{WithFanInTree(0, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := New("merge", tt.inputs, 1, nil, relay, tt.opts...)
+			for i := range tt.inputs {
+				items := make([]int, perInput)
+				for k := range items {
+					items[k] = i*perInput + k
+				}
+				if err := n.SetInput(i, feed(items...)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out := make(chan int)
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			got := drain(out)
+			if errs := runNodes(t, context.Background(), n); len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+
+			// все значения доставлены, порядок каждого входа сохранён
+			next := make([]int, tt.inputs)
+			for _, v := range got() {
+				i, k := v/perInput, v%perInput
+				if k != next[i] {
+					t.Fatalf("input %d: got %d, want %d", i, k, next[i])
+				}
+				next[i]++
+			}
+			for i, k := range next {
+				if k != perInput {
+					t.Errorf("input %d: delivered %d of %d", i, k, perInput)
+				}
+			}
+		})
+	}
+}
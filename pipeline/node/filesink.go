@@ -0,0 +1,122 @@
+package node
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// PartialSuffix суффикс незавершённого файла, сохраняемого с WithKeepPartial
+const PartialSuffix = ".partial"
+
+// WithKeepPartial сохраняет незавершённый файл файлового приёмника (AtomicFileSink) под именем
+// с суффиксом PartialSuffix вместо удаления при ошибке или отмене
+func WithKeepPartial() Option {
+	return func(c *config) {
+		c.keepPartial = true
+	}
+}
+
+// WithFsync включает fsync файла файлового приёмника перед переименованием
+func WithFsync() Option {
+	return func(c *config) {
+		c.fsync = true
+	}
+}
+
+// AtomicFileSink создаёт узел-приёмник с одним входом, записывающий значения через encode во
+// временный файл в директории path. Когда вход закрывается без ошибок, файл переименовывается
+// в path, так что читатели видят либо прежний файл, либо полностью записанный новый (при пустом
+// входе — пустой файл). При ошибке
+// encode или записи узел прекращает работу; при ошибке или отмене контекста временный файл
+// удаляется (или сохраняется с WithKeepPartial). Поддерживает WithFsync.
+func AtomicFileSink[T any](name string, path string, encode func(w io.Writer, v T) error, opts ...Option) *Node[T, struct{}] {
+	if encode == nil {
+		panic("nil encode func")
+	}
+
+	cfg := newConfig(opts)
+	handler := func(ctx context.Context, input <-chan T, _ chan<- struct{}, errChan chan<- error) {
+		tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+		if err != nil {
+			errChan <- err
+			return
+		}
+
+		w := bufio.NewWriter(tmp)
+		err = writeAll(ctx, input, func(v T) error { return encode(w, v) })
+		if err == nil {
+			cfg.inputClosed.Store(true)
+			err = w.Flush()
+		}
+		if err == nil {
+			err = commitFile(tmp, path, cfg.fsync)
+			if err == nil {
+				return
+			}
+		}
+
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			errChan <- err
+		}
+		if cfg.keepPartial {
+			_ = w.Flush()
+		}
+		discardFile(tmp, path, cfg.keepPartial)
+	}
+
+	n := newNode[T, struct{}](name, 1, 0, nil, cfg)
+	n.handler = handler
+	return n
+}
+
+// writeAll вызывает write для каждого значения input до закрытия входа. Возвращает ошибку write
+// или контекста, если вход не был дочитан.
+func writeAll[T any](ctx context.Context, input <-chan T, write func(v T) error) error {
+	for {
+		select {
+		case v, ok := <-input:
+			if !ok {
+				return nil
+			}
+			if err := Yield(ctx); err != nil {
+				return err
+			}
+			if err := write(v); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// commitFile закрывает временный файл и переименовывает его в path
+func commitFile(tmp *os.File, path string, fsync bool) error {
+	if fsync {
+		if err := tmp.Sync(); err != nil {
+			return fmt.Errorf("fsync %s: %w", tmp.Name(), err)
+		}
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// discardFile удаляет временный файл или сохраняет его как path с PartialSuffix
+func discardFile(tmp *os.File, path string, keep bool) {
+	_ = tmp.Close()
+	if keep {
+		_ = os.Rename(tmp.Name(), path+PartialSuffix)
+		return
+	}
+	_ = os.Remove(tmp.Name())
+}
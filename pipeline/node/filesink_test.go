@@ -0,0 +1,111 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+var errBadLine = errors.New("bad line")
+
+// writeLine encode, записывающий строку; "bad" не записывается
+func writeLine(w io.Writer, v string) error {
+	if v == "bad" {
+		return errBadLine
+	}
+	_, err := fmt.Fprintln(w, v)
+	return err
+}
+
+// dirFiles возвращает имена файлов директории и их содержимое
+func dirFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[e.Name()] = string(data)
+	}
+	return files
+}
+
+func TestAtomicFileSink(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []string
+		// cancel контекст отменяется после записи элементов, вход не закрывается
+		cancel  bool
+		opts    []Option
+		want    map[string]string
+		wantErr error
+	}{
+		// прежний файл заменяется новым целиком
+		{"commit", []string{"a", "b", "c"}, false, nil, map[string]string{"out.txt": "a\nb\nc\n"}, nil},
+		{"fsync", []string{"a"}, false, []Option{WithFsync()}, map[string]string{"out.txt": "a\n"}, nil},
+		{"empty input", nil, false, nil, map[string]string{"out.txt": ""}, nil},
+		// при неудаче прежний файл не меняется, а временный удаляется
+		{"encode error", []string{"a", "bad", "c"}, false, nil, map[string]string{"out.txt": "old\n"}, errBadLine},
+		{"cancelled", []string{"a"}, true, nil, map[string]string{"out.txt": "old\n"}, nil},
+		{"keep partial", []string{"a", "bad", "c"}, false, []Option{WithKeepPartial()},
+			map[string]string{"out.txt": "old\n", "out.txt" + PartialSuffix: "a\n"}, errBadLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "out.txt")
+			if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			written := 0
+			n := AtomicFileSink("file", path, func(w io.Writer, v string) error {
+				written++
+				if tt.cancel && written == len(tt.items) {
+					cancel()
+				}
+				return writeLine(w, v)
+			}, tt.opts...)
+			input := feed(tt.items...)
+			if tt.cancel {
+				input = make(chan string, len(tt.items))
+				for _, v := range tt.items {
+					input <- v
+				}
+			}
+			if err := n.SetInput(0, input); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := runNodes(t, ctx, n)
+			switch {
+			case tt.wantErr == nil && len(errs) > 0:
+				t.Errorf("errors: %v", errs)
+			case tt.wantErr != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.wantErr)):
+				t.Errorf("errors = %v, want %v", errs, tt.wantErr)
+			}
+			got := dirFiles(t, dir)
+			if len(got) != len(tt.want) {
+				t.Errorf("files %v, want %v", slices.Sorted(maps.Keys(got)), slices.Sorted(maps.Keys(tt.want)))
+			}
+			for name, content := range tt.want {
+				if got[name] != content {
+					t.Errorf("%s = %q, want %q", name, got[name], content)
+				}
+			}
+		})
+	}
+}
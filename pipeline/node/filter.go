@@ -0,0 +1,50 @@
+package node
+
+import "context"
+
+// FilterFn предикат узла-фильтра: true означает, что значение передаётся дальше
+type FilterFn[T any] func(ctx context.Context, v T) (bool, error)
+
+// filtered результат предиката вместе с исходным значением
+type filtered[T any] struct {
+	v    T
+	keep bool
+}
+
+// NewFilter создаёт узел, передающий в выход только значения, для которых pred вернул true.
+// Ошибка pred отправляется в канал ошибок, значение при этом отбрасывается. Выходы закрываются
+// по завершении обработки входа. Как и NewMap, узел имеет один вход и один выход; другое их
+// количество задаёт WithPorts, буферы выходов — WithOutputBuffers. Поддерживает опции WithRetry,
+// WithTimeout, WithConcurrency и WithOrderedOutput.
+func NewFilter[T any](name string, pred FilterFn[T], opts ...Option) *Node[T, T] {
+	if pred == nil {
+		panic("nil filter func")
+	}
+
+	cfg := newConfig(opts)
+	cfg.gated = true
+	name = autoName(name, funcName(pred), cfg)
+	handler := func(ctx context.Context, input <-chan T, output chan<- T, errChan chan<- error) {
+		defer closeOutput(output)
+		send := func(out filtered[T]) bool {
+			if !out.keep || output == nil {
+				return true
+			}
+			select {
+			case output <- out.v:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		runItemsFunc(ctx, cfg, input, send, errChan, func(ctx context.Context, in T) (filtered[T], error) {
+			keep, err := call(ctx, cfg, MapFn[T, bool](pred), in)
+			return filtered[T]{v: in, keep: keep}, err
+		})
+	}
+
+	inputNum, outputNum := cfg.portCounts()
+	n := newNode[T, T](name, inputNum, outputNum, nil, cfg)
+	n.handler = handler
+	return n
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultFlushTimeout время на финальный сброс буферов узла по умолчанию
+const DefaultFlushTimeout = 5 * time.Second
+
+var ErrFlushAbandoned = errors.New("final flush abandoned")
+
+// WithFlush регистрирует функцию финального сброса буферов узла (bufio, накопленные пакеты и т.п.).
+// Она вызывается после завершения обработчика при любом способе завершения: закрытии входа,
+// отмене контекста или остановке пайплайна, — до того как узел считается завершённым. Контекст
+// flush не зависит от отменённого контекста пайплайна и ограничен WithFlushTimeout. Если flush
+// не успевает, в канал ошибок отправляется ErrFlushAbandoned; горутина flush при этом не ожидается
+// и работает после завершения пайплайна, пока flush не вернётся.
+func WithFlush(flush func(ctx context.Context) error) Option {
+	return func(c *config) {
+		c.flush = flush
+	}
+}
+
+// WithFlushTimeout задаёт время на финальный сброс (по умолчанию DefaultFlushTimeout)
+func WithFlushTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.flushTimeout = d
+	}
+}
+
+// runFlush вызывает функцию сброса, если она задана, не дольше cfg.flushTimeout
+func (n *Node[I, O]) runFlush(ctx context.Context, errChan chan<- error) {
+	if n.cfg.flush == nil {
+		return
+	}
+
+	timeout := n.cfg.flushTimeout
+	if timeout <= 0 {
+		timeout = DefaultFlushTimeout
+	}
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- n.cfg.flush(flushCtx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			errChan <- Classify(ClassNode, fmt.Errorf("flush: %w", err))
+			return
+		}
+		if err != nil {
+			errChan <- Classify(ClassNode, fmt.Errorf("%w after %v: %w", ErrFlushAbandoned, timeout, err))
+		}
+	case <-flushCtx.Done():
+		errChan <- Classify(ClassNode, fmt.Errorf("%w after %v", ErrFlushAbandoned, timeout))
+	}
+}
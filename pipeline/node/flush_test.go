@@ -0,0 +1,90 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWithFlush(t *testing.T) {
+	errWrite := errors.New("write failed")
+	tests := []struct {
+		name string
+		// cancel контекст отменяется после первого элемента, вход не закрывается
+		cancel bool
+		// panicAt элемент, на котором обработчик паникует (-1 — без паники)
+		panicAt int
+		flush   func(ctx context.Context) error
+		timeout time.Duration
+		// wantFlushed элементы, сброшенные flush
+		wantFlushed []int
+		wantErr     error
+	}{
+		{"input closed", false, -1, nil, 0, seq(3), nil},
+		{"cancelled", true, -1, nil, 0, seq(1), nil},
+		{"panic", false, 2, nil, 0, seq(2), nil},
+		{"flush error", false, -1, func(context.Context) error { return errWrite }, 0, nil, errWrite},
+		{"timeout", false, -1, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, 10 * time.Millisecond, nil, ErrFlushAbandoned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pending, flushed []int
+			var flushCtxErr error
+			flush := tt.flush
+			if flush == nil {
+				// сброс накопленного в «хранилище»; контекст сброса не отменён вместе с узлом
+				flush = func(ctx context.Context) error {
+					flushCtxErr = ctx.Err()
+					flushed = append(flushed, pending...)
+					return nil
+				}
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			n := NewSink("sink", 1, func(_ context.Context, v int) error {
+				if v == tt.panicAt {
+					panic("boom")
+				}
+				pending = append(pending, v)
+				if tt.cancel {
+					cancel()
+				}
+				return nil
+			}, WithFlush(flush), WithFlushTimeout(tt.timeout))
+			input := feed(seq(3)...)
+			if tt.cancel {
+				input = make(chan int, 3)
+				input <- 0
+			}
+			if err := n.SetInput(0, input); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := runNodes(t, ctx, n)
+			if !slices.Equal(flushed, tt.wantFlushed) {
+				t.Errorf("flushed %v, want %v", flushed, tt.wantFlushed)
+			}
+			if flushCtxErr != nil {
+				t.Errorf("flush context error = %v, want a live context", flushCtxErr)
+			}
+			var flushErrs []error
+			for _, err := range errs {
+				if !errors.As(err, new(*PanicError)) {
+					flushErrs = append(flushErrs, err)
+				}
+			}
+			switch {
+			case tt.wantErr == nil && len(flushErrs) > 0:
+				t.Errorf("errors: %v", flushErrs)
+			case tt.wantErr != nil && (len(flushErrs) != 1 || !errors.Is(flushErrs[0], tt.wantErr) ||
+				ClassOf(flushErrs[0]) != ClassNode):
+				t.Errorf("errors = %v, want one ClassNode error wrapping %v", flushErrs, tt.wantErr)
+			}
+		})
+	}
+}
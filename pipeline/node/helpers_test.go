@@ -0,0 +1,189 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// runner узел, запускаемый тестом без пайплайна
+type runner interface {
+	Run(ctx context.Context, wg *sync.WaitGroup, errChan chan<- error, commonErrChan bool)
+}
+
+// runNodes запускает узлы с общим каналом ошибок, дожидается их завершения и возвращает ошибки
+func runNodes(t *testing.T, ctx context.Context, nodes ...runner) []error {
+	t.Helper()
+	errChan := make(chan error)
+	var errs []error
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for err := range errChan {
+			errs = append(errs, err)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		n.Run(ctx, &wg, errChan, true)
+	}
+	waitGroup(t, &wg)
+	close(errChan)
+	<-collected
+	return errs
+}
+
+// waitGroup дожидается wg и проваливает тест, если ожидание затянулось
+func waitGroup(t *testing.T, wg *sync.WaitGroup) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("nodes did not finish")
+	}
+}
+
+// eventually ждёт выполнения cond и проваливает тест, если оно не наступило за разумное время
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// feed возвращает закрытый канал со значениями items
+func feed[T any](items ...T) chan T {
+	ch := make(chan T, len(items))
+	for _, v := range items {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+// drain читает канал до закрытия в отдельной горутине; результат доступен после закрытия канала
+func drain[T any](ch <-chan T) func() []T {
+	var got []T
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for v := range ch {
+			got = append(got, v)
+		}
+	}()
+	return func() []T {
+		<-done
+		return got
+	}
+}
+
+// seq возвращает числа 0..n-1
+func seq(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+// fakeClock Clock, время которого сдвигает тест через Advance; канал After срабатывает, когда
+// время доходит до срока
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// waiting возвращает количество ожидающих каналов After
+func (c *fakeClock) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Advance сдвигает время на d и срабатывает наступившие сроки After
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// countingClock fakeClock, считающий вызовы Now и After: по ним тест узнаёт, что узел обратился к
+// часам, например начал новый отсчёт
+type countingClock struct {
+	*fakeClock
+	nows, afters atomic.Int64
+}
+
+func (c *countingClock) Now() time.Time {
+	defer c.nows.Add(1)
+	return c.fakeClock.Now()
+}
+
+func (c *countingClock) After(d time.Duration) <-chan time.Time {
+	defer c.afters.Add(1)
+	return c.fakeClock.After(d)
+}
+
+// process пропускает items через узел с одним входом и одним выходом и возвращает его выход и ошибки
+func process[I, O any](t *testing.T, n *Node[I, O], items ...I) ([]O, []error) {
+	t.Helper()
+	if err := n.SetInput(0, feed(items...)); err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan O)
+	if err := n.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+	got := drain(out)
+	errs := runNodes(t, context.Background(), n)
+	return got(), errs
+}
@@ -0,0 +1,62 @@
+package node
+
+import (
+	"context"
+	"sync"
+)
+
+// WithInfiniteSource помечает узел-источник (NewSource) как бесконечный: он не завершается сам,
+// а останавливается через StopSource (например, из Pipeline.Shutdown). Функция источника
+// обязана завершаться при отмене своего контекста.
+func WithInfiniteSource() Option {
+	return func(c *config) {
+		c.infinite = true
+	}
+}
+
+// InfiniteSource сообщает, помечен ли узел как бесконечный источник
+func (n *Node[I, O]) InfiniteSource() bool {
+	return n.cfg.infinite
+}
+
+// StopSource останавливает узел-источник: контекст функции источника отменяется, после её
+// завершения выход закрывается, а нижестоящие узлы дообрабатывают уже выданные элементы.
+// Для узлов, созданных не через NewSource, ничего не делает.
+func (n *Node[I, O]) StopSource() {
+	if n.stop != nil {
+		n.stop.signal()
+	}
+}
+
+// stopSignal однократный сигнал остановки
+type stopSignal struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+func newStopSignal() *stopSignal {
+	return &stopSignal{ch: make(chan struct{})}
+}
+
+func (s *stopSignal) signal() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// withStop возвращает контекст, отменяемый при отмене ctx или по сигналу s. Возвращённая функция
+// отмены дожидается завершения горутины ожидания сигнала.
+func (s *stopSignal) withStop(ctx context.Context) (context.Context, context.CancelFunc) {
+	stopCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-s.ch:
+			cancel()
+		case <-stopCtx.Done():
+		}
+	}()
+	return stopCtx, func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,146 @@
+package node
+
+import (
+	"context"
+	"sync"
+)
+
+// inlineLink общее ребро цепочки схлопнутых узлов: канал, который они разделяют, и счётчики
+// схлопнутых узлов, поддерживаемые узлом, пишущим в этот канал
+type inlineLink struct {
+	ch       any
+	fed      bool
+	counters []*counters
+}
+
+// WithInline разрешает схлопывать узел-ретранслятор (NewPassThrough) в ребро: при Connect к
+// нижестоящему узлу тот читает непосредственно канал, который пишет вышестоящий узел, и горутина
+// ретранслятора не запускается. Ребро схлопывается, только если вход узла к этому моменту уже
+// подключён через Connect или Autowire. Статистика узла сохраняется: её ведёт вышестоящий узел,
+// поэтому, если у схлопнутых узлов есть статистика (WithStats), в ребро добавляется одна пересылка
+// на всю цепочку, и выигрыш меньше, чем без статистики (см. BenchmarkPassThroughChain).
+// Для остальных видов узлов и вместе с WithPausable опция игнорируется.
+func WithInline() Option {
+	return func(c *config) {
+		c.inline = true
+	}
+}
+
+// NewPassThrough создаёт узел-ретранслятор с одним входом и одним выходом, передающий значения
+// без изменений. Полезен как точка подключения статистики и управления; с WithInline не стоит
+// лишней горутины и пересылки через канал.
+func NewPassThrough[T any](name string, opts ...Option) *Node[T, T] {
+	cfg := newConfig(opts)
+	handler := func(ctx context.Context, input <-chan T, output chan<- T, errChan chan<- error) {
+		defer close(output)
+		for {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					return
+				}
+				select {
+				case output <- val:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	n := newNode[T, T](name, 1, 1, nil, cfg)
+	n.handler = handler
+	if cfg.inline && !cfg.pausable {
+		n.inline = &inlineLink{}
+	}
+	return n
+}
+
+// feedInline запоминает канал, поступающий на вход встраиваемого узла to, и поручает узлу from
+// вести счётчики цепочки
+func feedInline[I, O, T any](from *Node[I, O], outIdx int, to *Node[O, T], ch chan O) {
+	if to.inline == nil || from.collapsed {
+		return
+	}
+
+	to.inline.ch = ch
+	to.inline.fed = true
+	if from.inlineLinks == nil {
+		from.inlineLinks = make([]*inlineLink, len(from.outputs))
+	}
+	from.inlineLinks[outIdx] = to.inline
+}
+
+// collapse схлопывает встраиваемый узел from: его вход напрямую становится входом to[inIdx].
+// Возвращает false, если схлопнуть нельзя.
+func collapse[I, O, T any](from *Node[I, O], to *Node[O, T], inIdx int) bool {
+	if from.inline == nil || !from.inline.fed {
+		return false
+	}
+	ch, ok := from.inline.ch.(chan O)
+	if !ok {
+		return false
+	}
+
+	from.outputs[0] = ch
+	from.collapsed = true
+	if from.counters != nil {
+		from.inline.counters = append(from.inline.counters, from.counters)
+	}
+	to.inputs[inIdx] = ch
+	if to.inline != nil {
+		to.inline = from.inline
+	}
+	return true
+}
+
+// countInlineOutputs возвращает выходы узла, где выходы в схлопнутые узлы со статистикой
+// обёрнуты countInline
+func (n *Node[I, O]) countInlineOutputs(ctx context.Context, wg *sync.WaitGroup) []chan<- O {
+	outputs := append([]chan<- O(nil), n.outputs...)
+	for i, link := range n.inlineLinks {
+		if link != nil && len(link.counters) > 0 {
+			outputs[i] = countInline(ctx, wg, n.cfg.clock, outputs[i], link.counters)
+		}
+	}
+	return outputs
+}
+
+// countInline ретранслирует output, ведя счётчики схлопнутых узлов цепочки
+func countInline[T any](ctx context.Context, wg *sync.WaitGroup, clock Clock, output chan<- T, cnts []*counters) chan<- T {
+	proxy := make(chan T)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(output)
+
+		for _, c := range cnts {
+			c.startedAt.Store(clock.Now().UnixNano())
+		}
+		defer func() {
+			for _, c := range cnts {
+				c.finishedAt.Store(clock.Now().UnixNano())
+			}
+		}()
+
+		for val := range proxy {
+			now := clock.Now()
+			for _, c := range cnts {
+				c.countIn(now, -1)
+				c.countOut(now, -1)
+			}
+			select {
+			case output <- val:
+			case <-ctx.Done():
+				for _, c := range cnts {
+					c.uncountOut(-1)
+				}
+			}
+		}
+	}()
+
+	return proxy
+}
@@ -0,0 +1,146 @@
+package node
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// passChain цепочка head -> stages ретрансляторов -> tail; reverse подключает рёбра от конца к
+// началу, так что к моменту подключения нижестоящего узла вход ретранслятора ещё не подключён
+type passChain struct {
+	head, tail *Node[int, int]
+	stages     []*Node[int, int]
+}
+
+func newPassChain(tb testing.TB, stages int, reverse bool, opts ...Option) passChain {
+	tb.Helper()
+	id := func(_ context.Context, v int) (int, error) { return v, nil }
+	c := passChain{
+		head: NewMap("head", id, WithStats()),
+		tail: NewMap("tail", id, WithStats()),
+	}
+	for i := range stages {
+		c.stages = append(c.stages, NewPassThrough[int]("pass "+string(rune('a'+i)), opts...))
+	}
+
+	nodes := append(append([]*Node[int, int]{c.head}, c.stages...), c.tail)
+	edges := make([]int, len(nodes)-1)
+	for i := range edges {
+		edges[i] = i
+	}
+	if reverse {
+		slices.Reverse(edges)
+	}
+	for _, i := range edges {
+		if err := Connect(nodes[i], 0, nodes[i+1], 0); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return c
+}
+
+// runners узлы цепочки для runNodes
+func (c passChain) runners() []runner {
+	rs := []runner{c.head, c.tail}
+	for _, s := range c.stages {
+		rs = append(rs, s)
+	}
+	return rs
+}
+
+func TestInlineStats(t *testing.T) {
+	const items = 200
+	tests := []struct {
+		name      string
+		opts      []Option
+		reverse   bool
+		collapsed bool
+	}{
+		{"plain", []Option{WithStats()}, false, false},
+		{"inline", []Option{WithStats(), WithInline()}, false, true},
+		// WithPausable и неподключённый вход оставляют ретранслятор отдельным узлом
+		{"pausable fallback", []Option{WithStats(), WithInline(), WithPausable()}, false, false},
+		{"unfed fallback", []Option{WithStats(), WithInline()}, true, false},
+	}
+	var want []Stats
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newPassChain(t, 3, tt.reverse, tt.opts...)
+			if err := c.head.SetInput(0, feed(seq(items)...)); err != nil {
+				t.Fatal(err)
+			}
+			out := make(chan int)
+			if err := c.tail.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			got := drain(out)
+			if errs := runNodes(t, context.Background(), c.runners()...); len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+
+			if !slices.Equal(got(), seq(items)) {
+				t.Errorf("output = %v, want 0..%d in order", got(), items-1)
+			}
+			var stats []Stats
+			for _, n := range append([]*Node[int, int]{c.head, c.tail}, c.stages...) {
+				s := n.Stats()
+				stats = append(stats, Stats{ItemsIn: s.ItemsIn, ItemsOut: s.ItemsOut, Discarded: s.Discarded})
+			}
+			for _, s := range c.stages {
+				if s.collapsed != tt.collapsed {
+					t.Errorf("%s collapsed = %v, want %v", s.Name(), s.collapsed, tt.collapsed)
+				}
+			}
+			// счётчики схлопнутой цепочки совпадают с цепочкой отдельных узлов
+			if want == nil {
+				want = stats
+			} else if !slices.Equal(stats, want) {
+				t.Errorf("stats = %+v, want as plain chain %+v", stats, want)
+			}
+		})
+	}
+}
+
+// BenchmarkPassThroughChain задержка элемента в цепочке из 6 ретрансляторов между двумя узлами
+// Map: отдельными узлами и схлопнутыми (WithInline), без статистики и с ней
+func BenchmarkPassThroughChain(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"inline", []Option{WithInline()}},
+		{"plain stats", []Option{WithStats()}},
+		{"inline stats", []Option{WithInline(), WithStats()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			c := newPassChain(b, 6, false, bc.opts...)
+			in := make(chan int)
+			out := make(chan int)
+			if err := c.head.SetInput(0, in); err != nil {
+				b.Fatal(err)
+			}
+			if err := c.tail.SetOutput(0, out); err != nil {
+				b.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			errChan := make(chan error)
+			for _, r := range c.runners() {
+				r.Run(context.Background(), &wg, errChan, true)
+			}
+			b.ResetTimer()
+			go func() {
+				defer close(in)
+				for i := range b.N {
+					in <- i
+				}
+			}()
+			for range out {
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInputNotOwned = errors.New("input not owned by node")
+
+// WithInputBuffers создаёт входы узла заранее: вход i получает собственный канал с буфером
+// sizes[i] и считается подключённым, так что Autowire его пропускает. Писать в такой вход
+// производитель может через Node.Input, закрытие канала его ответственность. Конструктор
+// паникует, если число размеров не совпадает с количеством входов.
+func WithInputBuffers(sizes ...int) Option {
+	return func(c *config) {
+		c.inputBuffSize = sizes
+	}
+}
+
+// Input возвращает канал входа idx, созданный узлом по WithInputBuffers. Возвращает
+// ErrInputIdxOutOfRange для неверного индекса и ErrInputNotOwned, если вход создан не узлом или
+// заменён через ForceSetInput.
+func (n *Node[I, O]) Input(idx int) (chan<- I, error) {
+	if idx < 0 || idx >= len(n.inputs) {
+		return nil, n.wrapError(ErrInputIdxOutOfRange)
+	}
+
+	wiring.Lock()
+	defer wiring.Unlock()
+	if n.ownInputs == nil || n.ownInputs[idx] == nil {
+		return nil, n.wrapError(fmt.Errorf("input %d: %w", idx, ErrInputNotOwned))
+	}
+	return n.ownInputs[idx], nil
+}
+
+// ownInputBuffers создаёт входы узла по WithInputBuffers
+func (n *Node[I, O]) ownInputBuffers(sizes []int) {
+	n.ownInputs = make([]chan I, len(sizes))
+	for i, size := range sizes {
+		n.ownInputs[i] = make(chan I, max(size, 0))
+		n.inputs[i] = n.ownInputs[i]
+		n.occupyInput(i)
+	}
+}
+
+// disownInput снимает с входа idx признак созданного узлом при переподключении
+func (n *Node[I, O]) disownInput(idx int) {
+	if n.ownInputs != nil {
+		n.ownInputs[idx] = nil
+	}
+}
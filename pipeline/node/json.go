@@ -0,0 +1,74 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// WithStrictJSON включает строгий режим JSONDecode: поля, отсутствующие в типе результата,
+// считаются ошибкой (json.Decoder.DisallowUnknownFields)
+func WithStrictJSON() Option {
+	return func(c *config) {
+		c.strictJSON = true
+	}
+}
+
+// JSONDecode создаёт узел, разбирающий JSON из входных значений в значения типа T. Некорректная
+// запись при заданном WithDeadLetter уходит в dead-letter как DeadLetter с исходными байтами
+// в Item, иначе об ошибке сообщается в канал ошибок; поток при этом продолжается. Поддерживает
+// WithStrictJSON, WithConcurrency и WithOrderedOutput.
+func JSONDecode[T any](name string, opts ...Option) *Node[[]byte, T] {
+	cfg := newConfig(opts)
+	name = autoName(name, "JSONDecode", cfg)
+	return newJSONNode(name, cfg, func(ctx context.Context, in []byte) (T, error) {
+		var out T
+		var err error
+		if cfg.strictJSON {
+			dec := json.NewDecoder(bytes.NewReader(in))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(&out)
+		} else {
+			err = json.Unmarshal(in, &out)
+		}
+		if err != nil {
+			return out, cfg.malformed(name, in, fmt.Errorf("json decode: %w", err))
+		}
+		return out, nil
+	})
+}
+
+// JSONEncode создаёт узел, кодирующий входные значения в JSON. Значение, которое не удалось
+// закодировать, обрабатывается так же, как некорректная запись в JSONDecode (в Item — само значение).
+func JSONEncode[T any](name string, opts ...Option) *Node[T, []byte] {
+	cfg := newConfig(opts)
+	name = autoName(name, "JSONEncode", cfg)
+	return newJSONNode(name, cfg, func(ctx context.Context, in T) ([]byte, error) {
+		out, err := json.Marshal(in)
+		if err != nil {
+			return nil, cfg.malformed(name, in, fmt.Errorf("json encode: %w", err))
+		}
+		return out, nil
+	})
+}
+
+// newJSONNode создаёт узел Map-стиля с одним входом и выходом, применяющий f
+func newJSONNode[I, O any](name string, cfg *config, f MapFn[I, O]) *Node[I, O] {
+	cfg.gated = true
+	n := newNode[I, O](name, 1, 1, nil, cfg)
+	n.handler = func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+		defer closeOutput(output)
+		runItems(ctx, cfg, input, output, errChan, f)
+	}
+	return n
+}
+
+// malformed возвращает ошибку некорректной записи: DeadLetter с записью item, если задан
+// канал dead-letter, иначе err
+func (c *config) malformed(name string, item any, err error) error {
+	if c.deadLetter == nil {
+		return err
+	}
+	return &DeadLetter{Node: name, Item: item, Err: err}
+}
@@ -0,0 +1,85 @@
+package node
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+type jsonItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestJSONDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+		lines  []string
+		want   []jsonItem
+		// wantBad номера некорректных записей, ошибки которых приходят в поток ошибок
+		wantBad []int
+		// wantErr ошибка первой некорректной записи, nil — не проверяется
+		wantErr error
+	}{
+		{"valid", false, []string{`{"id":1,"name":"a"}`, `{"id":2}`}, []jsonItem{{1, "a"}, {2, ""}}, nil, nil},
+		// некорректная запись пропускается, следующие разбираются
+		{"malformed line", false, []string{`{"id":1}`, `{"id":`, `{"id":3}`}, []jsonItem{{ID: 1}, {ID: 3}}, []int{1}, nil},
+		{"empty line", false, []string{``, `{"id":2}`}, []jsonItem{{ID: 2}}, []int{0}, nil},
+		{"unknown field", false, []string{`{"id":1,"extra":true}`}, []jsonItem{{ID: 1}}, nil, nil},
+		{"strict unknown field", true, []string{`{"id":1,"extra":true}`, `{"id":2}`}, []jsonItem{{ID: 2}}, []int{0}, nil},
+		// строгий режим читает запись через json.Decoder: пустая запись — io.EOF, оборванная — io.ErrUnexpectedEOF
+		{"strict EOF", true, []string{``}, nil, []int{0}, io.EOF},
+		{"strict truncated", true, []string{`{"id":1`}, nil, []int{0}, io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, deadLetter := range []bool{false, true} {
+				var opts []Option
+				if tt.strict {
+					opts = append(opts, WithStrictJSON())
+				}
+				dl := make(chan DeadLetter, len(tt.lines))
+				if deadLetter {
+					opts = append(opts, WithDeadLetter(dl))
+				}
+				items := make([][]byte, len(tt.lines))
+				for i, line := range tt.lines {
+					items[i] = []byte(line)
+				}
+
+				got, errs := process(t, JSONDecode[jsonItem]("decode", opts...), items...)
+				close(dl)
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("dead letter %v: output %v, want %v", deadLetter, got, tt.want)
+				}
+				var bad []error
+				var raw []string
+				if deadLetter {
+					// некорректные записи уходят в dead-letter с исходными байтами
+					for d := range dl {
+						bad = append(bad, d.Err)
+						raw = append(raw, string(d.Item.([]byte)))
+					}
+					if len(errs) > 0 {
+						t.Errorf("dead letter: errors %v", errs)
+					}
+				} else {
+					bad = errs
+				}
+				if len(bad) != len(tt.wantBad) {
+					t.Fatalf("dead letter %v: bad records %v, want %d", deadLetter, bad, len(tt.wantBad))
+				}
+				for i, idx := range tt.wantBad {
+					if deadLetter && raw[i] != tt.lines[idx] {
+						t.Errorf("dead letter item %q, want %q", raw[i], tt.lines[idx])
+					}
+					if tt.wantErr != nil && !errors.Is(bad[i], tt.wantErr) {
+						t.Errorf("dead letter %v: error %v, want %v", deadLetter, bad[i], tt.wantErr)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// PanicError ошибка, в которую преобразуется паника обработчика
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panicked: %v", e.Value)
+}
+
+// Unwrap возвращает значение паники, если оно — ошибка (panic(err))
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithInit задаёт функцию, вызываемую перед каждым запуском обработчика (в том числе перед
+// перезапуском). Ошибка init отправляется в канал ошибок, обработчик при этом не запускается.
+func WithInit(init func(ctx context.Context) error) Option {
+	return func(c *config) {
+		c.init = init
+	}
+}
+
+// WithClose задаёт функцию, вызываемую после каждого завершения обработчика, успешно прошедшего init
+func WithClose(close func() error) Option {
+	return func(c *config) {
+		c.close = close
+	}
+}
+
+// invoke запускает обработчик с хуками жизненного цикла (init, flush, close), перезапусками, если
+// задана политика, и маркером завершения WithCompletionMarker
+func (n *Node[I, O]) invoke(ctx context.Context, h Handler[I, O], input <-chan I, output chan<- O, errChan chan<- error) {
+	if n.cfg.completionMarker != nil {
+		n.completeMarked(ctx, h, input, output, errChan)
+		return
+	}
+	n.invokeHandler(ctx, h, input, output, errChan)
+}
+
+// invokeHandler выполняет invoke без маркера завершения
+func (n *Node[I, O]) invokeHandler(ctx context.Context, h Handler[I, O], input <-chan I, output chan<- O,
+	errChan chan<- error) {
+	if n.cfg.restart != nil {
+		n.supervise(ctx, h, input, output, errChan)
+		n.finishRelay(input)
+		return
+	}
+
+	if err := n.runInit(ctx); err != nil {
+		n.setExit(ExitInitFailed)
+		errChan <- err
+		closeOutput(output)
+		n.afterExit(ctx, input, errChan)
+		return
+	}
+	n.runHandler(ctx, h, input, output, errChan)
+	n.runFlush(ctx, errChan)
+	n.runClose(errChan)
+	n.afterExit(ctx, input, errChan)
+}
+
+// runInit вызывает хук init, если он задан
+func (n *Node[I, O]) runInit(ctx context.Context) error {
+	if n.cfg.init == nil {
+		return nil
+	}
+	if err := n.cfg.init(ctx); err != nil {
+		return Classify(ClassNode, fmt.Errorf("init: %w", err))
+	}
+	return nil
+}
+
+// runClose вызывает хук close, если он задан, и отправляет его ошибку в errChan
+func (n *Node[I, O]) runClose(errChan chan<- error) {
+	if n.cfg.close == nil {
+		return
+	}
+	if err := n.cfg.close(); err != nil {
+		errChan <- Classify(ClassNode, fmt.Errorf("close: %w", err))
+	}
+}
+
+// callHandler вызывает обработчик с хуками жизненного цикла, преобразуя панику в PanicError
+// (кроме повторного закрытия выхода и отправки в закрытый выход, см. outputMisuse)
+func (n *Node[I, O]) callHandler(ctx context.Context, h Handler[I, O], input <-chan I, output chan<- O,
+	errChan chan<- error) (err error) {
+	if err := n.runInit(ctx); err != nil {
+		return err
+	}
+	defer n.runClose(errChan)
+	defer n.runFlush(ctx, errChan)
+
+	defer func() {
+		if r := recover(); r != nil {
+			if misuse := outputMisuse(r, output); misuse != nil {
+				errChan <- misuse
+				return
+			}
+			err = newPanicError(r)
+		}
+	}()
+	h(ctx, input, output, errChan)
+	return nil
+}
+
+// closeOutput закрывает выход, если он есть
+func closeOutput[T any](output chan<- T) {
+	if output != nil {
+		close(output)
+	}
+}
+
+// closeQuietly закрывает канал, игнорируя повторное закрытие и nil-канал
+func closeQuietly[T any](ch chan<- T) {
+	defer func() {
+		_ = recover()
+	}()
+	close(ch)
+}
+
+// Skip завершает узел, не запуская обработчик: закрывает выходы и дочитывает входы до их закрытия
+// или отмены контекста (элементы учитываются в Stats.Discarded). Используется пайплайном для
+// узлов, пропущенных из-за неудачи зависимости.
+func (n *Node[I, O]) Skip(ctx context.Context, wg *sync.WaitGroup) {
+	n.exit.Store(int32(ExitSkipped))
+	n.state.Store(int32(StateDone))
+	for _, output := range n.outputs {
+		if output != nil {
+			closeQuietly(output)
+		}
+	}
+
+	for _, input := range n.inputs {
+		if input == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case _, ok := <-input:
+					if !ok {
+						return
+					}
+					n.discard()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
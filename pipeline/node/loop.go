@@ -0,0 +1,114 @@
+package node
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// LoopFn функция поэлементной обработки для Loop: получает элемент входа и отправляет в выход
+// произвольное число значений через emit. emit.Send возвращает ошибку (ctx.Err()), если пайплайн
+// останавливается; функции достаточно вернуть её, чтобы корректно завершиться. emit — Emitter, а
+// не func(O) error: кроме Send он даёт неблокирующую отправку Try и учёт отброшенных значений Shed.
+// emit нельзя использовать после возврата из функции.
+type LoopFn[I, O any] func(ctx context.Context, item I, emit *Emitter[O]) error
+
+// Emitter отправляет значения функции Loop в выход узла
+type Emitter[O any] struct {
+	ctx    context.Context
+	output chan<- O
+	// shed счётчик отброшенных значений (Stats.Shed)
+	shed *atomic.Uint64
+}
+
+// NewEmitter создаёт Emitter, отправляющий значения в output до отмены ctx, для функций, которые
+// пишут в выход сами (например, SourceFn). Значения, учтённые через Shed, в статистику узла не попадают.
+func NewEmitter[O any](ctx context.Context, output chan<- O) *Emitter[O] {
+	return &Emitter[O]{ctx: ctx, output: output, shed: new(atomic.Uint64)}
+}
+
+// Send отправляет v в выход, ожидая места в нём. Возвращает ctx.Err() при остановке пайплайна.
+// Если у узла нет выходов, значение отбрасывается.
+func (e *Emitter[O]) Send(v O) error {
+	if e.output == nil {
+		return e.ctx.Err()
+	}
+
+	select {
+	case e.output <- v:
+		return nil
+	case <-e.ctx.Done():
+		return e.ctx.Err()
+	}
+}
+
+// Try отправляет v в выход без ожидания. Возвращает false, если выход заполнен (нижестоящий узел
+// не успевает) или пайплайн останавливается: значение не отправлено, и функция решает, отбросить
+// его (учитывая через Shed), агрегировать или отправить позже. Если у узла нет выходов, значение
+// отбрасывается.
+func (e *Emitter[O]) Try(v O) bool {
+	if e.ctx.Err() != nil {
+		return false
+	}
+	if e.output == nil {
+		return true
+	}
+
+	select {
+	case e.output <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shed учитывает значение, отброшенное функцией после отказа Try, в Stats.Shed
+func (e *Emitter[O]) Shed() {
+	e.shed.Add(1)
+}
+
+// Loop создаёт узел, вызывающий perItem для каждого входного значения. В отличие от New, цикл
+// чтения входа, отмену, закрытие выхода и отправку ошибок выполняет узел: ошибки perItem
+// отправляются в errChan, а возвращённые после отмены отбрасываются. Поддерживает WithConcurrency
+// (порядок выдачи при этом не гарантируется); WithRetry и WithOrderedOutput не действуют, так как
+// значения, уже отправленные через emit, нельзя отозвать. Количество входов и выходов задаёт
+// WithPorts, буферы выходов — WithOutputBuffers.
+func Loop[I, O any](name string, perItem LoopFn[I, O], opts ...Option) *Node[I, O] {
+	if perItem == nil {
+		panic("nil loop func")
+	}
+
+	cfg := newConfig(opts)
+	cfg.gated = true
+	name = autoName(name, funcName(perItem), cfg)
+
+	inputNum, outputNum := cfg.portCounts()
+	n := newNode[I, O](name, inputNum, outputNum, nil, cfg)
+	n.handler = func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+		defer closeOutput(output)
+		runLoop(ctx, cfg, input, output, errChan, perItem)
+	}
+	return n
+}
+
+// LoopHandler оборачивает perItem в Handler с последовательным циклом Loop, для мест, где
+// требуется обработчик (например, reducer в pipeline.MapReduce)
+func LoopHandler[I, O any](perItem LoopFn[I, O]) Handler[I, O] {
+	if perItem == nil {
+		panic("nil loop func")
+	}
+
+	return func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+		defer closeOutput(output)
+		runLoop(ctx, newConfig(nil), input, output, errChan, perItem)
+	}
+}
+
+// runLoop читает input через runItemsFunc и вызывает perItem с Emitter, отправляющим значения
+// в output до отмены контекста
+func runLoop[I, O any](ctx context.Context, cfg *config, input <-chan I, output chan<- O, errChan chan<- error,
+	perItem LoopFn[I, O]) {
+	runItemsFunc(ctx, cfg, input, func(struct{}) bool { return true }, errChan,
+		func(ctx context.Context, in I) (struct{}, error) {
+			return struct{}{}, perItem(ctx, in, &Emitter[O]{ctx: ctx, output: output, shed: &cfg.shed})
+		})
+}
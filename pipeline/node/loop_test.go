@@ -0,0 +1,99 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestEmitterTry(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []int
+		// outCap ёмкость выхода, который не читается до завершения узла; -1 — узел без выходов
+		outCap int
+		// cancel контекст отменяется перед первой попыткой
+		cancel    bool
+		wantTries []bool
+		wantOut   []int
+		wantShed  uint64
+	}{
+		{"room", seq(3), 3, false, []bool{true, true, true}, seq(3), 0},
+		// заполненный выход не ждёт: значение не отправлено, функция отбрасывает его
+		{"full", seq(5), 2, false, []bool{true, true, false, false, false}, seq(2), 3},
+		{"no outputs", seq(3), -1, false, []bool{true, true, true}, nil, 0},
+		{"cancelled", seq(1), 3, true, []bool{false}, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var tries []bool
+			var opts []Option
+			if tt.outCap < 0 {
+				opts = append(opts, WithPorts(1, 0))
+			}
+			n := Loop("loop", func(ctx context.Context, v int, emit *Emitter[int]) error {
+				if tt.cancel {
+					cancel()
+				}
+				ok := emit.Try(v)
+				tries = append(tries, ok)
+				if !ok && ctx.Err() == nil {
+					emit.Shed()
+				}
+				return nil
+			}, opts...)
+			if err := n.SetInput(0, feed(tt.items...)); err != nil {
+				t.Fatal(err)
+			}
+			var out chan int
+			if tt.outCap >= 0 {
+				out = make(chan int, tt.outCap)
+				if err := n.SetOutput(0, out); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var wg sync.WaitGroup
+			n.Run(ctx, &wg, make(chan error), true)
+			waitGroup(t, &wg)
+			var got []int
+			if out != nil {
+				for v := range out {
+					got = append(got, v)
+				}
+			}
+			if !slices.Equal(tries, tt.wantTries) {
+				t.Errorf("Try results %v, want %v", tries, tt.wantTries)
+			}
+			if !slices.Equal(got, tt.wantOut) {
+				t.Errorf("output %v, want %v", got, tt.wantOut)
+			}
+			if shed := n.Stats().Shed; shed != tt.wantShed {
+				t.Errorf("Shed = %d, want %d", shed, tt.wantShed)
+			}
+		})
+	}
+}
+
+func TestNewEmitter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan int, 1)
+	emit := NewEmitter(ctx, out)
+	if err := emit.Send(1); err != nil {
+		t.Fatalf("Send = %v", err)
+	}
+	// выход заполнен: Send ждёт места до отмены контекста
+	cancel()
+	if err := emit.Send(2); !errors.Is(err, context.Canceled) {
+		t.Errorf("Send after cancel = %v, want %v", err, context.Canceled)
+	}
+	emit.Shed()
+	if v := <-out; v != 1 {
+		t.Errorf("output %d, want 1", v)
+	}
+}
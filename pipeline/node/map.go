@@ -0,0 +1,418 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MapFn функция поэлементного преобразования: для каждого входного значения возвращает
+// одно выходное значение или ошибку
+type MapFn[I, O any] func(ctx context.Context, in I) (O, error)
+
+// SourceFn функция-источник: пишет данные в output до исчерпания или отмены контекста
+// и отправляет ошибки в errChan. Канал output закрывает узел после возврата из функции.
+type SourceFn[O any] func(ctx context.Context, output chan<- O, errChan chan<- error)
+
+// NewMap создаёт узел, применяющий f к каждому входному значению. Обработчик сам читает вход,
+// отправляет ошибки f в errChan (без выходного значения для элемента), прекращает работу при
+// отмене контекста и закрывает выход. Узел имеет один вход и один выход; другое их количество
+// задаёт WithPorts, буферы выходов — WithOutputBuffers. Поддерживает опции WithRetry, WithTimeout,
+// WithConcurrency, WithOrderedOutput, WithCircuitBreaker и WithErrorRateBreaker.
+func NewMap[I, O any](name string, f MapFn[I, O], opts ...Option) *Node[I, O] {
+	if f == nil {
+		panic("nil map func")
+	}
+
+	cfg := newConfig(opts)
+	inputNum, outputNum := cfg.portCounts()
+	return newMap(name, inputNum, outputNum, f, cfg)
+}
+
+// newMap создаёт узел NewMap с inputNum входами и outputNum выходами
+func newMap[I, O any](name string, inputNum int, outputNum int, f MapFn[I, O], cfg *config) *Node[I, O] {
+	cfg.gated = true
+	name = autoName(name, funcName(f), cfg)
+	var br *breaker
+	if cfg.breaker != nil {
+		br = newBreaker(cfg.breaker, cfg.clock, func(s CircuitState) {
+			cfg.notify(name, circuitEvents[s])
+		})
+	}
+
+	var rb *rateBreaker
+	if cfg.rateBreaker != nil {
+		rb = newRateBreaker(cfg.rateBreaker)
+	}
+	bypass := bypassFunc[I, O](name, cfg.rateBreaker)
+
+	handler := func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+		defer closeOutput(output)
+		apply := func(ctx context.Context, in I) (O, error) {
+			if br == nil {
+				return call(ctx, cfg, f, in)
+			}
+
+			token, ok := br.allow()
+			if !ok {
+				var zero O
+				return zero, &DeadLetter{Node: name, Item: in, Err: ErrCircuitOpen}
+			}
+			out, err := call(ctx, cfg, f, in)
+			if ctx.Err() == nil {
+				br.done(token, err)
+			}
+			return out, err
+		}
+		if rb == nil {
+			runItems(ctx, cfg, input, output, errChan, apply)
+			return
+		}
+
+		runItems(ctx, cfg, input, output, errChan, func(ctx context.Context, in I) (O, error) {
+			ok, probe := rb.admit()
+			if !ok {
+				rb.bypassed.Add(1)
+				return bypass(in)
+			}
+			out, err := apply(ctx, in)
+			if ctx.Err() != nil {
+				return out, err
+			}
+			if change := rb.done(err, probe); change != nil {
+				if errors.Is(change, ErrBypassEngaged) {
+					cfg.notify(name, EventBypassOn)
+				} else {
+					cfg.notify(name, EventBypassOff)
+				}
+				select {
+				case errChan <- change:
+				case <-ctx.Done():
+				}
+			}
+			if probe && err != nil {
+				rb.bypassed.Add(1)
+				return bypass(in)
+			}
+			return out, err
+		})
+	}
+
+	n := newNode[I, O](name, inputNum, outputNum, nil, cfg)
+	n.handler = handler
+	n.breaker = br
+	n.rateBreaker = rb
+	return n
+}
+
+// bypassFunc возвращает обработку элемента в режиме обхода WithErrorRateBreaker. Паникует при
+// BypassForward, если типы входа и выхода различаются.
+func bypassFunc[I, O any](name string, rc *rateBreakerConfig) func(in I) (O, error) {
+	deadLetter := func(in I) (O, error) {
+		var zero O
+		return zero, &DeadLetter{Node: name, Item: in, Err: ErrBypassed}
+	}
+	if rc == nil || rc.action == BypassDeadLetter {
+		return deadLetter
+	}
+	if reflect.TypeFor[I]() != reflect.TypeFor[O]() {
+		if rc.action == BypassForward {
+			panic("bypass forward requires identical input and output types")
+		}
+		return deadLetter
+	}
+	return func(in I) (O, error) {
+		return any(in).(O), nil
+	}
+}
+
+// NewSource создаёт узел-источник без входов, выполняющий fn. Выход закрывается после
+// завершения fn. Источник можно остановить досрочно через StopSource.
+func NewSource[O any](name string, outputNum int, outputBuffSize []int, fn SourceFn[O], opts ...Option) *Node[struct{}, O] {
+	if fn == nil {
+		panic("nil source func")
+	}
+
+	stop := newStopSignal()
+	handler := func(ctx context.Context, _ <-chan struct{}, output chan<- O, errChan chan<- error) {
+		defer close(output)
+		srcCtx, cancel := stop.withStop(ctx)
+		defer cancel()
+		fn(srcCtx, output, errChan)
+	}
+
+	n := New(autoNameOpts(name, funcName(fn), opts), 0, outputNum, outputBuffSize, handler, opts...)
+	n.stop = stop
+	return n
+}
+
+// Task создаёт узел без входов и выходов, однократно выполняющий fn (например, шаг подготовки или
+// очистки в графе). Ошибка fn отправляется в канал ошибок с классом ClassNode.
+func Task(name string, fn func(ctx context.Context) error, opts ...Option) *Node[struct{}, struct{}] {
+	if fn == nil {
+		panic("nil task func")
+	}
+
+	return New(autoNameOpts(name, funcName(fn), opts), 0, 0, nil, func(ctx context.Context, _ <-chan struct{}, _ chan<- struct{}, errChan chan<- error) {
+		if err := fn(ctx); err != nil {
+			errChan <- Classify(ClassNode, err)
+		}
+	}, opts...)
+}
+
+// NewSink создаёт узел-приёмник без выходов, вызывающий f для каждого входного значения.
+// Ошибки f отправляются в errChan. Поддерживает те же опции, что и NewMap.
+func NewSink[I any](name string, inputNum int, f func(ctx context.Context, in I) error, opts ...Option) *Node[I, struct{}] {
+	if f == nil {
+		panic("nil sink func")
+	}
+
+	return newMap(autoNameOpts(name, funcName(f), opts), inputNum, 0, func(ctx context.Context, in I) (struct{}, error) {
+		return struct{}{}, f(ctx, in)
+	}, newConfig(opts))
+}
+
+// FlatMapFn функция преобразования, разворачивающая входное значение в произвольное число выходных
+type FlatMapFn[I, O any] func(ctx context.Context, in I) ([]O, error)
+
+// NewFlatMap создаёт узел, применяющий f к каждому входному значению и отправляющий в выход
+// все элементы результата по порядку. Количество входов и выходов задаёт WithPorts, буферы
+// выходов — WithOutputBuffers. Поддерживает опции WithRetry, WithTimeout, WithConcurrency
+// и WithOrderedOutput.
+func NewFlatMap[I, O any](name string, f FlatMapFn[I, O], opts ...Option) *Node[I, O] {
+	if f == nil {
+		panic("nil flat map func")
+	}
+
+	cfg := newConfig(opts)
+	cfg.gated = true
+	name = autoName(name, funcName(f), cfg)
+	handler := func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+		defer closeOutput(output)
+		send := func(outs []O) bool {
+			if output == nil {
+				return true
+			}
+			for _, out := range outs {
+				select {
+				case output <- out:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		runItemsFunc(ctx, cfg, input, send, errChan, func(ctx context.Context, in I) ([]O, error) {
+			return call(ctx, cfg, MapFn[I, []O](f), in)
+		})
+	}
+
+	inputNum, outputNum := cfg.portCounts()
+	n := newNode[I, O](name, inputNum, outputNum, nil, cfg)
+	n.handler = handler
+	return n
+}
+
+// call вызывает f с учётом таймаута и повторов из cfg
+func call[I, O any](ctx context.Context, cfg *config, f MapFn[I, O], in I) (O, error) {
+	for attempt := 0; ; attempt++ {
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if cfg.timeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		}
+		out, err := f(callCtx, in)
+		cancel()
+
+		if err == nil || attempt >= cfg.retries || ctx.Err() != nil {
+			return out, err
+		}
+
+		if cfg.backoff != nil && !sleep(ctx, cfg.clock, cfg.backoff(attempt+1)) {
+			return out, err
+		}
+	}
+}
+
+// runItems читает input до закрытия или отмены контекста, применяет f к каждому элементу и
+// отправляет результаты в output, а ошибки в errChan. При cfg.concurrency > 1 элементы
+// обрабатываются параллельно; порядок выдачи соответствует порядку входа только при cfg.ordered.
+// Ошибки, возникшие после отмены контекста, не отправляются.
+func runItems[I, O any](ctx context.Context, cfg *config, input <-chan I, output chan<- O, errChan chan<- error,
+	f func(ctx context.Context, in I) (O, error)) {
+	runItemsFunc(ctx, cfg, input, func(out O) bool {
+		if output == nil {
+			return true
+		}
+
+		select {
+		case output <- out:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, errChan, f)
+}
+
+// runItemsFunc аналог runItems, передающий успешные результаты в send вместо канала. send
+// возвращает false, если обработку нужно прекратить.
+func runItemsFunc[I, O any](ctx context.Context, cfg *config, input <-chan I, send func(out O) bool,
+	errChan chan<- error, f func(ctx context.Context, in I) (O, error)) {
+	f = trackInFlight(&cfg.inFlight, cfg.statsGate, f)
+	if cfg.cpuAccounting {
+		f = trackBusy(&cfg.busy, f)
+	}
+	emit := func(out O, err error) bool {
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			return cfg.sendError(ctx, errChan, err)
+		}
+		return send(out)
+	}
+
+	if cfg.concurrency <= 1 {
+		for cfg.gate.wait(ctx) {
+			select {
+			case in, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					return
+				}
+				if Yield(ctx) != nil {
+					return
+				}
+				if !emit(f(ctx, in)) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+		return
+	}
+
+	type result struct {
+		out O
+		err error
+	}
+
+	// queue очередь слотов с результатами: в упорядоченном режиме слот ставится в очередь при
+	// чтении элемента, иначе после вычисления результата
+	// panicked паника обработки элемента, перенесённая в горутину обработчика узла
+	var panicked atomic.Pointer[PanicError]
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queue := make(chan chan result, cfg.concurrency)
+	sem := make(chan struct{}, cfg.concurrency)
+	emitterDone := make(chan struct{})
+	go func() {
+		defer close(emitterDone)
+		for slot := range queue {
+			r := <-slot
+			if ctx.Err() == nil {
+				emit(r.out, r.err)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		close(queue)
+		<-emitterDone
+		if pe := panicked.Load(); pe != nil {
+			panic(pe)
+		}
+	}()
+
+	for cfg.gate.wait(ctx) {
+		select {
+		case in, ok := <-input:
+			if !ok {
+				cfg.inputClosed.Store(true)
+				return
+			}
+			if Yield(ctx) != nil {
+				return
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			slot := make(chan result, 1)
+			if cfg.ordered {
+				queue <- slot
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var r result
+				func() {
+					defer func() {
+						if p := recover(); p != nil {
+							panicked.CompareAndSwap(nil, newPanicError(p))
+							cancel()
+						}
+					}()
+					r.out, r.err = f(ctx, in)
+				}()
+				slot <- r
+				<-sem
+				if !cfg.ordered {
+					queue <- slot
+				}
+			}()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// trackInFlight оборачивает функцию узла, учитывая в inFlight элементы, которые она обрабатывает:
+// две атомарные операции на элемент. С подключённым шлюзом gate изменения inFlight согласуются со
+// снимками.
+func trackInFlight[I, O any](inFlight *atomic.Int64, gate *StatsGate,
+	f func(ctx context.Context, in I) (O, error)) func(ctx context.Context, in I) (O, error) {
+	add := func(d int64) {
+		gate.enter()
+		inFlight.Add(d)
+		gate.leave()
+	}
+	return func(ctx context.Context, in I) (O, error) {
+		add(1)
+		defer add(-1)
+		return f(ctx, in)
+	}
+}
+
+// trackBusy оборачивает f, прибавляя к busy время каждого вызова в наносекундах: процессорное
+// время потока ОС, за которым на время вызова закрепляется горутина, или, если оно недоступно,
+// время по часам
+func trackBusy[I, O any](busy *atomic.Int64,
+	f func(ctx context.Context, in I) (O, error)) func(ctx context.Context, in I) (O, error) {
+	return func(ctx context.Context, in I) (O, error) {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		if start, ok := threadCPU(); ok {
+			defer func() {
+				end, _ := threadCPU()
+				busy.Add(int64(end - start))
+			}()
+			return f(ctx, in)
+		}
+
+		start := time.Now()
+		defer func() { busy.Add(int64(time.Since(start))) }()
+		return f(ctx, in)
+	}
+}
@@ -0,0 +1,123 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNewMapPorts(t *testing.T) {
+	id := func(_ context.Context, v int) (int, error) { return v, nil }
+	tests := []struct {
+		name            string
+		opts            []Option
+		inputs, outputs int
+	}{
+		{"default", nil, 1, 1},
+		{"ports", []Option{WithPorts(3, 2)}, 3, 2},
+		{"sink-like", []Option{WithPorts(2, 0)}, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewMap("map", id, tt.opts...)
+			if n.InputCount() != tt.inputs || n.OutputCount() != tt.outputs {
+				t.Errorf("ports = %d/%d, want %d/%d", n.InputCount(), n.OutputCount(), tt.inputs, tt.outputs)
+			}
+		})
+	}
+}
+
+func TestNewMapItems(t *testing.T) {
+	errOdd := errors.New("odd")
+	n := NewMap("map", func(_ context.Context, v int) (int, error) {
+		if v%2 == 1 {
+			return 0, errOdd
+		}
+		return v * 10, nil
+	}, WithOutputBuffers(4))
+	if err := n.SetInput(0, feed(seq(6)...)); err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan int)
+	if err := n.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+	got := drain(out)
+
+	errs := runNodes(t, context.Background(), n)
+	// элемент с ошибкой не порождает выходного значения
+	if want := []int{0, 20, 40}; !slices.Equal(got(), want) {
+		t.Errorf("output = %v, want %v", got(), want)
+	}
+	if len(errs) != 3 {
+		t.Errorf("errors = %v, want 3", errs)
+	}
+}
+
+func TestNewMapCancelNotReportedPerItem(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := NewMap("map", func(ctx context.Context, v int) (int, error) {
+		cancel()
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, WithPorts(1, 0))
+	if err := n.SetInput(0, feed(seq(100)...)); err != nil {
+		t.Fatal(err)
+	}
+
+	// ошибки, возникшие после отмены контекста, не отправляются
+	if errs := runNodes(t, ctx, n); len(errs) != 0 {
+		t.Errorf("errors after cancellation = %v, want none", errs)
+	}
+	if r := n.ExitReason(); r != ExitCancelled {
+		t.Errorf("ExitReason = %v, want %v", r, ExitCancelled)
+	}
+}
+
+func TestTask(t *testing.T) {
+	errSetup := errors.New("setup failed")
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context) error
+		// cancel контекст отменён до запуска
+		cancel  bool
+		wantErr error
+	}{
+		{"ok", func(context.Context) error { return nil }, false, nil},
+		{"error", func(context.Context) error { return errSetup }, false, errSetup},
+		// fn получает контекст узла и завершается по его отмене
+		{"cancelled", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			n := Task("task", func(ctx context.Context) error {
+				calls++
+				return tt.fn(ctx)
+			})
+			if n.InputCount() != 0 || n.OutputCount() != 0 {
+				t.Errorf("ports = %d/%d, want 0/0", n.InputCount(), n.OutputCount())
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			errs := runNodes(t, ctx, n)
+			if calls != 1 {
+				t.Errorf("fn called %d times, want 1", calls)
+			}
+			switch {
+			case tt.wantErr == nil && len(errs) > 0:
+				t.Errorf("errors: %v", errs)
+			case tt.wantErr != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.wantErr) || ClassOf(errs[0]) != ClassNode):
+				t.Errorf("errors = %v, want one ClassNode error wrapping %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
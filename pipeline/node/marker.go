@@ -0,0 +1,74 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WithCompletionMarker задаёт функцию, вызываемую один раз после успешного завершения узла:
+// обработчик вернулся после закрытия входа, хуки WithFlush и WithClose отработали, узел не
+// отправил ни одной ошибки и контекст не отменён (Stop, WithFailFast и т.п.). fn получает
+// статистику узла (опция включает WithStats), например, чтобы записать маркер готовности
+// результата для внешних потребителей (см. SuccessFileMarker). Ошибка fn отправляется в канал
+// ошибок с классом ClassInfra. Обработчик, вернувшийся до закрытия входа, маркер не вызывает
+// (см. Node.ExitReason).
+func WithCompletionMarker(fn func(ctx context.Context, stats Stats) error) Option {
+	return func(c *config) {
+		c.completionMarker = fn
+		c.stats = true
+	}
+}
+
+// SuccessFileMarker возвращает функцию для WithCompletionMarker, создающую пустой файл path
+// (например, "out/_SUCCESS"). Файл создаётся под временным именем и переименовывается, так что
+// внешний потребитель не увидит его до готовности.
+func SuccessFileMarker(path string) func(ctx context.Context, stats Stats) error {
+	return func(ctx context.Context, _ Stats) error {
+		tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		if err := commitFile(tmp, path, false); err != nil {
+			_ = os.Remove(tmp.Name())
+			return err
+		}
+		return nil
+	}
+}
+
+// completeMarked вызывает invokeHandler и, если узел завершился успешно, функцию WithCompletionMarker
+func (n *Node[I, O]) completeMarked(ctx context.Context, h Handler[I, O], input <-chan I, output chan<- O,
+	errChan chan<- error) {
+	watched, errored := watchErrors(errChan)
+	n.invokeHandler(ctx, h, input, output, watched)
+	if errored() || ctx.Err() != nil || n.ExitReason() != ExitInputClosed {
+		return
+	}
+
+	if err := n.cfg.completionMarker(ctx, n.Stats()); err != nil {
+		errChan <- Classify(ClassInfra, fmt.Errorf("completion marker: %w", err))
+	}
+}
+
+// watchErrors ретранслирует ошибки в errChan. Функция errored закрывает канал-обёртку, дожидается
+// передачи всех ошибок и сообщает, была ли хотя бы одна.
+func watchErrors(errChan chan<- error) (chan<- error, func() bool) {
+	proxy := make(chan error)
+	done := make(chan struct{})
+	var seen bool
+	go func() {
+		defer close(done)
+		for err := range proxy {
+			seen = true
+			errChan <- err
+		}
+	}()
+
+	return proxy, func() bool {
+		close(proxy)
+		<-done
+		return seen
+	}
+}
@@ -0,0 +1,71 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompletionMarker(t *testing.T) {
+	failing := func(_ context.Context, input <-chan int, _ chan<- struct{}, errChan chan<- error) {
+		for range input {
+		}
+		errChan <- errors.New("write failed")
+	}
+	tests := []struct {
+		name    string
+		handler Handler[int, struct{}]
+		opts    []Option
+		cancel  bool
+		want    bool
+	}{
+		{"success", readAll, nil, false, true},
+		{"success drain", readAll, []Option{WithEarlyExit(EarlyExitDrain)}, false, true},
+		{"early", takeOne, nil, false, false},
+		{"errored", failing, nil, false, false},
+		{"cancelled", readAll, nil, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "_SUCCESS")
+			opts := append([]Option{WithCompletionMarker(SuccessFileMarker(path))}, tt.opts...)
+			n := New("sink", 1, 0, nil, tt.handler, opts...)
+			if err := n.SetInput(0, feed(1, 2, 3)); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			runNodes(t, ctx, n)
+			_, err := os.Stat(path)
+			if got := err == nil; got != tt.want {
+				t.Errorf("marker written = %v, want %v (exit %v)", got, tt.want, n.ExitReason())
+			}
+		})
+	}
+}
+
+func TestCompletionMarkerStatsAndError(t *testing.T) {
+	var stats Stats
+	n := NewSink("sink", 1, func(context.Context, int) error { return nil },
+		WithCompletionMarker(func(_ context.Context, s Stats) error {
+			stats = s
+			return errors.New("disk full")
+		}))
+	if err := n.SetInput(0, feed(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := runNodes(t, context.Background(), n)
+	if stats.ItemsIn != 3 {
+		t.Errorf("marker Stats.ItemsIn = %d, want 3", stats.ItemsIn)
+	}
+	if len(errs) != 1 || ClassOf(errs[0]) != ClassInfra {
+		t.Errorf("errors = %v, want one %v error", errs, ClassInfra)
+	}
+}
@@ -0,0 +1,47 @@
+package node
+
+import "fmt"
+
+// WithItemSize задаёт оценку размера одного элемента выхода узла в байтах для учёта памяти,
+// занятой буферами выходов (BufferUsage)
+func WithItemSize(bytes int) Option {
+	return func(c *config) {
+		c.itemSize = max(bytes, 0)
+	}
+}
+
+// BufferUsage текущая заполненность внутреннего буфера узла
+type BufferUsage struct {
+	// Buffer имя буфера: имя ребра выхода (как в StrandedEdge) или "<узел> keys" для ключей
+	// и элементов, накопленных узлом-агрегатором (ShardedSink, TemplateSink с WithCollect)
+	Buffer string
+	Items  int
+	// Bytes оценка занятой памяти: Items × WithItemSize для выходов, 0 без WithItemSize
+	Bytes uint64
+}
+
+// BufferUsage возвращает заполненность буферов выходов узла и количество накопленных им ключей.
+// Пустые буферы не включаются. Можно вызывать во время работы пайплайна.
+func (n *Node[I, O]) BufferUsage() []BufferUsage {
+	if n.collapsed || n.cfg == nil {
+		return nil
+	}
+
+	var usage []BufferUsage
+	if held := n.cfg.held.Load(); held > 0 {
+		usage = append(usage, BufferUsage{Buffer: n.name + " keys", Items: int(held)})
+	}
+	for i, output := range n.outputs {
+		if output == nil || len(output) == 0 {
+			continue
+		}
+
+		u := BufferUsage{Buffer: fmt.Sprintf("%s[%d]", n.name, i), Items: len(output)}
+		if i < len(n.edges) && n.edges[i].ch != nil {
+			u.Buffer = n.edges[i].name
+		}
+		u.Bytes = uint64(u.Items) * uint64(n.cfg.itemSize)
+		usage = append(usage, u)
+	}
+	return usage
+}
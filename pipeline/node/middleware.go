@@ -0,0 +1,126 @@
+package node
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Middleware оборачивает обработчик узла, например, чтобы измерить время, записать в журнал или
+// подсчитать элементы. Обёртка отвечает за то же, что и обработчик: если она подменяет input или
+// output, то должна закрыть свой output после возврата обёрнутого обработчика и не возвращаться,
+// пока работают её горутины.
+type Middleware[I, O any] func(Handler[I, O]) Handler[I, O]
+
+// Chain оборачивает h в middleware: первая из mw внешняя, то есть получает каналы узла первой и
+// завершается последней
+func Chain[I, O any](h Handler[I, O], mw ...Middleware[I, O]) Handler[I, O] {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// WithMiddleware оборачивает обработчик узла в mw (см. Chain) при каждом запуске, в том числе при
+// перезапусках WithRestartPolicy. Несколько опций добавляют обёртки по порядку. Тип middleware должен
+// соответствовать узлу, иначе конструктор паникует. Для узла с SelectHandler обёртка получает
+// вместо input nil.
+func WithMiddleware[I, O any](mw ...Middleware[I, O]) Option {
+	return func(c *config) {
+		for _, m := range mw {
+			c.middleware = append(c.middleware, m)
+		}
+	}
+}
+
+// applyMiddleware оборачивает h в middleware узла
+func (n *Node[I, O]) applyMiddleware(h Handler[I, O]) Handler[I, O] {
+	mw := make([]Middleware[I, O], len(n.cfg.middleware))
+	for i, m := range n.cfg.middleware {
+		mw[i] = m.(Middleware[I, O])
+	}
+	return Chain(h, mw...)
+}
+
+// LogLifecycle middleware, сообщающий через logf о запуске обработчика узла и о его завершении с
+// длительностью работы. Имя узла берётся из контекста (NodeName).
+func LogLifecycle[I, O any](logf func(format string, args ...any)) Middleware[I, O] {
+	return func(h Handler[I, O]) Handler[I, O] {
+		return func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+			name := NodeName(ctx)
+			start := time.Now()
+			logf("%s: started", name)
+			defer func() {
+				logf("%s: stopped after %s", name, time.Since(start))
+			}()
+			h(ctx, input, output, errChan)
+		}
+	}
+}
+
+// CountItems middleware, подсчитывающий в in элементы, прочитанные обработчиком, а в out —
+// отправленные им (nil-счётчик не ведётся). Обработчику передаются каналы-обёртки; выход узла
+// закрывается после того, как обработчик закрыл свой выход и все значения переданы дальше.
+func CountItems[I, O any](in, out *atomic.Uint64) Middleware[I, O] {
+	return func(h Handler[I, O]) Handler[I, O] {
+		return func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+			stop := make(chan struct{})
+			var inDone, outDone chan struct{}
+
+			if input != nil && in != nil {
+				proxy := make(chan I)
+				inDone = make(chan struct{})
+				go func(input <-chan I) {
+					defer close(inDone)
+					defer close(proxy)
+					for {
+						select {
+						case v, ok := <-input:
+							if !ok {
+								return
+							}
+							in.Add(1)
+							select {
+							case proxy <- v:
+							case <-stop:
+								return
+							}
+						case <-stop:
+							return
+						}
+					}
+				}(input)
+				input = proxy
+			}
+
+			if output != nil && out != nil {
+				proxy := make(chan O)
+				outDone = make(chan struct{})
+				go func(output chan<- O) {
+					defer close(outDone)
+					defer close(output)
+					for v := range proxy {
+						select {
+						case output <- v:
+							out.Add(1)
+						case <-ctx.Done():
+						}
+					}
+				}(output)
+				defer func() {
+					closeQuietly(proxy)
+					<-outDone
+				}()
+				output = proxy
+			}
+
+			defer func() {
+				close(stop)
+				if inDone != nil {
+					<-inDone
+				}
+			}()
+			h(ctx, input, output, errChan)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// trace middleware, записывающий в events вход в обёртку и выход из неё
+func trace(events *[]string, mu *sync.Mutex, tag string) Middleware[int, int] {
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		*events = append(*events, event)
+	}
+	return func(h Handler[int, int]) Handler[int, int] {
+		return func(ctx context.Context, input <-chan int, output chan<- int, errChan chan<- error) {
+			record(tag + " enter")
+			defer record(tag + " exit")
+			h(ctx, input, output, errChan)
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	want := []string{"a enter", "b enter", "c enter", "c exit", "b exit", "a exit"}
+	tests := []struct {
+		name  string
+		build func(mw ...Middleware[int, int]) *Node[int, int]
+	}{
+		{"Chain", func(mw ...Middleware[int, int]) *Node[int, int] {
+			return New("chained", 1, 1, nil, Chain(relay, mw...))
+		}},
+		{"one option", func(mw ...Middleware[int, int]) *Node[int, int] {
+			return New("wrapped", 1, 1, nil, relay, WithMiddleware(mw...))
+		}},
+		// несколько опций добавляют обёртки по порядку: первая остаётся внешней
+		{"several options", func(mw ...Middleware[int, int]) *Node[int, int] {
+			return New("wrapped", 1, 1, nil, relay, WithMiddleware(mw[0]), WithMiddleware(mw[1:]...))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var events []string
+			n := tt.build(trace(&events, &mu, "a"), trace(&events, &mu, "b"), trace(&events, &mu, "c"))
+			if err := n.SetInput(0, feed(seq(5)...)); err != nil {
+				t.Fatal(err)
+			}
+			out := make(chan int, 5)
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			if errs := runNodes(t, context.Background(), n); len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+			if got := drain(out)(); !slices.Equal(got, seq(5)) {
+				t.Errorf("output %v, want %v", got, seq(5))
+			}
+			if !slices.Equal(events, want) {
+				t.Errorf("events %v, want %v", events, want)
+			}
+		})
+	}
+}
+
+func TestCountItems(t *testing.T) {
+	const items = 10
+	tests := []struct {
+		name            string
+		handler         Handler[int, int]
+		countIn         bool
+		countOut        bool
+		wantIn, wantOut uint64
+		wantDelivered   int
+	}{
+		{"relay", relay, true, true, items, items, items},
+		{"filter", func(_ context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+			defer close(output)
+			for v := range input {
+				if v%2 == 0 {
+					output <- v
+				}
+			}
+		}, true, true, items, items / 2, items / 2},
+		// обработчик не закрывает свой выход: обёртка закрывает выход узла после его возврата
+		{"handler leaves output open", func(_ context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+			for v := range input {
+				output <- v
+			}
+		}, true, true, items, items, items},
+		{"input only", relay, true, false, items, 0, items},
+		{"output only", relay, false, true, 0, items, items},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in, out atomic.Uint64
+			var inPtr, outPtr *atomic.Uint64
+			if tt.countIn {
+				inPtr = &in
+			}
+			if tt.countOut {
+				outPtr = &out
+			}
+			n := New("counted", 1, 1, nil, tt.handler, WithMiddleware(CountItems[int, int](inPtr, outPtr)))
+			if err := n.SetInput(0, feed(seq(items)...)); err != nil {
+				t.Fatal(err)
+			}
+			output := make(chan int)
+			if err := n.SetOutput(0, output); err != nil {
+				t.Fatal(err)
+			}
+			// выход закрыт ровно один раз: drain завершается, а узел не сообщает о повторном закрытии
+			got := drain(output)
+			if errs := runNodes(t, context.Background(), n); len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+			if l := len(got()); l != tt.wantDelivered {
+				t.Errorf("delivered %d items, want %d", l, tt.wantDelivered)
+			}
+			if in.Load() != tt.wantIn || out.Load() != tt.wantOut {
+				t.Errorf("counted in %d, out %d; want in %d, out %d", in.Load(), out.Load(), tt.wantIn, tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestLogLifecycle(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	var out atomic.Uint64
+	var countedAtStop uint64
+	logf := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, fmt.Sprintf(format, args...))
+		countedAtStop = out.Load()
+	}
+	// журнал внешний: сообщение о завершении пишется после того, как счётчик передал все значения
+	n := New("logged", 1, 1, nil, relay, WithMiddleware(LogLifecycle[int, int](logf), CountItems[int, int](nil, &out)),
+		WithMiddleware(trace(&lines, &mu, "inner")))
+	if err := n.SetInput(0, feed(seq(3)...)); err != nil {
+		t.Fatal(err)
+	}
+	output := make(chan int, 3)
+	if err := n.SetOutput(0, output); err != nil {
+		t.Fatal(err)
+	}
+	if errs := runNodes(t, context.Background(), n); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+
+	if len(lines) != 4 || lines[0] != "logged: started" || lines[1] != "inner enter" || lines[2] != "inner exit" ||
+		!strings.HasPrefix(lines[3], "logged: stopped after ") {
+		t.Fatalf("log %q", lines)
+	}
+	if countedAtStop != 3 {
+		t.Errorf("counted %d items when stopped, want 3", countedAtStop)
+	}
+}
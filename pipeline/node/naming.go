@@ -0,0 +1,103 @@
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// nameCounters счётчики сгенерированных имён по основе имени (имени функции или шаблону)
+var nameCounters = struct {
+	mu   sync.Mutex
+	next map[string]int
+}{next: make(map[string]int)}
+
+// WithName задаёт имя узла, созданного с пустым именем (например, через Build). Имя, переданное
+// конструктору явно, имеет приоритет.
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithNameTemplate задаёт шаблон имени узла, созданного с пустым именем: "{i}" заменяется
+// порядковым номером узла с этим шаблоном, начиная с 1 (например, "hasher-{i}" даёт "hasher-1",
+// "hasher-2", ...). Нумерация сквозная для всех узлов процесса с тем же шаблоном, поэтому имена
+// уникальны и при нескольких сериях узлов. Шаблон без "{i}" дополняется суффиксом "#<номер>".
+func WithNameTemplate(tmpl string) Option {
+	return func(c *config) {
+		c.nameTemplate = tmpl
+	}
+}
+
+// autoName возвращает name или, если оно пустое, имя WithName, а без него — уникальное имя по
+// шаблону WithNameTemplate или по основе base (обычно funcName обработчика) с суффиксом
+// "#<номер>" (например, "HashFile#1")
+func autoName(name string, base string, cfg *config) string {
+	if name == "" {
+		name = cfg.name
+	}
+	if name != "" {
+		return name
+	}
+
+	if cfg.nameTemplate != "" {
+		base = cfg.nameTemplate
+	}
+
+	nameCounters.mu.Lock()
+	nameCounters.next[base]++
+	i := nameCounters.next[base]
+	nameCounters.mu.Unlock()
+
+	if strings.Contains(base, "{i}") {
+		return strings.ReplaceAll(base, "{i}", strconv.Itoa(i))
+	}
+	return fmt.Sprintf("%s#%d", base, i)
+}
+
+// autoNameOpts аналог autoName для конструкторов, передающих опции другому конструктору
+func autoNameOpts(name string, base string, opts []Option) string {
+	if name != "" {
+		return name
+	}
+	return autoName(name, base, newConfig(opts))
+}
+
+// funcName возвращает короткое имя функции fn: "HashFile" для example.HashFile, "hashFile" для
+// метода Config.hashFile, имя объемлющей функции для замыкания и "node", если имя не определено
+func funcName(fn any) string {
+	v := reflect.ValueOf(fn)
+	if fn == nil || v.Kind() != reflect.Func || v.IsNil() {
+		return "node"
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return "node"
+	}
+
+	full := strings.TrimSuffix(f.Name(), "-fm")
+	full = strings.ReplaceAll(full, "[...]", "")
+	if slash := strings.LastIndex(full, "/"); slash >= 0 {
+		full = full[slash+1:]
+	}
+	parts := strings.Split(full, ".")
+	// пакет и суффиксы замыканий (func1, func1.2) не входят в имя
+	for len(parts) > 1 && isClosureSuffix(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) < 2 {
+		return "node"
+	}
+	return parts[len(parts)-1]
+}
+
+// isClosureSuffix сообщает, что элемент имени функции — суффикс замыкания ("func1" или "2")
+func isClosureSuffix(s string) bool {
+	s = strings.TrimPrefix(s, "func")
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
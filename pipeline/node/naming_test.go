@@ -0,0 +1,79 @@
+package node
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// hashItem функция Map-стиля, по имени которой строится имя узла
+func hashItem(_ context.Context, v int) (int, error) {
+	return v, nil
+}
+
+// resetNames обнуляет счётчик сгенерированных имён основы base, чтобы номера не зависели от
+// порядка и количества запусков тестов
+func resetNames(base string) {
+	nameCounters.mu.Lock()
+	defer nameCounters.mu.Unlock()
+	delete(nameCounters.next, base)
+}
+
+func TestWithNameTemplate(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  string
+		build func() []*Node[int, int]
+		want  []string
+	}{
+		// нумерация сквозная: вторая серия узлов продолжает первую
+		{"two series", "hasher-{i}", func() []*Node[int, int] {
+			var nodes []*Node[int, int]
+			for range 2 {
+				for range 3 {
+					nodes = append(nodes, NewMap("", hashItem, WithNameTemplate("hasher-{i}")))
+				}
+			}
+			return nodes
+		}, []string{"hasher-1", "hasher-2", "hasher-3", "hasher-4", "hasher-5", "hasher-6"}},
+		{"without placeholder", "worker", func() []*Node[int, int] {
+			return []*Node[int, int]{
+				NewMap("", hashItem, WithNameTemplate("worker")),
+				NewMap("", hashItem, WithNameTemplate("worker")),
+			}
+		}, []string{"worker#1", "worker#2"}},
+		// явное имя и WithName важнее шаблона и не расходуют номер
+		{"explicit name", "x-{i}", func() []*Node[int, int] {
+			return []*Node[int, int]{
+				NewMap("explicit", hashItem, WithNameTemplate("x-{i}")),
+				NewMap("", hashItem, WithName("named"), WithNameTemplate("x-{i}")),
+				NewMap("", hashItem, WithNameTemplate("x-{i}")),
+			}
+		}, []string{"explicit", "named", "x-1"}},
+		{"function name", "hashItem", func() []*Node[int, int] {
+			return []*Node[int, int]{NewMap("", hashItem), NewMap("", hashItem)}
+		}, []string{"hashItem#1", "hashItem#2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetNames(tt.base)
+			var got []string
+			for _, n := range tt.build() {
+				got = append(got, n.Name())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("names %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratedNameInErrors(t *testing.T) {
+	resetNames("failing-{i}")
+	n := NewMap("", hashItem, WithNameTemplate("failing-{i}"))
+	// сгенерированное имя входит в префикс ошибок узла
+	err := n.SetInput(5, make(chan int))
+	if want := "pipeline/node=failing-1: input index out of range"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
-	"unsafe"
+	"sync/atomic"
 
 	"github.com/tom-lepsky/pipeline/pipeline/util"
 )
@@ -17,16 +18,29 @@ var (
 	ErrOutputIdxOutOfRange = errors.New("output index out of range")
 	ErrInputsWired         = errors.New("all inputs are wire")
 	ErrOutputsWired        = errors.New("all outputs are wire")
+	ErrNilChannel          = errors.New("nil channel")
+	ErrFrozen              = errors.New("topology frozen")
+	ErrSlotOccupied        = errors.New("slot already wired")
+	ErrAlreadyRunning      = errors.New("node already running")
 )
 
 // Handler представляет собой функцию-обработчик, которая принимает контекст, канал входных данных,
 // канал выходных данных и канал для ошибок. Обработчик должен читать из input, писать в output
-// и отправлять ошибки в errChan при необходимости. Закрытие каналов output и errChan ответственность клиента
+// и отправлять ошибки в errChan при необходимости. Закрытие каналов output и errChan ответственность клиента.
+// Повторное закрытие output и отправка в закрытый output не приводят к панике: обработчик прерывается,
+// а в errChan отправляется ErrOutputClosedTwice или ErrSendAfterClose.
 type Handler[I, O any] func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error)
 
+// SelectHandler обработчик, получающий входы узла по отдельности, без слияния через FanIn. Позволяет
+// по-разному обрабатывать входы (например, данные и сигнал перезагрузки конфигурации). Обработка
+// закрытия входов и отмены контекста, как и закрытие output, ответственность обработчика (см. util.Closed).
+type SelectHandler[I, O any] func(ctx context.Context, inputs []<-chan I, output chan<- O, errChan chan<- error)
+
 // Node представляет собой базовый узел в пайплайне обработки данных. Поддерживает множественные
-// входы и выходы на основе каналов.
+// входы и выходы на основе каналов. Конструкторы возвращают *Node; копировать узел нельзя, так как
+// копия не разделяет с оригиналом подключённые входы и выходы.
 type Node[I, O any] struct {
+	noCopy         noCopy
 	name           string
 	inputsMask     uint64
 	outputsMask    uint64
@@ -34,54 +48,243 @@ type Node[I, O any] struct {
 	inputs         []<-chan I
 	outputs        []chan<- O
 	handler        Handler[I, O]
+	selectHandler  SelectHandler[I, O]
+	cfg            *config
+	counters       *counters
+	breaker        *breaker
+	stop           *stopSignal
+	// inline ребро встраиваемого узла (WithInline), collapsed узел схлопнут в ребро
+	inline    *inlineLink
+	collapsed bool
+	// inlineLinks рёбра выходов, ведущие в схлопнутые узлы
+	inlineLinks []*inlineLink
+	// edges рёбра выходов, созданные Connect; fanInStranded элементы, оставшиеся в буфере FanIn
+	edges         []edge[O]
+	fanInStranded int
+	// seeds элементы, подаваемые на входы до основного потока (SeedInput)
+	seeds [][]I
+	// taps функции записи трафика выходов (TapOutput)
+	taps []func(any)
+	// exit причина завершения последнего запуска (ExitReason)
+	exit atomic.Int32
+	// outLimits, inLimits ограничения объёма рёбер выходов и входов (ConnectWithByteLimit)
+	outLimits []*byteLimit[O]
+	inLimits  []*byteLimit[I]
+	// frozen запрещает изменение подключения узла (Freeze)
+	frozen bool
+	// rateBreaker режим обхода по доле ошибок (WithErrorRateBreaker)
+	rateBreaker *rateBreaker
+	// ownInputs входы, созданные узлом (WithInputBuffers); nil для переподключённых
+	ownInputs []chan I
+	// unusedMask выходы, намеренно оставленные неподключёнными (MarkOutputUnused)
+	unusedMask uint64
+	// state состояние последнего запуска (State)
+	state atomic.Int32
+	// relay пересылка входа текущего запуска (WithExitTracking, WithStats); held элемент, полученный
+	// пересылкой, но не принятый обработчиком (см. Stranded)
+	relay *inputRelay[I]
+	held  *I
 }
 
 // New создаёт новый узел с заданным именем, количеством входов, выходов, опциональными буферами
-// для выходных каналов и обработчиком. Паникует, если handler nil, размеры буферов не совпадают
-// с количеством выходов.
-func New[I, O any](name string, inputNum int, outputNum int, outputBuffSize []int, handler Handler[I, O]) Node[I, O] {
+// для выходных каналов, обработчиком и опциями. Буферизованные входы, принадлежащие узлу, задаются
+// WithInputBuffers. Для пустого имени генерируется уникальное имя
+// по имени обработчика или шаблону WithNameTemplate (так же и в остальных конструкторах).
+// Паникует, если handler nil, размеры буферов не совпадают с количеством выходов или входов.
+// Build создаёт такой же узел с буферами и именем в опциях и возвращает ошибку вместо паники.
+func New[I, O any](name string, inputNum int, outputNum int, outputBuffSize []int, handler Handler[I, O], opts ...Option) *Node[I, O] {
+	if handler == nil {
+		panic("nil handler")
+	}
+
+	cfg := newConfig(opts)
+	n := newNode[I, O](autoName(name, funcName(handler), cfg), inputNum, outputNum, outputBuffSize, cfg)
+	n.handler = handler
+	return n
+}
+
+// NewSelect создаёт узел с обработчиком SelectHandler, получающим входы по отдельности.
+// Параметры и паники аналогичны New. Политика перезапуска (WithRestartPolicy) к узлу не применяется.
+func NewSelect[I, O any](name string, inputNum int, outputNum int, outputBuffSize []int, handler SelectHandler[I, O],
+	opts ...Option) *Node[I, O] {
 	if handler == nil {
 		panic("nil handler")
 	}
 
-	if outputBuffSize != nil && len(outputBuffSize) != outputNum {
-		panic("mismatch output buff size")
+	cfg := newConfig(opts)
+	n := newNode[I, O](autoName(name, funcName(handler), cfg), inputNum, outputNum, outputBuffSize, cfg)
+	n.selectHandler = handler
+	return n
+}
+
+// newNode проверяет параметры и создаёт узел без обработчика. Буферы выходов, не заданные
+// параметром, берутся из WithOutputBuffers.
+func newNode[I, O any](name string, inputNum int, outputNum int, outputBuffSize []int, cfg *config) *Node[I, O] {
+	if outputBuffSize == nil {
+		outputBuffSize = cfg.outputBuffSize
+	}
+	if err := checkNode[I, O](inputNum, outputNum, outputBuffSize, cfg); err != nil {
+		panic(err.Error())
 	}
 
-	if inputNum > maxIO || outputNum > maxIO {
-		panic("I/O out of range")
+	name = autoName(name, "node", cfg)
+	var cnt *counters
+	if cfg.stats {
+		cnt = &counters{}
 	}
 
-	return Node[I, O]{
+	n := &Node[I, O]{
 		name:           name,
 		outputBuffSize: outputBuffSize,
 		inputs:         make([]<-chan I, inputNum),
 		outputs:        make([]chan<- O, outputNum),
-		handler:        handler,
+		cfg:            cfg,
+		counters:       cnt,
+	}
+	if cfg.inputBuffSize != nil {
+		n.ownInputBuffers(cfg.inputBuffSize)
+	}
+	return n
+}
+
+// Name возвращает имя узла
+func (n *Node[I, O]) Name() string {
+	return n.name
+}
+
+// wiring синхронизирует подключение узлов (SetInput, Connect, Autowire и т.п.) и чтение его
+// состояния. Мьютекс общий для всех узлов: Connect меняет оба узла и цепочки встраиваемых узлов.
+var wiring sync.Mutex
+
+// Freeze запрещает дальнейшее подключение входов и выходов узла: SetInput, SetOutput, Connect и
+// другие функции подключения возвращают ErrFrozen. Вызывается пайплайном в Pipeline.Freeze.
+func (n *Node[I, O]) Freeze() {
+	wiring.Lock()
+	defer wiring.Unlock()
+	n.frozen = true
+}
+
+// InputCount возвращает количество входов узла
+func (n *Node[I, O]) InputCount() int {
+	return len(n.inputs)
+}
+
+// OutputCount возвращает количество выходов узла
+func (n *Node[I, O]) OutputCount() int {
+	return len(n.outputs)
+}
+
+// InputWired сообщает, подключён ли вход idx. Возвращает ErrInputIdxOutOfRange для неверного индекса.
+func (n *Node[I, O]) InputWired(idx int) (bool, error) {
+	if idx < 0 || idx >= len(n.inputs) {
+		return false, n.wrapError(ErrInputIdxOutOfRange)
+	}
+
+	wiring.Lock()
+	defer wiring.Unlock()
+	return n.inputsMask&(1<<uint(idx)) != 0, nil
+}
+
+// OutputWired сообщает, подключён ли выход idx. Возвращает ErrOutputIdxOutOfRange для неверного индекса.
+func (n *Node[I, O]) OutputWired(idx int) (bool, error) {
+	if idx < 0 || idx >= len(n.outputs) {
+		return false, n.wrapError(ErrOutputIdxOutOfRange)
 	}
+
+	wiring.Lock()
+	defer wiring.Unlock()
+	return n.outputsMask&(1<<uint(idx)) != 0, nil
+}
+
+// WiringComplete сообщает, что все входы и выходы узла подключены
+func (n *Node[I, O]) WiringComplete() bool {
+	wiring.Lock()
+	defer wiring.Unlock()
+	return n.vacantInput() == -1 && n.vacantOutput() == -1
 }
 
 // SetInput устанавливает канал входа по указанному индексу. Возвращает ошибку, если индекс
-// выходит за пределы количества входов.
+// выходит за пределы количества входов, канал nil (ErrNilChannel) или вход уже подключён
+// (ErrSlotOccupied; заменить подключение можно через ForceSetInput).
 func (n *Node[I, O]) SetInput(idx int, input <-chan I) error {
+	wiring.Lock()
+	defer wiring.Unlock()
+	return n.setInput(idx, input, false)
+}
+
+// ForceSetInput устанавливает канал входа так же, как SetInput, заменяя уже подключённый канал.
+// Прежний канал узлом больше не читается: его писатель должен быть отключён или остановлен.
+func (n *Node[I, O]) ForceSetInput(idx int, input <-chan I) error {
+	wiring.Lock()
+	defer wiring.Unlock()
+	return n.setInput(idx, input, true)
+}
+
+// setInput выполняет SetInput (при force — ForceSetInput) под мьютексом wiring
+func (n *Node[I, O]) setInput(idx int, input <-chan I, force bool) error {
+	if n.frozen {
+		return n.wrapError(ErrFrozen)
+	}
 	if idx < 0 || idx >= len(n.inputs) {
 		return n.wrapError(ErrInputIdxOutOfRange)
 	}
+	if input == nil {
+		return n.wrapError(fmt.Errorf("input %d: %w", idx, ErrNilChannel))
+	}
+	if !force && n.inputOccupied(idx) {
+		return n.wrapError(fmt.Errorf("input %d: %w", idx, ErrSlotOccupied))
+	}
 
 	n.inputs[idx] = input
+	n.disownInput(idx)
+	if n.inLimits != nil {
+		n.inLimits[idx] = nil
+	}
 	n.occupyInput(idx)
 
 	return nil
 }
 
 // SetOutput устанавливает канал выхода по указанному индексу и помечает его как занятый.
-// Возвращает ошибку, если индекс выходит за пределы количества выходов.
+// Возвращает ошибку, если индекс выходит за пределы количества выходов, канал nil (ErrNilChannel)
+// или выход уже подключён (ErrSlotOccupied; заменить подключение можно через ForceSetOutput).
+// Выход, помеченный MarkOutputUnused, подключить можно.
 func (n *Node[I, O]) SetOutput(idx int, output chan<- O) error {
+	wiring.Lock()
+	defer wiring.Unlock()
+	return n.setOutput(idx, output, false)
+}
+
+// ForceSetOutput устанавливает канал выхода так же, как SetOutput, заменяя уже подключённый канал.
+// Прежний канал узлом больше не пишется и не закрывается: его читатель должен быть отключён.
+func (n *Node[I, O]) ForceSetOutput(idx int, output chan<- O) error {
+	wiring.Lock()
+	defer wiring.Unlock()
+	return n.setOutput(idx, output, true)
+}
+
+// setOutput выполняет SetOutput (при force — ForceSetOutput) под мьютексом wiring
+func (n *Node[I, O]) setOutput(idx int, output chan<- O, force bool) error {
+	if n.frozen {
+		return n.wrapError(ErrFrozen)
+	}
 	if idx < 0 || idx >= len(n.outputs) {
 		return n.wrapError(ErrOutputIdxOutOfRange)
 	}
+	if output == nil {
+		return n.wrapError(fmt.Errorf("output %d: %w", idx, ErrNilChannel))
+	}
+	if !force && n.outputOccupied(idx) {
+		return n.wrapError(fmt.Errorf("output %d: %w", idx, ErrSlotOccupied))
+	}
 
 	n.outputs[idx] = output
+	if n.edges != nil {
+		n.edges[idx] = edge[O]{}
+	}
+	if n.outLimits != nil {
+		n.outLimits[idx] = nil
+	}
 	n.occupyOutput(idx)
 
 	return nil
@@ -92,9 +295,31 @@ func (n *Node[I, O]) occupyInput(idx int) {
 	n.inputsMask = setBit(n.inputsMask, idx)
 }
 
-// occupyOutput помечает выход по индексу как занятый в маске
+// occupyOutput помечает выход по индексу как занятый в маске и снимает пометку MarkOutputUnused
 func (n *Node[I, O]) occupyOutput(idx int) {
 	n.outputsMask = setBit(n.outputsMask, idx)
+	n.unusedMask &^= 1 << uint(idx)
+}
+
+// inputOccupied сообщает, что вход idx уже подключён
+func (n *Node[I, O]) inputOccupied(idx int) bool {
+	return n.inputsMask&(1<<uint(idx)) != 0
+}
+
+// outputOccupied сообщает, что выход idx уже подключён; выход MarkOutputUnused занятым не считается
+func (n *Node[I, O]) outputOccupied(idx int) bool {
+	return (n.outputsMask&^n.unusedMask)&(1<<uint(idx)) != 0
+}
+
+// checkSlots возвращает ErrSlotOccupied, если выход from[outIdx] или вход to[inIdx] уже подключён
+func checkSlots[I, O, T any](from *Node[I, O], outIdx int, to *Node[O, T], inIdx int) error {
+	if from.outputOccupied(outIdx) {
+		return from.wrapError(fmt.Errorf("output %d: %w", outIdx, ErrSlotOccupied))
+	}
+	if to.inputOccupied(inIdx) {
+		return to.wrapError(fmt.Errorf("input %d: %w", inIdx, ErrSlotOccupied))
+	}
+	return nil
 }
 
 // setBit устанавливает бит
@@ -114,17 +339,24 @@ func (n *Node[I, O]) vacantInput() int {
 }
 
 // AutowireInput подключает предоставленные каналы входа к первым свободным слотам.
-// Возвращает ошибку, если все входы уже подключены или произошла ошибка установки.
+// Возвращает ошибку, если все входы уже подключены или произошла ошибка установки. Если среди
+// каналов есть nil, ничего не подключается и возвращается ErrNilChannel с номером аргумента.
 func (n *Node[I, O]) AutowireInput(input ...chan I) error {
+	if i := slices.Index(input, nil); i != -1 {
+		return n.wrapError(fmt.Errorf("input argument %d: %w", i, ErrNilChannel))
+	}
+
+	wiring.Lock()
+	defer wiring.Unlock()
 	for i := 0; i < len(input); i++ {
 		inIdx := n.vacantInput()
 		if inIdx == -1 {
 			return n.wrapError(ErrInputsWired)
 		}
 
-		err := n.SetInput(inIdx, input[i])
+		err := n.setInput(inIdx, input[i], false)
 		if err != nil {
-			return n.wrapError(err)
+			return err
 		}
 	}
 	return nil
@@ -141,64 +373,182 @@ func (n *Node[I, O]) vacantOutput() int {
 }
 
 // AutowireOutput подключает предоставленные каналы выхода к первым свободным слотам.
-// Возвращает ошибку, если все выходы уже подключены или произошла ошибка установки.
+// Возвращает ошибку, если все выходы уже подключены или произошла ошибка установки. Если среди
+// каналов есть nil, ничего не подключается и возвращается ErrNilChannel с номером аргумента.
 func (n *Node[I, O]) AutowireOutput(output ...chan O) error {
+	if i := slices.Index(output, nil); i != -1 {
+		return n.wrapError(fmt.Errorf("output argument %d: %w", i, ErrNilChannel))
+	}
+
+	wiring.Lock()
+	defer wiring.Unlock()
 	for i := 0; i < len(output); i++ {
 		outIdx := n.vacantOutput()
 		if outIdx == -1 {
 			return n.wrapError(ErrOutputsWired)
 		}
 
-		err := n.SetOutput(outIdx, output[i])
+		err := n.setOutput(outIdx, output[i], false)
 		if err != nil {
-			return n.wrapError(err)
+			return err
 		}
 	}
 	return nil
 }
 
 // Run запускает обработчик узла в горутине.
-// Паникует, если какой-то вход не подключен. Запуск происходит только один раз (sync.Once).
+// Если какой-то вход не подключен, обработчик не запускается: ошибки неподключённых входов
+// (ErrUnwired, объединённые errors.Join) отправляются в errChan, а узел завершается как при Skip.
+// Узел рассчитан на один запуск: защиты от повторного вызова Run нет, а пайплайн запускает
+// каждую ноду один раз.
 // ВАЖНО: Закрытие каналов output лежит на ответственности реализатора handler
 func (n *Node[I, O]) Run(ctx context.Context, wg *sync.WaitGroup, errChan chan<- error, commonErrChan bool) {
-	for i, ch := range n.inputs {
-		if ch == nil {
-			panic(n.wrapError(fmt.Errorf("input %d: unused", i)))
-		}
+	if errs := n.unwiredInputs(); errs != nil {
+		n.Skip(ctx, wg)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errChan <- errors.Join(errs...)
+		}()
+		return
 	}
 
+	if n.collapsed {
+		return
+	}
+
+	n.exit.Store(int32(ExitRunning))
+	n.cfg.inputClosed.Store(false)
+	// состояние и отводы выходов меняются под wiring, чтобы TapOutput не гонялся с запуском
+	wiring.Lock()
+	n.state.Store(int32(StateRunning))
+	taps := n.taps
+	wiring.Unlock()
+	n.resetStats()
+	n.held = nil
+	if n.cfg.params != nil {
+		ctx = context.WithValue(ctx, paramsKey{}, n.cfg.params)
+	}
+	ctx = context.WithValue(ctx, nodeNameKey{}, n.name)
+	// горутины слияния и распределения (util) учитываются в wg наравне с обработчиком
+	ctx = util.WithWaitGroup(ctx, wg)
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		defer n.state.Store(int32(StateDone))
 
 		var input <-chan I
-		if len(n.inputs) == 1 {
-			input = n.inputs[0]
-		} else {
-			input = util.FanIn(ctx, n.inputs...)
+		var inputs []<-chan I
+		nodeInputs := n.limitInputs(ctx, wg)
+		switch {
+		case n.selectHandler != nil:
+			inputs = append(inputs, nodeInputs...)
+		case len(nodeInputs) == 1:
+			input = nodeInputs[0]
+		case len(nodeInputs) > 1:
+			merged := fanIn(ctx, n.cfg, nodeInputs)
+			defer n.drainFanIn(ctx, merged)
+			input = merged
+		}
+
+		if n.seeds != nil {
+			if input != nil {
+				input = prependInput(ctx, wg, n.takeSeeds(), input)
+			}
+			for i := range inputs {
+				inputs[i] = prependInput(ctx, wg, n.seeds[i], inputs[i])
+			}
+			n.seeds = nil
+		}
+
+		if n.cfg.pausable && !n.cfg.gated {
+			if input != nil {
+				input = gateInput(ctx, wg, input, n.cfg.gate)
+			}
+			for i := range inputs {
+				inputs[i] = gateInput(ctx, wg, inputs[i], n.cfg.gate)
+			}
+		}
+
+		outputs := n.outputs
+		if n.inlineLinks != nil {
+			outputs = n.countInlineOutputs(ctx, wg)
+		}
+		if n.outLimits != nil {
+			outputs = n.limitOutputs(ctx, wg, outputs)
+		}
+		if taps != nil {
+			outputs = tapOutputs(ctx, wg, outputs, taps)
+		}
+		if n.unusedMask != 0 {
+			outputs = n.discardUnused(ctx, outputs)
+		}
+
+		if n.cfg.bufAdvisor != nil {
+			done := make(chan struct{})
+			defer close(done)
+			sample(ctx, wg, n.cfg.clock, n.cfg.bufAdvisor, n.outputs, done)
 		}
 
 		var output chan<- O
-		if len(n.outputs) == 1 {
-			output = n.outputs[0]
+		if len(outputs) == 1 {
+			output = outputs[0]
 		} else {
-			output = util.FanOut(ctx, n.outputs...)
+			output = fanOut(ctx, n.cfg, outputs)
+		}
+
+		sizeIn, _ := n.cfg.sizeFunc.(func(I) int)
+		if input != nil && (n.cfg.trackExit || n.counters != nil) {
+			input, n.relay = n.relayInput(ctx, wg, input, sizeIn)
+		}
+		if n.counters != nil {
+			n.counters.startedAt.Store(n.cfg.clock.Now().UnixNano())
+			defer func() { n.counters.finishedAt.Store(n.cfg.clock.Now().UnixNano()) }()
+			sizeOut, _ := n.cfg.sizeFunc.(func(O) int)
+			for i := range inputs {
+				inputs[i] = countInput(ctx, wg, inputs[i], n.counters, n.cfg.clock, sizeIn)
+			}
+			if output != nil {
+				output = countOutput(ctx, wg, output, n.counters, n.cfg.clock, sizeOut)
+			}
 		}
 
 		errCh := errChan
-		if !commonErrChan {
-			proxyErr := n.proxyErrChan(wg, errChan)
+		if !commonErrChan || n.counters != nil || n.cfg.errorTransform != nil {
+			proxyErr := n.proxyErrChan(wg, errChan, !commonErrChan, RunID(ctx))
 			errCh = proxyErr
 			defer close(proxyErr)
 
 		}
-		n.handler(ctx, input, output, errCh)
+		handler := n.handler
+		if n.selectHandler != nil {
+			handler = func(ctx context.Context, _ <-chan I, output chan<- O, errChan chan<- error) {
+				n.selectHandler(ctx, inputs, output, errChan)
+			}
+		}
+		if n.cfg.middleware != nil {
+			handler = n.applyMiddleware(handler)
+		}
+		n.invoke(ctx, handler, input, output, errCh)
 	}()
 }
 
 // Connect подключает выход from[outIdx] к входу to[inIdx]
-// Помечает выход как занятый. Возвращает ошибку, если индексы неверны.
+// Помечает выход как занятый. Возвращает ошибку, если индексы неверны или выход либо вход уже
+// подключён (ErrSlotOccupied). Подключение узлов
+// (Connect, Autowire, SetInput и т.п.) безопасно вызывать из нескольких горутин до Run.
 func Connect[I, O, T any](from *Node[I, O], outIdx int, to *Node[O, T], inIdx int) error {
+	wiring.Lock()
+	defer wiring.Unlock()
+	return connect(from, outIdx, to, inIdx)
+}
+
+// connect выполняет Connect под мьютексом wiring
+func connect[I, O, T any](from *Node[I, O], outIdx int, to *Node[O, T], inIdx int) error {
+	if err := checkFrozen(from, to); err != nil {
+		return err
+	}
 	if outIdx < 0 || outIdx >= len(from.outputs) {
 		return from.wrapError(ErrOutputIdxOutOfRange)
 	}
@@ -206,27 +556,50 @@ func Connect[I, O, T any](from *Node[I, O], outIdx int, to *Node[O, T], inIdx in
 	if inIdx < 0 || inIdx >= len(to.inputs) {
 		return to.wrapError(ErrInputIdxOutOfRange)
 	}
+	if err := checkSlots(from, outIdx, to, inIdx); err != nil {
+		return err
+	}
 
-	buffSize := 0
-	if from.outputBuffSize != nil {
-		buffSize = from.outputBuffSize[outIdx]
+	if !collapse(from, to, inIdx) {
+		ch := connectChan(from, outIdx, to, inIdx)
+		feedInline(from, outIdx, to, ch)
 	}
-	from.outputs[outIdx] = make(chan O, buffSize)
-	to.inputs[inIdx] = toBidirectional(from.outputs[outIdx])
 	to.occupyInput(inIdx)
 	from.occupyOutput(outIdx)
 
 	return nil
 }
 
-// toBidirectional выполняет каст chan<- T в chan T для bidirectional использования
-func toBidirectional[T any](ch chan<- T) chan T {
-	return *(*chan T)(unsafe.Pointer(&ch))
+// checkFrozen возвращает ErrFrozen, если подключение одного из узлов ребра заморожено (Freeze)
+func checkFrozen[I, O, T any](from *Node[I, O], to *Node[O, T]) error {
+	if from.frozen {
+		return from.wrapError(ErrFrozen)
+	}
+	if to.frozen {
+		return to.wrapError(ErrFrozen)
+	}
+	return nil
+}
+
+// connectChan создаёт канал ребра from[outIdx] -> to[inIdx] с буфером выхода from и возвращает
+// его. Выход и вход получают направленные стороны того же канала.
+func connectChan[I, O, T any](from *Node[I, O], outIdx int, to *Node[O, T], inIdx int) chan O {
+	buffSize := 0
+	if from.outputBuffSize != nil {
+		buffSize = from.outputBuffSize[outIdx]
+	}
+	ch := make(chan O, buffSize)
+	from.outputs[outIdx] = ch
+	to.inputs[inIdx] = ch
+	from.setEdge(outIdx, fmt.Sprintf("%s[%d] -> %s[%d]", from.name, outIdx, to.name, inIdx), ch)
+	return ch
 }
 
 // Autowire автоматически подключает свободные выходы from к свободным входам to-узлов.
 // Возвращает ошибку, если выходы/входы исчерпаны или произошла ошибка подключения.
 func Autowire[I, O, T any](from *Node[I, O], to ...*Node[O, T]) error {
+	wiring.Lock()
+	defer wiring.Unlock()
 	for i := 0; i < len(to); i++ {
 		outIdx := from.vacantOutput()
 		if outIdx == -1 {
@@ -238,29 +611,78 @@ func Autowire[I, O, T any](from *Node[I, O], to ...*Node[O, T]) error {
 			return to[i].wrapError(ErrInputsWired)
 		}
 
-		err := Connect(from, outIdx, to[i], inIdx)
+		err := connect(from, outIdx, to[i], inIdx)
 		if err != nil {
-			return from.wrapError(err)
+			return err
 		}
 	}
 	return nil
 }
 
-// wrapError оборачивает ошибку в префикс с именем узла для удобства отладки
+// NodeError ошибка узла. Текст ошибки имеет стабильный формат "pipeline/node=<имя>: <ошибка>",
+// на который можно опираться при разборе логов.
+type NodeError struct {
+	Node string
+	// RunID идентификатор запуска пайплайна (см. RunID), "" для ошибок вне запуска
+	RunID string
+	Err   error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("pipeline/node=%s: %v", e.Node, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError оборачивает ошибку в NodeError с именем узла. Ошибка, уже обёрнутая этим узлом,
+// возвращается без изменений
 func (n *Node[I, O]) wrapError(err error) error {
-	return fmt.Errorf("[%s] %w", n.name, err)
+	return n.wrapRunError(err, "")
+}
+
+// wrapRunError оборачивает ошибку запуска runID в NodeError (см. wrapError)
+func (n *Node[I, O]) wrapRunError(err error, runID string) error {
+	var ne *NodeError
+	if errors.As(err, &ne) && ne.Node == n.name {
+		return err
+	}
+	return &NodeError{Node: n.name, RunID: runID, Err: err}
 }
 
-// proxyErrChan декоратор для ошибок
-func (n *Node[I, O]) proxyErrChan(wg *sync.WaitGroup, errChan chan<- error) chan<- error {
+// proxyErrChan декоратор для ошибок: подсчитывает ошибки (если включена статистика)
+// и при wrap добавляет к ним имя узла и идентификатор запуска runID
+func (n *Node[I, O]) proxyErrChan(wg *sync.WaitGroup, errChan chan<- error, wrap bool, runID string) chan<- error {
 	proxy := make(chan error, 1)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		for err := range proxy {
-			errChan <- n.wrapError(err)
+			if n.cfg.errorTransform != nil {
+				if err = n.cfg.errorTransform(err); err == nil {
+					if n.counters != nil {
+						n.counters.suppressed.Add(1)
+					}
+					continue
+				}
+			}
+			if n.counters != nil {
+				n.counters.countError()
+			}
+			if wrap {
+				err = n.wrapRunError(err, runID)
+			}
+			errChan <- err
 		}
 	}()
 
 	return proxy
 }
+
+// noCopy запрещает копирование содержащей его структуры: go vet (copylocks) сообщает о копиях
+// значений с методами Lock и Unlock
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
@@ -0,0 +1,137 @@
+package node
+
+import (
+	"context"
+	"errors"
+)
+
+// Handler2 обработчик узла с выходами двух типов (New2). Читает input и отправляет значения типа
+// O1 в out1, типа O2 в out2. Как и Handler, отвечает за закрытие обоих выходов; выход без
+// подключённых каналов равен nil, и его закрывать не нужно.
+type Handler2[I, O1, O2 any] func(ctx context.Context, input <-chan I, out1 chan<- O1, out2 chan<- O2,
+	errChan chan<- error)
+
+// Node2 узел с выходами двух типов, например, разобранные записи для обработки и отклонённые
+// строки для аудита. Вход, первые выходы (O1), опции и запуск принадлежат встроенному Node;
+// вторые выходы (O2) подключаются через ConnectSecond, SetOutput2 и AutowireOutput2.
+type Node2[I, O1, O2 any] struct {
+	*Node[I, O1]
+	// second хранит каналы и рёбра вторых выходов; как самостоятельный узел не запускается
+	second *Node[I, O2]
+}
+
+// New2 создаёт узел с inputNum входами, outputNum1 выходами типа O1 и outputNum2 выходами типа O2.
+// Параметры, опции и паники аналогичны New; рёбра вторых выходов называются "<имя>.second[i] -> ...".
+// Политика перезапуска (WithRestartPolicy) к узлу не применяется.
+func New2[I, O1, O2 any](name string, inputNum int, outputNum1 int, outputBuffSize1 []int, outputNum2 int,
+	outputBuffSize2 []int, handler Handler2[I, O1, O2], opts ...Option) *Node2[I, O1, O2] {
+	if handler == nil {
+		panic("nil handler")
+	}
+
+	cfg := newConfig(opts)
+	cfg.restart = nil
+	name = autoName(name, funcName(handler), cfg)
+	n := &Node2[I, O1, O2]{
+		Node:   newNode[I, O1](name, inputNum, outputNum1, outputBuffSize1, cfg),
+		second: newNode[I, O2](name+".second", 0, outputNum2, outputBuffSize2, newConfig(nil)),
+	}
+	n.handler = func(ctx context.Context, input <-chan I, out1 chan<- O1, errChan chan<- error) {
+		var out2 chan<- O2
+		outputs := n.second.outputs
+		if n.second.unusedMask != 0 {
+			outputs = n.second.discardUnused(ctx, outputs)
+		}
+		if len(outputs) == 1 {
+			out2 = outputs[0]
+		} else {
+			out2 = fanOut(ctx, cfg, outputs)
+		}
+		handler(ctx, input, out1, out2, errChan)
+	}
+	return n
+}
+
+// SetOutput2 устанавливает канал второго выхода по индексу idx (см. SetOutput)
+func (n *Node2[I, O1, O2]) SetOutput2(idx int, output chan<- O2) error {
+	return n.second.SetOutput(idx, output)
+}
+
+// MarkOutputUnused2 помечает второй выход idx как намеренно неиспользуемый (см. MarkOutputUnused)
+func (n *Node2[I, O1, O2]) MarkOutputUnused2(idx int) error {
+	return n.second.MarkOutputUnused(idx)
+}
+
+// Validate проверяет подключение входов, первых и вторых выходов узла (см. Node.Validate)
+func (n *Node2[I, O1, O2]) Validate() error {
+	errs := []error{n.Node.Validate()}
+	wiring.Lock()
+	for _, err := range n.second.unwiredOutputs("second output") {
+		errs = append(errs, n.wrapError(err))
+	}
+	wiring.Unlock()
+	return errors.Join(errs...)
+}
+
+// AutowireOutput2 подключает каналы к свободным вторым выходам (см. AutowireOutput)
+func (n *Node2[I, O1, O2]) AutowireOutput2(output ...chan O2) error {
+	return n.second.AutowireOutput(output...)
+}
+
+// Ports возвращает входы узла и его выходы: сначала первые, затем вторые
+func (n *Node2[I, O1, O2]) Ports() (inputs, outputs []Port) {
+	inputs, outputs = n.Node.Ports()
+	_, second := n.second.Ports()
+	return inputs, append(outputs, second...)
+}
+
+// Stranded возвращает элементы, оставшиеся в буферах первых и вторых выходов (см. Node.Stranded)
+func (n *Node2[I, O1, O2]) Stranded(limit int) []StrandedEdge {
+	return append(n.Node.Stranded(limit), n.second.Stranded(limit)...)
+}
+
+// BufferUsage возвращает заполненность буферов первых и вторых выходов (см. Node.BufferUsage)
+func (n *Node2[I, O1, O2]) BufferUsage() []BufferUsage {
+	return append(n.Node.BufferUsage(), n.second.BufferUsage()...)
+}
+
+// WiringComplete сообщает, что все входы, первые и вторые выходы узла подключены
+func (n *Node2[I, O1, O2]) WiringComplete() bool {
+	return n.Node.WiringComplete() && n.second.WiringComplete()
+}
+
+// Freeze запрещает подключение входов, первых и вторых выходов узла (см. Node.Freeze)
+func (n *Node2[I, O1, O2]) Freeze() {
+	n.Node.Freeze()
+	n.second.Freeze()
+}
+
+// ConnectFirst подключает первый выход from[outIdx] к входу to[inIdx] (см. Connect)
+func ConnectFirst[I, O1, O2, T any](from *Node2[I, O1, O2], outIdx int, to *Node[O1, T], inIdx int) error {
+	return Connect(from.Node, outIdx, to, inIdx)
+}
+
+// ConnectSecond подключает второй выход from[outIdx] к входу to[inIdx]. Встраиваемый узел
+// (WithInline) на втором выходе не схлопывается и работает как обычный.
+func ConnectSecond[I, O1, O2, T any](from *Node2[I, O1, O2], outIdx int, to *Node[O2, T], inIdx int) error {
+	wiring.Lock()
+	defer wiring.Unlock()
+	if err := checkFrozen(from.second, to); err != nil {
+		return err
+	}
+	if outIdx < 0 || outIdx >= len(from.second.outputs) {
+		return from.wrapError(ErrOutputIdxOutOfRange)
+	}
+
+	if inIdx < 0 || inIdx >= len(to.inputs) {
+		return to.wrapError(ErrInputIdxOutOfRange)
+	}
+	if err := checkSlots(from.second, outIdx, to, inIdx); err != nil {
+		return err
+	}
+
+	connectChan(from.second, outIdx, to, inIdx)
+	to.occupyInput(inIdx)
+	from.second.occupyOutput(outIdx)
+	return nil
+}
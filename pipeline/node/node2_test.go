@@ -0,0 +1,121 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// parseOrReject обработчик New2: числа уходят в первый выход, остальные строки — во второй
+func parseOrReject(ctx context.Context, input <-chan string, out1 chan<- int, out2 chan<- string, _ chan<- error) {
+	defer closeOutput(out1)
+	defer closeOutput(out2)
+	for line := range input {
+		if v, err := strconv.Atoi(line); err == nil {
+			out1 <- v
+		} else {
+			out2 <- line
+		}
+	}
+}
+
+// collectSink приёмник, собирающий значения под мьютексом
+func collectSink[T any](name string) (*Node[T, struct{}], func() []T) {
+	var mu sync.Mutex
+	var got []T
+	sink := NewSink(name, 1, func(_ context.Context, v T) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, v)
+		return nil
+	})
+	return sink, func() []T {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(got)
+	}
+}
+
+func TestNew2(t *testing.T) {
+	lines := []string{"1", "x", "2", "y", "3"}
+	tests := []struct {
+		name string
+		// rejects второй выход подключён к приёмнику, иначе помечен неиспользуемым
+		rejects     bool
+		wantRecords []int
+		wantRejects []string
+	}{
+		{"both outputs", true, []int{1, 2, 3}, []string{"x", "y"}},
+		// значения неиспользуемого второго выхода отбрасываются, обработчик не блокируется
+		{"second unused", false, []int{1, 2, 3}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := New2("parse", 1, 1, nil, 1, nil, parseOrReject)
+			if err := n.SetInput(0, feed(lines...)); err != nil {
+				t.Fatal(err)
+			}
+			records, gotRecords := collectSink[int]("records")
+			rejects, gotRejects := collectSink[string]("rejects")
+			if err := ConnectFirst(n, 0, records, 0); err != nil {
+				t.Fatal(err)
+			}
+			nodes := []runner{n, records}
+			if tt.rejects {
+				if err := ConnectSecond(n, 0, rejects, 0); err != nil {
+					t.Fatal(err)
+				}
+				nodes = append(nodes, rejects)
+			} else if err := n.MarkOutputUnused2(0); err != nil {
+				t.Fatal(err)
+			}
+			if err := n.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			if errs := runNodes(t, context.Background(), nodes...); len(errs) > 0 {
+				t.Errorf("errors: %v", errs)
+			}
+			if !slices.Equal(gotRecords(), tt.wantRecords) {
+				t.Errorf("records %v, want %v", gotRecords(), tt.wantRecords)
+			}
+			if !slices.Equal(gotRejects(), tt.wantRejects) {
+				t.Errorf("rejects %v, want %v", gotRejects(), tt.wantRejects)
+			}
+		})
+	}
+}
+
+func TestNew2Wiring(t *testing.T) {
+	n := New2("parse", 1, 1, nil, 1, []int{3}, parseOrReject)
+	rejects, _ := collectSink[string]("rejects")
+
+	// неподключённый второй выход — ошибка проверки
+	if err := n.SetInput(0, make(chan string)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.SetOutput(0, make(chan int)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Validate(); !errors.Is(err, ErrUnwired) {
+		t.Errorf("Validate = %v, want %v", err, ErrUnwired)
+	}
+	if err := ConnectSecond(n, 1, rejects, 0); !errors.Is(err, ErrOutputIdxOutOfRange) {
+		t.Errorf("ConnectSecond to output 1 = %v, want %v", err, ErrOutputIdxOutOfRange)
+	}
+	if err := ConnectSecond(n, 0, rejects, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	// выходы в Ports: сначала первые, затем вторые, со своими буферами
+	_, outputs := n.Ports()
+	if len(outputs) != 2 || outputs[0].Cap != 0 || outputs[1].Cap != 3 {
+		t.Errorf("outputs %+v, want the first then the second output with buffer 3", outputs)
+	}
+}
@@ -0,0 +1,90 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestNodeErrorFormat(t *testing.T) {
+	errBoom := errors.New("boom")
+	tests := []struct {
+		name string
+		// sent ошибка, которую обработчик отправляет в errChan
+		sent      error
+		wantText  string
+		wantRunID string
+		// wantInner узел вложенной NodeError, "" если её нет
+		wantInner string
+	}{
+		{"plain", errBoom, "pipeline/node=parse: boom", "run-1", ""},
+		{"wrapped sentinel", fmt.Errorf("line %d: %w", 3, errBoom), "pipeline/node=parse: line 3: boom", "run-1", ""},
+		// ошибка, уже обёрнутая этим узлом, возвращается как есть
+		{"already wrapped", &NodeError{Node: "parse", Err: errBoom}, "pipeline/node=parse: boom", "", ""},
+		{"other node", &NodeError{Node: "read", Err: errBoom}, "pipeline/node=parse: pipeline/node=read: boom",
+			"run-1", "read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := New("parse", 0, 0, nil, func(_ context.Context, _ <-chan struct{}, _ chan<- struct{}, errChan chan<- error) {
+				errChan <- tt.sent
+			})
+			// узел с собственным каналом ошибок (не общим) сам добавляет к ним своё имя
+			errChan := make(chan error, 1)
+			var wg sync.WaitGroup
+			n.Run(ContextWithRunID(context.Background(), "run-1"), &wg, errChan, false)
+			waitGroup(t, &wg)
+			err := <-errChan
+
+			if err.Error() != tt.wantText {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantText)
+			}
+			if !errors.Is(err, errBoom) {
+				t.Errorf("errors.Is(%v, errBoom) = false", err)
+			}
+			var ne *NodeError
+			if !errors.As(err, &ne) || ne.Node != "parse" {
+				t.Fatalf("errors.As found %+v, want the NodeError of parse", ne)
+			}
+			if ne.RunID != tt.wantRunID {
+				t.Errorf("RunID = %q, want %q", ne.RunID, tt.wantRunID)
+			}
+			if tt.wantInner != "" {
+				var inner *NodeError
+				if !errors.As(ne.Err, &inner) || inner.Node != tt.wantInner {
+					t.Errorf("inner NodeError %+v, want node %s", inner, tt.wantInner)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeErrorOutsideRun(t *testing.T) {
+	n := NewMap("parse", func(_ context.Context, v int) (int, error) { return v, nil })
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+		wantText string
+	}{
+		{"wiring", n.SetInput(5, make(chan int)), ErrInputIdxOutOfRange, "pipeline/node=parse: input index out of range"},
+		{"command", n.Control(Command{Kind: CmdFlush}), ErrUnsupportedCommand, "pipeline/node=parse: unsupported command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil || tt.err.Error() != tt.wantText {
+				t.Fatalf("error = %v, want %q", tt.err, tt.wantText)
+			}
+			if !errors.Is(tt.err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", tt.err, tt.sentinel)
+			}
+			// ошибки вне запуска не имеют идентификатора запуска
+			var ne *NodeError
+			if !errors.As(tt.err, &ne) || ne.Node != "parse" || ne.RunID != "" {
+				t.Errorf("errors.As found %+v, want NodeError of parse without RunID", ne)
+			}
+		})
+	}
+}
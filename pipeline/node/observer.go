@@ -0,0 +1,50 @@
+package node
+
+import "time"
+
+// EventKind вид события узла
+type EventKind int
+
+const (
+	EventCircuitOpen EventKind = iota
+	EventCircuitHalfOpen
+	EventCircuitClosed
+	// EventBypassOn, EventBypassOff переход узла в режим обхода и выход из него (WithErrorRateBreaker)
+	EventBypassOn
+	EventBypassOff
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventCircuitOpen:
+		return "circuit open"
+	case EventCircuitHalfOpen:
+		return "circuit half-open"
+	case EventCircuitClosed:
+		return "circuit closed"
+	case EventBypassOn:
+		return "bypass on"
+	case EventBypassOff:
+		return "bypass off"
+	default:
+		return "unknown"
+	}
+}
+
+// Event событие узла, передаваемое в Observer
+type Event struct {
+	Node string
+	Kind EventKind
+	At   time.Time
+}
+
+// Observer получает события узлов. Вызывается синхронно из горутин узла, поэтому
+// не должен блокироваться.
+type Observer func(Event)
+
+// notify передаёт событие наблюдателю, если он задан
+func (c *config) notify(name string, kind EventKind) {
+	if c.observer != nil {
+		c.observer(Event{Node: name, Kind: kind, At: c.clock.Now()})
+	}
+}
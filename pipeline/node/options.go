@@ -0,0 +1,210 @@
+package node
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Option настраивает поведение узла. Опции, не имеющие смысла для конкретного вида узла
+// (например, повторы для узла с произвольным Handler), им игнорируются.
+type Option func(*config)
+
+// config содержит параметры узла, задаваемые через Option
+type config struct {
+	retries       int
+	backoff       func(attempt int) time.Duration
+	timeout       time.Duration
+	stats         bool
+	concurrency   int
+	ordered       bool
+	clock         Clock
+	observer      Observer
+	deadLetter    chan<- DeadLetter
+	breaker       *breakerConfig
+	init          func(ctx context.Context) error
+	close         func() error
+	flush         func(ctx context.Context) error
+	flushTimeout  time.Duration
+	restart       *restartPolicy
+	controller    Controllable
+	fanOut        FanOutStrategy
+	fanOutWeights []int
+	earlyExit     EarlyExitPolicy
+	infinite      bool
+	cardinality   Cardinality
+	params        any
+	strictJSON    bool
+	collect       bool
+	exitErrors    bool
+	fanInTree     int
+	fanInBranch   int
+	bufAdvisor    *bufAdvisor
+	panicPolicy   PanicPolicy
+	inline        bool
+	reorderWindow int
+	gapPolicy     GapPolicy
+	keepPartial   bool
+	fsync         bool
+	pausable      bool
+	// errorTransform преобразование ошибок узла (WithErrorTransform)
+	errorTransform func(error) error
+	// nameTemplate шаблон имени узла, созданного с пустым именем (WithNameTemplate)
+	nameTemplate string
+	// gated узел сам проверяет gate перед чтением входа (узлы Map-стиля)
+	gated bool
+	gate  *gate
+	// inFlight элементы, переданные функции узла Map-стиля и ещё не обработанные
+	inFlight atomic.Int64
+	// itemSize оценка размера элемента выхода (WithItemSize); held ключи и элементы, накопленные
+	// узлом-агрегатором
+	itemSize int
+	held     atomic.Int64
+	// cpuAccounting учёт времени работы функции узла (WithCPUAccounting), busy накопленное время в нс
+	cpuAccounting bool
+	busy          atomic.Int64
+	// shed значения, отброшенные функцией узла после отказа Emitter.Try
+	shed atomic.Uint64
+	// itemDelay задержка узла Delay в зависимости от значения (func(T) time.Duration)
+	itemDelay any
+	// sizeFunc размер элемента входа или выхода в байтах (WithSizeFunc, func(T) int)
+	sizeFunc any
+	// completionMarker функция WithCompletionMarker
+	completionMarker func(ctx context.Context, stats Stats) error
+	// inputLabels, inputWeights метки входов и их веса (WithFairFanIn)
+	inputLabels  []string
+	inputWeights map[string]int
+	// statsGate шлюз согласованного снимка счётчиков (SetStatsGate)
+	statsGate *StatsGate
+	// rateBreaker параметры обхода по доле ошибок (WithErrorRateBreaker)
+	rateBreaker *rateBreakerConfig
+	// emitOnEmpty фабрика результата для пустого входа (WithEmitOnEmpty, func() O), writeEmpty
+	// создание пустого результата приёмниками (WithWriteEmptyResult)
+	emitOnEmpty any
+	writeEmpty  bool
+	// inputBuffSize буферы входов, создаваемых узлом (WithInputBuffers), outputBuffSize буферы
+	// выходов (WithOutputBuffers), name имя узла (WithName)
+	inputBuffSize  []int
+	outputBuffSize []int
+	name           string
+	// ports количество входов и выходов узлов без параметров inputNum и outputNum (WithPorts), nil —
+	// один вход и один выход
+	ports *[2]int
+	// middleware обёртки обработчика (WithMiddleware, Middleware[I, O])
+	middleware []any
+	// inputClosed обработчик узла прочитал закрытие входа в текущем запуске (см. Node.ExitReason)
+	inputClosed atomic.Bool
+	// trackExit вход обработчика пересылается через relayInput (WithExitTracking)
+	trackExit bool
+}
+
+// newConfig применяет опции к конфигурации по умолчанию
+func newConfig(opts []Option) *config {
+	cfg := &config{clock: realClock{}, gate: &gate{}, fanInTree: DefaultFanInTreeThreshold, fanInBranch: DefaultFanInBranching}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cfg)
+		}
+	}
+	return cfg
+}
+
+// WithRetry задаёт количество повторных вызовов функции узла (Map-стиль) при ошибке.
+// backoff возвращает паузу перед повтором с номером attempt (начиная с 1), nil означает повтор без паузы.
+func WithRetry(retries int, backoff func(attempt int) time.Duration) Option {
+	return func(c *config) {
+		c.retries = max(retries, 0)
+		c.backoff = backoff
+	}
+}
+
+// WithTimeout ограничивает время одного вызова функции узла (Map-стиль). Контекст вызова
+// отменяется по истечении d. Нулевое значение снимает ограничение.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// WithStats включает подсчёт статистики узла (см. Node.Stats). Без опции каналы узла
+// не оборачиваются и накладных расходов нет.
+func WithStats() Option {
+	return func(c *config) {
+		c.stats = true
+	}
+}
+
+// WithCPUAccounting включает учёт времени, проведённого в функции узла Map-стиля (NewMap, NewSink,
+// NewFlatMap, Loop и т.п.), доступного в Stats.Busy. На Linux на время вызова горутина закрепляется
+// за потоком ОС и считается процессорное время этого потока: работа горутин, запущенных функцией,
+// не учитывается, а ожидание ввода-вывода, таймеров и каналов не считается занятым временем. На
+// остальных системах время измеряется по часам вокруг вызова и приближает процессорное только для
+// функций, которые не блокируются. При WithConcurrency время параллельных вызовов суммируется.
+func WithCPUAccounting() Option {
+	return func(c *config) {
+		c.cpuAccounting = true
+	}
+}
+
+// WithConcurrency задаёт количество элементов, обрабатываемых узлом (Map-стиль) одновременно
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		c.concurrency = n
+	}
+}
+
+// WithOrderedOutput сохраняет порядок входных элементов на выходе при параллельной обработке
+// (WithConcurrency). Медленный элемент задерживает выдачу всех последующих.
+func WithOrderedOutput() Option {
+	return func(c *config) {
+		c.ordered = true
+	}
+}
+
+// WithClock подменяет источник времени узла (таймауты выключателя, паузы повторов и т.п.)
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithObserver задаёт наблюдателя за событиями узла
+func WithObserver(o Observer) Option {
+	return func(c *config) {
+		c.observer = o
+	}
+}
+
+// WithDeadLetter задаёт канал для элементов, которые узел не смог обработать (см. DeadLetter).
+// Канал может быть общим для нескольких узлов, его закрытие ответственность клиента.
+func WithDeadLetter(ch chan<- DeadLetter) Option {
+	return func(c *config) {
+		c.deadLetter = ch
+	}
+}
+
+// WithErrorTransform задаёт преобразование ошибок узла перед отправкой в канал ошибок (до обёртки
+// именем узла): например, чтобы добавить к ошибке сведения об элементе или заменить ошибку
+// доменной. Если fn возвращает nil, ошибка подавляется и учитывается в Stats.Suppressed. Класс
+// ошибки (Classify) сохраняется, если fn возвращает исходную ошибку или оборачивает её через %w.
+func WithErrorTransform(fn func(error) error) Option {
+	return func(c *config) {
+		c.errorTransform = fn
+	}
+}
+
+// sleep ожидает d по часам clock с учётом отмены контекста. Возвращает false, если контекст отменён.
+func sleep(ctx context.Context, clock Clock, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-clock.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
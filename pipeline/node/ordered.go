@@ -0,0 +1,233 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+var ErrLateItem = errors.New("item arrived after its reorder gap was skipped")
+
+// GapPolicy поведение упорядоченной стадии, когда окно переупорядочивания заполнено, а очередной
+// по порядку элемент ещё обрабатывается
+type GapPolicy int
+
+const (
+	// GapBlock ждать медленный элемент: новые элементы не принимаются, пока он не будет выдан
+	GapBlock GapPolicy = iota
+	// GapSkip выдать следующие элементы с пропуском, когда все остальные элементы окна уже
+	// обработаны. Пропущенный элемент, обработанный позже, отправляется как DeadLetter с ErrLateItem.
+	GapSkip
+)
+
+// WithReorderWindow задаёт размер окна переупорядочивания упорядоченной стадии (NewOrderedStage) —
+// максимальное количество элементов, одновременно находящихся в обработке, — и поведение при
+// медленном элементе. По умолчанию окно вдвое больше количества реплик, политика GapBlock.
+func WithReorderWindow(size int, gap GapPolicy) Option {
+	return func(c *config) {
+		c.reorderWindow = size
+		c.gapPolicy = gap
+	}
+}
+
+// HasOrderedOutput сообщает, включён ли WithOrderedOutput в opts. Используется сборщиками
+// составных стадий (например, pipeline.MapReduce) для выбора схемы соединения.
+func HasOrderedOutput(opts ...Option) bool {
+	return newConfig(opts).ordered
+}
+
+// Sequenced элемент с порядковым номером, присвоенным упорядоченной стадией
+type Sequenced[T any] struct {
+	Seq uint64
+	Val T
+	// skip элемент не дал результата (ошибка обработки), номер только освобождает место в окне
+	skip bool
+}
+
+// OrderedStage параллельная стадия из реплик, выдающая результаты в порядке входа: Sequencer
+// нумерует входные элементы и распределяет их по Workers, Reorder восстанавливает порядок.
+// Узлы стадии уже соединены между собой; вход Sequencer и выход Reorder подключает вызывающий,
+// все узлы нужно добавить в пайплайн.
+type OrderedStage[I, O any] struct {
+	Sequencer *Node[I, Sequenced[I]]
+	Workers   []*Node[Sequenced[I], Sequenced[O]]
+	Reorder   *Node[Sequenced[O], O]
+}
+
+// NewOrderedStage создаёт упорядоченную стадию из workers реплик, применяющих f. Реплики
+// поддерживают те же опции, что и NewMap (кроме WithCircuitBreaker); ошибки f отправляются в канал
+// ошибок реплики. Размер окна и поведение при медленном элементе задаёт WithReorderWindow.
+// Паникует, если workers < 1 или f nil.
+func NewOrderedStage[I, O any](name string, workers int, f MapFn[I, O], opts ...Option) *OrderedStage[I, O] {
+	if workers < 1 {
+		panic("workers must be positive")
+	}
+	if f == nil {
+		panic("nil map func")
+	}
+
+	name = autoNameOpts(name, funcName(f), opts)
+	size := newConfig(opts).reorderWindow
+	if size < 1 {
+		size = 2 * workers
+	}
+	slots := make(chan struct{}, size)
+
+	seqCfg := newConfig(opts)
+	stage := &OrderedStage[I, O]{
+		Sequencer: newNode[I, Sequenced[I]](name+" sequencer", 1, 1, nil, seqCfg),
+		Workers:   make([]*Node[Sequenced[I], Sequenced[O]], workers),
+	}
+	stage.Sequencer.handler = sequence[I](slots, seqCfg)
+	reorderName := name + " reorder"
+	reorderCfg := newConfig(opts)
+	stage.Reorder = newNode[Sequenced[O], O](reorderName, workers, 1, nil, reorderCfg)
+	stage.Reorder.handler = reorder[O](reorderName, slots, reorderCfg)
+
+	// реплики читают общий канал: элемент достаётся свободной реплике и не ждёт за медленным
+	tasks := make(chan Sequenced[I])
+	_ = stage.Sequencer.SetOutput(0, tasks)
+	for i := range stage.Workers {
+		stage.Workers[i] = newSequencedMap(fmt.Sprintf("%s %d", name, i), f, opts)
+		_ = stage.Workers[i].SetInput(0, tasks)
+		if err := Connect(stage.Workers[i], 0, stage.Reorder, i); err != nil {
+			panic(err)
+		}
+	}
+	return stage
+}
+
+// sequence обработчик, нумерующий входные элементы. Перед выдачей каждого элемента занимает
+// место в окне slots, которое освобождает reorder.
+func sequence[T any](slots chan struct{}, cfg *config) Handler[T, Sequenced[T]] {
+	return func(ctx context.Context, input <-chan T, output chan<- Sequenced[T], errChan chan<- error) {
+		defer close(output)
+		var seq uint64
+		for {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					return
+				}
+				if Yield(ctx) != nil {
+					return
+				}
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case output <- Sequenced[T]{Seq: seq, Val: val}:
+					seq++
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// newSequencedMap создаёт реплику, применяющую f к значению с сохранением номера. При ошибке f
+// ошибка отправляется в канал ошибок, а дальше уходит пустой номер, чтобы не задерживать порядок.
+func newSequencedMap[I, O any](name string, f MapFn[I, O], opts []Option) *Node[Sequenced[I], Sequenced[O]] {
+	cfg := newConfig(opts)
+	cfg.gated = true
+	// порядок восстанавливает reorder; упорядочивание внутри реплики задерживало бы элементы
+	cfg.ordered = false
+	handler := func(ctx context.Context, input <-chan Sequenced[I], output chan<- Sequenced[O], errChan chan<- error) {
+		defer close(output)
+		runItems(ctx, cfg, input, output, errChan, func(ctx context.Context, in Sequenced[I]) (Sequenced[O], error) {
+			out, err := call(ctx, cfg, f, in.Val)
+			if err != nil {
+				if ctx.Err() != nil {
+					return Sequenced[O]{}, err
+				}
+				cfg.sendError(ctx, errChan, err)
+				return Sequenced[O]{Seq: in.Seq, skip: true}, nil
+			}
+			return Sequenced[O]{Seq: in.Seq, Val: out}, nil
+		})
+	}
+
+	n := newNode[Sequenced[I], Sequenced[O]](name, 1, 1, []int{1}, cfg)
+	n.handler = handler
+	return n
+}
+
+// reorder обработчик, выдающий значения в порядке номеров и освобождающий их места в окне slots
+func reorder[T any](name string, slots chan struct{}, cfg *config) Handler[Sequenced[T], T] {
+	return func(ctx context.Context, input <-chan Sequenced[T], output chan<- T, errChan chan<- error) {
+		defer close(output)
+
+		var next uint64
+		pending := make(map[uint64]Sequenced[T])
+		skipped := make(map[uint64]struct{})
+		emit := func(item Sequenced[T]) bool {
+			<-slots
+			if item.skip {
+				return true
+			}
+			select {
+			case output <- item.Val:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case item, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					// все реплики завершились: выдаём оставшееся по порядку
+					for _, seq := range slices.Sorted(maps.Keys(pending)) {
+						if !emit(pending[seq]) {
+							return
+						}
+					}
+					return
+				}
+				if Yield(ctx) != nil {
+					return
+				}
+
+				if _, late := skipped[item.Seq]; late {
+					delete(skipped, item.Seq)
+					if !item.skip {
+						cfg.sendError(ctx, errChan, &DeadLetter{Node: name, Item: item.Val, Err: ErrLateItem})
+					}
+					continue
+				}
+				pending[item.Seq] = item
+
+				for {
+					head, ok := pending[next]
+					if !ok {
+						if cfg.gapPolicy != GapSkip || len(pending) == 0 || len(pending) < cap(slots)-1 {
+							break
+						}
+						// все элементы окна, кроме очередного, обработаны: пропускаем медленный
+						skipped[next] = struct{}{}
+						<-slots
+						next++
+						continue
+					}
+					delete(pending, next)
+					next++
+					if !emit(head) {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// slowFirst функция, обрабатывающая каждый четвёртый элемент дольше остальных: без упорядочивания
+// следующие за ним элементы обгоняют его
+func slowFirst(_ context.Context, v int) (int, error) {
+	if v%4 == 0 {
+		time.Sleep(2 * time.Millisecond)
+	}
+	return v * 10, nil
+}
+
+func TestOrderedOutput(t *testing.T) {
+	const items = 40
+	n := NewMap("map", slowFirst, WithConcurrency(4), WithOrderedOutput())
+	got, errs := process(t, n, seq(items)...)
+	if len(errs) != 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	want := make([]int, items)
+	for i := range want {
+		want[i] = i * 10
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("output %v, want input order %v", got, want)
+	}
+}
+
+func TestOrderedStage(t *testing.T) {
+	const items = 50
+	errSeventh := errors.New("seventh")
+	tests := []struct {
+		name    string
+		workers int
+		opts    []Option
+	}{
+		{"single worker", 1, nil},
+		{"workers", 4, nil},
+		{"narrow window", 4, []Option{WithReorderWindow(2, GapBlock)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage := NewOrderedStage("stage", tt.workers, func(ctx context.Context, v int) (int, error) {
+				if v%7 == 0 {
+					return 0, errSeventh
+				}
+				return slowFirst(ctx, v)
+			}, tt.opts...)
+			if err := stage.Sequencer.SetInput(0, feed(seq(items)...)); err != nil {
+				t.Fatal(err)
+			}
+			out := make(chan int)
+			if err := stage.Reorder.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			got := drain(out)
+
+			errs := runNodes(t, context.Background(), stageNodes(stage)...)
+			// элемент с ошибкой не задерживает последующие и не попадает в выход
+			var want []int
+			for v := range items {
+				if v%7 != 0 {
+					want = append(want, v*10)
+				}
+			}
+			if !slices.Equal(got(), want) {
+				t.Errorf("output %v, want %v", got(), want)
+			}
+			if len(errs) != (items+6)/7 {
+				t.Errorf("got %d errors, want %d: %v", len(errs), (items+6)/7, errs)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, errSeventh) {
+					t.Errorf("unexpected error %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderedStageGapSkip(t *testing.T) {
+	release := make(chan struct{})
+	stage := NewOrderedStage("stage", 3, func(_ context.Context, v int) (int, error) {
+		if v == 0 {
+			<-release
+		}
+		return v, nil
+	}, WithReorderWindow(3, GapSkip))
+	// окно вмещает ровно три элемента: пропустить можно только 0, когда обработаны 1 и 2
+	if err := stage.Sequencer.SetInput(0, feed(seq(3)...)); err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan int)
+	if err := stage.Reorder.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+	// медленный элемент 0 отпускается после выдачи остальных
+	var got []int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for v := range out {
+			got = append(got, v)
+			if len(got) == 2 {
+				close(release)
+			}
+		}
+	}()
+
+	errs := runNodes(t, context.Background(), stageNodes(stage)...)
+	<-done
+	if want := []int{1, 2}; !slices.Equal(got, want) {
+		t.Errorf("output %v, want %v", got, want)
+	}
+	var dl *DeadLetter
+	if len(errs) != 1 || !errors.As(errs[0], &dl) || !errors.Is(errs[0], ErrLateItem) || dl.Item != 0 {
+		t.Errorf("errors = %v, want a late dead letter of item 0", errs)
+	}
+}
+
+// stageNodes возвращает все узлы упорядоченной стадии
+func stageNodes[I, O any](stage *OrderedStage[I, O]) []runner {
+	nodes := []runner{stage.Sequencer, stage.Reorder}
+	for _, w := range stage.Workers {
+		nodes = append(nodes, w)
+	}
+	return nodes
+}
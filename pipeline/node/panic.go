@@ -0,0 +1,82 @@
+package node
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+// PanicPolicy определяет поведение узла при панике обработчика. Нулевое значение означает
+// политику пайплайна (ContextWithPanicPolicy), а при её отсутствии — PanicToError.
+type PanicPolicy int
+
+const (
+	// PanicPropagate паника не перехватывается и завершает процесс. Значением паники
+	// становится *PanicError с исходным значением и стеком.
+	PanicPropagate PanicPolicy = iota + 1
+	// PanicToError паника преобразуется в *PanicError, отправляемую в канал ошибок узла
+	// обёрнутой в NodeError с именем узла; выход узла закрывается, остальные узлы продолжают
+	// работу (по умолчанию). Чтобы вышестоящие узлы не заблокировались на отправке, вход
+	// дочитывается до закрытия, в том числе при EarlyExitKeep; при EarlyExitCancel пайплайн
+	// отменяется.
+	PanicToError
+	// PanicCancelPipeline как PanicToError, но дополнительно отменяет пайплайн
+	PanicCancelPipeline
+)
+
+// WithPanicPolicy задаёт политику паники узла, переопределяя политику пайплайна.
+// При заданной WithRestartPolicy паника приводит к перезапуску, и политика не применяется.
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(c *config) {
+		c.panicPolicy = policy
+	}
+}
+
+// panicPolicyFor возвращает действующую политику паники узла
+func (c *config) panicPolicyFor(ctx context.Context) PanicPolicy {
+	if c.panicPolicy != 0 {
+		return c.panicPolicy
+	}
+	if p, ok := ctx.Value(panicKey{}).(PanicPolicy); ok && p != 0 {
+		return p
+	}
+	return PanicToError
+}
+
+// newPanicError создаёт PanicError из восстановленного значения. Значение, уже являющееся
+// *PanicError (паника, перенесённая из другой горутины узла), возвращается как есть.
+func newPanicError(r any) *PanicError {
+	if pe, ok := r.(*PanicError); ok {
+		return pe
+	}
+	return &PanicError{Value: r, Stack: debug.Stack()}
+}
+
+// runHandler вызывает обработчик, применяя к панике политику узла. Повторное закрытие выхода
+// и отправка в закрытый выход не считаются паникой: об ошибке сообщается в errChan.
+func (n *Node[I, O]) runHandler(ctx context.Context, h Handler[I, O], input <-chan I, output chan<- O,
+	errChan chan<- error) {
+	policy := n.cfg.panicPolicyFor(ctx)
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if err := outputMisuse(r, output); err != nil {
+			errChan <- err
+			return
+		}
+
+		pe := newPanicError(r)
+		if policy == PanicPropagate {
+			panic(pe)
+		}
+
+		n.setExit(ExitPanicked)
+		closeQuietly(output)
+		errChan <- n.wrapRunError(pe, RunID(ctx))
+		if policy == PanicCancelPipeline {
+			cancelPipeline(ctx)
+		}
+	}()
+	h(ctx, input, output, errChan)
+}
@@ -0,0 +1,22 @@
+package node
+
+import "context"
+
+// paramsKey ключ контекста для параметров узла
+type paramsKey struct{}
+
+// WithParams задаёт параметры узла произвольного типа (например, структуру конфигурации).
+// Узел сохраняет их в контексте, передаваемом обработчику, функциям Map-стиля и хукам
+// жизненного цикла; получить их можно через Params. Позволяет параметризовать узлы одного
+// вида без замыканий.
+func WithParams(p any) Option {
+	return func(c *config) {
+		c.params = p
+	}
+}
+
+// Params возвращает параметры узла, заданные WithParams, если они имеют тип T
+func Params[T any](ctx context.Context) (T, bool) {
+	p, ok := ctx.Value(paramsKey{}).(T)
+	return p, ok
+}
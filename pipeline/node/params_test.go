@@ -0,0 +1,59 @@
+package node
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+type scaleParams struct {
+	Factor int
+}
+
+func TestWithParams(t *testing.T) {
+	// одна функция для узлов одного вида, параметры приходят из контекста
+	scale := func(ctx context.Context, v int) (int, error) {
+		p, ok := Params[scaleParams](ctx)
+		if !ok {
+			return -1, nil
+		}
+		return v * p.Factor, nil
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		want []int
+	}{
+		{"double", []Option{WithParams(scaleParams{Factor: 2})}, []int{0, 2, 4}},
+		{"triple", []Option{WithParams(scaleParams{Factor: 3})}, []int{0, 3, 6}},
+		{"no params", nil, []int{-1, -1, -1}},
+		// параметры другого типа не возвращаются
+		{"other type", []Option{WithParams("2")}, []int{-1, -1, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := process(t, NewMap("scale", scale, tt.opts...), seq(3)...)
+			if len(errs) > 0 {
+				t.Errorf("errors: %v", errs)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("output = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithParamsHandler(t *testing.T) {
+	var got scaleParams
+	var ok bool
+	n := New("raw", 0, 0, nil, func(ctx context.Context, _ <-chan struct{}, _ chan<- struct{}, _ chan<- error) {
+		got, ok = Params[scaleParams](ctx)
+	}, WithParams(scaleParams{Factor: 5}))
+
+	if errs := runNodes(t, context.Background(), n); len(errs) > 0 {
+		t.Errorf("errors: %v", errs)
+	}
+	if !ok || got.Factor != 5 {
+		t.Errorf("Params in handler = %+v, %v; want Factor 5", got, ok)
+	}
+}
@@ -0,0 +1,97 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// NewPool создаёт узел, запускающий replicas копий handler: копии читают общий вход (после
+// слияния входов узла) и пишут в общий выход, который закрывается один раз, после завершения всех
+// копий. Для пайплайна это один узел с одним именем. Ошибки копий отправляются в канал ошибок с
+// номером копии ("replica 2: ..."). Паника копии отменяет контекст остальных копий и после их
+// завершения обрабатывается по политике паники узла. Количество входов и выходов задаёт WithPorts,
+// буферы выходов — WithOutputBuffers; остальные опции и паники аналогичны New, кроме того,
+// паникует, если replicas < 1.
+func NewPool[I, O any](name string, replicas int, handler Handler[I, O], opts ...Option) *Node[I, O] {
+	if handler == nil {
+		panic("nil handler")
+	}
+	if replicas < 1 {
+		panic("replicas must be positive")
+	}
+
+	cfg := newConfig(opts)
+	inputNum, outputNum := cfg.portCounts()
+	n := newNode[I, O](autoName(name, funcName(handler), cfg), inputNum, outputNum, nil, cfg)
+	n.handler = replicate(handler, replicas)
+	return n
+}
+
+// replicate возвращает обработчик, запускающий replicas копий h на общих входе и выходе
+func replicate[I, O any](h Handler[I, O], replicas int) Handler[I, O] {
+	return func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+		defer closeOutput(output)
+
+		replicaCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var panicked *PanicError
+
+		for i := range replicas {
+			var out chan O
+			if output != nil {
+				out = make(chan O)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for v := range out {
+						select {
+						case output <- v:
+						case <-ctx.Done():
+						}
+					}
+				}()
+			}
+
+			errs := make(chan error)
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for err := range errs {
+					errChan <- fmt.Errorf("replica %d: %w", i, err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				defer close(errs)
+				defer func() {
+					r := recover()
+					if r == nil {
+						return
+					}
+					if err := outputMisuse(r, chan<- O(out)); err != nil {
+						errs <- err
+						return
+					}
+					closeQuietly(chan<- O(out))
+					mu.Lock()
+					if panicked == nil {
+						panicked = newPanicError(r)
+					}
+					mu.Unlock()
+					cancel()
+				}()
+				h(replicaCtx, input, out, errs)
+				// копия, не закрывшая свой выход, не должна задерживать закрытие общего
+				closeQuietly(chan<- O(out))
+			}()
+		}
+
+		wg.Wait()
+		if panicked != nil {
+			panic(panicked)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolReplicas(t *testing.T) {
+	const perInput = 50
+	errBad := errors.New("bad")
+	tests := []struct {
+		name            string
+		replicas        int
+		inputs, outputs int
+		failEvery       int
+	}{
+		{"single replica", 1, 1, 1, 0},
+		{"replicas", 3, 1, 1, 0},
+		{"fan-in fan-out", 8, 2, 3, 0},
+		{"replica errors", 4, 2, 1, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// каждая копия начинает чтение, только когда запущены все: пул с меньшим числом
+			// одновременно работающих копий не завершится
+			var started atomic.Int32
+			all := make(chan struct{})
+			handler := func(ctx context.Context, input <-chan int, output chan<- int, errChan chan<- error) {
+				if started.Add(1) == int32(tt.replicas) {
+					close(all)
+				}
+				select {
+				case <-all:
+				case <-ctx.Done():
+					return
+				}
+				LoopHandler(func(_ context.Context, v int, emit *Emitter[int]) error {
+					if tt.failEvery > 0 && v%tt.failEvery == 0 {
+						return fmt.Errorf("%w %d", errBad, v)
+					}
+					return emit.Send(v)
+				})(ctx, input, output, errChan)
+			}
+			n := NewPool("pool", tt.replicas, handler, WithPorts(tt.inputs, tt.outputs))
+
+			var want []int
+			for i := range tt.inputs {
+				items := make([]int, perInput)
+				for k := range items {
+					items[k] = i*perInput + k
+					if tt.failEvery == 0 || items[k]%tt.failEvery != 0 {
+						want = append(want, items[k])
+					}
+				}
+				if err := n.SetInput(i, feed(items...)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var outs []func() []int
+			for i := range tt.outputs {
+				out := make(chan int)
+				if err := n.SetOutput(i, out); err != nil {
+					t.Fatal(err)
+				}
+				outs = append(outs, drain(out))
+			}
+
+			errs := runNodes(t, context.Background(), n)
+			if got := int(started.Load()); got != tt.replicas {
+				t.Errorf("started %d replicas, want %d", got, tt.replicas)
+			}
+			// порядок выдачи копий не определён, но каждое значение выдано ровно один раз
+			var got []int
+			for _, out := range outs {
+				got = append(got, out()...)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+
+			wantErrs := 0
+			if tt.failEvery > 0 {
+				wantErrs = tt.inputs * perInput / tt.failEvery
+			}
+			if len(errs) != wantErrs {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), wantErrs, errs)
+			}
+			tagged := regexp.MustCompile(fmt.Sprintf(`^replica [0-%d]: bad \d+$`, tt.replicas-1))
+			for _, err := range errs {
+				if !errors.Is(err, errBad) || !tagged.MatchString(err.Error()) {
+					t.Errorf("error %q is not a replica-tagged errBad", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPoolShutdown(t *testing.T) {
+	const replicas = 4
+	tests := []struct {
+		name string
+		// closeInput закрывает вход после отправки значений, иначе узел останавливается отменой
+		closeInput bool
+		handler    Handler[int, int]
+		wantPanic  bool
+	}{
+		// выход общий для копий и закрывается один раз, после завершения последней
+		{"input closed", true, relay, false},
+		{"replicas leave output open", true, func(_ context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+			for v := range input {
+				output <- v
+			}
+		}, false},
+		{"cancelled", false, LoopHandler(func(_ context.Context, v int, emit *Emitter[int]) error {
+			return emit.Send(v)
+		}), false},
+		// паника одной копии отменяет контекст остальных, хотя вход не закрыт и контекст узла не отменён
+		{"replica panics", false, func() Handler[int, int] {
+			var calls atomic.Int32
+			return LoopHandler(func(_ context.Context, v int, emit *Emitter[int]) error {
+				if calls.Add(1) == 5 {
+					panic("boom")
+				}
+				return emit.Send(v)
+			})
+		}(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exited atomic.Int32
+			allExited := make(chan struct{})
+			n := NewPool("pool", replicas, func(ctx context.Context, input <-chan int, output chan<- int, errChan chan<- error) {
+				defer func() {
+					if exited.Add(1) == replicas {
+						close(allExited)
+					}
+				}()
+				tt.handler(ctx, input, output, errChan)
+			})
+			input := make(chan int)
+			if err := n.SetInput(0, input); err != nil {
+				t.Fatal(err)
+			}
+			out := make(chan int)
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			got := drain(out)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() {
+				for i := range 10 {
+					select {
+					case input <- i:
+					case <-ctx.Done():
+						return
+					}
+				}
+				switch {
+				case tt.closeInput:
+					close(input)
+				case tt.wantPanic:
+					// после паники узел дочитывает вход до закрытия: закрываем его, когда все
+					// копии уже завершились
+					select {
+					case <-allExited:
+						close(input)
+					case <-time.After(5 * time.Second):
+					}
+				default:
+					time.Sleep(10 * time.Millisecond)
+					cancel()
+				}
+			}()
+
+			errs := runNodes(t, ctx, n)
+			items := got()
+			if exited.Load() != replicas {
+				t.Errorf("%d replicas exited, want %d", exited.Load(), replicas)
+			}
+			var pe *PanicError
+			switch {
+			case tt.wantPanic:
+				if len(errs) != 1 || !errors.As(errs[0], &pe) {
+					t.Fatalf("errors = %v, want one *PanicError", errs)
+				}
+			case len(errs) > 0:
+				t.Fatalf("errors: %v", errs)
+			}
+			if tt.closeInput {
+				slices.Sort(items)
+				if !slices.Equal(items, seq(10)) {
+					t.Errorf("got %v, want %v", items, seq(10))
+				}
+			}
+			if s := n.State(); s != StateDone {
+				t.Errorf("state %v, want %v", s, StateDone)
+			}
+		})
+	}
+}
+
+func TestPoolInvalidReplicas(t *testing.T) {
+	for _, replicas := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewPool with %d replicas did not panic", replicas)
+				}
+			}()
+			NewPool("pool", replicas, relay)
+		}()
+	}
+}
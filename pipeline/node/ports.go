@@ -0,0 +1,55 @@
+package node
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Port вход или выход узла. ID идентифицирует канал (0 для неподключённого входа или выхода):
+// совпадение ID выхода одного узла и входа другого означает ребро между ними. Cap ёмкость буфера канала,
+// Len количество элементов в нём на момент вызова Ports. Shared канал помечен SharedInput как
+// намеренно читаемый несколькими узлами.
+type Port struct {
+	ID     uintptr
+	Cap    int
+	Len    int
+	Shared bool
+}
+
+// sharedInputs каналы, помеченные SharedInput. Значение удерживает канал, чтобы его адрес не
+// достался другому каналу.
+var sharedInputs sync.Map
+
+// SharedInput помечает канал как намеренно общий вход нескольких узлов (узлы поочерёдно забирают
+// из него элементы) и возвращает его. Без пометки pipeline.Analyze сообщает о таком канале.
+func SharedInput[T any](ch <-chan T) <-chan T {
+	if ch != nil {
+		sharedInputs.Store(reflect.ValueOf(ch).Pointer(), ch)
+	}
+	return ch
+}
+
+// Ports возвращает входы и выходы узла для анализа топологии (см. pipeline.Analyze). Узел,
+// схлопнутый в ребро (WithInline), собственных портов не имеет: возвращаются nil, nil.
+func (n *Node[I, O]) Ports() (inputs, outputs []Port) {
+	wiring.Lock()
+	defer wiring.Unlock()
+	if n.collapsed {
+		return nil, nil
+	}
+	inputs = make([]Port, len(n.inputs))
+	for i, ch := range n.inputs {
+		if ch != nil {
+			id := reflect.ValueOf(ch).Pointer()
+			_, shared := sharedInputs.Load(id)
+			inputs[i] = Port{ID: id, Cap: cap(ch), Len: len(ch), Shared: shared}
+		}
+	}
+	outputs = make([]Port, len(n.outputs))
+	for i, ch := range n.outputs {
+		if ch != nil {
+			outputs[i] = Port{ID: reflect.ValueOf(ch).Pointer(), Cap: cap(ch), Len: len(ch)}
+		}
+	}
+	return inputs, outputs
+}
@@ -0,0 +1,292 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestWiringAccessors(t *testing.T) {
+	tests := []struct {
+		name            string
+		inputs, outputs int
+		wireIn, wireOut []int
+		wantIn, wantOut []bool
+		wantComplete    bool
+	}{
+		{"nothing wired", 2, 1, nil, nil, []bool{false, false}, []bool{false}, false},
+		{"partial", 2, 2, []int{1}, []int{0}, []bool{false, true}, []bool{true, false}, false},
+		{"complete", 2, 1, []int{0, 1}, []int{0}, []bool{true, true}, []bool{true}, true},
+		{"source", 0, 1, nil, []int{0}, []bool{}, []bool{true}, true},
+		{"sink", 1, 0, []int{0}, nil, []bool{true}, []bool{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := New("ports", tt.inputs, tt.outputs, nil, relay)
+			for _, i := range tt.wireIn {
+				if err := n.SetInput(i, make(chan int)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, i := range tt.wireOut {
+				if err := n.SetOutput(i, make(chan int)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if c := n.InputCount(); c != tt.inputs {
+				t.Errorf("InputCount = %d, want %d", c, tt.inputs)
+			}
+			if c := n.OutputCount(); c != tt.outputs {
+				t.Errorf("OutputCount = %d, want %d", c, tt.outputs)
+			}
+			for i, want := range tt.wantIn {
+				if got, err := n.InputWired(i); err != nil || got != want {
+					t.Errorf("InputWired(%d) = %v, %v; want %v", i, got, err, want)
+				}
+			}
+			for i, want := range tt.wantOut {
+				if got, err := n.OutputWired(i); err != nil || got != want {
+					t.Errorf("OutputWired(%d) = %v, %v; want %v", i, got, err, want)
+				}
+			}
+			if got := n.WiringComplete(); got != tt.wantComplete {
+				t.Errorf("WiringComplete = %v, want %v", got, tt.wantComplete)
+			}
+
+			for _, idx := range []int{-1, tt.inputs} {
+				if _, err := n.InputWired(idx); !errors.Is(err, ErrInputIdxOutOfRange) {
+					t.Errorf("InputWired(%d) error = %v, want ErrInputIdxOutOfRange", idx, err)
+				}
+			}
+			for _, idx := range []int{-1, tt.outputs} {
+				if _, err := n.OutputWired(idx); !errors.Is(err, ErrOutputIdxOutOfRange) {
+					t.Errorf("OutputWired(%d) error = %v, want ErrOutputIdxOutOfRange", idx, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentWiring(t *testing.T) {
+	const workers = 8
+	// каждая горутина подключает свой источник к общему узлу и свой приёмник к его выходу,
+	// одновременно читая состояние подключения
+	hub := New("hub", workers, workers, nil, relay)
+	sources := make([]*Node[struct{}, int], workers)
+	sinks := make([]*Node[int, struct{}], workers)
+	for i := range workers {
+		sources[i] = New("source", 0, 1, nil, func(_ context.Context, _ <-chan struct{}, output chan<- int, _ chan<- error) {
+			close(output)
+		})
+		sinks[i] = NewSink("sink", 1, func(context.Context, int) error { return nil })
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+	for i := range workers {
+		wg.Go(func() {
+			if err := Connect(sources[i], 0, hub, i); err != nil {
+				errs <- err
+			}
+			if err := Connect(hub, i, sinks[i], 0); err != nil {
+				errs <- err
+			}
+			hub.WiringComplete()
+			if _, err := hub.InputWired(i); err != nil {
+				errs <- err
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if !hub.WiringComplete() {
+		t.Fatal("hub wiring incomplete after concurrent Connect")
+	}
+	for i := range workers {
+		in, _ := hub.InputWired(i)
+		out, _ := hub.OutputWired(i)
+		if !in || !out {
+			t.Errorf("port %d: input wired %v, output wired %v", i, in, out)
+		}
+	}
+}
+
+func TestSlotOverwrite(t *testing.T) {
+	tests := []struct {
+		name string
+		// op меняет подключение src[0] -> dst[0]; redirect принимает значения src при замене выхода
+		op             func(src, dst *Node[int, int], redirect chan int) error
+		wantErr        error
+		runSrc, runDst bool
+		fromRedirect   bool
+		want           []int
+	}{
+		{"set input refused", func(_, dst *Node[int, int], _ chan int) error {
+			return dst.SetInput(0, feed(100))
+		}, ErrSlotOccupied, true, true, false, []int{1, 2, 3}},
+		{"set output refused", func(src, _ *Node[int, int], redirect chan int) error {
+			return src.SetOutput(0, redirect)
+		}, ErrSlotOccupied, true, true, false, []int{1, 2, 3}},
+		{"connect refused", func(src, dst *Node[int, int], _ chan int) error {
+			return Connect(src, 0, dst, 0)
+		}, ErrSlotOccupied, true, true, false, []int{1, 2, 3}},
+		// замена намеренная: dst читает новый канал, src не запускается, так как его выход больше никто не читает
+		{"force input", func(_, dst *Node[int, int], _ chan int) error {
+			return dst.ForceSetInput(0, feed(100))
+		}, nil, false, true, false, []int{100}},
+		{"force output", func(src, _ *Node[int, int], redirect chan int) error {
+			return src.ForceSetOutput(0, redirect)
+		}, nil, true, false, true, []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := New("src", 1, 1, nil, relay), New("dst", 1, 1, nil, relay)
+			if err := src.SetInput(0, feed(1, 2, 3)); err != nil {
+				t.Fatal(err)
+			}
+			if err := Connect(src, 0, dst, 0); err != nil {
+				t.Fatal(err)
+			}
+			out, redirect := make(chan int, 10), make(chan int, 10)
+			if err := dst.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tt.op(src, dst, redirect)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			var nodes []runner
+			if tt.runSrc {
+				nodes = append(nodes, src)
+			}
+			if tt.runDst {
+				nodes = append(nodes, dst)
+			}
+			if errs := runNodes(t, context.Background(), nodes...); len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+			read := out
+			if tt.fromRedirect {
+				read = redirect
+			}
+			if got := drain(read)(); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutowireSkipsOccupied(t *testing.T) {
+	src, dst := New("src", 1, 1, nil, relay), New("dst", 2, 1, nil, relay)
+	if err := src.SetInput(0, feed(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.SetInput(0, feed(100)); err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan int, 10)
+	if err := dst.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+	// занятый вход 0 не перезаписывается: src подключается к свободному входу 1
+	if err := Autowire(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if wired, _ := dst.InputWired(1); !wired {
+		t.Fatal("Autowire did not wire the vacant input")
+	}
+	if errs := runNodes(t, context.Background(), src, dst); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	got := drain(out)()
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3, 100}) {
+		t.Errorf("got %v, want [1 2 3 100]", got)
+	}
+
+	// все входы заняты: Autowire отказывает, а не перезаписывает
+	if err := Autowire(New("extra", 1, 1, nil, relay), dst); !errors.Is(err, ErrInputsWired) {
+		t.Errorf("Autowire to a fully wired node: %v, want ErrInputsWired", err)
+	}
+}
+
+func TestRunUnwiredInput(t *testing.T) {
+	n := New("partial", 3, 1, nil, relay)
+	if err := n.SetInput(1, feed(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan int, 3)
+	if err := n.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+
+	// Run не паникует: ошибки обоих неподключённых входов приходят в канал ошибок, выход закрыт,
+	// а подключённый вход дочитан
+	errs := runNodes(t, context.Background(), n)
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1: %v", len(errs), errs)
+	}
+	if !errors.Is(errs[0], ErrUnwired) {
+		t.Errorf("error %v, want ErrUnwired", errs[0])
+	}
+	var joined interface{ Unwrap() []error }
+	if !errors.As(errs[0], &joined) || len(joined.Unwrap()) != 2 {
+		t.Errorf("error %v does not join the errors of inputs 0 and 2", errs[0])
+	}
+	var nodeErr *NodeError
+	if !errors.As(errs[0], &nodeErr) || nodeErr.Node != "partial" {
+		t.Errorf("error %v is not a NodeError of partial", errs[0])
+	}
+	if got := drain(out)(); len(got) != 0 {
+		t.Errorf("handler ran and sent %v", got)
+	}
+	if s, r := n.State(), n.ExitReason(); s != StateDone || r != ExitSkipped {
+		t.Errorf("state %v, exit %v; want %v, %v", s, r, StateDone, ExitSkipped)
+	}
+}
+
+func TestNilChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		wire     func(n *Node[int, int]) error
+		wantText string
+	}{
+		{"SetInput", func(n *Node[int, int]) error { return n.SetInput(1, nil) },
+			"pipeline/node=ports: input 1: nil channel"},
+		{"ForceSetInput", func(n *Node[int, int]) error { return n.ForceSetInput(0, nil) },
+			"pipeline/node=ports: input 0: nil channel"},
+		{"SetOutput", func(n *Node[int, int]) error { return n.SetOutput(0, nil) },
+			"pipeline/node=ports: output 0: nil channel"},
+		{"ForceSetOutput", func(n *Node[int, int]) error { return n.ForceSetOutput(1, nil) },
+			"pipeline/node=ports: output 1: nil channel"},
+		// nil среди каналов: не подключается ни один, в том числе предшествующие
+		{"AutowireInput", func(n *Node[int, int]) error { return n.AutowireInput(make(chan int), nil) },
+			"pipeline/node=ports: input argument 1: nil channel"},
+		{"AutowireOutput", func(n *Node[int, int]) error { return n.AutowireOutput(nil, make(chan int)) },
+			"pipeline/node=ports: output argument 0: nil channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := New("ports", 2, 2, nil, relay)
+			err := tt.wire(n)
+			if !errors.Is(err, ErrNilChannel) || err.Error() != tt.wantText {
+				t.Fatalf("error = %v, want %q", err, tt.wantText)
+			}
+			for i := range 2 {
+				in, _ := n.InputWired(i)
+				out, _ := n.OutputWired(i)
+				if in || out {
+					t.Errorf("port %d wired after a nil channel: input %v, output %v", i, in, out)
+				}
+			}
+		})
+	}
+}
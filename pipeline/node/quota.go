@@ -0,0 +1,140 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaAction действие узла Quota при исчерпании бюджета
+type QuotaAction int32
+
+const (
+	// QuotaStopAccepting отбрасывает элементы, не уместившиеся в бюджет, и все последующие
+	QuotaStopAccepting QuotaAction = iota
+	// QuotaDrainAndStop как QuotaStopAccepting, но дополнительно останавливает источники пайплайна
+	// (StopSource), чтобы он завершился после дообработки уже выданных элементов
+	QuotaDrainAndStop
+	// QuotaError как QuotaStopAccepting, но дополнительно отправляет ErrQuotaExceeded (ClassNode)
+	// в канал ошибок узла; с pipeline.WithFailFast это останавливает пайплайн
+	QuotaError
+)
+
+func (a QuotaAction) String() string {
+	switch a {
+	case QuotaStopAccepting:
+		return "stop accepting"
+	case QuotaDrainAndStop:
+		return "drain and stop"
+	case QuotaError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// QuotaUsage расход бюджета узла Quota и его реплик
+type QuotaUsage struct {
+	// Quota имя бюджета: имя узла, созданного Quota
+	Quota string
+	Limit int64
+	Used  int64
+	// Dropped элементы, отброшенные после исчерпания бюджета
+	Dropped int64
+}
+
+// quotaBudget бюджет, общий для узла Quota и его реплик
+type quotaBudget struct {
+	name     string
+	limit    int64
+	used     atomic.Int64
+	dropped  atomic.Int64
+	exceeded atomic.Bool
+}
+
+// take списывает cost из бюджета. Возвращает false, если бюджет исчерпан этим или предыдущим
+// элементом; first сообщает, что исчерпание вызвал этот элемент.
+func (b *quotaBudget) take(cost int64) (ok, first bool) {
+	for !b.exceeded.Load() {
+		used := b.used.Load()
+		if used+cost > b.limit {
+			return false, !b.exceeded.Swap(true)
+		}
+		if b.used.CompareAndSwap(used, used+cost) {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// QuotaNode узел, пропускающий элементы без изменений, пока не исчерпан общий бюджет
+type QuotaNode[T any] struct {
+	*Node[T, T]
+	budget *quotaBudget
+	cost   func(T) int64
+	action QuotaAction
+}
+
+// Quota создаёт узел, пропускающий элементы, пока их суммарная стоимость cost (nil — 1 за элемент,
+// отрицательная считается 0) не превышает limit. Элемент, стоимость которого превысила бы остаток
+// бюджета, не пропускается и исчерпывает бюджет: он и все последующие элементы отбрасываются
+// с учётом в QuotaUsage.Dropped, а при исчерпании однократно выполняется действие onExceed.
+// Бюджет общий для горутин WithConcurrency и реплик узла (Replica). Расход бюджетов узлов
+// пайплайна доступен в pipeline.ErrorSummary.Quotas. Количество входов и выходов задаёт WithPorts,
+// буферы выходов — WithOutputBuffers.
+func Quota[T any](name string, limit int64, cost func(T) int64, onExceed QuotaAction, opts ...Option) *QuotaNode[T] {
+	if cost == nil {
+		cost = func(T) int64 { return 1 }
+	}
+
+	name = autoNameOpts(name, "Quota", opts)
+	q := &QuotaNode[T]{budget: &quotaBudget{name: name, limit: limit}, cost: cost, action: onExceed}
+	q.Node = q.newReplica(name, opts)
+	return q
+}
+
+// Replica создаёт узел с теми же стоимостью и действием, расходующий бюджет q. Параметры
+// аналогичны Quota.
+func (q *QuotaNode[T]) Replica(name string, opts ...Option) *QuotaNode[T] {
+	name = autoNameOpts(name, q.budget.name+" replica", opts)
+	r := &QuotaNode[T]{budget: q.budget, cost: q.cost, action: q.action}
+	r.Node = r.newReplica(name, opts)
+	return r
+}
+
+// QuotaUsage возвращает расход бюджета узла и его реплик
+func (q *QuotaNode[T]) QuotaUsage() QuotaUsage {
+	return QuotaUsage{
+		Quota:   q.budget.name,
+		Limit:   q.budget.limit,
+		Used:    q.budget.used.Load(),
+		Dropped: q.budget.dropped.Load(),
+	}
+}
+
+// newReplica создаёт узел, расходующий бюджет q
+func (q *QuotaNode[T]) newReplica(name string, opts []Option) *Node[T, T] {
+	return Loop(name, func(ctx context.Context, item T, emit *Emitter[T]) error {
+		cost := max(q.cost(item), 0)
+		ok, first := q.budget.take(cost)
+		if ok {
+			return emit.Send(item)
+		}
+
+		q.budget.dropped.Add(1)
+		if !first {
+			return nil
+		}
+		switch q.action {
+		case QuotaDrainAndStop:
+			drainPipeline(ctx)
+		case QuotaError:
+			return Classify(ClassNode, fmt.Errorf("%w: %d of %d used, item cost %d", ErrQuotaExceeded,
+				q.budget.used.Load(), q.budget.limit, cost))
+		}
+		return nil
+	}, opts...)
+}
@@ -0,0 +1,100 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestQuota(t *testing.T) {
+	byValue := func(v int) int64 { return int64(v) }
+	tests := []struct {
+		name     string
+		limit    int64
+		cost     func(int) int64
+		onExceed QuotaAction
+		items    []int
+		want     []int
+		// wantUsed, wantDropped ожидаемые QuotaUsage.Used и QuotaUsage.Dropped
+		wantUsed, wantDropped int64
+		wantDrains            int
+		wantErr               bool
+	}{
+		{"count", 3, nil, QuotaStopAccepting, seq(5), seq(3), 3, 2, 0, false},
+		// элемент, не уместившийся в остаток, исчерпывает бюджет: последующие отбрасываются,
+		// даже если поместились бы
+		{"cost", 5, byValue, QuotaStopAccepting, []int{1, 2, 3, 1}, []int{1, 2}, 3, 2, 0, false},
+		{"within budget", 10, byValue, QuotaError, []int{1, 2, 3}, []int{1, 2, 3}, 6, 0, 0, false},
+		{"negative cost", 1, func(int) int64 { return -1 }, QuotaStopAccepting, seq(3), seq(3), 0, 0, 0, false},
+		// действие выполняется однократно, при исчерпании
+		{"drain and stop", 2, nil, QuotaDrainAndStop, seq(5), seq(2), 2, 3, 1, false},
+		{"error", 2, nil, QuotaError, seq(5), seq(2), 2, 3, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Quota("quota", tt.limit, tt.cost, tt.onExceed)
+			if err := q.SetInput(0, feed(tt.items...)); err != nil {
+				t.Fatal(err)
+			}
+			out := make(chan int)
+			if err := q.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			got := drain(out)
+			drains := 0
+			ctx := ContextWithDrain(context.Background(), func() { drains++ })
+			errs := runNodes(t, ctx, q)
+
+			if !slices.Equal(got(), tt.want) {
+				t.Errorf("output %v, want %v", got(), tt.want)
+			}
+			want := QuotaUsage{Quota: "quota", Limit: tt.limit, Used: tt.wantUsed, Dropped: tt.wantDropped}
+			if u := q.QuotaUsage(); u != want {
+				t.Errorf("QuotaUsage = %+v, want %+v", u, want)
+			}
+			if drains != tt.wantDrains {
+				t.Errorf("%d drains, want %d", drains, tt.wantDrains)
+			}
+			switch {
+			case !tt.wantErr && len(errs) > 0:
+				t.Errorf("errors: %v", errs)
+			case tt.wantErr && (len(errs) != 1 || !errors.Is(errs[0], ErrQuotaExceeded) || ClassOf(errs[0]) != ClassNode):
+				t.Errorf("errors = %v, want one ClassNode %v", errs, ErrQuotaExceeded)
+			}
+		})
+	}
+}
+
+func TestQuotaReplica(t *testing.T) {
+	q := Quota[int]("quota", 4, nil, QuotaStopAccepting, WithConcurrency(2))
+	r := q.Replica("quota-2")
+	var outs []func() []int
+	for _, n := range []*QuotaNode[int]{q, r} {
+		if err := n.SetInput(0, feed(seq(3)...)); err != nil {
+			t.Fatal(err)
+		}
+		out := make(chan int)
+		if err := n.SetOutput(0, out); err != nil {
+			t.Fatal(err)
+		}
+		outs = append(outs, drain(out))
+	}
+	var wg sync.WaitGroup
+	q.Run(context.Background(), &wg, make(chan error), true)
+	r.Run(context.Background(), &wg, make(chan error), true)
+	waitGroup(t, &wg)
+
+	// бюджет общий: узел и реплика вместе пропускают limit элементов
+	passed := len(outs[0]()) + len(outs[1]())
+	if passed != 4 {
+		t.Errorf("%d items passed, want 4", passed)
+	}
+	want := QuotaUsage{Quota: "quota", Limit: 4, Used: 4, Dropped: 2}
+	for _, n := range []*QuotaNode[int]{q, r} {
+		if u := n.QuotaUsage(); u != want {
+			t.Errorf("%s: QuotaUsage = %+v, want %+v", n.Name(), u, want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrBypassed        = errors.New("node bypassed")
+	ErrBypassEngaged   = errors.New("error rate exceeded, node bypassed")
+	ErrBypassRecovered = errors.New("node recovered from bypass")
+)
+
+// BreakerAction обработка элементов узла, обойдённого WithErrorRateBreaker
+type BreakerAction int
+
+const (
+	// BypassAuto пересылать элементы без изменений, если типы входа и выхода совпадают, иначе
+	// отправлять в dead-letter
+	BypassAuto BreakerAction = iota
+	// BypassForward пересылать элементы без изменений; узел с разными типами входа и выхода паникует
+	BypassForward
+	// BypassDeadLetter отправлять элементы в dead-letter (или в канал ошибок) с ErrBypassed
+	BypassDeadLetter
+)
+
+func (a BreakerAction) String() string {
+	switch a {
+	case BypassAuto:
+		return "auto"
+	case BypassForward:
+		return "forward"
+	case BypassDeadLetter:
+		return "dead-letter"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// bypassProbeEvery каждый какой элемент в режиме обхода обрабатывается функцией как проба
+	bypassProbeEvery = 10
+	// bypassProbation число успешных проб подряд, после которого узел выходит из обхода
+	bypassProbation = 3
+)
+
+// WithErrorRateBreaker включает обход узлов NewMap и NewSink при высокой доле ошибок: если среди
+// последних window обработанных элементов доля ошибок превысила threshold, узел переходит в режим
+// обхода и обрабатывает элементы согласно action, не вызывая функцию. Каждый десятый элемент в этом
+// режиме обрабатывается функцией как проба; после трёх успешных проб подряд узел возвращается в
+// обычный режим, а результат неудачной пробы обрабатывается как обойдённый элемент. Переходы
+// отправляются в канал ошибок (ErrBypassEngaged, ErrBypassRecovered) и наблюдателю (EventBypassOn,
+// EventBypassOff), режим и число обойдённых элементов видны в Stats.Bypass и Stats.Bypassed.
+func WithErrorRateBreaker(threshold float64, window int, action BreakerAction) Option {
+	return func(c *config) {
+		c.rateBreaker = &rateBreakerConfig{
+			threshold: threshold,
+			window:    max(window, 1),
+			action:    action,
+		}
+	}
+}
+
+// rateBreakerConfig параметры WithErrorRateBreaker
+type rateBreakerConfig struct {
+	threshold float64
+	window    int
+	action    BreakerAction
+}
+
+// rateBreaker скользящее окно результатов обработки и режим обхода узла
+type rateBreaker struct {
+	cfg *rateBreakerConfig
+
+	mu       sync.Mutex
+	outcomes []bool
+	next     int
+	filled   int
+	failures int
+	// skipped элементы с последней пробы, clean успешные пробы подряд
+	skipped int
+	clean   int
+
+	bypass   atomic.Bool
+	bypassed atomic.Uint64
+}
+
+func newRateBreaker(cfg *rateBreakerConfig) *rateBreaker {
+	return &rateBreaker{cfg: cfg, outcomes: make([]bool, cfg.window)}
+}
+
+// admit сообщает, нужно ли вызвать функцию для очередного элемента и является ли вызов пробой
+func (b *rateBreaker) admit() (call, probe bool) {
+	if !b.bypass.Load() {
+		return true, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.bypass.Load() {
+		return true, false
+	}
+	b.skipped++
+	if b.skipped >= bypassProbeEvery {
+		b.skipped = 0
+		return true, true
+	}
+	return false, false
+}
+
+// done фиксирует результат вызова, разрешённого admit, и возвращает ошибку перехода в режим обхода
+// или выхода из него
+func (b *rateBreaker) done(err error, probe bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		if !b.bypass.Load() {
+			return nil
+		}
+		if err != nil {
+			b.clean = 0
+			return nil
+		}
+		b.clean++
+		if b.clean < bypassProbation {
+			return nil
+		}
+		b.resetWindow()
+		b.bypass.Store(false)
+		return fmt.Errorf("%w after %d clean probes", ErrBypassRecovered, bypassProbation)
+	}
+
+	// результаты элементов, начатых до перехода в обход, не учитываются
+	if b.bypass.Load() {
+		return nil
+	}
+	failed := err != nil
+	if b.filled == len(b.outcomes) && b.outcomes[b.next] {
+		b.failures--
+	}
+	b.outcomes[b.next] = failed
+	b.next = (b.next + 1) % len(b.outcomes)
+	b.filled = min(b.filled+1, len(b.outcomes))
+	if failed {
+		b.failures++
+	}
+
+	failures := b.failures
+	if b.filled < len(b.outcomes) || float64(failures)/float64(len(b.outcomes)) <= b.cfg.threshold {
+		return nil
+	}
+	b.resetWindow()
+	b.skipped, b.clean = 0, 0
+	b.bypass.Store(true)
+	return fmt.Errorf("%w: %d of last %d items failed", ErrBypassEngaged, failures, len(b.outcomes))
+}
+
+// resetWindow очищает окно результатов
+func (b *rateBreaker) resetWindow() {
+	clear(b.outcomes)
+	b.next, b.filled, b.failures = 0, 0, 0
+}
+
+// reset возвращает узел в обычный режим перед запуском
+func (b *rateBreaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetWindow()
+	b.skipped, b.clean = 0, 0
+	b.bypass.Store(false)
+	b.bypassed.Store(0)
+}
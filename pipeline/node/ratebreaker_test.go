@@ -0,0 +1,150 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestErrorRateBreaker(t *testing.T) {
+	errFail := errors.New("fail")
+	// bypassed n значений, обходящих функцию между пробами
+	bypassed := func(from, n int) []int {
+		vals := make([]int, n)
+		for i := range vals {
+			vals[i] = -(from + i)
+		}
+		return vals
+	}
+	// окно из четырёх элементов сдвигается: ошибка -3 вытесняет успешный 1, и доля ошибок 3/4
+	// превышает порог; при доле 2/4 узел ещё не обходится
+	items := []int{1, -1, -2, 2, -3}
+	items = append(items, bypassed(10, 9)...)
+	// неудачная проба обрабатывается как обойдённый элемент и сбрасывает серию успешных проб
+	items = append(items, -19)
+	for probe := range bypassProbation {
+		items = append(items, bypassed(20+10*probe, 9)...)
+		items = append(items, 100+probe)
+	}
+	// после трёх успешных проб ошибки снова передаются как есть
+	items = append(items, -50, 3)
+
+	want := []int{1, 2}
+	want = append(want, items[5:len(items)-2]...)
+	want = append(want, 3)
+	wantErrs := []error{errFail, errFail, ErrBypassEngaged, errFail, ErrBypassRecovered, errFail}
+
+	var mu sync.Mutex
+	var events []EventKind
+	n := NewMap("rate", func(_ context.Context, v int) (int, error) {
+		if v < 0 {
+			return 0, errFail
+		}
+		return v, nil
+	}, WithErrorRateBreaker(0.5, 4, BypassForward), WithObserver(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e.Kind)
+	}))
+	got, errs := process(t, n, items...)
+	if !slices.Equal(got, want) {
+		t.Errorf("output %v, want %v", got, want)
+	}
+	if !slices.EqualFunc(errs, wantErrs, func(err, target error) bool { return errors.Is(err, target) }) {
+		t.Errorf("errors %v, want %v", errs, wantErrs)
+	}
+	if wantEvents := []EventKind{EventBypassOn, EventBypassOff}; !slices.Equal(events, wantEvents) {
+		t.Errorf("events %v, want %v", events, wantEvents)
+	}
+	s := n.Stats()
+	// обойдены все выходные значения, кроме 1, 2, 3 и успешных проб
+	if wantBypassed := uint64(len(want) - 3 - bypassProbation); s.Bypass || s.Bypassed != wantBypassed {
+		t.Errorf("Bypass = %v, Bypassed = %d, want false, %d", s.Bypass, s.Bypassed, wantBypassed)
+	}
+}
+
+func TestErrorRateBreakerAction(t *testing.T) {
+	errFail := errors.New("fail")
+	// два сбоя подряд при окне 2 включают обход; следующие элементы обходят функцию
+	items := []int{-1, -2, 1, 2, 3}
+	tests := []struct {
+		name   string
+		action BreakerAction
+		// sameTypes узел int -> int, иначе int -> string
+		sameTypes bool
+		// wantOut число значений, пересланных без изменений, wantDead число значений с ErrBypassed
+		wantOut, wantDead int
+	}{
+		{"auto same types", BypassAuto, true, 3, 0},
+		{"auto different types", BypassAuto, false, 0, 3},
+		{"forward", BypassForward, true, 3, 0},
+		{"dead letter", BypassDeadLetter, true, 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := WithErrorRateBreaker(0.5, 2, tt.action)
+			var out []string
+			var errs []error
+			var stats Stats
+			if tt.sameTypes {
+				n := NewMap("rate", func(_ context.Context, v int) (int, error) {
+					if v < 0 {
+						return 0, errFail
+					}
+					return v * 10, nil
+				}, opt)
+				got, e := process(t, n, items...)
+				for _, v := range got {
+					out = append(out, strconv.Itoa(v))
+				}
+				errs, stats = e, n.Stats()
+			} else {
+				n := NewMap("rate", func(_ context.Context, v int) (string, error) {
+					if v < 0 {
+						return "", errFail
+					}
+					return fmt.Sprint(v * 10), nil
+				}, opt)
+				got, e := process(t, n, items...)
+				out, errs, stats = got, e, n.Stats()
+			}
+
+			// обойдённые значения пересылаются без изменений, функция их не видит
+			if want := []string{"1", "2", "3"}[:tt.wantOut]; !slices.Equal(out, want) {
+				t.Errorf("output %v, want %v", out, want)
+			}
+			dead := 0
+			for _, err := range errs {
+				var dl *DeadLetter
+				if errors.As(err, &dl) && errors.Is(err, ErrBypassed) {
+					dead++
+					if dl.Node != "rate" {
+						t.Errorf("dead letter from %q, want rate", dl.Node)
+					}
+				}
+			}
+			if dead != tt.wantDead {
+				t.Errorf("%d dead letters with %v, want %d: %v", dead, ErrBypassed, tt.wantDead, errs)
+			}
+			// обход продолжается до проб: режим виден в Stats после завершения
+			if !stats.Bypass || stats.Bypassed != 3 {
+				t.Errorf("Bypass = %v, Bypassed = %d, want true, 3", stats.Bypass, stats.Bypassed)
+			}
+		})
+	}
+}
+
+func TestErrorRateBreakerForwardTypeMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("BypassForward with different types did not panic")
+		}
+	}()
+	NewMap("rate", func(_ context.Context, v int) (string, error) {
+		return fmt.Sprint(v), nil
+	}, WithErrorRateBreaker(0.5, 2, BypassForward))
+}
@@ -0,0 +1,171 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrHandlerExited = errors.New("handler exited before input closed")
+	ErrRestartLimit  = errors.New("restart limit exceeded")
+)
+
+// RestartAction действие при исчерпании перезапусков обработчика
+type RestartAction int
+
+const (
+	// RestartDeadLetter отправляет оставшийся вход в dead-letter (или в канал ошибок)
+	RestartDeadLetter RestartAction = iota
+	// RestartCancelPipeline отменяет весь пайплайн
+	RestartCancelPipeline
+)
+
+// restartPolicy параметры перезапуска обработчика
+type restartPolicy struct {
+	maxRestarts int
+	backoff     func(restart int) time.Duration
+	action      RestartAction
+}
+
+// WithRestartPolicy включает перезапуск обработчика, если он завершился (или запаниковал), пока вход
+// не закрыт и контекст не отменён. Перед перезапуском повторно вызываются хуки WithInit/WithClose,
+// о каждом перезапуске сообщается в канал ошибок. backoff возвращает паузу перед перезапуском с номером
+// restart (начиная с 1). После maxRestarts перезапусков выполняется действие WithRestartEscalation.
+// Канал выхода при этом закрывает узел, а не обработчик; закрытие выхода обработчиком допустимо.
+// Для узлов с SelectHandler перезапуск не выполняется: узел не видит закрытие их входов.
+func WithRestartPolicy(maxRestarts int, backoff func(restart int) time.Duration) Option {
+	return func(c *config) {
+		action := RestartDeadLetter
+		if c.restart != nil {
+			action = c.restart.action
+		}
+		c.restart = &restartPolicy{maxRestarts: max(maxRestarts, 0), backoff: backoff, action: action}
+	}
+}
+
+// WithRestartEscalation задаёт действие при исчерпании перезапусков (по умолчанию RestartDeadLetter)
+func WithRestartEscalation(action RestartAction) Option {
+	return func(c *config) {
+		if c.restart == nil {
+			c.restart = &restartPolicy{}
+		}
+		c.restart.action = action
+	}
+}
+
+// supervise запускает обработчик и перезапускает его согласно политике. Вход пересылается через
+// общий для всех запусков канал, поэтому элемент, прочитанный до завершения обработчика, получит
+// следующий запуск. Выход каждого запуска пересылается через собственный канал.
+func (n *Node[I, O]) supervise(ctx context.Context, h Handler[I, O], input <-chan I, output chan<- O, errChan chan<- error) {
+	defer closeOutput(output)
+
+	stop := make(chan struct{})
+	forwarded := make(chan struct{})
+	defer func() {
+		close(stop)
+		<-forwarded
+	}()
+
+	var inputClosed atomic.Bool
+	var in chan I
+	if input == nil {
+		close(forwarded)
+	} else {
+		in = make(chan I)
+		go func() {
+			defer close(forwarded)
+			defer func() {
+				inputClosed.Store(true)
+				close(in)
+			}()
+			for {
+				select {
+				case val, ok := <-input:
+					if !ok {
+						return
+					}
+					select {
+					case in <- val:
+					case <-ctx.Done():
+						return
+					case <-stop:
+						return
+					}
+				case <-ctx.Done():
+					return
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	policy := n.cfg.restart
+	for restarts := 0; ; restarts++ {
+		err := n.runOnce(ctx, h, in, output, errChan)
+		if ctx.Err() != nil || input == nil || inputClosed.Load() {
+			n.setExit(exitOf(ctx, err))
+			if err != nil {
+				errChan <- err
+			}
+			return
+		}
+
+		if err == nil {
+			err = ErrHandlerExited
+		}
+		if restarts >= policy.maxRestarts {
+			n.setExit(ExitRestartLimit)
+			errChan <- fmt.Errorf("%w: %w", ErrRestartLimit, err)
+			n.escalate(ctx, in, errChan)
+			return
+		}
+
+		errChan <- Classify(ClassNode, fmt.Errorf("restart %d/%d: %w", restarts+1, policy.maxRestarts, err))
+		if policy.backoff != nil && !sleep(ctx, n.cfg.clock, policy.backoff(restarts+1)) {
+			n.setExit(ExitCancelled)
+			return
+		}
+	}
+}
+
+// runOnce выполняет один запуск обработчика с отдельным каналом выхода
+func (n *Node[I, O]) runOnce(ctx context.Context, h Handler[I, O], in <-chan I, output chan<- O, errChan chan<- error) error {
+	if output == nil {
+		return n.callHandler(ctx, h, in, nil, errChan)
+	}
+
+	proxy := make(chan O)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for val := range proxy {
+			select {
+			case output <- val:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	err := n.callHandler(ctx, h, in, proxy, errChan)
+	closeQuietly(proxy)
+	<-done
+	return err
+}
+
+// escalate выполняет действие при исчерпании перезапусков
+func (n *Node[I, O]) escalate(ctx context.Context, in <-chan I, errChan chan<- error) {
+	switch n.cfg.restart.action {
+	case RestartCancelPipeline:
+		cancelPipeline(ctx)
+	default:
+		for val := range in {
+			if !n.cfg.sendError(ctx, errChan, &DeadLetter{Node: n.name, Item: val, Err: ErrRestartLimit}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRestartPolicy(t *testing.T) {
+	const items = 10
+	tests := []struct {
+		name        string
+		maxRestarts int
+		// perRun количество элементов, после которого запуск run завершается (-1 — до закрытия входа);
+		// panics запуск run паникует вместо выхода
+		perRun      func(run int) int
+		panics      bool
+		wantRuns    int
+		wantOut     []int
+		wantRestart int
+		wantExit    ExitReason
+	}{
+		{"no restart needed", 2, func(int) int { return -1 }, false, 1, seq(items), 0, ExitInputClosed},
+		// элемент, прочитанный до выхода, обработан, а следующий запуск продолжает со следующего
+		{"recovers", 3, func(run int) int { return []int{2, 3, -1}[min(run, 2)] }, false, 3, seq(items), 2,
+			ExitInputClosed},
+		{"recovers after panic", 1, func(run int) int { return []int{4, -1}[min(run, 1)] }, true, 2, seq(items), 1,
+			ExitInputClosed},
+		// перезапуски исчерпаны: оставшиеся элементы уходят в dead-letter
+		{"gives up", 2, func(int) int { return 1 }, false, 3, seq(3), 2, ExitRestartLimit},
+		{"no restarts", 0, func(int) int { return 4 }, false, 1, seq(4), 0, ExitRestartLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs, inits atomic.Int32
+			var mu sync.Mutex
+			var backoffs []int
+			handler := func(_ context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+				run := int(runs.Add(1)) - 1
+				limit := tt.perRun(run)
+				for i := 0; limit < 0 || i < limit; i++ {
+					v, ok := <-input
+					if !ok {
+						break
+					}
+					output <- v
+				}
+				if tt.panics && limit >= 0 {
+					panic("boom")
+				}
+			}
+			n := New("restarted", 1, 1, nil, handler,
+				WithRestartPolicy(tt.maxRestarts, func(restart int) time.Duration {
+					mu.Lock()
+					defer mu.Unlock()
+					backoffs = append(backoffs, restart)
+					return time.Millisecond
+				}),
+				WithInit(func(context.Context) error {
+					inits.Add(1)
+					return nil
+				}))
+			got, errs := process(t, n, seq(items)...)
+
+			if !slices.Equal(got, tt.wantOut) {
+				t.Errorf("output %v, want %v", got, tt.wantOut)
+			}
+			if r := int(runs.Load()); r != tt.wantRuns || int(inits.Load()) != r {
+				t.Errorf("%d runs with %d inits, want %d runs with an init each", r, inits.Load(), tt.wantRuns)
+			}
+			var restarts, limits, deadLetters int
+			for _, err := range errs {
+				var dl *DeadLetter
+				switch {
+				case errors.As(err, &dl):
+					if !errors.Is(err, ErrRestartLimit) {
+						t.Errorf("dead letter %v without ErrRestartLimit", err)
+					}
+					deadLetters++
+				case errors.Is(err, ErrRestartLimit):
+					limits++
+				case errors.Is(err, ErrHandlerExited) && !tt.panics:
+					restarts++
+				case tt.panics && errors.As(err, new(*PanicError)):
+					restarts++
+				default:
+					t.Errorf("unexpected error %v", err)
+				}
+			}
+			wantLimits, wantDead := 0, 0
+			if tt.wantExit == ExitRestartLimit {
+				wantLimits, wantDead = 1, items-len(tt.wantOut)
+			}
+			if restarts != tt.wantRestart || limits != wantLimits || deadLetters != wantDead {
+				t.Errorf("restarts %d, limit errors %d, dead letters %d; want %d, %d, %d: %v",
+					restarts, limits, deadLetters, tt.wantRestart, wantLimits, wantDead, errs)
+			}
+			// пауза запрашивается перед каждым перезапуском с его номером
+			want := make([]int, tt.wantRestart)
+			for i := range want {
+				want[i] = i + 1
+			}
+			if !slices.Equal(backoffs, want) {
+				t.Errorf("backoff calls %v, want %v", backoffs, want)
+			}
+			if r := n.ExitReason(); r != tt.wantExit {
+				t.Errorf("ExitReason = %v, want %v", r, tt.wantExit)
+			}
+		})
+	}
+}
+
+func TestRestartCancelPipelineEscalation(t *testing.T) {
+	var cancelled atomic.Bool
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = ContextWithCancel(ctx, func() {
+		cancelled.Store(true)
+		cancel()
+	})
+	n := New("restarted", 1, 0, nil, func(_ context.Context, input <-chan int, _ chan<- struct{}, _ chan<- error) {
+		<-input
+	}, WithRestartPolicy(1, nil), WithRestartEscalation(RestartCancelPipeline))
+	input := make(chan int, 4)
+	for i := range 4 {
+		input <- i
+	}
+	close(input)
+	if err := n.SetInput(0, input); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := runNodes(t, ctx, n)
+	if !cancelled.Load() {
+		t.Error("pipeline not cancelled after the restart limit")
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], ErrHandlerExited) || !errors.Is(errs[1], ErrRestartLimit) {
+		t.Errorf("errors = %v, want a restart notice and ErrRestartLimit", errs)
+	}
+}
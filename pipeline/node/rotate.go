@@ -0,0 +1,175 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RotatingFileSink создаёт узел-приёмник с одним входом, записывающий значения через encode в
+// файлы с ротацией: новый файл открывается, когда запись очередного значения превысила бы maxSize
+// байт или текущий файл открыт дольше maxAge (нулевое значение отключает соответствующий предел).
+// Имя файла строится из pattern заменой "{n}" на номер файла (с 1) и "{time}" на время открытия
+// (20060102T150405, по Clock узла); без "{n}" номер добавляется к имени перед расширением.
+// Значение никогда не разбивается между файлами. Перед открытием следующего файла предыдущий
+// сбрасывается на диск (fsync) и закрывается. Ошибки ротации отправляются в канал ошибок, запись
+// при этом продолжается в текущий файл. Ошибка encode отправляется в канал ошибок, значение
+// пропускается. Файл закрывается при финальном сбросе узла (см. WithFlush). С WithWriteEmptyResult
+// при пустом входе создаётся пустой первый файл.
+func RotatingFileSink[T any](name string, pattern string, maxSize int64, maxAge time.Duration,
+	encode func(w io.Writer, v T) error, opts ...Option) *Node[T, struct{}] {
+	if encode == nil {
+		panic("nil encode func")
+	}
+
+	cfg := newConfig(opts)
+	r := &rotator{pattern: pattern, maxSize: maxSize, maxAge: maxAge, clock: cfg.clock}
+	userFlush := cfg.flush
+	cfg.flush = func(ctx context.Context) error {
+		err := r.close()
+		if userFlush != nil {
+			err = errors.Join(err, userFlush(ctx))
+		}
+		return err
+	}
+
+	handler := func(ctx context.Context, input <-chan T, _ chan<- struct{}, errChan chan<- error) {
+		var rec bytes.Buffer
+		for {
+			select {
+			case v, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					if cfg.writeEmpty && r.file == nil {
+						if err := r.open(); err != nil {
+							errChan <- err
+						}
+					}
+					return
+				}
+				if Yield(ctx) != nil {
+					return
+				}
+
+				rec.Reset()
+				if err := encode(&rec, v); err != nil {
+					errChan <- err
+					continue
+				}
+				if err := r.write(rec.Bytes(), errChan); err != nil {
+					errChan <- err
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	n := newNode[T, struct{}](name, 1, 0, nil, cfg)
+	n.handler = handler
+	return n
+}
+
+// rotator текущий файл ротируемого приёмника
+type rotator struct {
+	pattern  string
+	maxSize  int64
+	maxAge   time.Duration
+	clock    Clock
+	file     *os.File
+	w        *bufio.Writer
+	size     int64
+	openedAt time.Time
+	seq      int
+}
+
+// write записывает запись целиком, при необходимости предварительно выполняя ротацию. Ошибки
+// ротации отправляются в errChan; возвращается ошибка записи.
+func (r *rotator) write(rec []byte, errChan chan<- error) error {
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	} else if r.size > 0 && r.due(int64(len(rec))) {
+		if err := r.rotate(); err != nil {
+			errChan <- fmt.Errorf("rotate: %w", err)
+		}
+	}
+
+	n, err := r.w.Write(rec)
+	r.size += int64(n)
+	return err
+}
+
+// due сообщает, достигнут ли предел текущего файла с учётом записи размера n
+func (r *rotator) due(n int64) bool {
+	if r.maxSize > 0 && r.size+n > r.maxSize {
+		return true
+	}
+	return r.maxAge > 0 && r.clock.Now().Sub(r.openedAt) >= r.maxAge
+}
+
+// rotate сбрасывает текущий файл на диск и переключается на следующий. Если следующий файл
+// открыть не удалось, текущий остаётся открытым.
+func (r *rotator) rotate() error {
+	if err := r.w.Flush(); err != nil {
+		return err
+	}
+	if err := r.file.Sync(); err != nil {
+		return err
+	}
+
+	prev := r.file
+	if err := r.open(); err != nil {
+		return err
+	}
+	return prev.Close()
+}
+
+// open открывает следующий файл
+func (r *rotator) open() error {
+	r.seq++
+	now := r.clock.Now()
+	file, err := os.OpenFile(r.fileName(now), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		r.seq--
+		return err
+	}
+
+	r.file = file
+	r.w = bufio.NewWriter(file)
+	r.size = 0
+	r.openedAt = now
+	return nil
+}
+
+// fileName возвращает имя файла с номером r.seq, открытого в момент now
+func (r *rotator) fileName(now time.Time) string {
+	name := r.pattern
+	if !strings.Contains(name, "{n}") {
+		ext := filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext) + ".{n}" + ext
+	}
+	return strings.NewReplacer(
+		"{n}", strconv.Itoa(r.seq),
+		"{time}", now.Format("20060102T150405"),
+	).Replace(name)
+}
+
+// close сбрасывает и закрывает текущий файл
+func (r *rotator) close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := errors.Join(r.w.Flush(), r.file.Sync(), r.file.Close())
+	r.file = nil
+	return err
+}
@@ -0,0 +1,87 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stepClock Clock, время которого сдвигается на step при каждом вызове Now
+type stepClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+func (c *stepClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func TestRotatingFileSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		maxSize int64
+		maxAge  time.Duration
+		items   []string
+		opts    []Option
+		want    map[string]string
+		wantErr error
+	}{
+		// запись никогда не разбивается: слишком большая занимает отдельный файл целиком
+		{"by size", "log-{n}.txt", 8, 0, []string{"aaa", "bbb", "ccc", "dddddddddd", "e"}, nil, map[string]string{
+			"log-1.txt": "aaa\nbbb\n", "log-2.txt": "ccc\n", "log-3.txt": "dddddddddd\n", "log-4.txt": "e\n",
+		}, nil},
+		{"number before extension", "out.log", 4, 0, []string{"a", "b", "c"}, nil, map[string]string{
+			"out.1.log": "a\nb\n", "out.2.log": "c\n",
+		}, nil},
+		// часы сдвигаются на 40 секунд при каждом обращении: файл стареет на третьей записи
+		{"by age", "log-{time}-{n}.txt", 0, time.Minute, []string{"a", "b", "c", "d"}, nil, map[string]string{
+			"log-20240101T000000-1.txt": "a\nb\n", "log-20240101T000200-2.txt": "c\nd\n",
+		}, nil},
+		// значение с ошибкой encode пропускается, запись продолжается
+		{"encode error", "log-{n}.txt", 0, 0, []string{"a", "bad", "c"}, nil, map[string]string{
+			"log-1.txt": "a\nc\n",
+		}, errBadLine},
+		{"empty input", "log-{n}.txt", 8, 0, nil, nil, map[string]string{}, nil},
+		{"empty input with empty result", "log-{n}.txt", 8, 0, nil, []Option{WithWriteEmptyResult()},
+			map[string]string{"log-1.txt": ""}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			clock := &stepClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), step: 40 * time.Second}
+			n := RotatingFileSink("rotating", filepath.Join(dir, tt.pattern), tt.maxSize, tt.maxAge, writeLine,
+				append(tt.opts, WithClock(clock))...)
+			if err := n.SetInput(0, feed(tt.items...)); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := runNodes(t, context.Background(), n)
+			switch {
+			case tt.wantErr == nil && len(errs) > 0:
+				t.Errorf("errors: %v", errs)
+			case tt.wantErr != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.wantErr)):
+				t.Errorf("errors = %v, want %v", errs, tt.wantErr)
+			}
+			// последний файл сбрасывается и закрывается финальным сбросом узла
+			if got := dirFiles(t, dir); !maps.Equal(got, tt.want) {
+				t.Errorf("files %v = %q, want %v = %q", slices.Sorted(maps.Keys(got)), got,
+					slices.Sorted(maps.Keys(tt.want)), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package node
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Sample создаёт узел, пропускающий элементы без изменений, пока выход успевает их принимать.
+// Когда выход заполнен (нижестоящий узел не успевает), узел пропускает не более rps элементов
+// в секунду, ожидая места для них, а остальные отбрасывает с учётом в Stats.Shed; как только
+// выход освобождается, элементы снова проходят все. rps <= 0 означает отбрасывать все элементы,
+// не помещающиеся в выход. Количество входов и выходов задаёт WithPorts, буферы выходов —
+// WithOutputBuffers.
+func Sample[T any](name string, rps float64, opts ...Option) *Node[T, T] {
+	var interval time.Duration
+	if rps > 0 {
+		interval = time.Duration(math.Round(float64(time.Second) / rps))
+	}
+
+	// next время, с которого при заполненном выходе можно отправить следующий элемент
+	cfg := newConfig(opts)
+	var mu sync.Mutex
+	var next time.Time
+	allow := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if now := cfg.clock.Now(); interval > 0 && !now.Before(next) {
+			next = now.Add(interval)
+			return true
+		}
+		return false
+	}
+	return Loop(autoName(name, "Sample", cfg),
+		func(ctx context.Context, item T, emit *Emitter[T]) error {
+			if emit.Try(item) {
+				return nil
+			}
+			if allow() {
+				return emit.Send(item)
+			}
+			if ctx.Err() == nil {
+				emit.Shed()
+			}
+			return nil
+		}, opts...)
+}
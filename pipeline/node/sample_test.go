@@ -0,0 +1,82 @@
+package node
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSample(t *testing.T) {
+	// шаг сценария: send отправляет v во вход, read читает v из выхода, advance сдвигает часы на
+	// секунду; shed ждёт, пока отброшенных станет v, а limited — пока узел v раз не обратится к
+	// часам, то есть не застанет выход заполненным. Вход читается с опережением на элемент,
+	// поэтому завершённая отправка не означает, что узел уже обработал элемент.
+	type step struct {
+		op string
+		v  int
+	}
+	tests := []struct {
+		name  string
+		rps   float64
+		steps []step
+		// wantRest значения, оставшиеся в выходе после закрытия входа
+		wantRest []int
+	}{
+		// при заполненном выходе проходит один элемент в секунду, остальные отбрасываются
+		{"rate limited", 1, []step{
+			{"send", 0}, {"send", 1}, {"limited", 1}, {"read", 0}, {"send", 2}, {"send", 3}, {"shed", 2},
+			{"advance", 0}, {"send", 4}, {"limited", 4}, {"read", 1}, {"read", 4},
+			// выход освободился: элементы снова проходят все
+			{"send", 5},
+		}, []int{5}},
+		{"drop all", 0, []step{
+			{"send", 0}, {"send", 1}, {"send", 2}, {"shed", 2}, {"advance", 0}, {"send", 3}, {"shed", 3},
+			{"read", 0}, {"send", 4},
+		}, []int{4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &countingClock{fakeClock: newFakeClock()}
+			n := Sample[int]("sample", tt.rps, WithClock(clock))
+			in, out := make(chan int), make(chan int, 1)
+			if err := n.SetInput(0, in); err != nil {
+				t.Fatal(err)
+			}
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			var wg sync.WaitGroup
+			n.Run(context.Background(), &wg, make(chan error), true)
+
+			for i, s := range tt.steps {
+				switch s.op {
+				case "send":
+					in <- s.v
+				case "read":
+					select {
+					case v := <-out:
+						if v != s.v {
+							t.Errorf("step %d: read %d, want %d", i, v, s.v)
+						}
+					case <-time.After(5 * time.Second):
+						t.Fatalf("step %d: no output", i)
+					}
+				case "shed":
+					eventually(t, func() bool { return n.Stats().Shed == uint64(s.v) })
+				case "limited":
+					eventually(t, func() bool { return clock.nows.Load() == int64(s.v) })
+				case "advance":
+					clock.Advance(time.Second)
+				}
+			}
+			close(in)
+			rest := drain(out)
+			waitGroup(t, &wg)
+			if got := rest(); !slices.Equal(got, tt.wantRest) {
+				t.Errorf("rest of output %v, want %v", got, tt.wantRest)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// CombineFn объединяет результаты ветвей ScatterGather для входного значения in. results[i] и errs[i]
+// соответствуют ветви i; при errs[i] != nil значение results[i] нулевое.
+type CombineFn[I, M, O any] func(in I, results []M, errs []error) (O, error)
+
+// ScatterGather создаёт узел с одним входом и одним выходом, который для каждого входного значения
+// параллельно вызывает все ветви branches, дожидается их завершения и выдаёт результат combine.
+// Ошибки отдельных ветвей передаются в combine, ошибка combine отправляется в errChan.
+// WithTimeout и WithRetry действуют на каждую ветвь отдельно, WithConcurrency задаёт количество
+// одновременно обрабатываемых входных значений, WithOrderedOutput сохраняет порядок входа.
+func ScatterGather[I, M, O any](name string, branches []func(ctx context.Context, in I) (M, error),
+	combine CombineFn[I, M, O], opts ...Option) *Node[I, O] {
+	if len(branches) == 0 {
+		panic("no branches")
+	}
+
+	if combine == nil {
+		panic("nil combine func")
+	}
+
+	cfg := newConfig(opts)
+	cfg.gated = true
+	gather := func(ctx context.Context, in I) (O, error) {
+		results := make([]M, len(branches))
+		errs := make([]error, len(branches))
+
+		var panicked atomic.Pointer[PanicError]
+		var wg sync.WaitGroup
+		for i, branch := range branches {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() {
+					if p := recover(); p != nil {
+						panicked.CompareAndSwap(nil, newPanicError(p))
+					}
+				}()
+				res, err := call(ctx, cfg, branch, in)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				results[i] = res
+			}()
+		}
+		wg.Wait()
+		if pe := panicked.Load(); pe != nil {
+			panic(pe)
+		}
+
+		return combine(in, results, errs)
+	}
+
+	handler := func(ctx context.Context, input <-chan I, output chan<- O, errChan chan<- error) {
+		defer close(output)
+		runItems(ctx, cfg, input, output, errChan, gather)
+	}
+
+	n := newNode[I, O](name, 1, 1, nil, cfg)
+	n.handler = handler
+	return n
+}
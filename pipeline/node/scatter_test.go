@@ -0,0 +1,133 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScatterGather(t *testing.T) {
+	errBranch := errors.New("branch failed")
+	errCombine := errors.New("all branches failed")
+	double := func(_ context.Context, v int) (int, error) { return 2 * v, nil }
+	square := func(_ context.Context, v int) (int, error) { return v * v, nil }
+	failOdd := func(_ context.Context, v int) (int, error) {
+		if v%2 == 1 {
+			return 0, errBranch
+		}
+		return -v, nil
+	}
+	// combine записывает результаты ветвей и ошибки по порядку ветвей
+	describe := func(in int, results []int, errs []error) (string, error) {
+		s := fmt.Sprint(in, ":")
+		failed := 0
+		for i := range results {
+			if errs[i] != nil {
+				failed++
+				s += fmt.Sprintf(" %v(%d)", errs[i], results[i])
+				continue
+			}
+			s += fmt.Sprint(" ", results[i])
+		}
+		if failed == len(results) {
+			return "", errCombine
+		}
+		return s, nil
+	}
+	tests := []struct {
+		name     string
+		branches []func(context.Context, int) (int, error)
+		opts     []Option
+		items    []int
+		want     []string
+		errs     int
+	}{
+		{"scatter gather", []func(context.Context, int) (int, error){double, square}, nil, []int{1, 2, 3},
+			[]string{"1: 2 1", "2: 4 4", "3: 6 9"}, 0},
+		// ошибка ветви передаётся в combine с нулевым результатом и не попадает в errChan
+		{"branch error", []func(context.Context, int) (int, error){double, failOdd}, nil, []int{1, 2},
+			[]string{"1: 2 branch failed(0)", "2: 4 -2"}, 0},
+		{"combine error", []func(context.Context, int) (int, error){failOdd}, nil, []int{1, 2, 3},
+			[]string{"2: -2"}, 2},
+		{"ordered concurrent", []func(context.Context, int) (int, error){double, square},
+			[]Option{WithConcurrency(4), WithOrderedOutput()}, []int{5, 4, 3, 2, 1},
+			[]string{"5: 10 25", "4: 8 16", "3: 6 9", "2: 4 4", "1: 2 1"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := ScatterGather("scatter", tt.branches, describe, tt.opts...)
+			got, errs := process(t, n, tt.items...)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if len(errs) != tt.errs {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), tt.errs, errs)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, errCombine) {
+					t.Errorf("unexpected error %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestScatterGatherBranchesParallel(t *testing.T) {
+	const branches = 4
+	// каждая ветвь ждёт запуска остальных: последовательный вызов ветвей не завершится
+	var wg sync.WaitGroup
+	wg.Add(branches)
+	fns := make([]func(context.Context, int) (int, error), branches)
+	for i := range fns {
+		fns[i] = func(ctx context.Context, v int) (int, error) {
+			wg.Done()
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return v + i, nil
+			case <-time.After(5 * time.Second):
+				return 0, errors.New("branches are not parallel")
+			}
+		}
+	}
+	n := ScatterGather("scatter", fns, func(_ int, results []int, errs []error) (int, error) {
+		sum := 0
+		for i := range results {
+			if errs[i] != nil {
+				return 0, errs[i]
+			}
+			sum += results[i]
+		}
+		return sum, nil
+	})
+	got, errs := process(t, n, 10)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if !slices.Equal(got, []int{46}) {
+		t.Errorf("got %v, want [46]", got)
+	}
+}
+
+func TestScatterGatherBranchPanic(t *testing.T) {
+	n := ScatterGather("scatter", []func(context.Context, int) (int, error){
+		func(_ context.Context, v int) (int, error) { return v, nil },
+		func(context.Context, int) (int, error) { panic("boom") },
+	}, func(_ int, results []int, _ []error) (int, error) { return results[0], nil })
+	got, errs := process(t, n, 1)
+	var pe *PanicError
+	if len(errs) != 1 || !errors.As(errs[0], &pe) || pe.Value != "boom" {
+		t.Fatalf("errors = %v, want one *PanicError", errs)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v after a branch panic", got)
+	}
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrSeedType = errors.New("seed items type mismatch")
+
+// SeedInput добавляет элементы items (срез []I) для входа idx, которые узел обработает при запуске
+// раньше элементов, поступающих из входов. Используется для повторной подачи элементов,
+// оставшихся от предыдущего запуска (см. pipeline.Seed). Возвращает ErrSeedType, если тип
+// элементов не совпадает с типом входа.
+func (n *Node[I, O]) SeedInput(idx int, items any) error {
+	if idx < 0 || idx >= len(n.inputs) {
+		return n.wrapError(ErrInputIdxOutOfRange)
+	}
+
+	seeds, ok := items.([]I)
+	if !ok {
+		return n.wrapError(fmt.Errorf("%w: input %d accepts %T, got %T", ErrSeedType, idx, seeds, items))
+	}
+
+	if n.seeds == nil {
+		n.seeds = make([][]I, len(n.inputs))
+	}
+	n.seeds[idx] = append(n.seeds[idx], seeds...)
+	return nil
+}
+
+// takeSeeds возвращает накопленные элементы всех входов по порядку индексов и очищает их
+func (n *Node[I, O]) takeSeeds() []I {
+	var seeds []I
+	for _, s := range n.seeds {
+		seeds = append(seeds, s...)
+	}
+	n.seeds = nil
+	return seeds
+}
+
+// prependInput возвращает канал, выдающий сначала seeds, а затем элементы input до его закрытия
+// или отмены контекста
+func prependInput[T any](ctx context.Context, wg *sync.WaitGroup, seeds []T, input <-chan T) <-chan T {
+	proxy := make(chan T)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(proxy)
+		for _, val := range seeds {
+			select {
+			case proxy <- val:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		for {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					return
+				}
+				select {
+				case proxy <- val:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return proxy
+}
@@ -0,0 +1,57 @@
+package node
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestNewSelectRouting(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs [][]int
+		opts   []Option
+	}{
+		{"single input", [][]int{{1, 2, 3}}, nil},
+		{"three inputs", [][]int{{1, 2}, {3, 4, 5}, {}}, nil},
+		// пауза и статистика оборачивают каждый вход отдельно, не сливая их
+		{"with stats", [][]int{{1}, {2, 3}}, []Option{WithStats(), WithPausable()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// обработчик помечает значение номером входа, из которого оно прочитано
+			n := NewSelect("select", len(tt.inputs), 1, nil, func(ctx context.Context, inputs []<-chan int,
+				output chan<- [2]int, _ chan<- error) {
+				defer close(output)
+				if len(inputs) != len(tt.inputs) {
+					t.Errorf("handler got %d inputs, want %d", len(inputs), len(tt.inputs))
+					return
+				}
+				for i, in := range inputs {
+					for v := range in {
+						output <- [2]int{i, v}
+					}
+				}
+			}, tt.opts...)
+			var want [][2]int
+			for i, items := range tt.inputs {
+				for _, v := range items {
+					want = append(want, [2]int{i, v})
+				}
+				if err := n.SetInput(i, feed(items...)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out := make(chan [2]int, len(want))
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			if errs := runNodes(t, context.Background(), n); len(errs) > 0 {
+				t.Fatalf("errors: %v", errs)
+			}
+			if got := drain(out)(); !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package node
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// ShardedSink создаёт узел-приёмник с одним входом, записывающий каждое значение через encode в
+// писатель его ключа keyFn. Писатели открываются функцией open; одновременно открыто не более
+// maxOpen писателей, при превышении закрывается давно не использовавшийся. Для ключа, писатель
+// которого был закрыт, open вызывается повторно, поэтому она должна открывать файл на дозапись
+// (os.O_APPEND). Ошибки open, encode и закрытия (с указанием ключа) отправляются в канал ошибок,
+// значение при ошибке пропускается. Все писатели закрываются при финальном сбросе узла (см. WithFlush).
+// Паникует, если maxOpen < 1.
+func ShardedSink[T any, K comparable](name string, keyFn func(T) K, open func(K) (io.WriteCloser, error),
+	encode func(io.Writer, T) error, maxOpen int, opts ...Option) *Node[T, struct{}] {
+	if keyFn == nil || open == nil || encode == nil {
+		panic("nil sharded sink func")
+	}
+	if maxOpen < 1 {
+		panic("maxOpen must be positive")
+	}
+
+	cfg := newConfig(opts)
+	shards := &shardCache[K]{open: open, maxOpen: maxOpen, items: make(map[K]*list.Element), lru: list.New(),
+		held: &cfg.held}
+	userFlush := cfg.flush
+	cfg.flush = func(ctx context.Context) error {
+		err := shards.closeAll()
+		if userFlush != nil {
+			err = errors.Join(err, userFlush(ctx))
+		}
+		return err
+	}
+
+	handler := func(ctx context.Context, input <-chan T, _ chan<- struct{}, errChan chan<- error) {
+		for {
+			select {
+			case v, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					return
+				}
+				if Yield(ctx) != nil {
+					return
+				}
+
+				key := keyFn(v)
+				w, err := shards.get(key, errChan)
+				if err != nil {
+					errChan <- fmt.Errorf("shard %v: %w", key, err)
+					continue
+				}
+				if err := encode(w, v); err != nil {
+					errChan <- fmt.Errorf("shard %v: %w", key, err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	n := newNode[T, struct{}](name, 1, 0, nil, cfg)
+	n.handler = handler
+	return n
+}
+
+// shard открытый писатель ключа
+type shard[K comparable] struct {
+	key K
+	w   io.WriteCloser
+}
+
+// shardCache LRU открытых писателей
+type shardCache[K comparable] struct {
+	open    func(K) (io.WriteCloser, error)
+	maxOpen int
+	items   map[K]*list.Element
+	lru     *list.List
+	// held счётчик открытых писателей узла (BufferUsage)
+	held *atomic.Int64
+}
+
+// get возвращает писатель ключа, открывая его при необходимости. Ошибки закрытия вытесненного
+// писателя отправляются в errChan.
+func (c *shardCache[K]) get(key K, errChan chan<- error) (io.Writer, error) {
+	if el, ok := c.items[key]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*shard[K]).w, nil
+	}
+
+	if c.lru.Len() >= c.maxOpen {
+		oldest := c.lru.Back()
+		s := oldest.Value.(*shard[K])
+		c.lru.Remove(oldest)
+		delete(c.items, s.key)
+		if err := s.w.Close(); err != nil {
+			errChan <- fmt.Errorf("shard %v: close: %w", s.key, err)
+		}
+	}
+
+	w, err := c.open(key)
+	if err != nil {
+		return nil, err
+	}
+	c.items[key] = c.lru.PushFront(&shard[K]{key: key, w: w})
+	c.held.Store(int64(c.lru.Len()))
+	return w, nil
+}
+
+// closeAll закрывает все открытые писатели
+func (c *shardCache[K]) closeAll() error {
+	var errs []error
+	for el := c.lru.Front(); el != nil; el = el.Next() {
+		s := el.Value.(*shard[K])
+		if err := s.w.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("shard %v: close: %w", s.key, err))
+		}
+	}
+	c.lru.Init()
+	clear(c.items)
+	c.held.Store(0)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,101 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"strings"
+	"testing"
+)
+
+// shardStore «файлы» шардов в памяти: писатель дописывает в содержимое ключа, как файл с O_APPEND
+type shardStore struct {
+	files  map[string]*strings.Builder
+	opens  map[string]int
+	closes int
+}
+
+type shardWriter struct {
+	store *shardStore
+	key   string
+}
+
+func (w *shardWriter) Write(p []byte) (int, error) {
+	return w.store.files[w.key].Write(p)
+}
+
+func (w *shardWriter) Close() error {
+	w.store.closes++
+	return nil
+}
+
+func (s *shardStore) open(key string) (io.WriteCloser, error) {
+	if key == "x" {
+		return nil, errBadLine
+	}
+	if s.files[key] == nil {
+		s.files[key] = &strings.Builder{}
+	}
+	s.opens[key]++
+	return &shardWriter{store: s, key: key}, nil
+}
+
+func TestShardedSink(t *testing.T) {
+	items := []string{"a1", "b1", "a2", "c1", "b2", "a3", "x1"}
+	wantFiles := map[string]string{"a": "a1\na2\na3\n", "b": "b1\nb2\n", "c": "c1\n"}
+	tests := []struct {
+		name    string
+		maxOpen int
+		// wantOpens количество открытий писателя каждого ключа
+		wantOpens map[string]int
+	}{
+		{"all open", 3, map[string]int{"a": 1, "b": 1, "c": 1}},
+		// при двух открытых писателях c вытесняет b, b — a, а a — c, и они открываются повторно
+		{"eviction", 2, map[string]int{"a": 2, "b": 2, "c": 1}},
+		{"single", 1, map[string]int{"a": 3, "b": 2, "c": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &shardStore{files: make(map[string]*strings.Builder), opens: make(map[string]int)}
+			n := ShardedSink("sharded", func(v string) string { return v[:1] }, store.open, writeLine, tt.maxOpen)
+			if err := n.SetInput(0, feed(items...)); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := runNodes(t, context.Background(), n)
+			// ошибка открытия сообщается с ключом, значение пропускается
+			if len(errs) != 1 || !errors.Is(errs[0], errBadLine) || !strings.HasPrefix(errs[0].Error(), "shard x: ") {
+				t.Errorf("errors = %v, want one open error of shard x", errs)
+			}
+			got := make(map[string]string)
+			for key, b := range store.files {
+				got[key] = b.String()
+			}
+			if !maps.Equal(got, wantFiles) {
+				t.Errorf("shards %q, want %q", got, wantFiles)
+			}
+			if !maps.Equal(store.opens, tt.wantOpens) {
+				t.Errorf("opens %v, want %v", store.opens, tt.wantOpens)
+			}
+			// финальный сброс закрывает все оставшиеся писатели
+			opens := 0
+			for _, n := range store.opens {
+				opens += n
+			}
+			if store.closes != opens {
+				t.Errorf("%d writers closed, %d opened", store.closes, opens)
+			}
+		})
+	}
+}
+
+func TestShardedSinkInvalidMaxOpen(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("ShardedSink with maxOpen 0 did not panic")
+		}
+	}()
+	store := &shardStore{}
+	ShardedSink("sharded", func(v string) string { return v }, store.open, writeLine, 0)
+}
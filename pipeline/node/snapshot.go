@@ -0,0 +1,47 @@
+package node
+
+import "sync"
+
+// StatsGate согласует счётчики нескольких узлов для снимка: пока выполняется Hold, узлы с этим
+// шлюзом (SetStatsGate) не меняют счётчики, так что величины, вычисленные по нескольким узлам,
+// относятся к одному моменту. Пока шлюз подключён, каждое изменение счётчика узла берёт его
+// разделяемую блокировку, поэтому шлюз подключается только там, где нужны согласованные снимки.
+type StatsGate struct {
+	mu sync.RWMutex
+}
+
+// NewStatsGate создаёт шлюз счётчиков
+func NewStatsGate() *StatsGate {
+	return &StatsGate{}
+}
+
+// Hold вызывает fn, пока счётчики узлов с этим шлюзом не меняются. fn должна быть короткой:
+// на время её выполнения узлы останавливаются на учёте очередного элемента.
+func (g *StatsGate) Hold(fn func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	fn()
+}
+
+// enter начинает изменение счётчиков; для nil-шлюза ничего не делает
+func (g *StatsGate) enter() {
+	if g != nil {
+		g.mu.RLock()
+	}
+}
+
+// leave завершает изменение счётчиков, начатое enter
+func (g *StatsGate) leave() {
+	if g != nil {
+		g.mu.RUnlock()
+	}
+}
+
+// SetStatsGate подключает счётчики узла (Stats, включая InFlight) к шлюзу g. Вызывается до запуска
+// узла; nil отключает шлюз.
+func (n *Node[I, O]) SetStatsGate(g *StatsGate) {
+	n.cfg.statsGate = g
+	if n.counters != nil {
+		n.counters.gate = g
+	}
+}
@@ -0,0 +1,71 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// BenchmarkStatsGate стоимость учёта элемента узлом Map-стиля со статистикой без шлюза снимков и
+// с ним (SetStatsGate)
+func BenchmarkStatsGate(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		gate *StatsGate
+	}{
+		{"ungated", nil},
+		{"gated", NewStatsGate()},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			n := NewMap("map", func(_ context.Context, v int) (int, error) { return v, nil },
+				WithStats(), WithConcurrency(4))
+			n.SetStatsGate(bc.gate)
+			in := make(chan int, 1024)
+			out := make(chan int, 1024)
+			if err := n.SetInput(0, in); err != nil {
+				b.Fatal(err)
+			}
+			if err := n.SetOutput(0, out); err != nil {
+				b.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			errChan := make(chan error)
+			b.ResetTimer()
+			n.Run(context.Background(), &wg, errChan, true)
+			go func() {
+				defer close(in)
+				for i := range b.N {
+					in <- i
+				}
+			}()
+			for range out {
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// BenchmarkCountersGate учёт элементов счётчиками разных узлов (по узлу на горутину) без шлюза и
+// с общим шлюзом, как у узлов пайплайна с WithConsistentStats
+func BenchmarkCountersGate(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		gate *StatsGate
+	}{
+		{"ungated", nil},
+		{"gated", NewStatsGate()},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			now := time.Now()
+			b.RunParallel(func(pb *testing.PB) {
+				c := &counters{gate: bc.gate}
+				for pb.Next() {
+					c.countIn(now, -1)
+					c.countOut(now, -1)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package node
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// NodeState состояние запуска узла
+type NodeState int32
+
+const (
+	// StateNotStarted узел не запускался (или схлопнут в ребро, см. WithInline)
+	StateNotStarted NodeState = iota
+	// StateRunning узел запущен и его обработчик ещё не завершился
+	StateRunning
+	// StateDone обработчик узла завершился (или узел пропущен пайплайном)
+	StateDone
+)
+
+func (s NodeState) String() string {
+	switch s {
+	case StateNotStarted:
+		return "not started"
+	case StateRunning:
+		return "running"
+	case StateDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// State возвращает состояние последнего запуска узла. Безопасно вызывать из других горутин во
+// время работы пайплайна; причину завершения сообщает ExitReason.
+func (n *Node[I, O]) State() NodeState {
+	return NodeState(n.state.Load())
+}
+
+// WiredInputCount возвращает количество подключённых входов узла
+func (n *Node[I, O]) WiredInputCount() int {
+	wiring.Lock()
+	defer wiring.Unlock()
+	return bits.OnesCount64(n.inputsMask)
+}
+
+// WiredOutputCount возвращает количество подключённых выходов узла, включая помеченные
+// MarkOutputUnused
+func (n *Node[I, O]) WiredOutputCount() int {
+	wiring.Lock()
+	defer wiring.Unlock()
+	return bits.OnesCount64(n.outputsMask)
+}
+
+// String возвращает имя, подключение и состояние узла, например "Hasher 3 [in 1/1, out 1/1, running]"
+func (n *Node[I, O]) String() string {
+	return fmt.Sprintf("%s [in %d/%d, out %d/%d, %s]", n.name, n.WiredInputCount(), len(n.inputs),
+		n.WiredOutputCount(), len(n.outputs), n.State())
+}
@@ -0,0 +1,81 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestNodeString(t *testing.T) {
+	newNode := func() *Node[int, int] {
+		return New("Hasher 3", 2, 2, nil, func(ctx context.Context, input <-chan int, output chan<- int, _ chan<- error) {
+			defer close(output)
+			for v := range input {
+				output <- v
+			}
+		})
+	}
+	wireAll := func(t *testing.T, n *Node[int, int], in chan int) {
+		t.Helper()
+		for i := range 2 {
+			if err := n.SetInput(i, in); err != nil {
+				t.Fatal(err)
+			}
+			if err := n.SetOutput(i, make(chan int, 1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, n *Node[int, int])
+		wantIn  int
+		wantOut int
+		want    string
+	}{
+		{"unwired", func(*testing.T, *Node[int, int]) {}, 0, 0, "Hasher 3 [in 0/2, out 0/2, not started]"},
+		{"partially wired", func(t *testing.T, n *Node[int, int]) {
+			if err := n.SetInput(1, make(chan int)); err != nil {
+				t.Fatal(err)
+			}
+		}, 1, 0, "Hasher 3 [in 1/2, out 0/2, not started]"},
+		{"fully wired", func(t *testing.T, n *Node[int, int]) {
+			wireAll(t, n, make(chan int))
+		}, 2, 2, "Hasher 3 [in 2/2, out 2/2, not started]"},
+		{"running", func(t *testing.T, n *Node[int, int]) {
+			in := make(chan int)
+			wireAll(t, n, in)
+			var wg sync.WaitGroup
+			n.Run(context.Background(), &wg, make(chan error), true)
+			t.Cleanup(func() {
+				close(in)
+				waitGroup(t, &wg)
+			})
+			eventually(t, func() bool { return n.State() == StateRunning })
+		}, 2, 2, "Hasher 3 [in 2/2, out 2/2, running]"},
+		{"done", func(t *testing.T, n *Node[int, int]) {
+			in := make(chan int)
+			close(in)
+			wireAll(t, n, in)
+			var wg sync.WaitGroup
+			n.Run(context.Background(), &wg, make(chan error), true)
+			waitGroup(t, &wg)
+		}, 2, 2, "Hasher 3 [in 2/2, out 2/2, done]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newNode()
+			tt.prepare(t, n)
+			if got := n.WiredInputCount(); got != tt.wantIn {
+				t.Errorf("WiredInputCount = %d, want %d", got, tt.wantIn)
+			}
+			if got := n.WiredOutputCount(); got != tt.wantOut {
+				t.Errorf("WiredOutputCount = %d, want %d", got, tt.wantOut)
+			}
+			if got := n.String(); got != tt.want {
+				t.Errorf("String = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
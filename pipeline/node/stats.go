@@ -0,0 +1,251 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats снимок статистики узла
+type Stats struct {
+	ItemsIn  uint64
+	ItemsOut uint64
+	Errors   uint64
+	// Discarded элементы входа, отброшенные после досрочного завершения обработчика (WithEarlyExit)
+	Discarded uint64
+	// Suppressed ошибки, подавленные WithErrorTransform
+	Suppressed uint64
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Circuit состояние выключателя (для узлов с WithCircuitBreaker)
+	Circuit CircuitState
+	// InFlight элементы, обрабатываемые функцией узла в данный момент (узлы Map-стиля).
+	// Считается точно и без WithStats.
+	InFlight int64
+	// Busy суммарное время в функции узла при WithCPUAccounting
+	Busy time.Duration
+	// Shed значения, отброшенные при заполненном выходе (Emitter.Shed, Sample). Считается без WithStats.
+	Shed uint64
+	// BytesIn, BytesOut объём прочитанных и отправленных элементов по WithSizeFunc
+	BytesIn  uint64
+	BytesOut uint64
+	// LastActivity время последнего прочитанного или отправленного элемента
+	LastActivity time.Time
+	// Bypass узел в режиме обхода, Bypassed число обойдённых элементов (WithErrorRateBreaker).
+	// Считаются без WithStats.
+	Bypass   bool
+	Bypassed uint64
+}
+
+// counters атомарные счётчики узла со статистикой (WithStats). Принадлежат одному узлу и
+// обнуляются перед каждым его запуском. Пока подключён шлюз gate, каждое изменение выполняется
+// под его разделяемой блокировкой, чтобы снимок нескольких узлов относился к одному моменту.
+type counters struct {
+	itemsIn    atomic.Uint64
+	itemsOut   atomic.Uint64
+	errors     atomic.Uint64
+	discarded  atomic.Uint64
+	suppressed atomic.Uint64
+	bytesIn    atomic.Uint64
+	bytesOut   atomic.Uint64
+	startedAt  atomic.Int64
+	finishedAt atomic.Int64
+	// lastActivity время последнего прочитанного или отправленного элемента (UnixNano)
+	lastActivity atomic.Int64
+	// gate шлюз согласованного снимка (SetStatsGate)
+	gate *StatsGate
+}
+
+// snapshot возвращает текущие значения счётчиков
+func (c *counters) snapshot() Stats {
+	s := Stats{
+		ItemsIn:    c.itemsIn.Load(),
+		ItemsOut:   c.itemsOut.Load(),
+		Errors:     c.errors.Load(),
+		Discarded:  c.discarded.Load(),
+		Suppressed: c.suppressed.Load(),
+		BytesIn:    c.bytesIn.Load(),
+		BytesOut:   c.bytesOut.Load(),
+	}
+	if ts := c.startedAt.Load(); ts != 0 {
+		s.StartedAt = time.Unix(0, ts)
+	}
+	if ts := c.finishedAt.Load(); ts != 0 {
+		s.FinishedAt = time.Unix(0, ts)
+	}
+	if ts := c.lastActivity.Load(); ts != 0 {
+		s.LastActivity = time.Unix(0, ts)
+	}
+	return s
+}
+
+// reset обнуляет счётчики
+func (c *counters) reset() {
+	c.itemsIn.Store(0)
+	c.itemsOut.Store(0)
+	c.errors.Store(0)
+	c.discarded.Store(0)
+	c.suppressed.Store(0)
+	c.bytesIn.Store(0)
+	c.bytesOut.Store(0)
+	c.startedAt.Store(0)
+	c.finishedAt.Store(0)
+	c.lastActivity.Store(0)
+}
+
+// countIn учитывает прочитанный элемент размера size (-1, если размер не считается). Элемент
+// учитывается до передачи обработчику, чтобы в любом снимке ItemsIn узла был не меньше ItemsOut
+// элементов, порождённых из него; если передача не состоялась, учёт отменяется uncountIn.
+func (c *counters) countIn(now time.Time, size int) {
+	c.gate.enter()
+	defer c.gate.leave()
+	c.itemsIn.Add(1)
+	if size >= 0 {
+		c.bytesIn.Add(uint64(size))
+	}
+	c.lastActivity.Store(now.UnixNano())
+}
+
+// uncountIn отменяет учёт элемента countIn
+func (c *counters) uncountIn(size int) {
+	c.gate.enter()
+	defer c.gate.leave()
+	c.itemsIn.Add(^uint64(0))
+	if size >= 0 {
+		c.bytesIn.Add(^uint64(size - 1))
+	}
+}
+
+// countOut учитывает отправленный элемент размера size (-1, если размер не считается). Элемент
+// учитывается до отправки, чтобы в любом снимке ItemsOut узла был не меньше ItemsIn получателя;
+// если отправка не состоялась, учёт отменяется uncountOut.
+func (c *counters) countOut(now time.Time, size int) {
+	c.gate.enter()
+	defer c.gate.leave()
+	c.itemsOut.Add(1)
+	if size >= 0 {
+		c.bytesOut.Add(uint64(size))
+	}
+	c.lastActivity.Store(now.UnixNano())
+}
+
+// uncountOut отменяет учёт элемента countOut
+func (c *counters) uncountOut(size int) {
+	c.gate.enter()
+	defer c.gate.leave()
+	c.itemsOut.Add(^uint64(0))
+	if size >= 0 {
+		c.bytesOut.Add(^uint64(size - 1))
+	}
+}
+
+// countError учитывает ошибку узла
+func (c *counters) countError() {
+	c.gate.enter()
+	defer c.gate.leave()
+	c.errors.Add(1)
+}
+
+// sizeOf возвращает размер значения по size или -1, если size не задан
+func sizeOf[T any](size func(T) int, val T) int {
+	if size == nil {
+		return -1
+	}
+	return max(size(val), 0)
+}
+
+// resetStats обнуляет накопленную статистику узла перед запуском, чтобы Stats относилась к
+// текущему запуску
+func (n *Node[I, O]) resetStats() {
+	if n.counters != nil {
+		n.counters.reset()
+	}
+	n.cfg.busy.Store(0)
+	n.cfg.shed.Store(0)
+	if n.rateBreaker != nil {
+		n.rateBreaker.reset()
+	}
+}
+
+// HasStats сообщает, что узел ведёт статистику (WithStats или включающие её опции)
+func (n *Node[I, O]) HasStats() bool {
+	return n.counters != nil
+}
+
+// Stats возвращает статистику последнего запуска узла. Если узел создан без WithStats, счётчики нулевые.
+func (n *Node[I, O]) Stats() Stats {
+	var s Stats
+	if n.counters != nil {
+		s = n.counters.snapshot()
+	}
+	if n.breaker != nil {
+		s.Circuit = n.breaker.State()
+	}
+	if n.rateBreaker != nil {
+		s.Bypass = n.rateBreaker.bypass.Load()
+		s.Bypassed = n.rateBreaker.bypassed.Load()
+	}
+	if n.cfg != nil {
+		s.InFlight = n.cfg.inFlight.Load()
+		s.Busy = time.Duration(n.cfg.busy.Load())
+		s.Shed = n.cfg.shed.Load()
+	}
+	return s
+}
+
+// countInput оборачивает входной канал, подсчитывая в c прочитанные элементы, время последнего из
+// них и, если задан size, их объём. Канал-обёртка закрывается при закрытии input или отмене контекста.
+func countInput[T any](ctx context.Context, wg *sync.WaitGroup, input <-chan T, c *counters, clock Clock,
+	size func(T) int) <-chan T {
+	proxy := make(chan T)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(proxy)
+		for {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					return
+				}
+				n := sizeOf(size, val)
+				c.countIn(clock.Now(), n)
+				select {
+				case proxy <- val:
+				case <-ctx.Done():
+					c.uncountIn(n)
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return proxy
+}
+
+// countOutput оборачивает выходной канал, подсчитывая в c отправленные элементы, время последнего из
+// них и, если задан size, их объём. Канал output закрывается после закрытия обёртки обработчиком.
+// После отмены контекста элементы отбрасываются, чтобы обработчик не блокировался на отправке.
+func countOutput[T any](ctx context.Context, wg *sync.WaitGroup, output chan<- T, c *counters, clock Clock,
+	size func(T) int) chan<- T {
+	proxy := make(chan T)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(output)
+		for val := range proxy {
+			n := sizeOf(size, val)
+			c.countOut(clock.Now(), n)
+			select {
+			case output <- val:
+			case <-ctx.Done():
+				c.uncountOut(n)
+			}
+		}
+	}()
+
+	return proxy
+}
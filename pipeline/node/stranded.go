@@ -0,0 +1,83 @@
+package node
+
+import (
+	"context"
+	"fmt"
+)
+
+// StrandedEdge элементы, оставшиеся в буфере выходного канала узла после остановки пайплайна
+type StrandedEdge struct {
+	// Edge имя ребра: "<узел>[<выход>] -> <узел>[<вход>]" для рёбер, созданных Connect,
+	// "<узел>[<выход>]" для каналов, заданных через SetOutput, "<узел> fan-in" для внутреннего
+	// буфера слияния входов и "<узел> input" для элемента, удержанного пересылкой входа
+	// (WithExitTracking)
+	Edge  string
+	Count int
+	// Items извлечённые элементы (не более limit), только для рёбер, созданных Connect, и "<узел> input"
+	Items []any
+}
+
+// Stranded возвращает количество элементов в буферах выходов узла и извлекает до limit элементов
+// из каждого ребра, созданного Connect. Вызывать только после завершения всех узлов пайплайна,
+// иначе результат не определён.
+func (n *Node[I, O]) Stranded(limit int) []StrandedEdge {
+	if n.collapsed {
+		return nil
+	}
+
+	var edges []StrandedEdge
+	if n.held != nil {
+		edge := StrandedEdge{Edge: n.name + " input", Count: 1}
+		if limit > 0 {
+			edge.Items = []any{*n.held}
+		}
+		edges = append(edges, edge)
+	}
+	if n.fanInStranded > 0 {
+		edges = append(edges, StrandedEdge{Edge: n.name + " fan-in", Count: n.fanInStranded})
+	}
+	for i, output := range n.outputs {
+		if output == nil || len(output) == 0 {
+			continue
+		}
+
+		edge := StrandedEdge{Edge: fmt.Sprintf("%s[%d]", n.name, i), Count: len(output)}
+		if i < len(n.edges) && n.edges[i].ch != nil {
+			edge.Edge = n.edges[i].name
+			for len(edge.Items) < limit {
+				val, ok := <-n.edges[i].ch
+				if !ok {
+					break
+				}
+				edge.Items = append(edge.Items, val)
+			}
+		}
+		edges = append(edges, edge)
+	}
+	return edges
+}
+
+// edge канал, созданный Connect, и имя ребра
+type edge[T any] struct {
+	name string
+	ch   chan T
+}
+
+// setEdge запоминает ребро, созданное Connect
+func (n *Node[I, O]) setEdge(outIdx int, name string, ch chan O) {
+	if n.edges == nil {
+		n.edges = make([]edge[O], len(n.outputs))
+	}
+	n.edges[outIdx] = edge[O]{name: name, ch: ch}
+}
+
+// drainFanIn после отмены контекста дочитывает буфер слияния входов, дожидаясь завершения
+// горутин FanIn, чтобы они не забрали элементы из рёбер после остановки узлов
+func (n *Node[I, O]) drainFanIn(ctx context.Context, merged <-chan I) {
+	if ctx.Err() == nil {
+		return
+	}
+	for range merged {
+		n.fanInStranded++
+	}
+}
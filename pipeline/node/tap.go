@@ -0,0 +1,64 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// TapOutput задаёт функцию, вызываемую для каждого значения, отправленного в выход idx (например,
+// для записи трафика ребра). tap вызывается после успешной отправки в горутине-обёртке выхода.
+// Возвращает ErrFrozen после Freeze и ErrAlreadyRunning во время работы узла.
+func (n *Node[I, O]) TapOutput(idx int, tap func(v any)) error {
+	wiring.Lock()
+	defer wiring.Unlock()
+	if n.frozen {
+		return n.wrapError(ErrFrozen)
+	}
+	if n.State() == StateRunning {
+		return n.wrapError(ErrAlreadyRunning)
+	}
+	if idx < 0 || idx >= len(n.outputs) {
+		return n.wrapError(ErrOutputIdxOutOfRange)
+	}
+	if n.taps == nil {
+		n.taps = make([]func(any), len(n.outputs))
+	}
+	n.taps[idx] = tap
+	return nil
+}
+
+// OutputType возвращает тип значений выходов узла
+func (n *Node[I, O]) OutputType() reflect.Type {
+	return reflect.TypeFor[O]()
+}
+
+// tapOutputs возвращает выходы узла, где выходы с заданным TapOutput обёрнуты tapOutput
+func tapOutputs[O any](ctx context.Context, wg *sync.WaitGroup, outputs []chan<- O, taps []func(any)) []chan<- O {
+	tapped := append([]chan<- O(nil), outputs...)
+	for i, tap := range taps {
+		if tap != nil && tapped[i] != nil {
+			tapped[i] = tapOutput(ctx, wg, tapped[i], tap)
+		}
+	}
+	return tapped
+}
+
+// tapOutput ретранслирует output, вызывая tap для каждого отправленного значения
+func tapOutput[T any](ctx context.Context, wg *sync.WaitGroup, output chan<- T, tap func(v any)) chan<- T {
+	proxy := make(chan T)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(output)
+		for val := range proxy {
+			select {
+			case output <- val:
+				tap(val)
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return proxy
+}
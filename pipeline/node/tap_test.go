@@ -0,0 +1,54 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestTapOutput(t *testing.T) {
+	release := make(chan struct{})
+	n := NewMap("double", func(_ context.Context, v int) (int, error) {
+		<-release
+		return 2 * v, nil
+	})
+	if err := n.SetInput(0, feed(1, 2, 3)); err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan int, 3)
+	if err := n.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var tapped []int
+	if err := n.TapOutput(1, func(any) {}); !errors.Is(err, ErrOutputIdxOutOfRange) {
+		t.Errorf("TapOutput(1) = %v, want %v", err, ErrOutputIdxOutOfRange)
+	}
+	if err := n.TapOutput(0, func(v any) {
+		mu.Lock()
+		defer mu.Unlock()
+		tapped = append(tapped, v.(int))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, 1)
+	n.Run(context.Background(), &wg, errChan, true)
+	eventually(t, func() bool { return n.State() == StateRunning })
+	// во время работы отвод не меняется
+	if err := n.TapOutput(0, func(any) {}); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("TapOutput while running = %v, want %v", err, ErrAlreadyRunning)
+	}
+	close(release)
+	waitGroup(t, &wg)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []int{2, 4, 6}; !slices.Equal(tapped, want) {
+		t.Errorf("tapped %v, want %v", tapped, want)
+	}
+}
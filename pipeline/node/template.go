@@ -0,0 +1,94 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+// Template шаблон для TemplateSink; ему удовлетворяют *text/template.Template и *html/template.Template
+type Template interface {
+	Execute(w io.Writer, data any) error
+}
+
+// WithCollect включает сборный режим TemplateSink: элементы накапливаются, и шаблон выполняется
+// один раз со срезом всех элементов после закрытия входа (например, для итогового отчёта)
+func WithCollect() Option {
+	return func(c *config) {
+		c.collect = true
+	}
+}
+
+// TemplateSink создаёт узел-приёмник с одним входом, выполняющий tmpl для каждого значения и
+// пишущий результат в w через буфер, который сбрасывается при финальном сбросе узла (см. WithFlush).
+// Результат выполнения пишется целиком, только если шаблон выполнился без ошибки; иначе значение
+// отправляется как DeadLetter (см. WithDeadLetter), и приёмник продолжает работу. С WithCollect шаблон выполняется
+// один раз со срезом []T всех значений после закрытия входа; при отмене контекста неполный отчёт
+// не выводится.
+func TemplateSink[T any](name string, tmpl Template, w io.Writer, opts ...Option) *Node[T, struct{}] {
+	if tmpl == nil {
+		panic("nil template")
+	}
+
+	cfg := newConfig(opts)
+	name = autoName(name, "TemplateSink", cfg)
+	buf := bufio.NewWriter(w)
+	userFlush := cfg.flush
+	cfg.flush = func(ctx context.Context) error {
+		err := buf.Flush()
+		if userFlush != nil {
+			err = errors.Join(err, userFlush(ctx))
+		}
+		return err
+	}
+
+	var rendered bytes.Buffer
+	render := func(data any) error {
+		rendered.Reset()
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return err
+		}
+		_, err := buf.Write(rendered.Bytes())
+		return err
+	}
+
+	handler := func(ctx context.Context, input <-chan T, _ chan<- struct{}, errChan chan<- error) {
+		var items []T
+		cfg.held.Store(0)
+		for {
+			select {
+			case v, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					if cfg.collect {
+						if err := render(items); err != nil {
+							errChan <- err
+						}
+						cfg.held.Store(0)
+					}
+					return
+				}
+				if Yield(ctx) != nil {
+					return
+				}
+
+				if cfg.collect {
+					items = append(items, v)
+					cfg.held.Add(1)
+					continue
+				}
+				if err := render(v); err != nil && !cfg.sendError(ctx, errChan, &DeadLetter{Node: name, Item: v, Err: err}) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	n := newNode[T, struct{}](name, 1, 0, nil, cfg)
+	n.handler = handler
+	return n
+}
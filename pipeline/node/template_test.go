@@ -0,0 +1,124 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"flag"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+var errMissingHash = errors.New("missing hash")
+
+type reportItem struct {
+	Path string
+	Hash string
+}
+
+// Checked возвращает хеш или ошибку, если его нет; ошибка прерывает выполнение шаблона
+func (r reportItem) Checked() (string, error) {
+	if r.Hash == "" {
+		return "", errMissingHash
+	}
+	return r.Hash, nil
+}
+
+var reportItems = []reportItem{{"a.txt", "0cc175b9"}, {"<b>.txt", "92eb5ffe"}, {"c.txt", "4a8a08f0"}}
+
+// checkGolden сравнивает got с содержимым testdata/name; с флагом -update перезаписывает файл
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestTemplateSink(t *testing.T) {
+	line := template.Must(template.New("line").Parse("{{.Path}}: {{.Checked}}\n"))
+	table := htmltemplate.Must(htmltemplate.New("table").Parse(
+		"<table>\n{{range .}}<tr><td>{{.Path}}</td><td>{{.Hash}}</td></tr>\n{{end}}</table>\n"))
+	tests := []struct {
+		name   string
+		tmpl   Template
+		opts   []Option
+		items  []reportItem
+		golden string
+		// wantBad пути значений, отправленных как DeadLetter
+		wantBad []string
+	}{
+		{"per item", line, nil, reportItems, "template_items.golden", nil},
+		// частично выполненный шаблон не выводится, приёмник продолжает работу
+		{"execution error", line, nil, []reportItem{reportItems[0], {Path: "b.txt"}, reportItems[2]},
+			"template_error.golden", []string{"b.txt"}},
+		// в сборном режиме html/template выполняется один раз со срезом всех значений
+		{"collect", table, []Option{WithCollect()}, reportItems, "template_collect.golden", nil},
+		{"collect empty", table, []Option{WithCollect()}, nil, "template_collect_empty.golden", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			n := TemplateSink[reportItem]("report", tt.tmpl, &out, tt.opts...)
+			if err := n.SetInput(0, feed(tt.items...)); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := runNodes(t, context.Background(), n)
+			checkGolden(t, tt.golden, out.String())
+			if len(errs) != len(tt.wantBad) {
+				t.Fatalf("errors = %v, want %d dead letters", errs, len(tt.wantBad))
+			}
+			for i, err := range errs {
+				var dl *DeadLetter
+				if !errors.As(err, &dl) || dl.Item.(reportItem).Path != tt.wantBad[i] || !errors.Is(err, errMissingHash) {
+					t.Errorf("error %v, want a dead letter of %s", err, tt.wantBad[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateSinkCollectCancelled(t *testing.T) {
+	table := template.Must(template.New("table").Parse("{{range .}}{{.Path}}\n{{end}}"))
+	var out strings.Builder
+	n := TemplateSink[reportItem]("report", table, &out, WithCollect())
+	input := make(chan reportItem, len(reportItems))
+	for _, v := range reportItems {
+		input <- v
+	}
+	if err := n.SetInput(0, input); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// вход прочитан, но не закрыт: отмена прерывает сбор
+		for len(input) > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	if errs := runNodes(t, ctx, n); len(errs) > 0 {
+		t.Errorf("errors: %v", errs)
+	}
+	if out.Len() != 0 {
+		t.Errorf("cancelled collect wrote %q, want no report", out.String())
+	}
+}
@@ -0,0 +1,84 @@
+package node
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// ThrottleNode узел, пропускающий элементы без изменений с ограничением скорости
+type ThrottleNode[T any] struct {
+	*Node[T, T]
+	// interval минимальный интервал между элементами в наносекундах, 0 без ограничения
+	interval atomic.Int64
+}
+
+// NewThrottle создаёт узел, пропускающий не более rps элементов в секунду (rps <= 0 снимает
+// ограничение). Скорость меняется на ходу через SetRate или команду CmdSetParam с Param "rate"
+// и значением float64. Количество входов и выходов задаёт WithPorts, буферы выходов —
+// WithOutputBuffers. Поддерживает паузу.
+func NewThrottle[T any](name string, rps float64, opts ...Option) *ThrottleNode[T] {
+	cfg := newConfig(opts)
+	cfg.gated = true
+
+	t := &ThrottleNode[T]{}
+	t.SetRate(rps)
+	cfg.controller = controlFunc(func(cmd Command) error {
+		if cmd.Kind != CmdSetParam || cmd.Param != "rate" {
+			return ErrUnsupportedCommand
+		}
+		rps, err := paramValue[float64](cmd)
+		if err != nil {
+			return err
+		}
+		t.SetRate(rps)
+		return nil
+	})
+
+	inputNum, outputNum := cfg.portCounts()
+	t.Node = newNode[T, T](name, inputNum, outputNum, nil, cfg)
+	t.handler = func(ctx context.Context, input <-chan T, output chan<- T, errChan chan<- error) {
+		defer close(output)
+		var last time.Time
+		for cfg.gate.wait(ctx) {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					return
+				}
+				if Yield(ctx) != nil {
+					return
+				}
+
+				if interval := time.Duration(t.interval.Load()); interval > 0 && !last.IsZero() {
+					if !sleep(ctx, cfg.clock, last.Add(interval).Sub(cfg.clock.Now())) {
+						return
+					}
+				}
+				last = cfg.clock.Now()
+
+				select {
+				case output <- val:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	return t
+}
+
+// SetRate меняет ограничение скорости; действует со следующего элемента. Безопасен для вызова
+// во время работы узла.
+func (t *ThrottleNode[T]) SetRate(rps float64) {
+	var interval int64
+	if rps > 0 {
+		interval = int64(math.Round(float64(time.Second) / rps))
+	}
+	t.interval.Store(interval)
+}
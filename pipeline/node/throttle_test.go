@@ -0,0 +1,92 @@
+package node
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitLog Clock с остановленным временем: ожидания срабатывают сразу, а их длительности
+// запоминаются
+type waitLog struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *waitLog) Now() time.Time {
+	return c.now
+}
+
+func (c *waitLog) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// take возвращает ожидания, запрошенные с прошлого вызова
+func (c *waitLog) take() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	waits := c.waits
+	c.waits = nil
+	return waits
+}
+
+func TestThrottleSetRate(t *testing.T) {
+	setParam := func(v any) func(*ThrottleNode[int]) error {
+		return func(n *ThrottleNode[int]) error {
+			return n.Control(Command{Kind: CmdSetParam, Param: "rate", Value: v})
+		}
+	}
+	setRate := func(rps float64) func(*ThrottleNode[int]) error {
+		return func(n *ThrottleNode[int]) error {
+			n.SetRate(rps)
+			return nil
+		}
+	}
+	// шаги выполняются по порядку на одном узле; время стоит, поэтому каждый элемент после
+	// первого ждёт полный интервал текущей скорости
+	steps := []struct {
+		name     string
+		change   func(*ThrottleNode[int]) error
+		wantErr  error
+		wantWait time.Duration
+	}{
+		{"first item", nil, nil, 0},
+		{"initial rate", nil, nil, 100 * time.Millisecond},
+		{"SetRate", setRate(4), nil, 250 * time.Millisecond},
+		{"command", setParam(20.0), nil, 50 * time.Millisecond},
+		{"command wrong type", setParam(5), ErrParamType, 50 * time.Millisecond},
+		{"unknown param", func(n *ThrottleNode[int]) error {
+			return n.Control(Command{Kind: CmdSetParam, Param: "burst", Value: 5.0})
+		}, ErrUnsupportedCommand, 50 * time.Millisecond},
+		{"unlimited by command", setParam(0.0), nil, 0},
+		{"limited again", setRate(1), nil, time.Second},
+		{"negative rate", setRate(-1), nil, 0},
+	}
+	clock := &waitLog{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	n := NewThrottle[int]("throttle", 10, WithClock(clock))
+	s := newStepper(t, n.Node)
+	for i, st := range steps {
+		if st.change != nil {
+			if err := st.change(n); !errors.Is(err, st.wantErr) {
+				t.Fatalf("%s: error = %v, want %v", st.name, err, st.wantErr)
+			}
+		}
+		if v, err := s.step(i); err != nil || v != i {
+			t.Fatalf("%s: step(%d) = %d, %v", st.name, i, v, err)
+		}
+		var want []time.Duration
+		if st.wantWait > 0 {
+			want = []time.Duration{st.wantWait}
+		}
+		if got := clock.take(); len(got) != len(want) || len(got) > 0 && got[0] != want[0] {
+			t.Errorf("%s: waits %v, want %v", st.name, got, want)
+		}
+	}
+}
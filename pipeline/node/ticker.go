@@ -0,0 +1,89 @@
+package node
+
+import (
+	"context"
+	"time"
+)
+
+// TickerSource создаёт бесконечный узел-источник с одним выходом, выдающий текущее время
+// каждые interval. Время берётся из Clock узла (WithClock). Если нижестоящий узел не успевает
+// принимать тики, пропущенные тики схлопываются: в ожидании находится не более одного, самого
+// свежего тика. Источник завершается при отмене контекста или через StopSource.
+// Паникует, если interval <= 0.
+func TickerSource(name string, interval time.Duration, opts ...Option) *Node[struct{}, time.Time] {
+	if interval <= 0 {
+		panic("non-positive interval")
+	}
+
+	return newTickSource(name, func(t time.Time) time.Time {
+		return t.Add(interval)
+	}, opts)
+}
+
+// CronSource создаёт бесконечный узел-источник с одним выходом, выдающий время срабатывания
+// по расписанию schedule в формате cron из 5 полей (минута, час, день месяца, месяц, день недели).
+// Поведение при отставании нижестоящих узлов и остановка такие же, как у TickerSource.
+// Возвращает ошибку, если расписание не разобрано.
+func CronSource(name string, schedule string, opts ...Option) (*Node[struct{}, time.Time], error) {
+	sched, err := parseCron(schedule)
+	if err != nil {
+		return nil, &NodeError{Node: name, Err: err}
+	}
+
+	return newTickSource(name, sched.next, opts), nil
+}
+
+// newTickSource создаёт источник тиков, моменты срабатывания которого задаёт next. Нулевое
+// время от next означает, что срабатываний больше не будет.
+func newTickSource(name string, next func(time.Time) time.Time, opts []Option) *Node[struct{}, time.Time] {
+	cfg := newConfig(opts)
+	cfg.infinite = true
+	stop := newStopSignal()
+	handler := func(ctx context.Context, _ <-chan struct{}, output chan<- time.Time, _ chan<- error) {
+		defer close(output)
+		ctx, cancel := stop.withStop(ctx)
+		defer cancel()
+		emitTicks(ctx, cfg.clock, next, output)
+	}
+
+	n := newNode[struct{}, time.Time](name, 0, 1, nil, cfg)
+	n.handler = handler
+	n.stop = stop
+	return n
+}
+
+// emitTicks ожидает моменты срабатывания next и отправляет их в output до отмены контекста.
+// Пока output не готов принять тик, новые тики заменяют ожидающий.
+func emitTicks(ctx context.Context, clock Clock, next func(time.Time) time.Time, output chan<- time.Time) {
+	schedule := func(prev time.Time) (time.Time, <-chan time.Time) {
+		now := clock.Now()
+		at := next(prev)
+		if !at.IsZero() && !at.After(now) {
+			at = next(now)
+		}
+		if at.IsZero() {
+			return at, nil
+		}
+		return at, clock.After(at.Sub(now))
+	}
+
+	at, timer := schedule(clock.Now())
+	var pending time.Time
+	var hasPending bool
+	for timer != nil || hasPending {
+		var out chan<- time.Time
+		if hasPending {
+			out = output
+		}
+
+		select {
+		case t := <-timer:
+			pending, hasPending = t, true
+			at, timer = schedule(at)
+		case out <- pending:
+			hasPending = false
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTickerSourceStops(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(n *Node[struct{}, time.Time], cancel context.CancelFunc)
+		want ExitReason
+	}{
+		{"cancel", func(_ *Node[struct{}, time.Time], cancel context.CancelFunc) { cancel() }, ExitCancelled},
+		{"StopSource", func(n *Node[struct{}, time.Time], _ context.CancelFunc) { n.StopSource() }, ExitInputClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := TickerSource("ticker", 2*time.Millisecond)
+			if !n.InfiniteSource() {
+				t.Error("TickerSource is not an infinite source")
+			}
+			out := make(chan time.Time)
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var wg sync.WaitGroup
+			n.Run(ctx, &wg, make(chan error), true)
+
+			var prev time.Time
+			for range 3 {
+				select {
+				case tick := <-out:
+					if !tick.After(prev) {
+						t.Errorf("tick %v not after %v", tick, prev)
+					}
+					prev = tick
+				case <-time.After(5 * time.Second):
+					t.Fatal("no tick")
+				}
+			}
+			tt.stop(n, cancel)
+			// после остановки выход закрывается; ожидавший тик может быть выдан
+			got := drain(out)
+			waitGroup(t, &wg)
+			if ticks := got(); len(ticks) > 1 {
+				t.Errorf("%d ticks after the stop", len(ticks))
+			}
+			if r := n.ExitReason(); r != tt.want {
+				t.Errorf("ExitReason = %v, want %v", r, tt.want)
+			}
+		})
+	}
+}
+
+func TestTickerSourceCoalesces(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	n := TickerSource("ticker", time.Second, WithClock(clock))
+	out := make(chan time.Time)
+	if err := n.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	n.Run(ctx, &wg, make(chan error), true)
+	defer func() {
+		cancel()
+		waitGroup(t, &wg)
+	}()
+
+	// три тика без чтения выхода: ожидает только последний
+	for range 3 {
+		eventually(t, func() bool { return clock.waiting() == 1 })
+		clock.Advance(time.Second)
+	}
+	eventually(t, func() bool { return clock.waiting() == 1 })
+	if tick := <-out; !tick.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("tick %v, want the latest %v", tick, start.Add(3*time.Second))
+	}
+	select {
+	case tick := <-out:
+		t.Errorf("coalesced tick %v delivered", tick)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestCronSource(t *testing.T) {
+	clock := newFakeClock()
+	n, err := CronSource("cron", "*/15 * * * *", WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan time.Time)
+	if err := n.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	n.Run(ctx, &wg, make(chan error), true)
+
+	start := clock.Now()
+	for i := 1; i <= 2; i++ {
+		eventually(t, func() bool { return clock.waiting() == 1 })
+		clock.Advance(15 * time.Minute)
+		if tick := <-out; !tick.Equal(start.Add(time.Duration(i) * 15 * time.Minute)) {
+			t.Errorf("tick %d at %v, want %v", i, tick, start.Add(time.Duration(i)*15*time.Minute))
+		}
+	}
+	cancel()
+	waitGroup(t, &wg)
+	if _, ok := <-out; ok {
+		t.Error("output not closed after cancel")
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 — понедельник
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 * * * *", at(1, 0, 30), at(1, 1, 0)},
+		// строго после from
+		{"0 * * * *", at(1, 1, 0), at(1, 2, 0)},
+		{"*/20 8-9 * * *", at(1, 9, 50), at(2, 8, 0)},
+		{"30 9 * * 1-5", at(5, 10, 0), at(8, 9, 30)},
+		{"0 0 * * 7", at(1, 0, 0), at(7, 0, 0)},
+		// заданы день месяца и день недели: подходит любое совпадение
+		{"0 0 15 * 0", at(1, 0, 0), at(7, 0, 0)},
+		{"0 12 1,15 * *", at(2, 0, 0), at(15, 12, 0)},
+		{"0 0 31 2 *", at(1, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := parseCron(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronSourceInvalid(t *testing.T) {
+	for _, spec := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *", "* * 0 * *"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := CronSource("cron", spec)
+			var ne *NodeError
+			if !errors.Is(err, ErrInvalidCron) || !errors.As(err, &ne) || ne.Node != "cron" {
+				t.Errorf("error = %v, want ErrInvalidCron of node cron", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSilence вход узла TimeoutGuard молчал дольше заданного времени
+var ErrSilence = errors.New("no input within timeout")
+
+// TimeoutGuard создаёт узел, пропускающий элементы без изменений и сообщающий о тишине на входе:
+// если за время d не пришло ни одного элемента, вызывается onTimeout, и её значение отправляется
+// в выход, а если она вернула false — в канал ошибок отправляется ErrSilence. Отсчёт начинается
+// при запуске и после каждого элемента или отметки тишины, поэтому каждый следующий период тишины
+// даёт новую отметку. Время берётся из WithClock, количество входов и выходов — из WithPorts,
+// буферы выходов — из WithOutputBuffers. Паникует, если d <= 0 или onTimeout nil.
+func TimeoutGuard[T any](name string, d time.Duration, onTimeout func() (T, bool), opts ...Option) *Node[T, T] {
+	if d <= 0 {
+		panic("timeout must be positive")
+	}
+	if onTimeout == nil {
+		panic("nil timeout func")
+	}
+
+	cfg := newConfig(opts)
+	inputNum, outputNum := cfg.portCounts()
+	n := newNode[T, T](autoName(name, funcName(onTimeout), cfg), inputNum, outputNum, nil, cfg)
+	n.handler = func(ctx context.Context, input <-chan T, output chan<- T, errChan chan<- error) {
+		defer closeOutput(output)
+		send := func(val T) bool {
+			if output == nil {
+				return true
+			}
+			select {
+			case output <- val:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		silence := cfg.clock.After(d)
+		for {
+			select {
+			case val, ok := <-input:
+				if !ok {
+					cfg.inputClosed.Store(true)
+					return
+				}
+				if Yield(ctx) != nil || !send(val) {
+					return
+				}
+			case <-silence:
+				val, ok := onTimeout()
+				if !ok {
+					errChan <- fmt.Errorf("%w: %s", ErrSilence, d)
+				} else if !send(val) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			silence = cfg.clock.After(d)
+		}
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeoutGuard(t *testing.T) {
+	const d = 10 * time.Second
+	// шаг либо отправляет элементы, либо сдвигает время; marks — ожидаемые отметки тишины
+	type step struct {
+		send    []int
+		advance time.Duration
+		marks   int
+	}
+	steps := []step{
+		{advance: d, marks: 1},
+		// отсчёт начинается заново после отметки
+		{advance: d, marks: 1},
+		{send: []int{1, 2}},
+		{advance: d / 2},
+		// элемент сбрасывает отсчёт: прежний срок проходит без отметки
+		{send: []int{3}},
+		{advance: d / 2},
+		{advance: d / 2, marks: 1},
+	}
+	tests := []struct {
+		name string
+		// ok результат onTimeout: false — вместо отметки в канал ошибок отправляется ErrSilence
+		ok     bool
+		cancel bool
+		want   ExitReason
+	}{
+		{"markers", true, false, ExitInputClosed},
+		{"silence errors", false, true, ExitCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &countingClock{fakeClock: newFakeClock()}
+			n := TimeoutGuard("guard", d, func() (int, bool) { return -1, tt.ok }, WithClock(clock))
+			in := make(chan int)
+			out := make(chan int, 16)
+			if err := n.SetInput(0, in); err != nil {
+				t.Fatal(err)
+			}
+			if err := n.SetOutput(0, out); err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errChan := make(chan error, 16)
+			var wg sync.WaitGroup
+			n.Run(ctx, &wg, errChan, true)
+
+			var afters int64 = 1
+			eventually(t, func() bool { return clock.afters.Load() == afters })
+			for i, s := range steps {
+				for _, v := range s.send {
+					in <- v
+				}
+				clock.Advance(s.advance)
+
+				want := s.send
+				if tt.ok {
+					for range s.marks {
+						want = append(want, -1)
+					}
+				}
+				var got []int
+				for range want {
+					select {
+					case v := <-out:
+						got = append(got, v)
+					case <-time.After(5 * time.Second):
+						t.Fatalf("step %d: output %v, want %v", i, got, want)
+					}
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("step %d: output %v, want %v", i, got, want)
+				}
+				if !tt.ok {
+					for range s.marks {
+						select {
+						case err := <-errChan:
+							if !errors.Is(err, ErrSilence) {
+								t.Errorf("step %d: error %v, want %v", i, err, ErrSilence)
+							}
+						case <-time.After(5 * time.Second):
+							t.Fatalf("step %d: no silence error", i)
+						}
+					}
+				}
+				// каждый элемент и каждая отметка начинают новый отсчёт
+				afters += int64(len(s.send) + s.marks)
+				eventually(t, func() bool { return clock.afters.Load() == afters })
+			}
+
+			if tt.cancel {
+				cancel()
+			} else {
+				close(in)
+			}
+			rest := drain(out)
+			waitGroup(t, &wg)
+			if extra := rest(); len(extra) > 0 {
+				t.Errorf("unexpected output %v", extra)
+			}
+			if len(errChan) > 0 {
+				t.Errorf("unexpected error %v", <-errChan)
+			}
+			if r := n.ExitReason(); r != tt.want {
+				t.Errorf("ExitReason = %v, want %v", r, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeoutGuardInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		d         time.Duration
+		onTimeout func() (int, bool)
+	}{
+		{"zero timeout", 0, func() (int, bool) { return 0, true }},
+		{"nil func", time.Second, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("TimeoutGuard did not panic")
+				}
+			}()
+			TimeoutGuard("guard", tt.d, tt.onTimeout)
+		})
+	}
+}
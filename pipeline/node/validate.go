@@ -0,0 +1,86 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tom-lepsky/pipeline/pipeline/util"
+)
+
+var ErrUnwired = errors.New("not wired")
+
+// Validate проверяет подключение узла перед запуском и возвращает ошибки всех неподключённых
+// входов и выходов ("input 1: not wired", "output 7: not wired", ErrUnwired), объединённые
+// errors.Join и обёрнутые в NodeError. Выходы, помеченные MarkOutputUnused, не проверяются. Run
+// узла с неподключённым входом не запускает обработчик и сообщает ошибку в канал ошибок, а
+// значения в неподключённый выход блокируют обработчик, поэтому пайплайн вызывает Validate для
+// всех нод до запуска обработчиков.
+func (n *Node[I, O]) Validate() error {
+	wiring.Lock()
+	defer wiring.Unlock()
+
+	errs := n.unwiredInputs()
+	for _, err := range n.unwiredOutputs("output") {
+		errs = append(errs, n.wrapError(err))
+	}
+	return errors.Join(errs...)
+}
+
+// unwiredInputs возвращает ошибки неподключённых входов, обёрнутые в NodeError
+func (n *Node[I, O]) unwiredInputs() []error {
+	var errs []error
+	for i, ch := range n.inputs {
+		if ch == nil {
+			errs = append(errs, n.wrapError(fmt.Errorf("input %d: %w", i, ErrUnwired)))
+		}
+	}
+	return errs
+}
+
+// unwiredOutputs возвращает ошибки неподключённых выходов, не помеченных MarkOutputUnused
+func (n *Node[I, O]) unwiredOutputs(kind string) []error {
+	if n.collapsed {
+		return nil
+	}
+	var errs []error
+	for i, ch := range n.outputs {
+		if ch == nil && n.unusedMask&(1<<uint(i)) == 0 {
+			errs = append(errs, fmt.Errorf("%s %d: %w", kind, i, ErrUnwired))
+		}
+	}
+	return errs
+}
+
+// MarkOutputUnused помечает неподключённый выход idx как намеренно неиспользуемый: Validate о нём
+// не сообщает, Autowire его пропускает, а отправленные в него значения отбрасываются. Пометка
+// снимается при подключении выхода через SetOutput или Connect. Возвращает ErrOutputIdxOutOfRange
+// для неверного индекса, ErrSlotOccupied для подключённого выхода и ErrFrozen после Freeze.
+func (n *Node[I, O]) MarkOutputUnused(idx int) error {
+	wiring.Lock()
+	defer wiring.Unlock()
+
+	if n.frozen {
+		return n.wrapError(ErrFrozen)
+	}
+	if idx < 0 || idx >= len(n.outputs) {
+		return n.wrapError(ErrOutputIdxOutOfRange)
+	}
+	if n.outputOccupied(idx) {
+		return n.wrapError(fmt.Errorf("output %d: %w", idx, ErrSlotOccupied))
+	}
+	n.occupyOutput(idx)
+	n.unusedMask = setBit(n.unusedMask, idx)
+	return nil
+}
+
+// discardUnused возвращает выходы, где выходы MarkOutputUnused заменены каналами util.Discard
+func (n *Node[I, O]) discardUnused(ctx context.Context, outputs []chan<- O) []chan<- O {
+	outputs = append([]chan<- O(nil), outputs...)
+	for i := range outputs {
+		if n.unusedMask&(1<<uint(i)) != 0 {
+			outputs[i] = util.Discard[O](ctx)
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,61 @@
+package node
+
+import (
+	"context"
+	"runtime"
+)
+
+// DefaultYieldEvery период проверки контекста Yielder по умолчанию
+const DefaultYieldEvery = 64
+
+// Yield возвращает ctx.Err(), если ctx отменён, иначе nil. Проверка неблокирующая и дешёвая; её
+// следует вызывать в длинных циклах функций узлов, чтобы Stop не ждал окончания вычислений.
+// Узлы пакета проверяют контекст перед обработкой каждого элемента: после отмены они
+// дообрабатывают не больше одного уже полученного элемента.
+func Yield(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// Yielder амортизированная проверка контекста для горячих циклов: Yield проверяет контекст лишь
+// на каждом every-м вызове, так что отмена замечается не позже чем через every итераций.
+// Не безопасен для использования из нескольких горутин.
+type Yielder struct {
+	ctx     context.Context
+	every   int
+	gosched bool
+	n       int
+	err     error
+}
+
+// NewYielder создаёт Yielder с периодом every (DefaultYieldEvery при every <= 0). Если gosched,
+// на каждой проверке вызывается runtime.Gosched, уступая процессор другим горутинам.
+func NewYielder(ctx context.Context, every int, gosched bool) *Yielder {
+	if every <= 0 {
+		every = DefaultYieldEvery
+	}
+	return &Yielder{ctx: ctx, every: every, gosched: gosched}
+}
+
+// Yield возвращает ошибку контекста, если на очередной проверке он отменён; после этого
+// возвращает её при каждом вызове
+func (y *Yielder) Yield() error {
+	if y.err != nil {
+		return y.err
+	}
+	y.n++
+	if y.n < y.every {
+		return nil
+	}
+
+	y.n = 0
+	if y.gosched {
+		runtime.Gosched()
+	}
+	y.err = Yield(y.ctx)
+	return y.err
+}
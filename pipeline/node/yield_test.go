@@ -0,0 +1,98 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestYield(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"live", context.Background(), nil},
+		{"cancelled", cancelled, context.Canceled},
+		{"deadline", expired, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Yield(tt.ctx); !errors.Is(err, tt.want) {
+				t.Errorf("Yield = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestYielder(t *testing.T) {
+	tests := []struct {
+		name    string
+		every   int
+		gosched bool
+		// cancelAt вызов, перед которым отменяется контекст
+		cancelAt int
+		// wantAt первый вызов, вернувший ошибку
+		wantAt int
+	}{
+		{"every call", 1, false, 3, 3},
+		// отмена замечается на ближайшей проверке
+		{"every 4", 4, false, 2, 4},
+		{"on check", 4, false, 8, 8},
+		{"default period", 0, false, 1, DefaultYieldEvery},
+		{"gosched", 2, true, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			y := NewYielder(ctx, tt.every, tt.gosched)
+			gotAt := 0
+			for i := 1; i <= 2*DefaultYieldEvery; i++ {
+				if i == tt.cancelAt {
+					cancel()
+				}
+				err := y.Yield()
+				switch {
+				case err != nil && gotAt == 0:
+					gotAt = i
+				case err == nil && gotAt != 0:
+					// ошибка возвращается при каждом вызове после первой
+					t.Fatalf("call %d returned nil after the error at call %d", i, gotAt)
+				}
+			}
+			if gotAt != tt.wantAt {
+				t.Errorf("first error at call %d, want %d", gotAt, tt.wantAt)
+			}
+		})
+	}
+}
+
+func TestNodeYieldsBetweenItems(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	n := NewMap("map", func(_ context.Context, v int) (int, error) {
+		calls++
+		cancel()
+		return v, nil
+	})
+	if err := n.SetInput(0, feed(seq(10)...)); err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan int, 10)
+	if err := n.SetOutput(0, out); err != nil {
+		t.Fatal(err)
+	}
+	runNodes(t, ctx, n)
+
+	// после отмены узел дообрабатывает не больше одного уже полученного элемента
+	if calls > 2 {
+		t.Errorf("%d items processed, want at most the cancelling one and one received", calls)
+	}
+}
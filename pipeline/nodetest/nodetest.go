@@ -0,0 +1,129 @@
+// Package nodetest содержит узлы для контрактных тестов потребителей пайплайна: Recorder
+// записывает поток, полученный приёмником, а NewPlayer воспроизводит записанный поток как источник.
+// Записи сохраняются и читаются через Save и Load в формате строк JSON pipeline.Record, поэтому ими
+// можно обмениваться как фикстурами.
+package nodetest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// Item полученный элемент и время его получения
+type Item[T any] struct {
+	At  time.Time
+	Val T
+}
+
+// Recorder узел-приёмник, запоминающий каждый полученный элемент со временем получения
+type Recorder[T any] struct {
+	*node.Node[T, struct{}]
+	mu    sync.Mutex
+	items []Item[T]
+}
+
+// NewRecorder создаёт узел-приёмник Recorder с inputNum входами. Записанные элементы доступны
+// через Items и Values, в том числе во время работы пайплайна.
+func NewRecorder[T any](name string, inputNum int, opts ...node.Option) *Recorder[T] {
+	r := &Recorder[T]{}
+	r.Node = node.NewSink(name, inputNum, func(_ context.Context, v T) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.items = append(r.items, Item[T]{At: time.Now(), Val: v})
+		return nil
+	}, opts...)
+	return r
+}
+
+// Items возвращает записанные элементы в порядке получения
+func (r *Recorder[T]) Items() []Item[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Item[T](nil), r.items...)
+}
+
+// Values возвращает записанные значения в порядке получения
+func (r *Recorder[T]) Values() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := make([]T, len(r.items))
+	for i, item := range r.items {
+		values[i] = item.Val
+	}
+	return values
+}
+
+// Save записывает элементы в w строками JSON pipeline.Record, кодируя значения через codec
+func (r *Recorder[T]) Save(w io.Writer, codec pipeline.Codec[T]) error {
+	return Save(w, r.Items(), codec)
+}
+
+// Save записывает items в w строками JSON pipeline.Record с номерами по порядку, кодируя значения
+// через codec
+func Save[T any](w io.Writer, items []Item[T], codec pipeline.Codec[T]) error {
+	enc := json.NewEncoder(w)
+	for i, item := range items {
+		data, err := codec.Encode(item.Val)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(pipeline.Record{Seq: uint64(i + 1), At: item.At, Data: data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load читает записи, сохранённые Save, и декодирует значения через codec
+func Load[T any](r io.Reader, codec pipeline.Codec[T]) ([]Item[T], error) {
+	var items []Item[T]
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		var rec pipeline.Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, err
+		}
+		v, err := codec.Decode(rec.Data)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, Item[T]{At: rec.At, Val: v})
+	}
+	return items, scanner.Err()
+}
+
+// NewPlayer создаёт источник, отправляющий значения items по порядку. Если clock не nil, между
+// значениями выдерживаются записанные интервалы, делённые на speed (speed > 1 сжимает время,
+// speed <= 0 равносилен 1), иначе значения отправляются без задержек.
+func NewPlayer[T any](name string, items []Item[T], clock node.Clock, speed float64,
+	opts ...node.Option) *node.Node[struct{}, T] {
+	if speed <= 0 {
+		speed = 1
+	}
+
+	return node.NewSource(name, 1, nil, func(ctx context.Context, output chan<- T, _ chan<- error) {
+		for i, item := range items {
+			if clock != nil && i > 0 {
+				select {
+				case <-clock.After(time.Duration(float64(item.At.Sub(items[i-1].At)) / speed)):
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case output <- item.Val:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, opts...)
+}
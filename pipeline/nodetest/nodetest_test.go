@@ -0,0 +1,142 @@
+package nodetest
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline"
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// waitClock Clock, запоминающий запрошенные задержки и не ждущий их
+type waitClock struct {
+	mu    sync.Mutex
+	waits []time.Duration
+}
+
+func (c *waitClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *waitClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+// play воспроизводит items через Player в Recorder и возвращает Recorder после завершения пайплайна
+func play(t *testing.T, items []Item[string], clock node.Clock, speed float64) *Recorder[string] {
+	t.Helper()
+	player := NewPlayer("player", items, clock, speed)
+	rec := NewRecorder[string]("recorder", 1)
+	if err := node.Connect(player, 0, rec.Node, 0); err != nil {
+		t.Fatal(err)
+	}
+	p := pipeline.New()
+	if err := p.AddNode(player, rec); err != nil {
+		t.Fatal(err)
+	}
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for err := range p.ErrChan() {
+			errs = append(errs, err)
+		}
+	}()
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	p.Wait()
+	<-done
+	if len(errs) > 0 {
+		t.Errorf("errors: %v", errs)
+	}
+	return rec
+}
+
+func TestRecordPlayRoundTrip(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []Item[string]{
+		{At: start, Val: "a"},
+		{At: start.Add(time.Second), Val: "b"},
+		{At: start.Add(3 * time.Second), Val: "c"},
+	}
+	codec := pipeline.JSONCodec[string]()
+	tests := []struct {
+		name  string
+		clock *waitClock
+		speed float64
+		// wantWaits задержки между значениями; nil — без часов значения отправляются без задержек
+		wantWaits []time.Duration
+	}{
+		{"no clock", nil, 1, nil},
+		{"original timing", &waitClock{}, 1, []time.Duration{time.Second, 2 * time.Second}},
+		{"double speed", &waitClock{}, 2, []time.Duration{500 * time.Millisecond, time.Second}},
+		// скорость <= 0 равносильна 1
+		{"zero speed", &waitClock{}, 0, []time.Duration{time.Second, 2 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// фикстура проходит Save и Load без потерь
+			var fixture bytes.Buffer
+			if err := Save(&fixture, items, codec); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := Load(&fixture, codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.EqualFunc(loaded, items, func(a, b Item[string]) bool {
+				return a.Val == b.Val && a.At.Equal(b.At)
+			}) {
+				t.Fatalf("loaded %v, want %v", loaded, items)
+			}
+
+			var clock node.Clock
+			if tt.clock != nil {
+				clock = tt.clock
+			}
+			rec := play(t, loaded, clock, tt.speed)
+			want := []string{"a", "b", "c"}
+			if got := rec.Values(); !slices.Equal(got, want) {
+				t.Errorf("recorded %v, want %v", got, want)
+			}
+			if tt.clock != nil && !slices.Equal(tt.clock.waits, tt.wantWaits) {
+				t.Errorf("waits %v, want %v", tt.clock.waits, tt.wantWaits)
+			}
+
+			// записанный поток сохраняется и воспроизводится снова с временем получения
+			var recorded bytes.Buffer
+			if err := rec.Save(&recorded, codec); err != nil {
+				t.Fatal(err)
+			}
+			again, err := Load(&recorded, codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recItems := rec.Items()
+			if !slices.EqualFunc(again, recItems, func(a, b Item[string]) bool {
+				return a.Val == b.Val && a.At.Equal(b.At)
+			}) {
+				t.Errorf("reloaded %v, want %v", again, recItems)
+			}
+			if got := play(t, again, nil, 1).Values(); !slices.Equal(got, want) {
+				t.Errorf("replayed %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadMalformed(t *testing.T) {
+	if _, err := Load(bytes.NewBufferString("{not json\n"), pipeline.JSONCodec[string]()); err == nil {
+		t.Error("Load of a malformed record returned no error")
+	}
+}
@@ -0,0 +1,194 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// settleGoroutines дожидается, пока количество горутин вернётся к base
+func settleGoroutines(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("goroutine leak: %d > %d\n%s", runtime.NumGoroutine(), base, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPanickingHasher(t *testing.T) {
+	const hashers, items, panicAt = 3, 100, 5
+	base := runtime.NumGoroutine()
+
+	// у каждого хешера свой источник; хешер 1 паникует на элементе panicAt, остальные доходят до конца
+	p := New()
+	var got []int
+	sink := node.NewSink("sink", hashers, func(_ context.Context, v int) error {
+		got = append(got, v)
+		return nil
+	})
+	mustAdd(t, p, sink)
+	for i := range hashers {
+		src := sliceSource(fmt.Sprintf("source %d", i), ints(items))
+		hasher := node.NewMap(fmt.Sprintf("hasher %d", i), func(_ context.Context, v int) (int, error) {
+			if i == 1 && v == panicAt {
+				var m map[int]int
+				m[v] = v // запись в nil-отображение
+			}
+			return v, nil
+		})
+		mustConnect(t, src, hasher)
+		if err := node.Connect(hasher, 0, sink, i); err != nil {
+			t.Fatal(err)
+		}
+		mustAdd(t, p, src, hasher)
+	}
+
+	errs := runAndWait(t, p)
+
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v, want one panic", errs)
+	}
+	var pe *node.PanicError
+	var ne *node.NodeError
+	if !errors.As(errs[0], &pe) || !errors.As(errs[0], &ne) || ne.Node != "hasher 1" {
+		t.Errorf("error = %v, want *node.PanicError from hasher 1", errs[0])
+	}
+	if pe != nil && len(pe.Stack) == 0 {
+		t.Error("PanicError has no stack")
+	}
+
+	exits := p.ExitReport()
+	for name, want := range map[string]node.ExitReason{
+		"hasher 0": node.ExitInputClosed,
+		"hasher 1": node.ExitPanicked,
+		"hasher 2": node.ExitInputClosed,
+		// вход запаниковавшего хешера дочитывается, поэтому его источник не блокируется
+		"source 1": node.ExitInputClosed,
+		"sink":     node.ExitInputClosed,
+	} {
+		if exits[name] != want {
+			t.Errorf("%s exit = %v, want %v", name, exits[name], want)
+		}
+	}
+	if want := 2*items + panicAt; len(got) != want {
+		t.Errorf("sink got %d items, want %d", len(got), want)
+	}
+	settleGoroutines(t, base)
+}
+
+func TestPanicPolicyMatrix(t *testing.T) {
+	const items, panicAt = 10, 3
+	tests := []struct {
+		name     string
+		pipeline []Option
+		node     []node.Option
+		// wantOut значения, дошедшие до приёмника; после отмены пайплайна — их допустимый максимум,
+		// так как приёмник может не успеть получить последние
+		wantOut       []int
+		wantCancelled bool
+		wantExit      node.ExitReason
+	}{
+		{"default", nil, nil, ints(panicAt), false, node.ExitPanicked},
+		{"pipeline to error", []Option{WithPanicPolicy(node.PanicToError)}, nil, ints(panicAt), false,
+			node.ExitPanicked},
+		{"pipeline cancel", []Option{WithPanicPolicy(node.PanicCancelPipeline)}, nil, ints(panicAt), true,
+			node.ExitPanicked},
+		// политика ноды важнее политики пайплайна, в том числе PanicPropagate
+		{"node to error over pipeline cancel", []Option{WithPanicPolicy(node.PanicCancelPipeline)},
+			[]node.Option{node.WithPanicPolicy(node.PanicToError)}, ints(panicAt), false, node.ExitPanicked},
+		{"node to error over pipeline propagate", []Option{WithPanicPolicy(node.PanicPropagate)},
+			[]node.Option{node.WithPanicPolicy(node.PanicToError)}, ints(panicAt), false, node.ExitPanicked},
+		{"node cancel over pipeline to error", []Option{WithPanicPolicy(node.PanicToError)},
+			[]node.Option{node.WithPanicPolicy(node.PanicCancelPipeline)}, ints(panicAt), true, node.ExitPanicked},
+		// при перезапуске политика паники не применяется: теряется только элемент, вызвавший панику
+		{"restart over pipeline cancel", []Option{WithPanicPolicy(node.PanicCancelPipeline)},
+			[]node.Option{node.WithRestartPolicy(1, nil)},
+			slices.DeleteFunc(ints(items), func(v int) bool { return v == panicAt }), false, node.ExitInputClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.pipeline...)
+			src := sliceSource("source", ints(items))
+			panicky := node.NewMap("panicky", func(_ context.Context, v int) (int, error) {
+				if v == panicAt {
+					panic("boom")
+				}
+				return v, nil
+			}, tt.node...)
+			sink, got := sliceSink[int]("sink")
+			mustConnect(t, src, panicky)
+			mustConnect(t, panicky, sink)
+			// нода вне цепочки видит отмену пайплайна
+			var cancelled bool
+			other := node.Task("other", func(ctx context.Context) error {
+				select {
+				case <-ctx.Done():
+					cancelled = true
+				case <-time.After(100 * time.Millisecond):
+				}
+				return nil
+			})
+			mustAdd(t, p, src, panicky, sink, other)
+
+			errs := runAndWait(t, p)
+			if len(errs) != 1 {
+				t.Fatalf("errors = %v, want one", errs)
+			}
+			// о перезапуске сообщается уведомлением с паникой внутри, без NodeError
+			var pe *node.PanicError
+			var ne *node.NodeError
+			if !errors.As(errs[0], &pe) || pe.Value != "boom" {
+				t.Errorf("error = %v, want a *node.PanicError", errs[0])
+			}
+			if tt.wantExit == node.ExitPanicked && (!errors.As(errs[0], &ne) || ne.Node != "panicky") {
+				t.Errorf("error = %v, want a NodeError of panicky", errs[0])
+			}
+			if n := len(*got); tt.wantCancelled && n <= len(tt.wantOut) {
+				tt.wantOut = tt.wantOut[:n]
+			}
+			if !slices.Equal(*got, tt.wantOut) {
+				t.Errorf("sink got %v, want %v", *got, tt.wantOut)
+			}
+			if cancelled != tt.wantCancelled {
+				t.Errorf("pipeline cancelled = %v, want %v", cancelled, tt.wantCancelled)
+			}
+			if r := p.ExitReport()["panicky"]; r != tt.wantExit {
+				t.Errorf("panicky exit = %v, want %v", r, tt.wantExit)
+			}
+		})
+	}
+}
+
+// TestPanicPropagate проверяет PanicPropagate в дочернем процессе: паника завершает процесс
+func TestPanicPropagate(t *testing.T) {
+	if os.Getenv("PIPELINE_PANIC_CHILD") == "1" {
+		p := New(WithPanicPolicy(node.PanicPropagate))
+		mustAdd(t, p, node.Task("panicky", func(context.Context) error { panic("boom") }))
+		runAndWait(t, p)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestPanicPropagate$")
+	cmd.Env = append(os.Environ(), "PIPELINE_PANIC_CHILD=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("child process error = %v, want a non-zero exit\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "panic: handler panicked: boom") {
+		t.Errorf("child output has no propagated PanicError:\n%s", out)
+	}
+}
@@ -2,8 +2,21 @@ package pipeline
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var (
+	ErrAlreadyRunning = errors.New("pipeline already running")
+	ErrNoNodes        = errors.New("pipeline has no nodes")
+	ErrSharedOutput   = errors.New("channel wired to several outputs")
+	ErrFinished       = errors.New("pipeline already finished")
+	ErrDuplicateNode  = errors.New("duplicate node name")
 )
 
 // Runnable — интерфейс для объектов, которые могут быть запущены в пайплайне.
@@ -11,75 +24,432 @@ type Runnable interface {
 	Run(ctx context.Context, wg *sync.WaitGroup, errChan chan<- error, commonErrChan bool)
 }
 
+// Option настраивает пайплайн
+type Option func(*options)
+
+// options параметры пайплайна, задаваемые через Option
+type options struct {
+	failFast      bool
+	panicPolicy   node.PanicPolicy
+	strandedLimit int
+	// itemErrorBudget допустимое количество ошибок класса ClassItem (WithItemErrorBudget)
+	itemErrorBudget    int
+	hasItemErrorBudget bool
+	recording          *recording
+	memoryGuard        *memoryGuard
+	// maxRuntime ограничение WithMaxRuntime; clock источник времени пайплайна (WithClock)
+	maxRuntime           time.Duration
+	maxRuntimePauseAware bool
+	clock                node.Clock
+	// idleTimeout время бездействия до завершения пайплайна (WithIdleTimeout)
+	idleTimeout time.Duration
+	// consistentStats подключение нод к шлюзу согласованных снимков (WithConsistentStats)
+	consistentStats bool
+}
+
+// WithFailFast включает отмену всего пайплайна при первой ошибке любого узла
+func WithFailFast() Option {
+	return func(o *options) {
+		o.failFast = true
+	}
+}
+
+// PanicPolicy политика обработки паники в обработчиках нод
+type PanicPolicy = node.PanicPolicy
+
+const (
+	// PanicPropagate паника завершает процесс
+	PanicPropagate = node.PanicPropagate
+	// PanicToError паника преобразуется в *node.PanicError в канале ошибок (по умолчанию)
+	PanicToError = node.PanicToError
+	// PanicCancelPipeline паника преобразуется в ошибку и отменяет пайплайн
+	PanicCancelPipeline = node.PanicCancelPipeline
+)
+
+// WithPanicPolicy задаёт политику паники для всех нод пайплайна. Нода может переопределить её
+// через node.WithPanicPolicy. Восстановленное значение и стек доступны в *node.PanicError.
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(o *options) {
+		o.panicPolicy = policy
+	}
+}
+
 // Pipeline представляет собой оркестратор для выполнения узлов в пайплайне. Поддерживает добавление нод, запуск с
-// контекстом, ожидание завершения и остановку. Все ноды запускаются параллельно
+// контекстом, ожидание завершения и остановку. Все ноды запускаются параллельно. Создаётся через New;
+// копировать Pipeline нельзя.
+//
+// Пайплайн не имеет типизированных границ и сам не является нодой, поэтому вложенных пайплайнов
+// и соединения пайплайнов одним вызовом нет. Независимо собранные пайплайны соединяются каналом:
+// до Run он подключается к выходу ноды одного (SetOutput) и ко входу ноды другого (SetInput), оба
+// запускаются с общим ctx, и Wait вызывается у каждого. Закрытие выхода при завершении первого
+// закрывает вход второго; ошибки каждого читаются из его ErrChan.
 type Pipeline struct {
+	noCopy        noCopy
 	cancelFunc    context.CancelFunc
 	wg            *sync.WaitGroup
+	forwardWg     *sync.WaitGroup
+	errWg         *sync.WaitGroup
+	errForwardWg  *sync.WaitGroup
 	errChan       chan error
+	errStream     chan error
 	errChanClosed atomic.Bool
 	run           atomic.Bool
-	nodes         []Runnable
+	opts          options
+	groups        map[string]*group
+	groupOrder    []string
+	stranded      map[string]node.StrandedEdge
+	summary       *errorSummary
+	// monitorDone закрывается после завершения нод и потока ошибок; создаётся в New, так как
+	// пайплайн запускается один раз, и читается без синхронизации с запуском (ResultsWithErrors)
+	monitorDone chan struct{}
+	// finished закрывается в конце finish: Stop и Wait, проигравшие гонку за завершение, ждут его,
+	// чтобы не вернуться раньше сбора застрявших элементов и остановки вспомогательных горутин
+	finished chan struct{}
+	// deps зависимости по завершению (After): нода -> ноды, которых она ждёт
+	deps        map[Runnable][]Runnable
+	completions map[Runnable]*completion
+	// branches именованные ветки (Branch), disabled отключённые ветки
+	branches map[string][]Runnable
+	disabled map[string]bool
+	// trigger закрывается Trigger после PreStart, источники до этого не запускаются
+	trigger   chan struct{}
+	triggered atomic.Bool
+	// auxFns функции Go, ожидающие запуска; auxCtx контекст запущенного пайплайна
+	auxFns []func(ctx context.Context) error
+	auxCtx context.Context
+	auxWg  *sync.WaitGroup
+	auxMu  *sync.RWMutex
+	// auxSends отправки ошибок Go в ErrChan, auxDone закрывается, чтобы прервать их перед закрытием каналов
+	auxSends *sync.WaitGroup
+	auxDone  chan struct{}
+	// startMu удерживается на время запуска: Stop и Wait дожидаются его завершения
+	startMu *sync.Mutex
+	// pauses ноды, приостановленные через Command
+	pauses *pauses
+	// frozen топология заморожена (Freeze), plan вычисленный при этом план запуска
+	frozen atomic.Bool
+	plan   *plan
+	// dumps дампы рёбер в файлы (DumpEdge)
+	dumps []*edgeDump
+	// statsGate шлюз согласованных снимков статистики, snapshots число снятых снимков (StatsSnapshot)
+	statsGate *node.StatsGate
+	snapshots atomic.Uint64
 }
 
 // New создаёт новый пайплайн
-func New() Pipeline {
-	return Pipeline{
-		wg:      &sync.WaitGroup{},
-		errChan: make(chan error),
+func New(opts ...Option) *Pipeline {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	errChan := make(chan error)
+	return &Pipeline{
+		wg:           &sync.WaitGroup{},
+		forwardWg:    &sync.WaitGroup{},
+		errWg:        &sync.WaitGroup{},
+		errForwardWg: &sync.WaitGroup{},
+		monitorDone:  make(chan struct{}),
+		finished:     make(chan struct{}),
+		auxWg:        &sync.WaitGroup{},
+		auxMu:        &sync.RWMutex{},
+		auxSends:     &sync.WaitGroup{},
+		auxDone:      make(chan struct{}),
+		startMu:      &sync.Mutex{},
+		pauses:       newPauses(),
+		statsGate:    node.NewStatsGate(),
+		errChan:      errChan,
+		opts:         o,
+		summary:      &errorSummary{},
+		groups:       map[string]*group{DefaultGroup: newGroup(DefaultGroup, errChan)},
+		groupOrder:   []string{DefaultGroup},
 	}
 }
 
-// ErrChan получить канал для чтения ошибок
+// ErrChan получить канал для чтения ошибок узлов группы по умолчанию
 func (p *Pipeline) ErrChan() <-chan error {
 	return p.errChan
 }
 
-// AddNode добавляет ноды в пайплайн. Если пайплайн уже запущен (run=true),
-// добавление игнорируется
-func (p *Pipeline) AddNode(n ...Runnable) {
+// ErrChanFor получить канал для чтения ошибок узлов группы. Для неизвестной группы возвращает nil.
+func (p *Pipeline) ErrChanFor(group string) <-chan error {
+	g, ok := p.groups[group]
+	if !ok {
+		return nil
+	}
+	return g.errChan
+}
+
+// AddNode добавляет ноды в пайплайн. Если пайплайн уже запущен (run=true), добавление
+// игнорируется и возвращается ErrAlreadyRunning, после Freeze — ErrFrozen
+func (p *Pipeline) AddNode(n ...Runnable) error {
+	return p.AddNodeGroup(DefaultGroup, n...)
+}
+
+// AddNodeGroup добавляет ноды в именованную группу. Ошибки нод группы направляются в отдельный
+// канал ErrChanFor(group) вместо общего. Если пайплайн уже запущен или заморожен, добавление
+// игнорируется (см. AddNode). Имена нод уникальны в пределах пайплайна: если имя одной из нод
+// уже занято (в любой группе или в этом же вызове), ни одна нода не добавляется и возвращается
+// ErrDuplicateNode. Ноды без имени не проверяются.
+func (p *Pipeline) AddNodeGroup(group string, n ...Runnable) error {
+	if p.frozen.Load() {
+		return ErrFrozen
+	}
+	if p.run.Load() {
+		return ErrAlreadyRunning
+	}
+	if err := p.checkNames(n); err != nil {
+		return err
+	}
+	p.group(group).nodes = append(p.group(group).nodes, n...)
+	return nil
+}
+
+// checkNames возвращает ErrDuplicateNode, если имя одной из нод nodes уже занято
+func (p *Pipeline) checkNames(nodes []Runnable) error {
+	names := map[string]bool{}
+	for _, g := range p.groups {
+		for _, n := range g.nodes {
+			if named, ok := n.(interface{ Name() string }); ok {
+				names[named.Name()] = true
+			}
+		}
+	}
+	for _, n := range nodes {
+		named, ok := n.(interface{ Name() string })
+		if !ok {
+			continue
+		}
+		if names[named.Name()] {
+			return fmt.Errorf("%w: %s", ErrDuplicateNode, named.Name())
+		}
+		names[named.Name()] = true
+	}
+	return nil
+}
+
+// SetGroupFailFast включает отмену нод группы при первой ошибке в ней. Остальные группы продолжают
+// работу, если не включён WithFailFast для всего пайплайна. Если пайплайн уже запущен, вызов игнорируется
+func (p *Pipeline) SetGroupFailFast(group string, failFast bool) {
 	if p.run.Load() {
 		return
 	}
-	p.nodes = append(p.nodes, n...)
+	p.group(group).failFast = failFast
+}
+
+// GroupStats возвращает суммарную статистику нод группы. Bypass сообщает, что хотя бы одна нода
+// группы в режиме обхода; Circuit не агрегируется.
+func (p *Pipeline) GroupStats(group string) node.Stats {
+	g, ok := p.groups[group]
+	if !ok {
+		return node.Stats{}
+	}
+	return g.stats()
 }
 
-// Run запускает все ноды пайплайна параллельно в контексте, производном от parentCtx
-func (p *Pipeline) Run(parentCtx context.Context, commonErrors bool) {
+// group возвращает группу по имени, создавая её при необходимости
+func (p *Pipeline) group(name string) *group {
+	g, ok := p.groups[name]
+	if !ok {
+		g = newGroup(name, make(chan error))
+		p.groups[name] = g
+		p.groupOrder = append(p.groupOrder, name)
+	}
+	return g
+}
+
+// Run запускает все ноды пайплайна параллельно в контексте, производном от parentCtx; ноды
+// с зависимостями (After) запускаются после завершения нод, которых они ждут. Возвращает
+// ErrNoNodes, если в пайплайне нет нод, ErrAlreadyRunning, если он уже запущен, ErrFinished,
+// если он уже отработал (ноды закрыли свои выходы, повторный запуск невозможен), и ошибку
+// проверки зависимостей (ErrUnknownNode, ErrDependencyCycle), рёбер WithRecording (ErrNoCodec),
+// подключения нод (node.ErrUnwired, ошибки всех нод объединены errors.Join) или
+// ErrSharedOutput, если один канал подключён к нескольким выходам, и ErrIdleUntracked для
+// WithIdleTimeout без статистики источников. Замороженный пайплайн (Freeze)
+// запускается без повторных проверок.
+func (p *Pipeline) Run(parentCtx context.Context, commonErrors bool) error {
+	return p.start(parentCtx, commonErrors, nil)
+}
+
+// start запускает ноды пайплайна; при trigger != nil источники ждут его закрытия (PreStart)
+func (p *Pipeline) start(parentCtx context.Context, commonErrors bool, trigger chan struct{}) error {
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+	if p.empty() {
+		return ErrNoNodes
+	}
+	if p.run.Load() {
+		return ErrAlreadyRunning
+	}
+	if p.errChanClosed.Load() {
+		return ErrFinished
+	}
+	if p.frozen.Load() {
+		p.initCompletions()
+	} else if err := p.validate(); err != nil {
+		return err
+	}
+	if err := p.checkIdleTracking(); err != nil {
+		return err
+	}
+	if err := p.openDumps(); err != nil {
+		return err
+	}
+	p.shareStatsGate()
 	if !p.run.CompareAndSwap(false, true) {
-		return
+		return ErrAlreadyRunning
 	}
+	p.trigger = trigger
+	if trigger == nil {
+		p.releaseInlets()
+	}
+	runID := newRunID(p.clock().Now())
+	p.summary.begin(runID)
 	ctx, cancel := context.WithCancel(parentCtx)
 	p.cancelFunc = cancel
+	ctx = node.ContextWithCancel(ctx, cancel)
+	ctx = node.ContextWithRunID(ctx, runID)
+	ctx = node.ContextWithDrain(ctx, p.drainSources)
+	if p.opts.panicPolicy != 0 {
+		ctx = node.ContextWithPanicPolicy(ctx, p.opts.panicPolicy)
+	}
+
+	var cancelPipeline func()
+	if p.opts.failFast {
+		cancelPipeline = cancel
+	}
+
+	for _, name := range p.groupOrder {
+		if name == ErrorGroup {
+			continue
+		}
+		g := p.groups[name]
+		g.dest = g.errChan
+		if p.errStream != nil {
+			g.dest = p.errStream
+		}
+		g.start(ctx, p.wg, p.forwardWg, commonErrors, cancelPipeline, p.record, p.launch)
+	}
+
+	if g, ok := p.groups[ErrorGroup]; ok {
+		g.dest = p.errChan
+		g.start(ctx, p.errWg, p.errForwardWg, commonErrors, cancelPipeline, p.record, p.launch)
+	}
+
+	go p.monitor()
+	p.startAux(ctx)
+	p.auxMu.Lock()
+	if p.opts.memoryGuard != nil {
+		p.goAux(ctx, p.guardMemory)
+	}
+	if p.opts.maxRuntime > 0 {
+		p.goAux(ctx, p.guardRuntime)
+	}
+	if p.opts.idleTimeout > 0 {
+		p.goAux(ctx, p.guardIdle)
+	}
+	p.auxMu.Unlock()
+	return nil
+}
+
+// empty сообщает, что ни в одной группе нет нод
+func (p *Pipeline) empty() bool {
+	for _, g := range p.groups {
+		if len(g.nodes) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// record учитывает ошибку ноды в сводке
+func (p *Pipeline) record(err error) error {
+	return p.summary.record(err, p.opts)
+}
+
+// monitor дожидается завершения нод (кроме обрабатывающих ошибки), проверяет их соотношение
+// входов и выходов (node.WithCardinality) и закрывает поток ошибок
+func (p *Pipeline) monitor() {
+	defer close(p.monitorDone)
 
-	for i := 0; i < len(p.nodes); i++ {
-		p.nodes[i].Run(ctx, p.wg, p.errChan, commonErrors)
+	p.wg.Wait()
+	for _, name := range p.groupOrder {
+		if name != ErrorGroup {
+			p.groups[name].checkCardinality()
+			close(p.groups[name].errIn)
+		}
+	}
+	p.forwardWg.Wait()
+	if p.errStream != nil {
+		close(p.errStream)
 	}
 }
 
 // Wait ожидает завершения всех нод.
 // Блокирует вызывающую горутину до полного завершения пайплайна.
 func (p *Pipeline) Wait() {
+	p.awaitStart()
 	if !p.run.Load() {
+		// пайплайн уже останавливает или остановил Stop: ждём окончания finish
+		if p.cancelFunc != nil {
+			<-p.finished
+		}
 		return
 	}
-	p.wg.Wait()
-	if p.errChanClosed.CompareAndSwap(false, true) {
-		close(p.errChan)
-	}
+	p.finish()
 	p.run.Store(false)
 }
 
-// Stop останавливает пайплайн.
+// Stop останавливает пайплайн. Вызов во время Run дожидается запуска всех нод, так что они
+// останавливаются вместе с остальными; вызов до Run ничего не делает.
 func (p *Pipeline) Stop() {
+	p.awaitStart()
 	if p.run.CompareAndSwap(true, false) {
 		if p.cancelFunc != nil {
 			p.cancelFunc()
 		}
 
-		p.wg.Wait()
-		if p.errChanClosed.CompareAndSwap(false, true) {
-			close(p.errChan)
+		p.finish()
+	}
+}
+
+// awaitStart дожидается завершения выполняющегося запуска (start)
+func (p *Pipeline) awaitStart() {
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+}
+
+// finish дожидается завершения всех нод, закрывает каналы ошибок всех групп и дожидается
+// вспомогательных горутин (Go). Конкурирующий вызов ждёт, пока первый не закончит.
+func (p *Pipeline) finish() {
+	<-p.monitorDone
+	p.errWg.Wait()
+	if !p.errChanClosed.CompareAndSwap(false, true) {
+		<-p.finished
+		return
+	}
+	defer close(p.finished)
+
+	if g, ok := p.groups[ErrorGroup]; ok {
+		g.checkCardinality()
+		close(g.errIn)
+		p.errForwardWg.Wait()
+	}
+	// после Lock новые отправки ошибок Go не начинаются, начатые прерываются auxDone
+	p.auxMu.Lock()
+	close(p.auxDone)
+	p.auxMu.Unlock()
+	p.auxSends.Wait()
+	p.auxMu.Lock()
+	for _, name := range p.groupOrder {
+		g := p.groups[name]
+		if g.cancel != nil {
+			g.cancel()
 		}
+		close(g.errChan)
 	}
+	p.auxMu.Unlock()
+	p.collectStranded()
+	p.closeDumps()
+	p.stopAux()
 }
@@ -0,0 +1,199 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var (
+	// ErrInletFull буфер InletNode заполнен до запуска источника
+	ErrInletFull = errors.New("inlet buffer full")
+	// ErrInletClosed InletNode закрыт или его источник завершился
+	ErrInletClosed = errors.New("inlet closed")
+)
+
+// PreStart запускает пайплайн так же, как Run, но ноды-источники (ноды без входов) не запускаются
+// до вызова Trigger: остальные ноды работают и ждут данных, так что граф готов к работе, а дорогие
+// источники не открываются заранее. Хуки node.WithInit источников вызываются при их запуске.
+// Остановка до Trigger (Stop или отмена ctx) завершает пайплайн без запуска источников; они
+// записываются в Summary().Skipped. Элементы, поданные до Trigger, копит InletNode.
+func (p *Pipeline) PreStart(parentCtx context.Context, commonErrors bool) error {
+	return p.start(parentCtx, commonErrors, make(chan struct{}))
+}
+
+// Trigger запускает источники пайплайна, запущенного через PreStart. Повторные вызовы и вызов
+// после Run ничего не делают.
+func (p *Pipeline) Trigger() {
+	if p.trigger != nil && p.triggered.CompareAndSwap(false, true) {
+		p.releaseInlets()
+		close(p.trigger)
+	}
+}
+
+// releaseInlets переводит InletNode пайплайна в режим ожидания источника: после возврата Submit не
+// отвечает ErrInletFull, даже если источник ещё не успел запуститься
+func (p *Pipeline) releaseInlets() {
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if in, ok := n.(interface{ release() }); ok {
+				in.release()
+			}
+		}
+	}
+}
+
+// awaitTrigger откладывает запуск ноды-источника до Trigger. Возвращает false, если нода
+// запускается сразу.
+func (p *Pipeline) awaitTrigger(ctx context.Context, n Runnable, wg *sync.WaitGroup, errChan chan<- error,
+	commonErrors bool) bool {
+	if p.trigger == nil || !isSourceNode(n) {
+		return false
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		select {
+		case <-p.trigger:
+			p.launchNow(ctx, n, wg, errChan, commonErrors)
+		case <-ctx.Done():
+			p.skip(ctx, n, p.completions[n], wg, ctx.Err())
+		}
+	}()
+	return true
+}
+
+// isSourceNode сообщает, что нода не имеет входов
+func isSourceNode(n Runnable) bool {
+	pn, ok := n.(ported)
+	if !ok {
+		return false
+	}
+	// у схлопнутой ноды (node.WithInline) портов нет, она не источник
+	inputs, outputs := pn.Ports()
+	return len(inputs) == 0 && (inputs != nil || outputs != nil)
+}
+
+// InletNode нода-источник, элементы которой подаются вызовами Submit. До запуска источника (до Run
+// или, у пайплайна, запущенного через PreStart, до Trigger) элементы копятся в буфере ограниченного
+// размера и отправляются в порядке подачи после запуска.
+type InletNode[T any] struct {
+	*node.Node[struct{}, T]
+	items   chan T
+	started atomic.Bool
+	// mu защищает закрытие items от одновременных Submit
+	mu     sync.RWMutex
+	closed bool
+	// done закрывается, когда источник завершился или пропущен (Skip)
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// NewInlet создаёт ноду-источник, принимающую элементы через Submit. limit — размер буфера для
+// элементов, поданных до запуска источника (отрицательный считается нулём); после запуска Submit
+// ждёт, пока источник примет элемент. Источник завершается после Close, отправив все принятые
+// элементы.
+func NewInlet[T any](name string, outputNum int, outputBuffSize []int, limit int,
+	opts ...node.Option) *InletNode[T] {
+	in := &InletNode[T]{items: make(chan T, max(limit, 0)), done: make(chan struct{})}
+	in.Node = node.NewSource(name, outputNum, outputBuffSize, in.run, opts...)
+	return in
+}
+
+// Submit подаёт элемент v. До Run или Trigger элемент помещается в буфер, а при заполненном буфере
+// возвращается ErrInletFull; после них Submit ждёт, пока источник примет элемент, или отмены
+// ctx. После Close, завершения источника или его пропуска (остановка до Trigger, неудача
+// зависимости) возвращает ErrInletClosed.
+func (in *InletNode[T]) Submit(ctx context.Context, v T) error {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if in.closed {
+		return ErrInletClosed
+	}
+	select {
+	case <-in.done:
+		return ErrInletClosed
+	default:
+	}
+
+	if !in.started.Load() {
+		select {
+		case in.items <- v:
+			return nil
+		default:
+			return ErrInletFull
+		}
+	}
+
+	select {
+	case in.items <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-in.done:
+		return ErrInletClosed
+	}
+}
+
+// Close прекращает приём элементов: источник завершается, отправив уже принятые. Дожидается
+// выполняющихся вызовов Submit. Повторные вызовы ничего не делают.
+func (in *InletNode[T]) Close() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if !in.closed {
+		in.closed = true
+		close(in.items)
+	}
+}
+
+// Skip завершает пропущенный источник (см. node.Node.Skip) и отбрасывает элементы буфера:
+// последующие Submit возвращают ErrInletClosed
+func (in *InletNode[T]) Skip(ctx context.Context, wg *sync.WaitGroup) {
+	in.finish()
+	in.Node.Skip(ctx, wg)
+	for {
+		select {
+		case _, ok := <-in.items:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// run обработчик источника: отправляет элементы буфера и поданные позже до Close или отмены ctx
+func (in *InletNode[T]) run(ctx context.Context, output chan<- T, _ chan<- error) {
+	in.release()
+	defer in.finish()
+	for {
+		select {
+		case v, ok := <-in.items:
+			if !ok {
+				return
+			}
+			select {
+			case output <- v:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// release отмечает, что источник запускается
+func (in *InletNode[T]) release() {
+	in.started.Store(true)
+}
+
+// finish отмечает завершение источника
+func (in *InletNode[T]) finish() {
+	in.doneOnce.Do(func() { close(in.done) })
+}
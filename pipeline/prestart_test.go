@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// preStarted собирает inlet -> double -> sink и запускает его через PreStart
+func preStarted(t *testing.T, ctx context.Context, limit int) (*Pipeline, *InletNode[int],
+	*node.Node[int, int], *[]int) {
+	t.Helper()
+	inlet := NewInlet[int]("inlet", 1, nil, limit)
+	double := node.NewMap("double", func(_ context.Context, v int) (int, error) { return v * 2, nil })
+	sink, got := sliceSink[int]("sink")
+	mustConnect(t, inlet.Node, double)
+	mustConnect(t, double, sink)
+	p := New()
+	mustAdd(t, p, inlet, double, sink)
+	if err := p.PreStart(ctx, true); err != nil {
+		t.Fatalf("PreStart: %v", err)
+	}
+	return p, inlet, double, got
+}
+
+func TestPreStartSubmitBeforeTrigger(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		// submitted элементы, поданные до Trigger; accepted сколько из них принято
+		submitted int
+		accepted  int
+	}{
+		{"unbuffered", 0, 2, 0},
+		{"within limit", 4, 3, 3},
+		{"overflow", 3, 5, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, inlet, double, got := preStarted(t, context.Background(), tt.limit)
+			errs := collectErrors(p.ErrChan())
+
+			var want []int
+			for i := range tt.submitted {
+				err := inlet.Submit(context.Background(), i)
+				switch {
+				case i < tt.accepted && err != nil:
+					t.Fatalf("Submit(%d) before Trigger: %v", i, err)
+				case i >= tt.accepted && !errors.Is(err, ErrInletFull):
+					t.Fatalf("Submit(%d) over limit = %v, want ErrInletFull", i, err)
+				case err == nil:
+					want = append(want, i*2)
+				}
+			}
+			if s := double.State(); s != node.StateRunning {
+				t.Errorf("non-source node state before Trigger = %v, want running", s)
+			}
+			if s := inlet.State(); s == node.StateRunning {
+				t.Error("source started before Trigger")
+			}
+
+			p.Trigger()
+			// после запуска Submit ждёт источник и не ограничен буфером
+			for i := 100; i < 100+tt.limit+3; i++ {
+				if err := inlet.Submit(context.Background(), i); err != nil {
+					t.Fatalf("Submit(%d) after Trigger: %v", i, err)
+				}
+				want = append(want, i*2)
+			}
+			inlet.Close()
+			waitTimeout(t, p)
+
+			if e := errs.wait(t); len(e) > 0 {
+				t.Errorf("errors: %v", e)
+			}
+			if !slices.Equal(*got, want) {
+				t.Errorf("got %v, want buffered items first, in submission order: %v", *got, want)
+			}
+			if err := inlet.Submit(context.Background(), 0); !errors.Is(err, ErrInletClosed) {
+				t.Errorf("Submit after Close = %v, want ErrInletClosed", err)
+			}
+		})
+	}
+}
+
+func TestPreStartStopBeforeTrigger(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(p *Pipeline, cancel context.CancelFunc)
+	}{
+		{"stop", func(p *Pipeline, _ context.CancelFunc) { p.Stop() }},
+		{"cancel", func(p *Pipeline, cancel context.CancelFunc) {
+			cancel()
+			p.Wait()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			p, inlet, _, got := preStarted(t, ctx, 4)
+			errs := collectErrors(p.ErrChan())
+			for i := range 2 {
+				if err := inlet.Submit(context.Background(), i); err != nil {
+					t.Fatalf("Submit: %v", err)
+				}
+			}
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				tt.stop(p, cancel)
+			}()
+			eventually(t, func() bool {
+				select {
+				case <-done:
+					return true
+				default:
+					return false
+				}
+			})
+			errs.wait(t)
+
+			if len(*got) != 0 {
+				t.Errorf("sink got %v, want nothing before Trigger", *got)
+			}
+			if _, ok := p.Summary().Skipped["inlet"]; !ok {
+				t.Errorf("Skipped = %v, want the untriggered source", p.Summary().Skipped)
+			}
+			if r := p.ExitReport()["inlet"]; r != node.ExitSkipped {
+				t.Errorf("inlet exit = %v, want %v", r, node.ExitSkipped)
+			}
+			if err := inlet.Submit(context.Background(), 2); !errors.Is(err, ErrInletClosed) {
+				t.Errorf("Submit after stop = %v, want ErrInletClosed", err)
+			}
+			// Trigger после остановки ничего не запускает
+			p.Trigger()
+			if s := inlet.State(); s == node.StateRunning {
+				t.Error("source started after stop")
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestQuotaDrainAndStop(t *testing.T) {
+	// бесконечный источник останавливается исчерпанием бюджета, а не отменой
+	src := node.NewSource("numbers", 1, nil, func(ctx context.Context, output chan<- int, _ chan<- error) {
+		for i := 0; ; i++ {
+			select {
+			case output <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	q := node.Quota[int]("quota", 5, nil, node.QuotaDrainAndStop)
+	sink, got := sliceSink[int]("sink")
+	if err := node.Connect(src, 0, q.Node, 0); err != nil {
+		t.Fatal(err)
+	}
+	mustConnect(t, q.Node, sink)
+	p := New()
+	mustAdd(t, p, src, q, sink)
+
+	if errs := runAndWait(t, p); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if len(*got) != 5 {
+		t.Errorf("sink got %v, want 5 items", *got)
+	}
+	u, ok := p.Summary().Quotas["quota"]
+	if !ok || u.Used != 5 || u.Limit != 5 || u.Dropped < 1 {
+		t.Errorf("Summary().Quotas[quota] = %+v, %v; want 5 of 5 used and dropped items", u, ok)
+	}
+	if r := p.ExitReport()["numbers"]; r != node.ExitInputClosed {
+		t.Errorf("source exit %v, want %v", r, node.ExitInputClosed)
+	}
+}
@@ -0,0 +1,246 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+	"github.com/tom-lepsky/pipeline/pipeline/util"
+)
+
+var ErrNoCodec = errors.New("no codec registered for type")
+
+// EdgeRef ссылка на выход ноды, трафик которого записывается (WithRecording) или воспроизводится
+// (ReplaySource)
+type EdgeRef struct {
+	Node   string
+	Output int
+}
+
+// Edge возвращает ссылку на выход output ноды с именем nodeName
+func Edge(nodeName string, output int) EdgeRef {
+	return EdgeRef{Node: nodeName, Output: output}
+}
+
+func (e EdgeRef) String() string {
+	return fmt.Sprintf("%s[%d]", e.Node, e.Output)
+}
+
+// Record записанный элемент ребра: порядковый номер, время отправки и закодированное значение
+type Record struct {
+	Seq  uint64    `json:"seq"`
+	At   time.Time `json:"at"`
+	Data []byte    `json:"data"`
+}
+
+// RecordStore хранилище записей рёбер. Append вызывается конкурентно для разных рёбер.
+type RecordStore interface {
+	Append(edge EdgeRef, rec Record) error
+	Records(edge EdgeRef) ([]Record, error)
+}
+
+// Codec кодирование значений типа T для записи рёбер (тот же тип, что util.Codec)
+type Codec[T any] = util.Codec[T]
+
+// JSONCodec возвращает Codec на основе encoding/json
+func JSONCodec[T any]() Codec[T] {
+	return Codec[T]{
+		Encode: func(v T) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		Decode: func(data []byte) (T, error) {
+			var v T
+			err := json.Unmarshal(data, &v)
+			return v, err
+		},
+	}
+}
+
+// anyCodec Codec с удалённым типом значения
+type anyCodec struct {
+	encode func(v any) ([]byte, error)
+	decode func(data []byte) (any, error)
+}
+
+var codecs sync.Map // reflect.Type -> anyCodec
+
+// RegisterCodec регистрирует Codec для значений типа T. Запись ребра, по которому передаются
+// значения типа без кодека, невозможна (Run возвращает ErrNoCodec).
+func RegisterCodec[T any](c Codec[T]) {
+	if c.Encode == nil || c.Decode == nil {
+		panic("nil codec func")
+	}
+
+	codecs.Store(reflect.TypeFor[T](), anyCodec{
+		encode: func(v any) ([]byte, error) {
+			return c.Encode(v.(T))
+		},
+		decode: func(data []byte) (any, error) {
+			return c.Decode(data)
+		},
+	})
+}
+
+// codecFor возвращает кодек для типа t
+func codecFor(t reflect.Type) (anyCodec, error) {
+	c, ok := codecs.Load(t)
+	if !ok {
+		return anyCodec{}, fmt.Errorf("%w: %s", ErrNoCodec, t)
+	}
+	return c.(anyCodec), nil
+}
+
+// tapper нода, трафик выходов которой можно записать
+type tapper interface {
+	Name() string
+	TapOutput(idx int, tap func(v any)) error
+	OutputType() reflect.Type
+}
+
+// recording параметры записи рёбер (WithRecording)
+type recording struct {
+	store RecordStore
+	edges []EdgeRef
+}
+
+// WithRecording записывает в store каждый элемент, отправленный в выходы edges, с порядковым
+// номером и временем отправки. Для типа значений каждого ребра должен быть зарегистрирован кодек
+// (RegisterCodec). Ошибки кодирования и записи учитываются в Summary с классом node.ClassInfra;
+// элемент при этом доставляется. Записанный трафик воспроизводится через ReplaySource.
+func WithRecording(store RecordStore, edges ...EdgeRef) Option {
+	return func(o *options) {
+		o.recording = &recording{store: store, edges: edges}
+	}
+}
+
+// prepareRecording устанавливает запись на рёбра WithRecording и дампы DumpEdge
+func (p *Pipeline) prepareRecording() error {
+	var edges []EdgeRef
+	taps := make(map[EdgeRef][]func(v any))
+	if rec := p.opts.recording; rec != nil {
+		for _, edge := range rec.edges {
+			t, err := p.tapperOf(edge.Node)
+			if err != nil {
+				return err
+			}
+			codec, err := codecFor(t.OutputType())
+			if err != nil {
+				return fmt.Errorf("record %s: %w", edge, err)
+			}
+			edges = append(edges, edge)
+			taps[edge] = append(taps[edge], p.recorder(rec.store, edge, codec))
+		}
+	}
+	for _, d := range p.dumps {
+		edges = append(edges, d.edge)
+		taps[d.edge] = append(taps[d.edge], d.tap(p))
+	}
+
+	for _, edge := range edges {
+		fns, ok := taps[edge]
+		if !ok {
+			continue
+		}
+		delete(taps, edge)
+		t, err := p.tapperOf(edge.Node)
+		if err != nil {
+			return err
+		}
+		if err := t.TapOutput(edge.Output, joinTaps(fns)); err != nil {
+			return fmt.Errorf("record %s: %w", edge, err)
+		}
+	}
+	return nil
+}
+
+// joinTaps объединяет функции записи одного ребра
+func joinTaps(fns []func(v any)) func(v any) {
+	if len(fns) == 1 {
+		return fns[0]
+	}
+	return func(v any) {
+		for _, fn := range fns {
+			fn(v)
+		}
+	}
+}
+
+// tapperOf возвращает ноду с именем name
+func (p *Pipeline) tapperOf(name string) (tapper, error) {
+	for _, g := range p.groupOrder {
+		for _, n := range p.groups[g].nodes {
+			if t, ok := n.(tapper); ok && t.Name() == name {
+				return t, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownNode, name)
+}
+
+// recorder возвращает функцию, записывающую значения ребра edge в store
+func (p *Pipeline) recorder(store RecordStore, edge EdgeRef, codec anyCodec) func(v any) {
+	var seq atomic.Uint64
+	return func(v any) {
+		rec := Record{Seq: seq.Add(1), At: time.Now()}
+		data, err := codec.encode(v)
+		if err == nil {
+			rec.Data = data
+			err = store.Append(edge, rec)
+		}
+		if err != nil {
+			_ = p.record(node.Classify(node.ClassInfra, fmt.Errorf("record %s: %w", edge, err)))
+		}
+	}
+}
+
+// ReplaySource создаёт источник, отправляющий в выход записанные значения ребра edge по порядку
+// номеров. Если clock не nil, между значениями выдерживаются исходные интервалы, иначе значения
+// отправляются без задержек. Возвращает ErrNoCodec, если для T не зарегистрирован кодек. Ошибки
+// чтения хранилища отправляются в канал ошибок с классом node.ClassNode, ошибки декодирования —
+// с классом node.ClassItem.
+func ReplaySource[T any](name string, store RecordStore, edge EdgeRef, clock node.Clock, opts ...node.Option) (*node.Node[struct{}, T], error) {
+	codec, err := codecFor(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, fmt.Errorf("replay %s: %w", edge, err)
+	}
+
+	return node.NewSource(name, 1, nil, func(ctx context.Context, output chan<- T, errChan chan<- error) {
+		records, err := store.Records(edge)
+		if err != nil {
+			errChan <- node.Classify(node.ClassNode, fmt.Errorf("replay %s: %w", edge, err))
+			return
+		}
+		slices.SortFunc(records, func(a, b Record) int {
+			return cmp.Compare(a.Seq, b.Seq)
+		})
+
+		for i, rec := range records {
+			if clock != nil && i > 0 {
+				select {
+				case <-clock.After(rec.At.Sub(records[i-1].At)):
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			v, err := codec.decode(rec.Data)
+			if err != nil {
+				errChan <- fmt.Errorf("replay %s #%d: %w", edge, rec.Seq, err)
+				continue
+			}
+			select {
+			case output <- v.(T):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, opts...), nil
+}
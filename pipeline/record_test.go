@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// recItem значение записываемого ребра; кодек регистрируется только для этого типа
+type recItem struct {
+	N    int
+	Name string
+}
+
+func init() {
+	RegisterCodec(JSONCodec[recItem]())
+}
+
+// replayClock Clock, запоминающий запрошенные задержки и не ждущий их
+type replayClock struct {
+	mu    sync.Mutex
+	waits []time.Duration
+}
+
+func (c *replayClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *replayClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	edge := Edge("walker", 0)
+	hasher := func(name string) (*node.Node[recItem, string], *[]string) {
+		var got []string
+		return node.NewMap(name, func(_ context.Context, v recItem) (string, error) {
+			got = append(got, v.Name)
+			return v.Name, nil
+		}, node.WithPorts(1, 0)), &got
+	}
+
+	// запись ребра walker → hasher полного пайплайна
+	store, err := NewFileRecordStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	walker := node.NewMap("walker", func(_ context.Context, v int) (recItem, error) {
+		return recItem{N: v, Name: string(rune('a' + v))}, nil
+	})
+	recorded, want := hasher("hasher")
+	src := sliceSource("src", ints(5))
+	mustConnect(t, src, walker)
+	mustConnect(t, walker, recorded)
+	p := New(WithRecording(store, edge))
+	mustAdd(t, p, src, walker, recorded)
+	if errs := runAndWait(t, p); len(errs) > 0 {
+		t.Fatalf("record errors: %v", errs)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := store.Records(edge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("%d records, want 5", len(records))
+	}
+
+	tests := []struct {
+		name  string
+		clock *replayClock
+	}{
+		{"no delays", nil},
+		// между значениями выдерживаются записанные интервалы
+		{"original timing", &replayClock{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// хранилище открывается заново, как при отладке после записи
+			replayStore, err := NewFileRecordStore(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var clock node.Clock
+			if tt.clock != nil {
+				clock = tt.clock
+			}
+			replay, err := ReplaySource[recItem]("replay", replayStore, edge, clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayed, got := hasher("hasher")
+			mustConnect(t, replay, replayed)
+			p := New()
+			mustAdd(t, p, replay, replayed)
+			if errs := runAndWait(t, p); len(errs) > 0 {
+				t.Errorf("replay errors: %v", errs)
+			}
+			if !slices.Equal(*got, *want) {
+				t.Errorf("replayed %v, want %v", *got, *want)
+			}
+			if tt.clock == nil {
+				return
+			}
+			var wantWaits []time.Duration
+			for i := 1; i < len(records); i++ {
+				wantWaits = append(wantWaits, records[i].At.Sub(records[i-1].At))
+			}
+			if !slices.Equal(tt.clock.waits, wantWaits) {
+				t.Errorf("waits %v, want %v", tt.clock.waits, wantWaits)
+			}
+		})
+	}
+}
+
+func TestRecordReplayErrors(t *testing.T) {
+	type noCodec struct{}
+	if _, err := ReplaySource[noCodec]("replay", nil, Edge("src", 0), nil); !errors.Is(err, ErrNoCodec) {
+		t.Errorf("ReplaySource = %v, want %v", err, ErrNoCodec)
+	}
+
+	tests := []struct {
+		name    string
+		edge    EdgeRef
+		wantErr error
+	}{
+		// значения int не имеют кодека
+		{"no codec", Edge("src", 0), ErrNoCodec},
+		{"unknown node", Edge("missing", 0), ErrUnknownNode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewFileRecordStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			src := sliceSource("src", ints(3))
+			sink, _ := sliceSink[int]("sink")
+			mustConnect(t, src, sink)
+			p := New(WithRecording(store, tt.edge))
+			mustAdd(t, p, src, sink)
+			if err := p.Run(context.Background(), true); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileRecordStore RecordStore, хранящий записи каждого ребра в отдельном файле каталога dir
+// (JSON-объект Record на строку). Файлы дописываются, поэтому записи повторных запусков
+// накапливаются; для новой записи используйте новый каталог.
+type FileRecordStore struct {
+	dir   string
+	mu    sync.Mutex
+	files map[EdgeRef]*os.File
+}
+
+// NewFileRecordStore создаёт хранилище в каталоге dir, создавая каталог при необходимости
+func NewFileRecordStore(dir string) (*FileRecordStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileRecordStore{dir: dir, files: make(map[EdgeRef]*os.File)}, nil
+}
+
+// Append дописывает запись в файл ребра
+func (s *FileRecordStore) Append(edge EdgeRef, rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[edge]
+	if !ok {
+		f, err = os.OpenFile(s.path(edge), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		s.files[edge] = f
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Records читает записи ребра. Для ребра без записей возвращает nil.
+func (s *FileRecordStore) Records(edge EdgeRef) ([]Record, error) {
+	return readRecords(s.path(edge))
+}
+
+// readRecords читает записи из файла (JSON-объект Record на строку). Для отсутствующего файла
+// возвращает nil.
+func readRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", f.Name(), line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// Close закрывает открытые для записи файлы
+func (s *FileRecordStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for edge, f := range s.files {
+		errs = append(errs, f.Close())
+		delete(s.files, edge)
+	}
+	return errors.Join(errs...)
+}
+
+// path возвращает путь файла ребра
+func (s *FileRecordStore) path(edge EdgeRef) string {
+	return filepath.Join(s.dir, url.PathEscape(edge.String())+".jsonl")
+}
@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"iter"
+
+	"github.com/tom-lepsky/pipeline/pipeline/util"
+)
+
+// Results возвращает итератор по выходному каналу result пайплайна p для range-over-func.
+// Итерация завершается при закрытии result. Выход из цикла (break) вызывает p.Stop, а непрочитанные
+// элементы result отбрасываются, чтобы ноды, пишущие в result, не заблокировались навсегда.
+func Results[T any](p *Pipeline, result <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range util.Iter(context.Background(), result) {
+			if !yield(v) {
+				stopDraining(p, result, nil)
+				return
+			}
+		}
+	}
+}
+
+// ResultsWithErrors возвращает итератор по выходному каналу result и общему каналу ошибок
+// p.ErrChan пайплайна, запущенного с commonErrors: значение отдаётся парой (v, nil), ошибка — парой
+// (нулевое значение, err). Итерация завершается, когда result закрыт, все ноды завершились и их
+// ошибки прочитаны; p.Wait после цикла закрывает каналы ошибок. Вызывается после Run, выход из
+// цикла аналогичен Results.
+func ResultsWithErrors[T any](p *Pipeline, result <-chan T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		errs, done := p.ErrChan(), p.monitorDone
+		for result != nil || done != nil {
+			select {
+			case v, ok := <-result:
+				if !ok {
+					result = nil
+				} else if !yield(v, nil) {
+					stopDraining(p, result, errs)
+					return
+				}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+				} else if !yield(zero, err) {
+					stopDraining(p, result, errs)
+					return
+				}
+			case <-done:
+				done = nil
+			}
+		}
+
+		// после завершения нод все их ошибки уже переданы в errs
+		for {
+			select {
+			case err, ok := <-errs:
+				if !ok || !yield(zero, err) {
+					return
+				}
+			default:
+				return
+			}
+		}
+	}
+}
+
+// stopDraining останавливает пайплайн, вычитывая в фоне оставшиеся элементы result и ошибки errs
+func stopDraining[T any](p *Pipeline, result <-chan T, errs <-chan error) {
+	go func() {
+		for range util.Iter2(context.Background(), result, errs) {
+		}
+	}()
+	p.Stop()
+}
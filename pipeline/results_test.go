@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestResultsWithErrorsConcurrentRun(t *testing.T) {
+	errOdd := errors.New("odd")
+	src := sliceSource("source", ints(10))
+	check := node.NewMap("check", func(_ context.Context, v int) (int, error) {
+		if v%2 == 1 {
+			return 0, errOdd
+		}
+		return v, nil
+	})
+	result := make(chan int)
+	mustConnect(t, src, check)
+	if err := check.SetOutput(0, result); err != nil {
+		t.Fatal(err)
+	}
+	p := New()
+	mustAdd(t, p, src, check)
+
+	// итератор начинает читать до и во время Run: под -race проверяется, что он не гоняется с запуском
+	type pair struct {
+		vals []int
+		errs int
+	}
+	done := make(chan pair)
+	started := make(chan struct{})
+	go func() {
+		var got pair
+		close(started)
+		for v, err := range ResultsWithErrors(p, result) {
+			if err != nil {
+				got.errs++
+				continue
+			}
+			got.vals = append(got.vals, v)
+		}
+		done <- got
+	}()
+	<-started
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-done
+	waitTimeout(t, p)
+	slices.Sort(got.vals)
+	if want := []int{0, 2, 4, 6, 8}; !slices.Equal(got.vals, want) {
+		t.Errorf("values = %v, want %v", got.vals, want)
+	}
+	if got.errs != 5 {
+		t.Errorf("errors = %d, want 5", got.errs)
+	}
+}
+
+// resultsPipeline пайплайн source → check с выходом в result; check возвращает errOdd для
+// нечётных значений, если odd == true
+func resultsPipeline(t *testing.T, n int, odd bool) (*Pipeline, <-chan int) {
+	t.Helper()
+	src := sliceSource("source", ints(n))
+	check := node.NewMap("check", func(_ context.Context, v int) (int, error) {
+		if odd && v%2 == 1 {
+			return 0, errOdd
+		}
+		return v, nil
+	})
+	result := make(chan int)
+	mustConnect(t, src, check)
+	if err := check.SetOutput(0, result); err != nil {
+		t.Fatal(err)
+	}
+	p := New()
+	mustAdd(t, p, src, check)
+	return p, result
+}
+
+func TestResults(t *testing.T) {
+	tests := []struct {
+		name  string
+		items int
+		// limit количество значений, после которого цикл прерывается, 0 — до конца
+		limit int
+		want  []int
+	}{
+		{"all", 10, 0, ints(10)},
+		// break останавливает пайплайн, и ноды, пишущие в result, не блокируются
+		{"break", 100, 3, ints(3)},
+		{"break at first", 100, 1, ints(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, result := resultsPipeline(t, tt.items, false)
+			errs := collectErrors(p.ErrChan())
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []int
+			for v := range Results(p, result) {
+				got = append(got, v)
+				if len(got) == tt.limit {
+					break
+				}
+			}
+			waitTimeout(t, p)
+			errs.wait(t)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("values = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultsWithErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		// stopOnError цикл прерывается на первой ошибке
+		stopOnError bool
+	}{
+		{"all", false},
+		{"break on error", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, result := resultsPipeline(t, 10, true)
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+
+			var vals []int
+			var errs []error
+			for v, err := range ResultsWithErrors(p, result) {
+				if err != nil {
+					errs = append(errs, err)
+					if tt.stopOnError {
+						break
+					}
+					continue
+				}
+				vals = append(vals, v)
+			}
+			waitTimeout(t, p)
+			for _, err := range errs {
+				if !errors.Is(err, errOdd) {
+					t.Errorf("error %v, want %v", err, errOdd)
+				}
+			}
+			if tt.stopOnError {
+				if len(errs) != 1 {
+					t.Errorf("errors = %v, want the loop to stop at the first one", errs)
+				}
+				return
+			}
+			// значения и ошибки приходят из разных каналов, их взаимный порядок не определён
+			if want := []int{0, 2, 4, 6, 8}; !slices.Equal(vals, want) {
+				t.Errorf("values = %v, want %v", vals, want)
+			}
+			if len(errs) != 5 {
+				t.Errorf("errors = %v, want 5", errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package pipeline
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// crockford алфавит Crockford base32, используемый в ULID
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newRunID возвращает идентификатор запуска в формате ULID: 26 символов base32, первые 10 из
+// которых кодируют время t в миллисекундах, остальные 16 — 80 случайных бит. Идентификаторы
+// сортируются по времени запуска.
+func newRunID(t time.Time) string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(t.UnixMilli())<<16)
+	_, _ = rand.Read(b[6:])
+
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var id [26]byte
+	for i := 25; i >= 0; i-- {
+		id[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(id[:])
+}
+
+// RunID возвращает идентификатор запуска пайплайна (формат ULID) или "" до Run. Идентификатор
+// доступен обработчикам через node.RunID, входит в node.NodeError и Summary.
+//
+// Пайплайн запускается один раз: повторный Run возвращает ErrFinished, так как ноды закрывают
+// рёбра, а пайплайн — каналы ошибок, которые читатели ErrChan ждут закрытыми. Поэтому сброса
+// статистики между запусками нет: идентификатор, Stats и Summary относятся к единственному
+// запуску, а различаемые запуски — это запуски разных пайплайнов, каждый со своим RunID.
+func (p *Pipeline) RunID() string {
+	p.summary.mu.Lock()
+	defer p.summary.mu.Unlock()
+	return p.summary.RunID
+}
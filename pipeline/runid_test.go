@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var errOdd = errors.New("odd")
+
+// oddFailing пайплайн источник -> "check" -> приёмник, в котором нода check отклоняет нечётные
+// числа и запоминает идентификатор запуска из контекста
+func oddFailing(t *testing.T, n int) (*Pipeline, *[]int, *[]string) {
+	var mu sync.Mutex
+	var seen []string
+	check := node.NewMap("check", func(ctx context.Context, v int) (int, error) {
+		mu.Lock()
+		seen = append(seen, node.RunID(ctx))
+		mu.Unlock()
+		if v%2 == 1 {
+			return 0, errOdd
+		}
+		return v, nil
+	}, node.WithStats())
+	src := sliceSource("source", ints(n))
+	sink, got := sliceSink[int]("sink")
+	mustConnect(t, src, check)
+	mustConnect(t, check, sink)
+
+	p := New()
+	mustAdd(t, p, src, check, sink)
+	return p, got, &seen
+}
+
+// Повторный запуск одного пайплайна отклоняется (TestRunAfterFinish), поэтому последовательные
+// запуски — это запуски двух пайплайнов
+func TestRunIDSuccessivePipelines(t *testing.T) {
+	first, _, firstSeen := oddFailing(t, 4)
+	runAndWait(t, first)
+	firstID := first.RunID()
+
+	second, got, secondSeen := oddFailing(t, 7)
+	runAndWait(t, second)
+	secondID := second.RunID()
+
+	if len(firstID) != 26 || len(secondID) != 26 {
+		t.Fatalf("run IDs %q, %q are not ULIDs", firstID, secondID)
+	}
+	if firstID == secondID {
+		t.Fatalf("consecutive runs share run ID %q", firstID)
+	}
+	if firstID[:10] > secondID[:10] {
+		t.Errorf("time part of run ID %q of the later run sorts before %q", secondID, firstID)
+	}
+	for _, id := range *firstSeen {
+		if id != firstID {
+			t.Fatalf("handler saw run ID %q, want %q", id, firstID)
+		}
+	}
+	for _, id := range *secondSeen {
+		if id != secondID {
+			t.Fatalf("handler saw run ID %q, want %q", id, secondID)
+		}
+	}
+
+	// счётчики каждого запуска относятся только к нему
+	if s := first.Summary(); s.RunID != firstID || s.Item != 2 || s.ItemsIn["check"] != 4 {
+		t.Errorf("first summary = %+v, want run %s with 2 item errors and 4 items in", s, firstID)
+	}
+	if s := second.Summary(); s.RunID != secondID || s.Item != 3 || s.ItemsIn["check"] != 7 {
+		t.Errorf("second summary = %+v, want run %s with 3 item errors and 7 items in", s, secondID)
+	}
+	if st := second.Stats()["check"]; st.ItemsOut != 4 || len(*got) != 4 {
+		t.Errorf("second run: %d items out, %d received, want 4", st.ItemsOut, len(*got))
+	}
+}
+
+func TestRunIDNodeError(t *testing.T) {
+	p, _, _ := oddFailing(t, 2)
+	collector := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	waitTimeout(t, p)
+	errs := collector.wait(t)
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+	var ne *node.NodeError
+	if !errors.As(errs[0], &ne) || ne.RunID != p.RunID() {
+		t.Fatalf("error %v does not carry run ID %s", errs[0], p.RunID())
+	}
+}
+
+func TestRunAfterFinish(t *testing.T) {
+	p, _, _ := oddFailing(t, 3)
+	runAndWait(t, p)
+	id, summary := p.RunID(), p.Summary()
+
+	if err := p.Run(context.Background(), true); !errors.Is(err, ErrFinished) {
+		t.Fatalf("second Run = %v, want ErrFinished", err)
+	}
+	p.Wait()
+	p.Stop()
+	if p.RunID() != id {
+		t.Errorf("RunID changed to %s after rejected Run", p.RunID())
+	}
+	if s := p.Summary(); s.RunID != summary.RunID || s.Item != summary.Item {
+		t.Errorf("summary changed after rejected Run: %+v, was %+v", s, summary)
+	}
+}
+
+func TestRunAfterStop(t *testing.T) {
+	p, _, _ := oddFailing(t, 3)
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	p.Stop()
+	errs.wait(t)
+	if err := p.Run(context.Background(), true); !errors.Is(err, ErrFinished) {
+		t.Fatalf("Run after Stop = %v, want ErrFinished", err)
+	}
+}
@@ -0,0 +1,31 @@
+package pipeline
+
+import "fmt"
+
+// seedable нода, принимающая элементы для повторной подачи на вход
+type seedable interface {
+	Name() string
+	SeedInput(idx int, items any) error
+}
+
+// Seed подаёт items на вход inputIdx ноды entryNode: при запуске пайплайна нода обработает их
+// раньше элементов, поступающих из её входов. Используется для повторной обработки элементов,
+// оставшихся от предыдущего запуска (StrandedOf, dead-letter). Вызывается до Run, иначе
+// возвращает ErrAlreadyRunning. Возвращает ErrUnknownNode, если ноды нет в пайплайне, и
+// node.ErrSeedType, если тип элементов не совпадает с типом входа.
+func Seed[T any](pipe *Pipeline, entryNode string, inputIdx int, items []T) error {
+	if pipe.run.Load() {
+		return ErrAlreadyRunning
+	}
+
+	for _, name := range pipe.groupOrder {
+		for _, n := range pipe.groups[name].nodes {
+			s, ok := n.(seedable)
+			if ok && s.Name() == entryNode {
+				return s.SeedInput(inputIdx, items)
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownNode, entryNode)
+}
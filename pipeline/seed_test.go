@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestSeedEmittedFirst(t *testing.T) {
+	p := New()
+	src := sliceSource("source", ints(5))
+	double := node.NewMap("double", func(_ context.Context, v int) (int, error) { return v * 2, nil })
+	sink, got := sliceSink[int]("sink")
+	mustConnect(t, src, double)
+	mustConnect(t, double, sink)
+	mustAdd(t, p, src, double, sink)
+
+	// элементы нескольких вызовов Seed накапливаются по порядку
+	if err := Seed(p, "double", 0, []int{100, 101}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Seed(p, "double", 0, []int{102}); err != nil {
+		t.Fatal(err)
+	}
+	if errs := runAndWait(t, p); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if want := []int{200, 202, 204, 0, 2, 4, 6, 8}; !slices.Equal(*got, want) {
+		t.Errorf("sink got %v, want %v", *got, want)
+	}
+}
+
+func TestSeedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    string
+		idx     int
+		items   any
+		running bool
+		wantErr error
+	}{
+		{"unknown node", "missing", 0, []int{1}, false, ErrUnknownNode},
+		{"bad index", "sink", 1, []int{1}, false, node.ErrInputIdxOutOfRange},
+		{"negative index", "sink", -1, []int{1}, false, node.ErrInputIdxOutOfRange},
+		{"type mismatch", "sink", 0, []string{"a"}, false, node.ErrSeedType},
+		{"running", "sink", 0, []int{1}, true, ErrAlreadyRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			block := make(chan int)
+			sink := node.NewSink("sink", 1, func(context.Context, int) error { return nil })
+			if err := sink.SetInput(0, block); err != nil {
+				t.Fatal(err)
+			}
+			mustAdd(t, p, sink)
+			if tt.running {
+				if err := p.Run(context.Background(), true); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(p.Stop)
+			}
+
+			var err error
+			switch items := tt.items.(type) {
+			case []int:
+				err = Seed(p, tt.node, tt.idx, items)
+			case []string:
+				err = Seed(p, tt.node, tt.idx, items)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Seed error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
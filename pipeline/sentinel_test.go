@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+	"github.com/tom-lepsky/pipeline/pipeline/util"
+)
+
+func TestSentinelRoundTrip(t *testing.T) {
+	sentinels := []error{
+		// pipeline
+		ErrAlreadyRunning, ErrNoNodes, ErrSharedOutput, ErrFinished, ErrDuplicateNode, ErrMemoryLimit,
+		ErrStageType, ErrAuxiliary, ErrInvalidWorkers, ErrUnknownNode, ErrMaxRuntime, ErrInletFull,
+		ErrInletClosed, ErrUnknownBranch, ErrBranchDisabled, ErrDumpType, ErrDependencyFailed,
+		ErrDependencyCycle, ErrItemErrorBudget, ErrErrorSourceExists, ErrIdleTimeout, ErrIdleUntracked,
+		ErrNoCodec,
+		// node
+		node.ErrSilence, node.ErrFlushAbandoned, node.ErrLateItem, node.ErrInputNotOwned,
+		node.ErrUnsupportedCommand, node.ErrParamType, node.ErrNoSizeFunc, node.ErrUnwired,
+		node.ErrOutputClosedTwice, node.ErrSendAfterClose, node.ErrCardinality, node.ErrBypassed,
+		node.ErrBypassEngaged, node.ErrBypassRecovered, node.ErrCircuitOpen, node.ErrInvalidCron,
+		node.ErrSeedType, node.ErrNilHandler, node.ErrOutputBuffMismatch, node.ErrInputBuffMismatch,
+		node.ErrIOOutOfRange, node.ErrSizeFuncType, node.ErrInputLabels, node.ErrInputWeight,
+		node.ErrMiddlewareType, node.ErrQuotaExceeded, node.ErrInputIdxOutOfRange,
+		node.ErrOutputIdxOutOfRange, node.ErrInputsWired, node.ErrOutputsWired, node.ErrNilChannel,
+		node.ErrFrozen, node.ErrSlotOccupied, node.ErrEmptyArgv, node.ErrExitCode, node.ErrHandlerExited,
+		node.ErrRestartLimit, node.ErrAlreadyRunning,
+		// util
+		util.ErrCorruptSegment,
+	}
+	for _, sentinel := range sentinels {
+		t.Run(sentinel.Error(), func(t *testing.T) {
+			// самый глубокий путь: fmt.Errorf, DeadLetter, Classify, паника обработчика (PanicError),
+			// NodeError с идентификатором запуска и сводка ошибок пайплайна
+			src := sliceSource("src", []int{1})
+			sink := node.NewSink("sink", 1, func(_ context.Context, v int) error {
+				panic(node.Classify(node.ClassInfra, &node.DeadLetter{
+					Node: "sink",
+					Item: v,
+					Err:  fmt.Errorf("item %d: %w", v, sentinel),
+				}))
+			})
+			mustConnect(t, src, sink)
+			p := New()
+			mustAdd(t, p, src, sink)
+
+			errs := runAndWait(t, p)
+			if len(errs) != 1 {
+				t.Fatalf("errors %v, want one", errs)
+			}
+			err := errs[0]
+			var ne *node.NodeError
+			if !errors.As(err, &ne) || ne.Node != "sink" || ne.RunID != p.RunID() {
+				t.Errorf("error %v, want NodeError of sink in run %s", err, p.RunID())
+			}
+			var pe *node.PanicError
+			if !errors.As(err, &pe) {
+				t.Errorf("error %v is not a PanicError", err)
+			}
+			if !errors.Is(err, sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", err, sentinel)
+			}
+			summaryErr := p.Summary().Err()
+			if !errors.Is(summaryErr, sentinel) {
+				t.Errorf("errors.Is(Summary().Err() = %v, %v) = false", summaryErr, sentinel)
+			}
+		})
+	}
+}
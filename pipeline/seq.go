@@ -0,0 +1,30 @@
+package pipeline
+
+import (
+	"context"
+	"iter"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// SeqSource возвращает функцию источника, отдающую значения seq, для node.NewSource и MapReduce.
+// seq выполняется в горутине обработчика ноды, поэтому его паники обрабатываются по политике
+// паник ноды. При отмене контекста значения перестают запрашиваться у seq на ближайшем yield;
+// seq, заблокированный внутри себя (например, курсор базы данных), должен сам следить за отменой.
+func SeqSource[T any](seq iter.Seq[T]) node.SourceFn[T] {
+	return func(ctx context.Context, output chan<- T, errChan chan<- error) {
+		for v := range seq {
+			select {
+			case output <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// SourceFromSeq создаёт ноду-источник, отдающую значения seq (см. SeqSource)
+func SourceFromSeq[T any](name string, seq iter.Seq[T], outputNum int, outputBuffSize []int,
+	opts ...node.Option) *node.Node[struct{}, T] {
+	return node.NewSource(name, outputNum, outputBuffSize, SeqSource(seq), opts...)
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestSourceFromSeq(t *testing.T) {
+	tests := []struct {
+		name string
+		// cancelAt количество значений, после которого приёмник отменяет запуск, 0 — без отмены
+		cancelAt int
+		// panicAt значение, на котором seq паникует, -1 — без паники
+		panicAt   int
+		want      []int
+		wantPanic bool
+	}{
+		{"all", 0, -1, ints(5), false},
+		// при отмене значения перестают запрашиваться у бесконечной seq
+		{"cancel", 3, -1, ints(3), false},
+		// паника seq обрабатывается по политике паник ноды (по умолчанию — ошибка)
+		{"panic", 0, 2, ints(2), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stopped atomic.Bool
+			seq := func(yield func(int) bool) {
+				for i := 0; tt.cancelAt > 0 || i < 5; i++ {
+					if i == tt.panicAt {
+						panic("boom")
+					}
+					if !yield(i) {
+						stopped.Store(true)
+						return
+					}
+				}
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			src := SourceFromSeq("src", seq, 1, nil)
+			var got []int
+			sink := node.NewSink("sink", 1, func(_ context.Context, v int) error {
+				got = append(got, v)
+				if len(got) == tt.cancelAt {
+					cancel()
+				}
+				return nil
+			})
+			mustConnect(t, src, sink)
+			p := New()
+			mustAdd(t, p, src, sink)
+
+			errs := collectErrors(p.ErrChan())
+			if err := p.Run(ctx, true); err != nil {
+				t.Fatal(err)
+			}
+			waitTimeout(t, p)
+			gotErrs := errs.wait(t)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sink got %v, want %v", got, tt.want)
+			}
+			if tt.cancelAt > 0 && !stopped.Load() {
+				t.Error("seq was not stopped after cancel")
+			}
+			switch {
+			case tt.wantPanic && (len(gotErrs) != 1 || !errors.As(gotErrs[0], new(*node.PanicError))):
+				t.Errorf("errors = %v, want one PanicError", gotErrs)
+			case !tt.wantPanic && len(gotErrs) > 0:
+				t.Errorf("errors: %v", gotErrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestSharedInput(t *testing.T) {
+	const consumers, items = 3, 100
+	ch := make(chan int)
+	shared := node.SharedInput(ch)
+
+	var mu sync.Mutex
+	var got []int
+	p := New()
+	for range consumers {
+		sink := node.NewSink("", 1, func(_ context.Context, v int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, v)
+			return nil
+		}, node.WithNameTemplate("consumer-{i}"))
+		if err := sink.SetInput(0, shared); err != nil {
+			t.Fatal(err)
+		}
+		mustAdd(t, p, sink)
+	}
+	// помеченный общий вход не считается ошибкой подключения
+	for _, f := range Analyze(p) {
+		t.Errorf("unexpected finding: %v", f)
+	}
+
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range ints(items) {
+		ch <- v
+	}
+	close(ch)
+	waitTimeout(t, p)
+	if errs := errs.wait(t); len(errs) > 0 {
+		t.Errorf("errors: %v", errs)
+	}
+
+	// потребители поочерёдно забирают элементы: каждый доставлен ровно одному
+	slices.Sort(got)
+	if !slices.Equal(got, ints(items)) {
+		t.Errorf("consumers got %d items %v, want each of %d once", len(got), got, items)
+	}
+}
+
+func TestSharedOutputRejected(t *testing.T) {
+	ch := make(chan int)
+	a := sliceSource("a", ints(3))
+	b := sliceSource("b", ints(3))
+	sink, _ := sliceSink[int]("sink")
+	for _, n := range []*node.Node[struct{}, int]{a, b} {
+		if err := n.SetOutput(0, ch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.SetInput(0, ch); err != nil {
+		t.Fatal(err)
+	}
+	p := New()
+	mustAdd(t, p, a, b, sink)
+
+	// повторное закрытие канала привело бы к панике: пайплайн не запускается
+	if err := p.Run(context.Background(), true); !errors.Is(err, ErrSharedOutput) {
+		t.Errorf("Run = %v, want %v", err, ErrSharedOutput)
+	}
+	if err := p.Freeze(); !errors.Is(err, ErrSharedOutput) {
+		t.Errorf("Freeze = %v, want %v", err, ErrSharedOutput)
+	}
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import "context"
+
+// infiniteSource нода-источник, которую можно остановить извне
+type infiniteSource interface {
+	InfiniteSource() bool
+	StopSource()
+}
+
+// Shutdown корректно завершает пайплайн с бесконечными источниками (node.WithInfiniteSource):
+// останавливает их, так что их выходы закрываются, и ожидает, пока остальные ноды дообработают
+// уже выданные элементы. Если ctx завершается раньше, пайплайн останавливается через Stop
+// и возвращается ошибка ctx.
+func (p *Pipeline) Shutdown(ctx context.Context) error {
+	p.awaitStart()
+	if !p.run.Load() {
+		return nil
+	}
+
+	p.stopSources()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Wait()
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// stoppableSource нода-источник, которую можно остановить (node.NewSource)
+type stoppableSource interface {
+	StopSource()
+}
+
+// drainSources останавливает все источники пайплайна, созданные node.NewSource, включая конечные.
+// Передаётся нодам через node.ContextWithDrain.
+func (p *Pipeline) drainSources() {
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if src, ok := n.(stoppableSource); ok {
+				src.StopSource()
+			}
+		}
+	}
+}
+
+// stopSources останавливает бесконечные источники пайплайна
+func (p *Pipeline) stopSources() {
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if src, ok := n.(infiniteSource); ok && src.InfiniteSource() {
+				src.StopSource()
+			}
+		}
+	}
+}
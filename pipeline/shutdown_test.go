@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestShutdown(t *testing.T) {
+	tests := []struct {
+		name string
+		// stuck приёмник не возвращается из обработки элемента до отмены контекста
+		stuck    bool
+		deadline time.Duration
+		wantErr  error
+		wantExit node.ExitReason
+	}{
+		// источник остановлен, приёмник дообрабатывает выданные элементы и завершается по закрытию входа
+		{"graceful", false, 5 * time.Second, nil, node.ExitInputClosed},
+		// приёмник не успевает к сроку: пайплайн останавливается отменой
+		{"deadline", true, 50 * time.Millisecond, context.DeadlineExceeded, node.ExitCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent, received atomic.Int64
+			source := node.NewSource("source", 1, nil, func(ctx context.Context, output chan<- int, _ chan<- error) {
+				for i := 0; ; i++ {
+					select {
+					case output <- i:
+						sent.Add(1)
+					case <-ctx.Done():
+						return
+					}
+				}
+			}, node.WithInfiniteSource())
+			sink := node.NewSink("sink", 1, func(ctx context.Context, _ int) error {
+				if tt.stuck {
+					<-ctx.Done()
+					return nil
+				}
+				received.Add(1)
+				return nil
+			})
+			mustConnect(t, source, sink)
+			p := New()
+			mustAdd(t, p, source, sink)
+
+			errs := collectErrors(p.ErrChan())
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			// без Shutdown бесконечный источник не даёт пайплайну завершиться
+			eventually(t, func() bool { return sent.Load() > 0 })
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+			defer cancel()
+			if err := p.Shutdown(ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Shutdown error = %v, want %v", err, tt.wantErr)
+			}
+			if errs := errs.wait(t); len(errs) > 0 {
+				t.Errorf("errors: %v", errs)
+			}
+			if r := sink.ExitReason(); r != tt.wantExit {
+				t.Errorf("sink ExitReason = %v, want %v", r, tt.wantExit)
+			}
+			if !tt.stuck && received.Load() != sent.Load() {
+				t.Errorf("sink processed %d of %d sent items", received.Load(), sent.Load())
+			}
+			// повторный вызов после завершения ничего не делает
+			if err := p.Shutdown(context.Background()); err != nil {
+				t.Errorf("second Shutdown error = %v", err)
+			}
+		})
+	}
+}
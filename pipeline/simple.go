@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var ErrStageType = errors.New("invalid stage")
+
+// SimpleParallelism количество элементов, обрабатываемых каждой стадией Simple одновременно
+var SimpleParallelism = runtime.GOMAXPROCS(0)
+
+// Simple обрабатывает inputs цепочкой стадий и возвращает результаты и ошибки после завершения.
+// Стадия — функция вида func(context.Context, X) (Y, error), где X принимает значения типа I для
+// первой стадии и Y предыдущей стадии для остальных, а Y последней стадии присваивается O.
+// Совместимость типов проверяется при вызове: при ошибке возвращается ErrStageType, и ничего не
+// запускается. Внутри строится обычный пайплайн: источник, по ноде node.NewMap на стадию
+// (SimpleParallelism параллельных вызовов, порядок сохраняется, WithOrderedOutput) и сборщик
+// результатов, поэтому поведение совпадает с явно собранным графом. Элемент, на котором стадия
+// вернула ошибку, в результаты не попадает; ошибки содержат имя ноды ("stage <номер>"). Отмена
+// ctx останавливает пайплайн, и возвращаются уже собранные результаты.
+func Simple[I, O any](ctx context.Context, inputs []I, stages ...any) ([]O, []error) {
+	fns, err := simpleStages(reflect.TypeFor[I](), reflect.TypeFor[O](), stages)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	items := make([]any, len(inputs))
+	for i, v := range inputs {
+		items[i] = v
+	}
+	source := node.NewSource("source", 1, []int{1}, SeqSource(func(yield func(any) bool) {
+		for _, v := range items {
+			if !yield(v) {
+				return
+			}
+		}
+	}))
+
+	var results []O
+	sink := node.NewSink("results", 1, func(_ context.Context, v any) error {
+		out, _ := v.(O)
+		results = append(results, out)
+		return nil
+	})
+
+	p := New()
+	p.AddNode(source, sink)
+	if err := connectStages(p, source, fns, sink); err != nil {
+		return nil, []error{err}
+	}
+
+	if err := p.Run(ctx, false); err != nil {
+		return nil, []error{err}
+	}
+	var errs []error
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for err := range p.ErrChan() {
+			errs = append(errs, err)
+		}
+	})
+	p.Wait()
+	wg.Wait()
+	return results, errs
+}
+
+// connectStages создаёт ноды стадий и соединяет source -> стадии -> sink
+func connectStages(p *Pipeline, source *node.Node[struct{}, any], fns []node.MapFn[any, any],
+	sink *node.Node[any, struct{}]) error {
+	if len(fns) == 0 {
+		return node.Connect(source, 0, sink, 0)
+	}
+
+	stages := make([]*node.Node[any, any], len(fns))
+	for i, fn := range fns {
+		stages[i] = node.NewMap(fmt.Sprintf("stage %d", i+1), fn, node.WithOutputBuffers(1),
+			node.WithConcurrency(SimpleParallelism), node.WithOrderedOutput())
+		p.AddNode(stages[i])
+		if i > 0 {
+			if err := node.Connect(stages[i-1], 0, stages[i], 0); err != nil {
+				return err
+			}
+		}
+	}
+	if err := node.Connect(source, 0, stages[0], 0); err != nil {
+		return err
+	}
+	return node.Connect(stages[len(stages)-1], 0, sink, 0)
+}
+
+// simpleStages проверяет типы стадий Simple и оборачивает их в функции node.MapFn
+func simpleStages(in, out reflect.Type, stages []any) ([]node.MapFn[any, any], error) {
+	ctxType := reflect.TypeFor[context.Context]()
+	errType := reflect.TypeFor[error]()
+	fns := make([]node.MapFn[any, any], 0, len(stages))
+	for i, stage := range stages {
+		t := reflect.TypeOf(stage)
+		if t == nil || t.Kind() != reflect.Func || t.NumIn() != 2 || t.NumOut() != 2 || t.IsVariadic() ||
+			t.In(0) != ctxType || t.Out(1) != errType {
+			return nil, fmt.Errorf("%w: stage %d: want func(context.Context, X) (Y, error), got %v", ErrStageType,
+				i+1, t)
+		}
+		if !in.AssignableTo(t.In(1)) {
+			return nil, fmt.Errorf("%w: stage %d: accepts %v, got %v", ErrStageType, i+1, t.In(1), in)
+		}
+		fns = append(fns, reflectStage(reflect.ValueOf(stage)))
+		in = t.Out(0)
+	}
+	if !in.AssignableTo(out) {
+		return nil, fmt.Errorf("%w: result %v is not assignable to %v", ErrStageType, in, out)
+	}
+	return fns, nil
+}
+
+// reflectStage оборачивает проверенную стадию Simple в node.MapFn
+func reflectStage(fn reflect.Value) node.MapFn[any, any] {
+	argType := fn.Type().In(1)
+	return func(ctx context.Context, in any) (any, error) {
+		arg := reflect.Zero(argType)
+		if in != nil {
+			arg = reflect.ValueOf(in)
+		}
+		res := fn.Call([]reflect.Value{reflect.ValueOf(ctx), arg})
+		err, _ := res[1].Interface().(error)
+		return res[0].Interface(), err
+	}
+}
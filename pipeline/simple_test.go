@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestSimple(t *testing.T) {
+	errOddItem := errors.New("odd item")
+	double := func(_ context.Context, v int) (int, error) { return 2 * v, nil }
+	itoa := func(_ context.Context, v int) (string, error) { return strconv.Itoa(v), nil }
+	tests := []struct {
+		name   string
+		stages []any
+		want   []string
+		// wantErrs количество ошибок стадии 1, оборачивающих errOddItem
+		wantErrs int
+	}{
+		// порядок сохраняется при параллельной обработке
+		{"chain", []any{double, itoa}, []string{"2", "4", "6", "8", "10"}, 0},
+		// параметр стадии принимает значение предыдущей, если оно ему присваивается
+		{"assignable", []any{func(_ context.Context, v any) (string, error) {
+			return fmt.Sprint(v), nil
+		}}, []string{"1", "2", "3", "4", "5"}, 0},
+		// элемент с ошибкой не попадает в результаты
+		{"stage error", []any{func(_ context.Context, v int) (int, error) {
+			if v%2 == 1 {
+				return 0, errOddItem
+			}
+			return v, nil
+		}, itoa}, []string{"2", "4"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := Simple[int, string](context.Background(), []int{1, 2, 3, 4, 5}, tt.stages...)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("results %v, want %v", got, tt.want)
+			}
+			if len(errs) != tt.wantErrs {
+				t.Fatalf("errors %v, want %d", errs, tt.wantErrs)
+			}
+			for _, err := range errs {
+				var ne *node.NodeError
+				if !errors.Is(err, errOddItem) || !errors.As(err, &ne) || ne.Node != "stage 1" {
+					t.Errorf("error %v, want %v of stage 1", err, errOddItem)
+				}
+			}
+		})
+	}
+}
+
+func TestSimpleNoStages(t *testing.T) {
+	// без стадий значения проходят к результатам как есть
+	got, errs := Simple[int, int](context.Background(), []int{1, 2, 3})
+	if !slices.Equal(got, []int{1, 2, 3}) || len(errs) > 0 {
+		t.Errorf("Simple = %v, %v; want [1 2 3] without errors", got, errs)
+	}
+}
+
+func TestSimpleStageType(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []any
+	}{
+		{"not a func", []any{42}},
+		{"nil stage", []any{nil}},
+		{"no context", []any{func(v int) (int, error) { return v, nil }}},
+		{"no error", []any{func(_ context.Context, v int) int { return v }}},
+		{"variadic", []any{func(_ context.Context, v ...int) (int, error) { return 0, nil }}},
+		{"input type", []any{func(_ context.Context, v string) (string, error) { return v, nil }}},
+		{"chain type", []any{
+			func(_ context.Context, v int) (int, error) { return v, nil },
+			func(_ context.Context, v string) (string, error) { return v, nil },
+		}},
+		{"result type", []any{func(_ context.Context, v int) (int, error) { return v, nil }}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := Simple[int, string](context.Background(), []int{1}, tt.stages...)
+			if got != nil || len(errs) != 1 || !errors.Is(errs[0], ErrStageType) {
+				t.Errorf("Simple = %v, %v; want only %v", got, errs, ErrStageType)
+			}
+		})
+	}
+}
+
+func TestSimpleCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// отменённый контекст останавливает пайплайн: возвращаются уже собранные результаты
+	got, errs := Simple[int, int](ctx, ints(1000), func(_ context.Context, v int) (int, error) { return v, nil })
+	if len(got) == 1000 {
+		t.Error("all items processed despite the cancelled context")
+	}
+	if len(errs) > 0 {
+		t.Errorf("errors: %v", errs)
+	}
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// Snapshot снимок статистики нод пайплайна (StatsSnapshot). С WithConsistentStats счётчики всех нод
+// сняты в один момент, поэтому величины по нескольким нодам (например, элементы внутри участка —
+// ItemsOut ноды минус ItemsIn следующей за ней) не противоречат друг другу
+type Snapshot struct {
+	// Seq номер снимка, растущий от снимка к снимку в пределах пайплайна
+	Seq uint64
+	// Time момент снимка по часам пайплайна (WithClock)
+	Time time.Time
+	// Nodes статистика нод в порядке добавления
+	Nodes []NodeStats
+	// Buffers заполненность буферов нод на момент снимка. Буферы не останавливаются на время
+	// снимка, поэтому согласованы со счётчиками лишь приблизительно.
+	Buffers []node.BufferUsage
+}
+
+// NodeStats статистика одной ноды в Snapshot
+type NodeStats struct {
+	Node string
+	node.Stats
+}
+
+// Stats возвращает статистику ноды name из снимка
+func (s Snapshot) Stats(name string) (node.Stats, bool) {
+	for _, ns := range s.Nodes {
+		if ns.Node == name {
+			return ns.Stats, true
+		}
+	}
+	return node.Stats{}, false
+}
+
+// WithConsistentStats подключает счётчики нод к шлюзу снимков, так что StatsSnapshot, Stats и
+// Summary снимают статистику всех нод в один момент. Каждое изменение счётчика ноды проходит
+// через общий для пайплайна шлюз, поэтому опция замедляет учёт элементов; без неё счётчики нод
+// читаются по очереди без остановки учёта.
+func WithConsistentStats() Option {
+	return func(o *options) {
+		o.consistentStats = true
+	}
+}
+
+// statsGated нода, счётчики которой можно подключить к шлюзу снимков
+type statsGated interface {
+	SetStatsGate(g *node.StatsGate)
+}
+
+// StatsSnapshot возвращает снимок статистики всех нод пайплайна. С WithConsistentStats снимок
+// согласован: на время снимка ноды приостанавливают учёт элементов. Можно вызывать во время
+// работы пайплайна.
+func (p *Pipeline) StatsSnapshot() Snapshot {
+	var s Snapshot
+	p.statsGate.Hold(func() {
+		s.Seq = p.snapshots.Add(1)
+		s.Time = p.clock().Now()
+		for _, name := range p.groupOrder {
+			for _, n := range p.groups[name].nodes {
+				ns, ok := n.(interface {
+					Name() string
+					Stats() node.Stats
+				})
+				if ok {
+					s.Nodes = append(s.Nodes, NodeStats{Node: ns.Name(), Stats: ns.Stats()})
+				}
+			}
+		}
+		s.Buffers = p.BufferUsage()
+	})
+	return s
+}
+
+// Stats возвращает статистику нод пайплайна по именам из снимка (см. StatsSnapshot).
+// У нод без node.WithStats счётчики элементов нулевые.
+func (p *Pipeline) Stats() map[string]node.Stats {
+	snap := p.StatsSnapshot()
+	stats := make(map[string]node.Stats, len(snap.Nodes))
+	for _, ns := range snap.Nodes {
+		stats[ns.Node] = ns.Stats
+	}
+	return stats
+}
+
+// shareStatsGate подключает счётчики нод к шлюзу снимков пайплайна перед запуском (WithConsistentStats)
+func (p *Pipeline) shareStatsGate() {
+	if !p.opts.consistentStats {
+		return
+	}
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			if g, ok := n.(statsGated); ok {
+				g.SetStatsGate(p.statsGate)
+			}
+		}
+	}
+}
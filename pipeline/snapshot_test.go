@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestStatsSnapshotConsistent(t *testing.T) {
+	src := sliceSource("source", ints(5000), node.WithStats())
+	double := node.NewMap("double", func(_ context.Context, v int) (int, error) {
+		return 2 * v, nil
+	}, node.WithStats(), node.WithConcurrency(4))
+	sink, _ := sliceSink[int]("sink", node.WithStats())
+	mustConnect(t, src, double)
+	mustConnect(t, double, sink)
+
+	p := New(WithConsistentStats())
+	mustAdd(t, p, src, double, sink)
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Wait()
+	}()
+
+	var seq uint64
+	inside := func(s Snapshot, from, to string) int64 {
+		out, _ := s.Stats(from)
+		in, _ := s.Stats(to)
+		return int64(out.ItemsOut) - int64(in.ItemsIn)
+	}
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		s := p.StatsSnapshot()
+		if s.Seq <= seq {
+			t.Fatalf("snapshot Seq %d after %d", s.Seq, seq)
+		}
+		seq = s.Seq
+		// элементы внутри участка: отправленные нодой, но ещё не прочитанные следующей
+		for _, edge := range [][2]string{{"source", "double"}, {"double", "sink"}} {
+			if d := inside(s, edge[0], edge[1]); d < 0 {
+				t.Fatalf("snapshot %d: %s -> %s holds %d items", s.Seq, edge[0], edge[1], d)
+			}
+		}
+		if d, _ := s.Stats("double"); d.InFlight < 0 {
+			t.Fatalf("snapshot %d: InFlight = %d", s.Seq, d.InFlight)
+		}
+		// снимки подряд останавливали бы учёт почти непрерывно
+		time.Sleep(10 * time.Microsecond)
+	}
+	errs.wait(t)
+
+	final := p.StatsSnapshot()
+	if s, _ := final.Stats("sink"); s.ItemsIn != 5000 {
+		t.Errorf("sink ItemsIn = %d, want 5000", s.ItemsIn)
+	}
+}
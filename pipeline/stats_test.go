@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestStatsCounters(t *testing.T) {
+	const items = 20
+	errFifth := errors.New("fifth")
+	source := sliceSource("source", ints(items), node.WithStats())
+	// каждое пятое значение — ошибка, остальные проходят дальше
+	check := node.NewMap("check", func(_ context.Context, v int) (int, error) {
+		if v%5 == 0 {
+			return 0, errFifth
+		}
+		return v, nil
+	}, node.WithStats())
+	double := node.NewFlatMap("double", func(_ context.Context, v int) ([]int, error) {
+		return []int{v, v}, nil
+	}, node.WithStats())
+	sink, got := sliceSink[int]("sink", node.WithStats())
+	// узел без WithStats: счётчики не ведутся
+	quiet := node.NewMap("quiet", func(_ context.Context, v int) (int, error) { return v, nil })
+
+	mustConnect(t, source, check)
+	mustConnect(t, check, double)
+	mustConnect(t, double, quiet)
+	mustConnect(t, quiet, sink)
+	p := New()
+	mustAdd(t, p, source, check, double, quiet, sink)
+	errs := runAndWait(t, p)
+
+	const failed = items / 5
+	if len(errs) != failed {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), failed, errs)
+	}
+	if len(*got) != 2*(items-failed) {
+		t.Fatalf("sink got %d items, want %d", len(*got), 2*(items-failed))
+	}
+
+	want := map[string]node.Stats{
+		"source": {ItemsOut: items},
+		"check":  {ItemsIn: items, ItemsOut: items - failed, Errors: failed},
+		"double": {ItemsIn: items - failed, ItemsOut: 2 * (items - failed)},
+		"quiet":  {},
+		"sink":   {ItemsIn: 2 * (items - failed)},
+	}
+	nodes := map[string]interface{ Stats() node.Stats }{
+		"source": source, "check": check, "double": double, "quiet": quiet, "sink": sink,
+	}
+	stats := p.Stats()
+	if len(stats) != len(want) {
+		t.Fatalf("Stats has %d nodes, want %d", len(stats), len(want))
+	}
+	for name, w := range want {
+		s, ok := stats[name]
+		if !ok {
+			t.Errorf("no stats for %s", name)
+			continue
+		}
+		// агрегат пайплайна совпадает со статистикой самой ноды
+		if ns := nodes[name].Stats(); ns.ItemsIn != s.ItemsIn || ns.ItemsOut != s.ItemsOut || ns.Errors != s.Errors {
+			t.Errorf("%s: node stats %+v differ from pipeline stats %+v", name, ns, s)
+		}
+		if s.ItemsIn != w.ItemsIn || s.ItemsOut != w.ItemsOut || s.Errors != w.Errors {
+			t.Errorf("%s: in %d, out %d, errors %d; want in %d, out %d, errors %d", name,
+				s.ItemsIn, s.ItemsOut, s.Errors, w.ItemsIn, w.ItemsOut, w.Errors)
+		}
+		if name == "quiet" {
+			if !s.StartedAt.IsZero() || !s.FinishedAt.IsZero() {
+				t.Errorf("quiet: timestamps recorded without WithStats")
+			}
+			continue
+		}
+		if s.StartedAt.IsZero() || s.FinishedAt.Before(s.StartedAt) {
+			t.Errorf("%s: started %v, finished %v", name, s.StartedAt, s.FinishedAt)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package pipeline
+
+import "github.com/tom-lepsky/pipeline/pipeline/node"
+
+// strandedReporter нода, сообщающая об элементах, оставшихся в буферах её выходов
+type strandedReporter interface {
+	Stranded(limit int) []node.StrandedEdge
+}
+
+// WithStrandedCapture включает извлечение до limit элементов из буфера каждого ребра, созданного
+// node.Connect, после завершения пайплайна. Извлечённые элементы доступны через StrandedItems,
+// например, чтобы сохранить их или передать в источник при следующем запуске.
+func WithStrandedCapture(limit int) Option {
+	return func(o *options) {
+		o.strandedLimit = max(limit, 0)
+	}
+}
+
+// Stranded возвращает количество элементов, оставшихся в буферах рёбер после завершения пайплайна
+// (Stop или Wait), по именам рёбер (см. node.StrandedEdge). До завершения возвращает nil.
+func (p *Pipeline) Stranded() map[string]int {
+	if p.stranded == nil {
+		return nil
+	}
+
+	counts := make(map[string]int, len(p.stranded))
+	for name, e := range p.stranded {
+		counts[name] = e.Count
+	}
+	return counts
+}
+
+// StrandedItems возвращает элементы, извлечённые из буферов рёбер при WithStrandedCapture
+func (p *Pipeline) StrandedItems() map[string][]any {
+	items := make(map[string][]any)
+	for name, e := range p.stranded {
+		if len(e.Items) > 0 {
+			items[name] = e.Items
+		}
+	}
+	return items
+}
+
+// StrandedOf возвращает извлечённые элементы ребра edge, имеющие тип T
+func StrandedOf[T any](p *Pipeline, edge string) []T {
+	var items []T
+	for _, item := range p.stranded[edge].Items {
+		if v, ok := item.(T); ok {
+			items = append(items, v)
+		}
+	}
+	return items
+}
+
+// collectStranded собирает элементы, оставшиеся в буферах рёбер. Вызывается после завершения
+// всех нод.
+func (p *Pipeline) collectStranded() {
+	stranded := make(map[string]node.StrandedEdge)
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			r, ok := n.(strandedReporter)
+			if !ok {
+				continue
+			}
+			for _, e := range r.Stranded(p.opts.strandedLimit) {
+				stranded[e.Edge] = e
+			}
+		}
+	}
+	p.stranded = stranded
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestStranded(t *testing.T) {
+	const buffer, limit = 5, 3
+	var sent atomic.Int32
+	source := node.NewSource("source", 1, []int{buffer}, func(ctx context.Context, output chan<- int, _ chan<- error) {
+		for i := 0; ; i++ {
+			select {
+			case output <- i:
+				sent.Add(1)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	// обработчик читает один элемент и завершается; пересылка входа успевает получить следующий
+	early := node.New("early", 1, 0, nil, func(_ context.Context, input <-chan int, _ chan<- struct{}, _ chan<- error) {
+		<-input
+	}, node.WithExitTracking())
+	mustConnect(t, source, early)
+	p := New(WithStrandedCapture(limit))
+	mustAdd(t, p, source, early)
+
+	errs := collectErrors(p.ErrChan())
+	if err := p.Run(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	// источник блокируется, когда заполнен буфер ребра: 0 прочитал обработчик, 1 удерживает пересылка
+	eventually(t, func() bool { return sent.Load() == buffer+2 && early.ExitReason() == node.ExitEarly })
+	if s := p.Stranded(); s != nil {
+		t.Errorf("Stranded before the end = %v, want nil", s)
+	}
+	p.Stop()
+	if errs := errs.wait(t); len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+
+	const edge = "source[0] -> early[0]"
+	want := map[string]int{edge: buffer, "early input": 1}
+	if got := p.Stranded(); !maps.Equal(got, want) {
+		t.Errorf("Stranded = %v, want %v", got, want)
+	}
+	// извлекается не больше limit элементов ребра, по порядку
+	if got := StrandedOf[int](p, edge); !slices.Equal(got, []int{2, 3, 4}) {
+		t.Errorf("stranded items of %s = %v, want [2 3 4]", edge, got)
+	}
+	if got := StrandedOf[int](p, "early input"); !slices.Equal(got, []int{1}) {
+		t.Errorf("item held by the input relay = %v, want [1]", got)
+	}
+	if got := StrandedOf[string](p, edge); got != nil {
+		t.Errorf("items of another type = %v, want nil", got)
+	}
+	if items := p.StrandedItems(); len(items) != 2 {
+		t.Errorf("StrandedItems = %v, want two edges", items)
+	}
+}
@@ -0,0 +1,265 @@
+package pipeline
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+var ErrItemErrorBudget = errors.New("item error budget exceeded")
+
+// ErrorSummary количество ошибок нод пайплайна по классам (см. node.ErrorClass)
+type ErrorSummary struct {
+	// RunID идентификатор запуска, к которому относится сводка (см. Pipeline.RunID)
+	RunID string
+	Item  int
+	Node  int
+	Infra int
+	// FirstNode, FirstInfra первые ошибки классов ClassNode и ClassInfra
+	FirstNode  error
+	FirstInfra error
+	// FlushAbandoned количество нод, не успевших выполнить финальный сброс (node.WithFlush)
+	FlushAbandoned int
+	// Skipped ноды, не запущенные из-за неудачи зависимости (After) или отключённой ветки
+	// (Disable), и причины пропуска
+	Skipped map[string]error
+	// Terminated причина принудительного завершения пайплайна (например, ErrMaxRuntime)
+	Terminated error
+	// Busy время в функциях нод с node.WithCPUAccounting по убыванию
+	Busy []NodeBusy
+	// Exits причины завершения нод (см. ExitReport)
+	Exits map[string]node.ExitReason
+	// Quotas расход бюджетов нод node.Quota по именам бюджетов
+	Quotas map[string]node.QuotaUsage
+	// Bytes объём прочитанных и отправленных элементов нод с node.WithSizeFunc
+	Bytes map[string]NodeBytes
+	// Dumps итоги дампов рёбер (DumpEdge) по рёбрам ("<нода>[<выход>]")
+	Dumps map[string]DumpUsage
+	// ItemsIn число прочитанных элементов нод со статистикой и входами, включая нули: пустой вход
+	// отличим от отсутствия статистики
+	ItemsIn map[string]uint64
+	// Buffers рекомендации размеров буферов выходов нод с node.WithBufferAdvice (см. BufferAdvice)
+	Buffers []node.BufferAdvice
+}
+
+// NodeBytes объём данных ноды по node.WithSizeFunc (см. node.Stats.BytesIn, node.Stats.BytesOut)
+type NodeBytes struct {
+	In  uint64
+	Out uint64
+}
+
+// NodeBusy время, проведённое в функции ноды (см. node.WithCPUAccounting)
+type NodeBusy struct {
+	Node string
+	Busy time.Duration
+	// Share доля от суммарного времени всех нод с учётом
+	Share float64
+}
+
+// Err возвращает nil, если нет ошибок классов ClassNode и ClassInfra и пайплайн не был завершён
+// принудительно, иначе ошибку со сводкой, оборачивающую Terminated, первую ошибку ClassInfra или
+// ClassNode (в этом порядке)
+func (s ErrorSummary) Err() error {
+	if s.Terminated != nil {
+		return fmt.Errorf("pipeline terminated (%d infra, %d node, %d item errors): %w", s.Infra, s.Node, s.Item,
+			s.Terminated)
+	}
+
+	first := s.FirstInfra
+	if first == nil {
+		first = s.FirstNode
+	}
+	if first == nil {
+		return nil
+	}
+	return fmt.Errorf("pipeline failed (%d infra, %d node, %d item errors): %w", s.Infra, s.Node, s.Item, first)
+}
+
+// WithItemErrorBudget допускает не более n ошибок класса ClassItem: следующая ошибка этого класса
+// дополнительно порождает ошибку ErrItemErrorBudget класса ClassNode, и WaitErr возвращает ошибку
+func WithItemErrorBudget(n int) Option {
+	return func(o *options) {
+		o.itemErrorBudget = max(n, 0)
+		o.hasItemErrorBudget = true
+	}
+}
+
+// Summary возвращает количество ошибок нод по классам, отправленных с момента запуска, время
+// в функциях нод с node.WithCPUAccounting, причины завершения нод, расход их бюджетов (node.Quota) и
+// объём данных нод с node.WithSizeFunc, итоги дампов рёбер (DumpEdge), число прочитанных элементов
+// нод со статистикой и рекомендации размеров буферов (node.WithBufferAdvice)
+func (p *Pipeline) Summary() ErrorSummary {
+	p.summary.mu.Lock()
+	defer p.summary.mu.Unlock()
+	s := p.summary.ErrorSummary
+	s.Skipped = maps.Clone(s.Skipped)
+	snap := p.StatsSnapshot()
+	s.Busy = busyOf(snap)
+	s.Exits = p.ExitReport()
+	s.Quotas = p.quotas()
+	s.Bytes = bytesOf(snap)
+	s.Dumps = p.dumpUsage()
+	s.ItemsIn = p.itemsIn(snap)
+	s.Buffers = p.BufferAdvice()
+	return s
+}
+
+// itemsIn собирает из снимка Stats.ItemsIn нод со статистикой и хотя бы одним входом
+func (p *Pipeline) itemsIn(snap Snapshot) map[string]uint64 {
+	var items map[string]uint64
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			sn, ok := n.(interface {
+				Name() string
+				HasStats() bool
+				InputCount() int
+			})
+			if !ok || !sn.HasStats() || sn.InputCount() == 0 {
+				continue
+			}
+			stats, ok := snap.Stats(sn.Name())
+			if !ok {
+				continue
+			}
+			if items == nil {
+				items = make(map[string]uint64)
+			}
+			items[sn.Name()] = stats.ItemsIn
+		}
+	}
+	return items
+}
+
+// bytesOf собирает из снимка объём данных нод с ненулевыми Stats.BytesIn или Stats.BytesOut
+func bytesOf(snap Snapshot) map[string]NodeBytes {
+	var bytes map[string]NodeBytes
+	for _, ns := range snap.Nodes {
+		if ns.BytesIn == 0 && ns.BytesOut == 0 {
+			continue
+		}
+		if bytes == nil {
+			bytes = make(map[string]NodeBytes)
+		}
+		bytes[ns.Node] = NodeBytes{In: ns.BytesIn, Out: ns.BytesOut}
+	}
+	return bytes
+}
+
+// quotas собирает расход бюджетов нод node.Quota; реплики одного бюджета дают одну запись
+func (p *Pipeline) quotas() map[string]node.QuotaUsage {
+	var quotas map[string]node.QuotaUsage
+	for _, name := range p.groupOrder {
+		for _, n := range p.groups[name].nodes {
+			q, ok := n.(interface{ QuotaUsage() node.QuotaUsage })
+			if !ok {
+				continue
+			}
+			if quotas == nil {
+				quotas = make(map[string]node.QuotaUsage)
+			}
+			u := q.QuotaUsage()
+			quotas[u.Quota] = u
+		}
+	}
+	return quotas
+}
+
+// busyOf собирает из снимка время в функциях нод с ненулевым Stats.Busy
+func busyOf(snap Snapshot) []NodeBusy {
+	var busy []NodeBusy
+	var total time.Duration
+	for _, ns := range snap.Nodes {
+		if ns.Busy > 0 {
+			busy = append(busy, NodeBusy{Node: ns.Node, Busy: ns.Busy})
+			total += ns.Busy
+		}
+	}
+
+	for i := range busy {
+		busy[i].Share = float64(busy[i].Busy) / float64(total)
+	}
+	slices.SortStableFunc(busy, func(a, b NodeBusy) int {
+		return cmp.Compare(b.Busy, a.Busy)
+	})
+	return busy
+}
+
+// WaitErr ожидает завершения пайплайна (см. Wait) и возвращает Summary().Err(). Ошибки класса
+// ClassItem не приводят к ошибке, пока не превышен WithItemErrorBudget.
+func (p *Pipeline) WaitErr() error {
+	p.Wait()
+	return p.Summary().Err()
+}
+
+// errorSummary потокобезопасная сводка ошибок, пополняемая пересылкой ошибок групп
+type errorSummary struct {
+	mu sync.Mutex
+	ErrorSummary
+}
+
+// begin начинает сводку запуска runID
+func (s *errorSummary) begin(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ErrorSummary = ErrorSummary{RunID: runID}
+}
+
+// skip записывает пропуск ноды
+func (s *errorSummary) skip(name string, reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Skipped == nil {
+		s.Skipped = make(map[string]error)
+	}
+	s.Skipped[name] = reason
+}
+
+// terminate записывает причину принудительного завершения, если она ещё не задана
+func (s *errorSummary) terminate(reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Terminated == nil {
+		s.Terminated = reason
+	}
+}
+
+// record учитывает ошибку и возвращает ошибку превышения бюджета, если она возникла
+func (s *errorSummary) record(err error, opts options) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if errors.Is(err, node.ErrFlushAbandoned) {
+		s.FlushAbandoned++
+	}
+
+	switch node.ClassOf(err) {
+	case node.ClassInfra:
+		s.Infra++
+		if s.FirstInfra == nil {
+			s.FirstInfra = err
+		}
+	case node.ClassNode:
+		s.Node++
+		if s.FirstNode == nil {
+			s.FirstNode = err
+		}
+	default:
+		s.Item++
+		if opts.hasItemErrorBudget && s.Item == opts.itemErrorBudget+1 {
+			budgetErr := node.Classify(node.ClassNode,
+				fmt.Errorf("%w: more than %d item errors", ErrItemErrorBudget, opts.itemErrorBudget))
+			s.Node++
+			if s.FirstNode == nil {
+				s.FirstNode = budgetErr
+			}
+			return budgetErr
+		}
+	}
+	return nil
+}
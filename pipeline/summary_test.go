@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestWaitErrItemErrorBudget(t *testing.T) {
+	errBad := errors.New("bad item")
+	errNode := node.Classify(node.ClassNode, errors.New("node failed"))
+	errInfra := node.Classify(node.ClassInfra, errors.New("disk full"))
+	items := func(n int) []error {
+		errs := make([]error, n)
+		for i := range errs {
+			errs[i] = fmt.Errorf("item %d: %w", i, errBad)
+		}
+		return errs
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		sent []error
+		// wantErr ошибка, которую оборачивает WaitErr, nil — WaitErr без ошибки
+		wantErr error
+		// wantBudget в канал ошибок дополнительно приходит ошибка превышения бюджета
+		wantBudget bool
+		wantItem   int
+		wantNode   int
+	}{
+		// без бюджета ошибки элементов не делают запуск неудачным
+		{"no budget", nil, items(5), nil, false, 5, 0},
+		{"within budget", []Option{WithItemErrorBudget(3)}, items(3), nil, false, 3, 0},
+		{"budget exceeded", []Option{WithItemErrorBudget(3)}, items(5), ErrItemErrorBudget, true, 5, 1},
+		{"zero budget", []Option{WithItemErrorBudget(0)}, items(1), ErrItemErrorBudget, true, 1, 1},
+		{"node error", nil, []error{errNode}, errNode, false, 0, 1},
+		// первой оборачивается ошибка инфраструктуры, даже отправленная позже
+		{"infra over node", nil, []error{errNode, errInfra}, errInfra, false, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(tt.opts...)
+			mustAdd(t, p, node.New("failing", 0, 0, nil, func(_ context.Context, _ <-chan struct{}, _ chan<- struct{}, errChan chan<- error) {
+				for _, err := range tt.sent {
+					errChan <- err
+				}
+			}))
+
+			errs := collectErrors(p.ErrChan())
+			if err := p.Run(context.Background(), true); err != nil {
+				t.Fatal(err)
+			}
+			err := p.WaitErr()
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("WaitErr = %v, want %v", err, tt.wantErr)
+			}
+
+			got := errs.wait(t)
+			budget := 0
+			for _, err := range got {
+				if errors.Is(err, ErrItemErrorBudget) {
+					budget++
+				}
+			}
+			if want := len(tt.sent) + budget; len(got) != want || (budget == 1) != tt.wantBudget {
+				t.Errorf("ErrChan got %v, want the sent errors and budget error = %v", got, tt.wantBudget)
+			}
+			if s := p.Summary(); s.Item != tt.wantItem || s.Node != tt.wantNode {
+				t.Errorf("summary item %d, node %d; want %d, %d", s.Item, s.Node, tt.wantItem, tt.wantNode)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+func TestRunNoNodes(t *testing.T) {
+	p := New()
+	if err := p.Run(context.Background(), true); !errors.Is(err, ErrNoNodes) {
+		t.Fatalf("Run on empty pipeline = %v, want %v", err, ErrNoNodes)
+	}
+	// после неудачного Run пайплайн остаётся пригодным: добавленная нода запускается
+	ran := false
+	mustAdd(t, p, node.Task("task", func(context.Context) error {
+		ran = true
+		return nil
+	}))
+	if errs := runAndWait(t, p); len(errs) > 0 {
+		t.Errorf("errors: %v", errs)
+	}
+	if !ran {
+		t.Error("task did not run")
+	}
+}
+
+func TestTaskPipeline(t *testing.T) {
+	errSetup := errors.New("setup failed")
+	p := New()
+	mustAdd(t, p, node.Task("setup", func(context.Context) error { return errSetup }))
+
+	errs := runAndWait(t, p)
+	if len(errs) != 1 || !errors.Is(errs[0], errSetup) {
+		t.Errorf("errors = %v, want %v", errs, errSetup)
+	}
+}
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// MaxHops наибольшее количество отметок Traced.Hops: при переполнении отбрасываются самые старые
+// (время входа сохраняется в EnteredAt)
+const MaxHops = 16
+
+// Hop отметка прохождения элемента через ноду
+type Hop struct {
+	Node string
+	At   time.Time
+}
+
+// Traced конверт элемента со сведениями о происхождении: время входа в пайплайн и ноды, через
+// которые прошёл элемент. Используется по желанию: ноды без конверта не несут накладных расходов.
+type Traced[T any] struct {
+	Val       T
+	EnteredAt time.Time
+	Hops      []Hop
+}
+
+// Trace помещает v в конверт с временем входа и первой отметкой ноды name
+func Trace[T any](name string, v T) Traced[T] {
+	now := time.Now()
+	hops := make([]Hop, 1, MaxHops)
+	hops[0] = Hop{Node: name, At: now}
+	return Traced[T]{Val: v, EnteredAt: now, Hops: hops}
+}
+
+// Latency возвращает время от входа в пайплайн до последней отметки
+func (t Traced[T]) Latency() time.Duration {
+	if len(t.Hops) == 0 {
+		return 0
+	}
+	return t.Hops[len(t.Hops)-1].At.Sub(t.EnteredAt)
+}
+
+// withHop возвращает Hops с новой отметкой ноды name
+func withHop(hops []Hop, name string) []Hop {
+	hop := Hop{Node: name, At: time.Now()}
+	if len(hops) < MaxHops {
+		return append(hops, hop)
+	}
+	copy(hops, hops[1:])
+	hops[len(hops)-1] = hop
+	return hops
+}
+
+// TraceSource оборачивает функцию источника так, что каждое выданное значение помещается в
+// конверт Trace с отметкой ноды name
+func TraceSource[T any](name string, fn node.SourceFn[T]) node.SourceFn[Traced[T]] {
+	return func(ctx context.Context, output chan<- Traced[T], errChan chan<- error) {
+		values := make(chan T)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for v := range values {
+				select {
+				case output <- Trace(name, v):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		fn(ctx, values, errChan)
+		close(values)
+		<-done
+	}
+}
+
+// TraceMap оборачивает функцию преобразования так, что она применяется к значению конверта, а
+// к результату добавляется отметка ноды name
+func TraceMap[I, O any](name string, f node.MapFn[I, O]) node.MapFn[Traced[I], Traced[O]] {
+	return func(ctx context.Context, in Traced[I]) (Traced[O], error) {
+		out, err := f(ctx, in.Val)
+		return Traced[O]{Val: out, EnteredAt: in.EnteredAt, Hops: withHop(in.Hops, name)}, err
+	}
+}
+
+// TracedMap создаёт ноду node.NewMap, применяющую f к значениям конвертов (см. TraceMap)
+func TracedMap[I, O any](name string, inputNum int, outputNum int, outputBuffSize []int, f node.MapFn[I, O],
+	opts ...node.Option) *node.Node[Traced[I], Traced[O]] {
+	return node.NewMap(name, TraceMap(name, f), mapPorts(inputNum, outputNum, outputBuffSize, opts)...)
+}
+
+// Untrace создаёт ноду, извлекающую значения из конвертов. Если задан latency, он вызывается для
+// каждого элемента с конвертом (с отметкой этой ноды) и временем от входа в пайплайн, например,
+// для записи задержки в метрики; latency не должен блокироваться.
+func Untrace[T any](name string, inputNum int, outputBuffSize []int, latency func(t Traced[T], d time.Duration),
+	opts ...node.Option) *node.Node[Traced[T], T] {
+	return node.NewMap(name, func(ctx context.Context, in Traced[T]) (T, error) {
+		if latency != nil {
+			in.Hops = withHop(in.Hops, name)
+			latency(in, in.Latency())
+		}
+		return in.Val, nil
+	}, mapPorts(inputNum, 1, outputBuffSize, opts)...)
+}
+
+// mapPorts дополняет опции ноды node.NewMap портами и буферами выходов, заданными параметрами.
+// Буферы из opts используются, если outputBuffSize nil.
+func mapPorts(inputNum int, outputNum int, outputBuffSize []int, opts []node.Option) []node.Option {
+	opts = append(slices.Clip(opts), node.WithPorts(inputNum, outputNum))
+	if outputBuffSize != nil {
+		opts = append(opts, node.WithOutputBuffers(outputBuffSize...))
+	}
+	return opts
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tom-lepsky/pipeline/pipeline/node"
+)
+
+// hopNodes возвращает имена нод отметок
+func hopNodes(hops []Hop) []string {
+	names := make([]string, len(hops))
+	for i, h := range hops {
+		names[i] = h.Node
+	}
+	return names
+}
+
+func TestTraced(t *testing.T) {
+	src := node.NewSource("src", 1, nil, TraceSource("src", SeqSource(slices.Values(ints(3)))))
+	double := TracedMap("double", 1, 1, nil, func(_ context.Context, v int) (int, error) { return v * 2, nil })
+	var traces []Traced[int]
+	var latencies []time.Duration
+	untrace := Untrace("untrace", 1, nil, func(t Traced[int], d time.Duration) {
+		traces = append(traces, t)
+		latencies = append(latencies, d)
+	})
+	sink, got := sliceSink[int]("sink")
+	mustConnect(t, src, double)
+	mustConnect(t, double, untrace)
+	mustConnect(t, untrace, sink)
+	p := New()
+	mustAdd(t, p, src, double, untrace, sink)
+
+	if errs := runAndWait(t, p); len(errs) > 0 {
+		t.Errorf("errors: %v", errs)
+	}
+	// конверт снимается, значения проходят преобразование
+	if want := []int{0, 2, 4}; !slices.Equal(*got, want) {
+		t.Errorf("sink got %v, want %v", *got, want)
+	}
+	if len(traces) != 3 {
+		t.Fatalf("latency called %d times, want 3", len(traces))
+	}
+	for i, tr := range traces {
+		if want := []string{"src", "double", "untrace"}; !slices.Equal(hopNodes(tr.Hops), want) {
+			t.Errorf("item %d hops %v, want %v", i, hopNodes(tr.Hops), want)
+		}
+		if !tr.Hops[0].At.Equal(tr.EnteredAt) {
+			t.Errorf("item %d entered at %v, first hop at %v", i, tr.EnteredAt, tr.Hops[0].At)
+		}
+		for j := 1; j < len(tr.Hops); j++ {
+			if tr.Hops[j].At.Before(tr.Hops[j-1].At) {
+				t.Errorf("item %d hops out of order: %v", i, tr.Hops)
+			}
+		}
+		if want := tr.Hops[len(tr.Hops)-1].At.Sub(tr.EnteredAt); latencies[i] != want || latencies[i] < 0 {
+			t.Errorf("item %d latency %v, want %v", i, latencies[i], want)
+		}
+	}
+}
+
+func TestTracedMaxHops(t *testing.T) {
+	id := func(_ context.Context, v int) (int, error) { return v, nil }
+	tr := Trace("src", 1)
+	for i := range MaxHops + 4 {
+		var err error
+		if tr, err = TraceMap(fmt.Sprintf("n%d", i), id)(context.Background(), tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// при переполнении отбрасываются самые старые отметки, время входа сохраняется
+	if len(tr.Hops) != MaxHops {
+		t.Fatalf("%d hops, want %d", len(tr.Hops), MaxHops)
+	}
+	if first, last := tr.Hops[0].Node, tr.Hops[MaxHops-1].Node; first != "n4" || last != fmt.Sprintf("n%d", MaxHops+3) {
+		t.Errorf("hops %v, want n4..n%d", hopNodes(tr.Hops), MaxHops+3)
+	}
+	if tr.EnteredAt.After(tr.Hops[0].At) {
+		t.Errorf("entered at %v after the oldest kept hop %v", tr.EnteredAt, tr.Hops[0].At)
+	}
+}
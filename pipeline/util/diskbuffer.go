@@ -0,0 +1,357 @@
+package util
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrCorruptSegment = errors.New("corrupt disk buffer segment")
+
+// Codec кодирование значений типа T в байты и обратно
+type Codec[T any] struct {
+	Encode func(v T) ([]byte, error)
+	Decode func(data []byte) (T, error)
+}
+
+const (
+	// segmentSize размер сегмента DiskBuffer, после которого запись переходит в новый файл
+	segmentSize = 4 << 20
+	// segmentExt расширение файлов сегментов
+	segmentExt = ".seg"
+	// frameHeader заголовок записи сегмента: длина данных и их CRC32
+	frameHeader = 8
+)
+
+// DiskBuffer буферизует in без блокировки отправителя при остановке получателя: до memLimit
+// значений (минимум 1) хранятся в памяти, следующие кодируются codec и дописываются в файлы
+// сегментов в dir, откуда читаются обратно по мере освобождения памяти. Порядок значений
+// сохраняется, прочитанные сегменты удаляются. Когда сегменты занимают diskLimit байт и больше
+// (diskLimit <= 0 — без ограничения), чтение in приостанавливается. Выходной канал закрывается
+// после закрытия in и выдачи всех значений (оставшиеся файлы сегментов при этом удаляются) или
+// при отмене ctx: тогда значения из памяти теряются, а сегменты остаются в dir и будут выданы
+// первыми при следующем запуске с тем же dir (уже выданные значения частично прочитанного
+// сегмента — повторно). Возвращает ошибку, если dir недоступна или оставшийся от прошлого запуска
+// сегмент повреждён (ErrCorruptSegment): такой сегмент нужно удалить или перенести вручную.
+// Ошибки кодирования и ввода-вывода во время работы отбрасываются; чтобы получать их,
+// используйте DiskBufferOnError.
+func DiskBuffer[T any](ctx context.Context, in <-chan T, dir string, codec Codec[T], memLimit int,
+	diskLimit int64) (<-chan T, error) {
+	return DiskBufferOnError(ctx, in, dir, codec, memLimit, diskLimit, nil)
+}
+
+// DiskBufferOnError буферизует in так же, как DiskBuffer, и сообщает onError (может быть nil) об
+// ошибках во время работы. Значение, которое не удалось закодировать или декодировать, пропускается.
+// После ошибки записи сегмента буфер больше не пишет на диск и ограничивается памятью; при
+// повреждении сегмента его непрочитанный остаток пропускается.
+func DiskBufferOnError[T any](ctx context.Context, in <-chan T, dir string, codec Codec[T], memLimit int,
+	diskLimit int64, onError func(error)) (<-chan T, error) {
+	if codec.Encode == nil || codec.Decode == nil {
+		panic("nil codec func")
+	}
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	disk, err := openDiskQueue(dir, codec, diskLimit)
+	if err != nil {
+		return nil, err
+	}
+	memLimit = max(memLimit, 1)
+
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		var mem []T
+		for {
+			for len(mem) < memLimit && disk.items > 0 {
+				v, err := disk.pop()
+				if err != nil {
+					onError(err)
+					continue
+				}
+				mem = append(mem, v)
+			}
+			if in == nil && len(mem) == 0 && disk.items == 0 {
+				disk.remove()
+				return
+			}
+
+			accept := in
+			if disk.items > 0 || len(mem) >= memLimit {
+				if disk.failed || disk.full() {
+					accept = nil
+				}
+			}
+			var send chan<- T
+			var head T
+			if len(mem) > 0 {
+				send, head = out, mem[0]
+			}
+
+			select {
+			case v, ok := <-accept:
+				if !ok {
+					in = nil
+					continue
+				}
+				if disk.items == 0 && len(mem) < memLimit {
+					mem = append(mem, v)
+				} else if err := disk.push(v); err != nil {
+					onError(err)
+				}
+			case send <- head:
+				mem = mem[1:]
+			case <-ctx.Done():
+				disk.close()
+				return
+			}
+		}
+	})
+
+	return out, nil
+}
+
+// segment файл сегмента: путь, размер в байтах и число значений
+type segment struct {
+	path  string
+	size  int64
+	items int
+}
+
+// diskQueue очередь значений в файлах сегментов. Читаются только закрытые сегменты: если читать
+// нечего, текущий записываемый сегмент закрывается.
+type diskQueue[T any] struct {
+	dir   string
+	codec Codec[T]
+	limit int64
+	// segs закрытые сегменты от старых к новым, segs[0] читается через r
+	segs []segment
+	r    *bufio.Reader
+	rf   *os.File
+	read int
+	roff int64
+	// w текущий записываемый сегмент
+	w    *bufio.Writer
+	wf   *os.File
+	wseg segment
+	// items значений на диске, used байт в сегментах, next номер следующего сегмента
+	items  int
+	used   int64
+	next   int
+	failed bool
+}
+
+// openDiskQueue создаёт dir и подхватывает сегменты, оставшиеся от прошлого запуска, проверяя
+// их целостность
+func openDiskQueue[T any](dir string, codec Codec[T], limit int64) (*diskQueue[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &diskQueue[T]{dir: dir, codec: codec, limit: limit}
+	for _, e := range entries {
+		name := e.Name()
+		num, err := strconv.Atoi(strings.TrimSuffix(name, segmentExt))
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) || err != nil {
+			continue
+		}
+		seg, err := scanSegment(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		q.segs = append(q.segs, seg)
+		q.items += seg.items
+		q.used += seg.size
+		q.next = max(q.next, num+1)
+	}
+	slices.SortFunc(q.segs, func(a, b segment) int { return strings.Compare(a.path, b.path) })
+	return q, nil
+}
+
+// scanSegment проверяет все записи сегмента и считает их
+func scanSegment(path string) (segment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return segment{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return segment{}, err
+	}
+
+	seg := segment{path: path, size: info.Size()}
+	r := bufio.NewReader(f)
+	for offset := int64(0); offset < seg.size; seg.items++ {
+		data, err := readFrame(r, seg.size-offset)
+		if err != nil {
+			return segment{}, fmt.Errorf("%w: %s: record %d: %w", ErrCorruptSegment, path, seg.items, err)
+		}
+		offset += frameHeader + int64(len(data))
+	}
+	return seg, nil
+}
+
+// readFrame читает запись сегмента, в котором осталось remaining байт
+func readFrame(r io.Reader, remaining int64) ([]byte, error) {
+	var header [frameHeader]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	if size > remaining-frameHeader {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errors.New("checksum mismatch")
+	}
+	return data, nil
+}
+
+// full сообщает, что сегменты заняли лимит диска
+func (q *diskQueue[T]) full() bool {
+	return q.limit > 0 && q.used >= q.limit
+}
+
+// push дописывает значение в текущий сегмент
+func (q *diskQueue[T]) push(v T) error {
+	data, err := q.codec.Encode(v)
+	if err != nil {
+		return err
+	}
+	if q.w == nil {
+		path := filepath.Join(q.dir, fmt.Sprintf("%020d%s", q.next, segmentExt))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			q.failed = true
+			return err
+		}
+		q.next++
+		q.wf, q.w, q.wseg = f, bufio.NewWriter(f), segment{path: path}
+	}
+
+	var header [frameHeader]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(data))
+	if _, err := q.w.Write(header[:]); err != nil {
+		q.failed = true
+		return err
+	}
+	if _, err := q.w.Write(data); err != nil {
+		q.failed = true
+		return err
+	}
+	n := int64(frameHeader + len(data))
+	q.wseg.size += n
+	q.wseg.items++
+	q.used += n
+	q.items++
+	if q.wseg.size >= segmentSize {
+		return q.rotate()
+	}
+	return nil
+}
+
+// rotate закрывает текущий записываемый сегмент и ставит его в очередь на чтение
+func (q *diskQueue[T]) rotate() error {
+	if q.w == nil {
+		return nil
+	}
+	err := q.w.Flush()
+	if cerr := q.wf.Close(); err == nil {
+		err = cerr
+	}
+	q.segs = append(q.segs, q.wseg)
+	q.w, q.wf = nil, nil
+	if err != nil {
+		q.failed = true
+	}
+	return err
+}
+
+// pop читает старейшее значение. При ошибке декодирования значение пропускается, при
+// повреждении сегмента — его непрочитанный остаток.
+func (q *diskQueue[T]) pop() (T, error) {
+	var zero T
+	for q.r == nil && len(q.segs) > 0 && q.segs[0].items == 0 {
+		q.drop()
+	}
+	if q.r == nil {
+		if len(q.segs) == 0 {
+			if err := q.rotate(); err != nil {
+				q.items -= q.segs[0].items
+				q.drop()
+				return zero, err
+			}
+		}
+		f, err := os.Open(q.segs[0].path)
+		if err != nil {
+			q.items -= q.segs[0].items
+			q.drop()
+			return zero, err
+		}
+		q.rf, q.r, q.read, q.roff = f, bufio.NewReader(f), 0, 0
+	}
+
+	seg := q.segs[0]
+	data, err := readFrame(q.r, seg.size-q.roff)
+	if err != nil {
+		q.items -= seg.items - q.read
+		q.drop()
+		return zero, fmt.Errorf("%w: %s: record %d: %w", ErrCorruptSegment, seg.path, q.read, err)
+	}
+	q.read++
+	q.roff += frameHeader + int64(len(data))
+	q.items--
+	if q.read == seg.items {
+		q.drop()
+	}
+	return q.codec.Decode(data)
+}
+
+// drop закрывает и удаляет старейший сегмент
+func (q *diskQueue[T]) drop() {
+	if q.rf != nil {
+		_ = q.rf.Close()
+	}
+	_ = os.Remove(q.segs[0].path)
+	q.used -= q.segs[0].size
+	q.segs = q.segs[1:]
+	q.r, q.rf = nil, nil
+}
+
+// close сохраняет записанное и закрывает файлы, оставляя сегменты в dir
+func (q *diskQueue[T]) close() {
+	_ = q.rotate()
+	if q.rf != nil {
+		_ = q.rf.Close()
+	}
+}
+
+// remove закрывает файлы и удаляет все сегменты
+func (q *diskQueue[T]) remove() {
+	q.close()
+	for _, seg := range q.segs {
+		_ = os.Remove(seg.path)
+	}
+	q.segs = nil
+}
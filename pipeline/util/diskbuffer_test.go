@@ -0,0 +1,163 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var errEncode = errors.New("encode failed")
+
+// intCodec кодек чисел; отрицательные значения не кодируются
+var intCodec = Codec[int]{
+	Encode: func(v int) ([]byte, error) {
+		if v < 0 {
+			return nil, errEncode
+		}
+		return []byte(strconv.Itoa(v)), nil
+	},
+	Decode: func(data []byte) (int, error) { return strconv.Atoi(string(data)) },
+}
+
+// segments возвращает файлы сегментов в dir
+func segments(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// collect читает out до закрытия
+func collect(t *testing.T, out <-chan int) []int {
+	t.Helper()
+	var got []int
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case v, ok := <-out:
+			if !ok {
+				return got
+			}
+			got = append(got, v)
+		case <-timeout:
+			t.Fatalf("output not closed, got %v", got)
+		}
+	}
+}
+
+func TestDiskBufferSpillAndReload(t *testing.T) {
+	dir := t.TempDir()
+	in := make(chan int)
+	out, err := DiskBuffer(context.Background(), in, dir, intCodec, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// получатель стоит: отправитель не блокируется, значения сверх memLimit уходят на диск
+	for _, v := range ints(100) {
+		in <- v
+	}
+	if len(segments(t, dir)) == 0 {
+		t.Fatal("no segments written while the receiver is stopped")
+	}
+	close(in)
+	if got := collect(t, out); !slices.Equal(got, ints(100)) {
+		t.Errorf("output %v, want 0..99 in order", got)
+	}
+	// после выдачи всех значений сегменты удаляются
+	if files := segments(t, dir); len(files) > 0 {
+		t.Errorf("segments left after the output closed: %v", files)
+	}
+}
+
+func TestDiskBufferResume(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out, err := DiskBuffer(ctx, in, dir, intCodec, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range ints(50) {
+		in <- v
+	}
+	// отмена: значения из памяти теряются, сегменты остаются
+	cancel()
+	collect(t, out)
+	if len(segments(t, dir)) == 0 {
+		t.Fatal("segments removed on cancel")
+	}
+
+	// следующий запуск с тем же dir выдаёт сохранённые значения первыми
+	in = make(chan int, 1)
+	in <- 100
+	close(in)
+	out, err = DiskBuffer(context.Background(), in, dir, intCodec, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(ints(50)[5:], 100)
+	if got := collect(t, out); !slices.Equal(got, want) {
+		t.Errorf("resumed output %v, want %v", got, want)
+	}
+}
+
+func TestDiskBufferLimit(t *testing.T) {
+	in := make(chan int)
+	out, err := DiskBuffer(context.Background(), in, t.TempDir(), intCodec, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// одно значение в памяти и одно на диске занимают лимит: чтение входа приостанавливается
+	in <- 0
+	in <- 1
+	select {
+	case in <- 2:
+		t.Fatal("input accepted past the disk limit")
+	case <-time.After(20 * time.Millisecond):
+	}
+	go func() {
+		in <- 2
+		close(in)
+	}()
+	if got := collect(t, out); !slices.Equal(got, ints(3)) {
+		t.Errorf("output %v, want [0 1 2]", got)
+	}
+}
+
+func TestDiskBufferOnError(t *testing.T) {
+	in := make(chan int)
+	var errs []error
+	out, err := DiskBufferOnError(context.Background(), in, t.TempDir(), intCodec, 1, 0,
+		func(err error) { errs = append(errs, err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// значение, которое не удалось закодировать, пропускается с сообщением об ошибке
+	for _, v := range []int{0, 1, -1, 2} {
+		in <- v
+	}
+	close(in)
+	if got := collect(t, out); !slices.Equal(got, ints(3)) {
+		t.Errorf("output %v, want [0 1 2]", got)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], errEncode) {
+		t.Errorf("errors %v, want %v", errs, errEncode)
+	}
+}
+
+func TestDiskBufferCorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000"+segmentExt), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DiskBuffer(context.Background(), make(chan int), dir, intCodec, 1, 0); !errors.Is(err, ErrCorruptSegment) {
+		t.Errorf("DiskBuffer = %v, want %v", err, ErrCorruptSegment)
+	}
+}
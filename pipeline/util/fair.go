@@ -0,0 +1,115 @@
+package util
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// FairFanIn объединяет помеченные входы в один канал по алгоритму дефицитного кругового обхода
+// (deficit round robin): за каждый круг вход с меткой l может выдать до weights[l] значений, так
+// что при постоянной нагрузке на все входы их доли в выходе пропорциональны весам, независимо от
+// того, насколько неравномерно значения поступают на входы. Вход без готового значения пропускается
+// и не копит неиспользованную долю: свободную пропускную способность получают остальные входы.
+// Метки без веса получают вес 1. Значения одного входа выдаются в порядке поступления, входы
+// читаются только по мере отправки в выходной канал. Выходной канал не буферизован, закрывается
+// после закрытия всех входов (nil-входы пропускаются) или отмены контекста. Если входов нет,
+// возвращает nil. Паникует при неположительном весе.
+func FairFanIn[T any](ctx context.Context, weights map[string]int, inputs map[string]<-chan T) <-chan T {
+	if len(inputs) == 0 {
+		return nil
+	}
+	for label, w := range weights {
+		if w <= 0 {
+			panic("non-positive weight: " + label)
+		}
+	}
+
+	var chans []<-chan T
+	var quantum []int
+	for _, label := range slices.Sorted(maps.Keys(inputs)) {
+		if inputs[label] == nil {
+			continue
+		}
+		w, ok := weights[label]
+		if !ok {
+			w = 1
+		}
+		chans = append(chans, inputs[label])
+		quantum = append(quantum, w)
+	}
+
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		send := func(val T) bool {
+			select {
+			case out <- val:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for len(chans) > 0 {
+			sent := false
+			for i := 0; i < len(chans); i++ {
+				for deficit := quantum[i]; deficit > 0; deficit-- {
+					select {
+					case val, ok := <-chans[i]:
+						if !ok {
+							chans, quantum = slices.Delete(chans, i, i+1), slices.Delete(quantum, i, i+1)
+							i--
+							deficit = 0
+							continue
+						}
+						if !send(val) {
+							return
+						}
+						sent = true
+					default:
+						// вход простаивает: неиспользованная доля не копится
+						deficit = 0
+					}
+				}
+			}
+			if sent || len(chans) == 0 {
+				continue
+			}
+
+			// все входы простаивают: ждём значение любого из них
+			i, val, ok := waitAny(ctx, chans)
+			switch {
+			case i == -1:
+				return
+			case !ok:
+				chans, quantum = slices.Delete(chans, i, i+1), slices.Delete(quantum, i, i+1)
+			case !send(val):
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// waitAny ждёт значение или закрытие любого из chans и возвращает его индекс; -1 при отмене ctx
+func waitAny[T any](ctx context.Context, chans []<-chan T) (int, T, bool) {
+	cases := make([]reflect.SelectCase, 0, len(chans)+1)
+	for _, ch := range chans {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)})
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+
+	var zero T
+	i, v, ok := reflect.Select(cases)
+	if i == len(chans) {
+		return -1, zero, false
+	}
+	if !ok {
+		return i, zero, false
+	}
+	return i, v.Interface().(T), true
+}
@@ -0,0 +1,103 @@
+package util
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// labelled возвращает заполненный буферизованный вход: значения label*1000 + 0..n-1
+func labelled(label, n int) chan int {
+	ch := make(chan int, n)
+	for i := range n {
+		ch <- label*1000 + i
+	}
+	return ch
+}
+
+// labels читает n значений из out и возвращает метки их входов ("a" — 0, "b" — 1, ...)
+func labels(t *testing.T, out <-chan int, n int) string {
+	t.Helper()
+	var b strings.Builder
+	for range n {
+		select {
+		case v := <-out:
+			b.WriteByte(byte('a' + v/1000))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("output %q, want %d values", b.String(), n)
+		}
+	}
+	return b.String()
+}
+
+func TestFairFanInWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]int
+		// want метки первых значений выхода при постоянной нагрузке на все входы
+		want string
+	}{
+		{"equal", nil, "abcabcabc"},
+		// доли пропорциональны весам, независимо от того, сколько значений ждёт на входе
+		{"weighted", map[string]int{"a": 3, "b": 1, "c": 2}, "aaabccaaabcc"},
+		{"missing weight", map[string]int{"b": 2}, "abbcabbc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			out := FairFanIn(ctx, tt.weights, map[string]<-chan int{
+				"a": labelled(0, 100), "b": labelled(1, 100), "c": labelled(2, 100),
+			})
+			if got := labels(t, out, len(tt.want)); got != tt.want {
+				t.Errorf("output labels %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFairFanInBurst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// всплеск на входе a не вытесняет b: b получает свою долю, как только в нём появляются значения
+	a, b := labelled(0, 1000), make(chan int, 10)
+	out := FairFanIn(ctx, map[string]int{"a": 1, "b": 1}, map[string]<-chan int{"a": a, "b": b})
+	if got := labels(t, out, 5); got != "aaaaa" {
+		t.Fatalf("output labels %q while b is idle, want only a", got)
+	}
+	// простаивавший вход не накопил долю: выдаёт наравне с a, а не все значения подряд
+	for i := range 10 {
+		b <- 1000 + i
+	}
+	got := labels(t, out, 12)
+	if n := strings.Count(got, "b"); n < 4 || strings.Contains(got, "bbb") {
+		t.Errorf("output labels %q after the burst of b, want alternation", got)
+	}
+}
+
+func TestFairFanInClose(t *testing.T) {
+	a, b := labelled(0, 3), labelled(1, 2)
+	close(a)
+	close(b)
+	// nil-вход пропускается; порядок значений одного входа сохраняется
+	out := FairFanIn(context.Background(), nil, map[string]<-chan int{"a": a, "b": b, "c": nil})
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	if want := []int{0, 1000, 1, 1001, 2}; !slices.Equal(got, want) {
+		t.Errorf("output %v, want %v", got, want)
+	}
+
+	if out := FairFanIn[int](context.Background(), nil, nil); out != nil {
+		t.Error("FairFanIn without inputs returned a channel")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("FairFanIn with weight 0 did not panic")
+		}
+	}()
+	FairFanIn(context.Background(), map[string]int{"a": 0}, map[string]<-chan int{"a": a})
+}
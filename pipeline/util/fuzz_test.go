@@ -0,0 +1,365 @@
+package util
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// harnessTimeout время, за которое выходы обязаны закрыться
+const harnessTimeout = 5 * time.Second
+
+// pause случайная пауза производителя или потребителя: чаще нулевая, иногда до 200 мкс
+func pause(rng *rand.Rand) {
+	if rng.Intn(4) == 0 {
+		time.Sleep(time.Duration(rng.Intn(200)) * time.Microsecond)
+	}
+}
+
+// settle дожидается, пока количество горутин вернётся к base
+func settle(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("goroutine leak: %d > %d\n%s", runtime.NumGoroutine(), base, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitOrFail дожидается wg не дольше harnessTimeout
+func waitOrFail(t *testing.T, wg *sync.WaitGroup, what string) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(harnessTimeout):
+		t.Fatalf("%s did not finish", what)
+	}
+}
+
+// fanInCase параметры прогона слияния, выведенные из seed
+type fanInCase struct {
+	rng         *rand.Rand
+	inputs      int
+	buffers     []int
+	counts      []int
+	cancelAfter int // отмена после стольких полученных значений, -1 без отмены
+	tree        int // ветвление FanInTree, 0 для FanIn
+}
+
+// mergers количество горутин слияния: по одной на канал каждого уровня дерева
+func (c fanInCase) mergers() int {
+	n, total := c.inputs, 0
+	for c.tree > 0 && n > c.tree {
+		total += n
+		n = (n + c.tree - 1) / c.tree
+	}
+	return total + n
+}
+
+func newFanInCase(seed int64, tree bool) fanInCase {
+	rng := rand.New(rand.NewSource(seed))
+	c := fanInCase{rng: rng, inputs: 1 + rng.Intn(12), cancelAfter: -1}
+	total := 0
+	for i := 0; i < c.inputs; i++ {
+		c.buffers = append(c.buffers, rng.Intn(4))
+		c.counts = append(c.counts, rng.Intn(25))
+		total += c.counts[i]
+	}
+	if rng.Intn(2) == 0 {
+		c.cancelAfter = rng.Intn(total + 1)
+	}
+	if tree {
+		c.tree = 2 + rng.Intn(3)
+	}
+	return c
+}
+
+// runFanIn прогоняет слияние и проверяет инварианты: выход закрывается, значения не дублируются и
+// приходят из входов в порядке отправки, каждое принятое значение доставлено, осталось во входе или
+// учтено как отброшенное (при отмене не больше одного на горутину слияния), горутины не утекают
+func runFanIn(t *testing.T, c fanInCase) {
+	base := runtime.NumGoroutine()
+	var wg sync.WaitGroup
+	var dropped atomic.Uint64
+	ctx, cancel := context.WithCancel(WithDropCounter(WithWaitGroup(context.Background(), &wg), &dropped))
+	defer cancel()
+
+	inputs := make([]chan int, c.inputs)
+	readOnly := make([]<-chan int, c.inputs)
+	for i := range inputs {
+		inputs[i] = make(chan int, c.buffers[i])
+		readOnly[i] = inputs[i]
+	}
+	var merged <-chan int
+	if c.tree > 0 {
+		merged = FanInTree(ctx, c.tree, readOnly...)
+	} else {
+		merged = FanIn(ctx, readOnly...)
+	}
+
+	// accepted значения, принятые каналами входов
+	accepted := make([][]int, c.inputs)
+	var producers sync.WaitGroup
+	for i := range inputs {
+		rng := rand.New(rand.NewSource(c.rng.Int63()))
+		producers.Add(1)
+		go func() {
+			defer producers.Done()
+			defer close(inputs[i])
+			for j := 0; j < c.counts[i]; j++ {
+				pause(rng)
+				select {
+				case inputs[i] <- i*1000 + j:
+					accepted[i] = append(accepted[i], i*1000+j)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	var got []int
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		rng := rand.New(rand.NewSource(c.rng.Int63()))
+		for v := range merged {
+			got = append(got, v)
+			if len(got) == c.cancelAfter {
+				cancel()
+			}
+			pause(rng)
+		}
+	}()
+	if c.cancelAfter == 0 {
+		cancel()
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(harnessTimeout):
+		t.Fatalf("merged output not closed (%+v)", c)
+	}
+	waitOrFail(t, &producers, "producers")
+	waitOrFail(t, &wg, "merge goroutines")
+
+	// значения, оставшиеся во входах, слиянием не приняты
+	left := 0
+	for _, in := range inputs {
+		for range in {
+			left++
+		}
+	}
+
+	next := make([]int, c.inputs)
+	seen := make(map[int]bool)
+	for _, v := range got {
+		i, j := v/1000, v%1000
+		if seen[v] {
+			t.Fatalf("value %d delivered twice", v)
+		}
+		seen[v] = true
+		if j < next[i] {
+			t.Fatalf("input %d reordered: %d after %d", i, j, next[i]-1)
+		}
+		if j >= len(accepted[i]) {
+			t.Fatalf("value %d was never accepted", v)
+		}
+		next[i] = j + 1
+	}
+
+	total := 0
+	for _, a := range accepted {
+		total += len(a)
+	}
+	lost := total - len(got) - left
+	cancelled := c.cancelAfter >= 0 && c.cancelAfter <= total
+	switch {
+	case lost != int(dropped.Load()):
+		t.Fatalf("accounting: %d accepted, %d delivered, %d left in inputs, %d dropped", total, len(got), left,
+			dropped.Load())
+	case !cancelled && lost != 0:
+		t.Fatalf("%d values lost without cancellation", lost)
+	case lost > c.mergers():
+		// каждая горутина слияния держит не больше одного прочитанного значения
+		t.Fatalf("%d values lost on cancel, at most %d in flight", lost, c.mergers())
+	}
+	cancel()
+	settle(t, base)
+}
+
+func FuzzFanIn(f *testing.F) {
+	for _, seed := range []int64{0, 1, 2, 42, 1 << 20} {
+		f.Add(seed, false)
+		f.Add(seed, true)
+	}
+	f.Fuzz(func(t *testing.T, seed int64, tree bool) {
+		runFanIn(t, newFanInCase(seed, tree))
+	})
+}
+
+// fanOutKind вариант распределителя
+type fanOutKind int
+
+const (
+	roundRobin fanOutKind = iota
+	strict
+	weighted
+	fanOutKinds
+)
+
+// fanOutCase параметры прогона распределения, выведенные из seed
+type fanOutCase struct {
+	rng         *rand.Rand
+	kind        fanOutKind
+	buffers     []int
+	weights     []int
+	count       int
+	cancelAfter int // отмена после стольких отправленных значений, -1 без отмены
+}
+
+func newFanOutCase(seed int64, kind uint8) fanOutCase {
+	rng := rand.New(rand.NewSource(seed))
+	c := fanOutCase{rng: rng, kind: fanOutKind(kind) % fanOutKinds, count: rng.Intn(60), cancelAfter: -1}
+	outputs := 1 + rng.Intn(6)
+	positive := false
+	for i := 0; i < outputs; i++ {
+		c.buffers = append(c.buffers, rng.Intn(4))
+		w := rng.Intn(4)
+		positive = positive || w > 0
+		c.weights = append(c.weights, w)
+	}
+	if !positive {
+		c.weights[rng.Intn(outputs)] = 1
+	}
+	if rng.Intn(2) == 0 {
+		c.cancelAfter = rng.Intn(c.count + 1)
+	}
+	return c
+}
+
+// runFanOut прогоняет распределение и проверяет инварианты: все выходы закрываются, значения не
+// дублируются, FanOutStrict отправляет k-е значение в выход k%n и при отмене, каждое принятое значение
+// доставлено или учтено как отброшенное, без отмены доставляются все, горутины не утекают
+func runFanOut(t *testing.T, c fanOutCase) {
+	base := runtime.NumGoroutine()
+	var wg sync.WaitGroup
+	var dropped atomic.Uint64
+	ctx, cancel := context.WithCancel(WithDropCounter(WithWaitGroup(context.Background(), &wg), &dropped))
+	defer cancel()
+
+	outputs := make([]chan int, len(c.buffers))
+	writeOnly := make([]chan<- int, len(c.buffers))
+	for i := range outputs {
+		outputs[i] = make(chan int, c.buffers[i])
+		writeOnly[i] = outputs[i]
+	}
+	var in chan<- int
+	switch c.kind {
+	case strict:
+		in = FanOutStrict(ctx, writeOnly...)
+	case weighted:
+		in = FanOutWeighted(ctx, c.weights, writeOnly...)
+	default:
+		in = FanOut(ctx, writeOnly...)
+	}
+
+	got := make([][]int, len(outputs))
+	var consumers sync.WaitGroup
+	for i := range outputs {
+		rng := rand.New(rand.NewSource(c.rng.Int63()))
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for v := range outputs[i] {
+				got[i] = append(got[i], v)
+				pause(rng)
+			}
+		}()
+	}
+
+	sent := 0
+	rng := rand.New(rand.NewSource(c.rng.Int63()))
+	if c.cancelAfter == 0 {
+		cancel()
+	}
+produce:
+	for ; sent < c.count; sent++ {
+		pause(rng)
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case in <- sent:
+		case <-ctx.Done():
+			break produce
+		}
+		if sent+1 == c.cancelAfter {
+			sent++
+			cancel()
+			break
+		}
+	}
+	close(in)
+
+	waitOrFail(t, &consumers, "output consumers")
+	waitOrFail(t, &wg, "distributor")
+
+	seen := make(map[int]bool)
+	delivered := 0
+	for i, vals := range got {
+		for k, v := range vals {
+			if seen[v] || v >= sent {
+				t.Fatalf("output %d: unexpected value %d (sent %d)", i, v, sent)
+			}
+			seen[v] = true
+			if c.kind == strict && v != k*len(outputs)+i {
+				t.Fatalf("strict output %d got %d at position %d", i, v, k)
+			}
+		}
+		delivered += len(vals)
+	}
+
+	if lost := sent - delivered; lost != int(dropped.Load()) {
+		t.Fatalf("accounting: %d sent, %d delivered, %d dropped", sent, delivered, dropped.Load())
+	} else if c.cancelAfter < 0 && lost != 0 {
+		t.Fatalf("%d of %d values lost without cancellation", lost, sent)
+	}
+	cancel()
+	settle(t, base)
+}
+
+func FuzzFanOut(f *testing.F) {
+	for _, seed := range []int64{0, 1, 2, 42, 1 << 20} {
+		for kind := range fanOutKinds {
+			f.Add(seed, uint8(kind))
+		}
+	}
+	f.Fuzz(func(t *testing.T, seed int64, kind uint8) {
+		runFanOut(t, newFanOutCase(seed, kind))
+	})
+}
+
+// TestFanInOutRandomized прогоняет инварианты фаззинга на фиксированном наборе seed без фаззера
+func TestFanInOutRandomized(t *testing.T) {
+	n := int64(300)
+	if testing.Short() {
+		n = 30
+	}
+	for seed := int64(0); seed < n; seed++ {
+		runFanIn(t, newFanInCase(seed, seed%2 == 1))
+		runFanOut(t, newFanOutCase(seed, uint8(seed)))
+	}
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"context"
+	"slices"
+)
+
+// Interleave объединяет входы строго по очереди: по одному значению из входа 0, 1, 2, …, затем
+// снова из 0 и т.д. Пока очередной вход пуст, Interleave ждёт именно его; закрытые входы выбывают
+// из очереди, nil-каналы пропускаются. В отличие от FanIn порядок детерминирован, в отличие от
+// MergeSorted не требует сравнения значений. Выходной канал закрывается после закрытия всех входов
+// или отмены ctx. Если входных каналов 0, возвращает nil.
+func Interleave[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	rotation := slices.DeleteFunc(slices.Clone(inputs), func(ch <-chan T) bool { return ch == nil })
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		for i := 0; len(rotation) > 0; {
+			var v T
+			var ok bool
+			select {
+			case v, ok = <-rotation[i]:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				rotation = slices.Delete(rotation, i, i+1)
+				if i == len(rotation) {
+					i = 0
+				}
+				continue
+			}
+
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+			i = (i + 1) % len(rotation)
+		}
+	})
+
+	return out
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// closedWith возвращает закрытый канал со значениями values
+func closedWith(values ...int) <-chan int {
+	ch := make(chan int, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+func TestInterleaveOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []<-chan int
+		want   []int
+	}{
+		{"equal", []<-chan int{closedWith(0, 1, 2), closedWith(10, 11, 12), closedWith(20, 21, 22)},
+			[]int{0, 10, 20, 1, 11, 21, 2, 12, 22}},
+		// закрытые входы выбывают из очереди, остальные продолжают по порядку
+		{"uneven", []<-chan int{closedWith(0, 1, 2), closedWith(10), closedWith(20, 21)},
+			[]int{0, 10, 20, 1, 21, 2}},
+		{"last closes first", []<-chan int{closedWith(0, 1), closedWith(10, 11), closedWith()},
+			[]int{0, 10, 1, 11}},
+		{"nil input", []<-chan int{closedWith(0, 1), nil, closedWith(20, 21)}, []int{0, 20, 1, 21}},
+		{"only nil", []<-chan int{nil}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for v := range Interleave(context.Background(), tt.inputs...) {
+				got = append(got, v)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("output %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterleaveWaitsForTurn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a, b := make(chan int, 3), make(chan int)
+	a <- 0
+	a <- 1
+	out := Interleave(ctx, a, b)
+	if v := <-out; v != 0 {
+		t.Fatalf("first value %d, want 0", v)
+	}
+	// очередь b: значение a не выдаётся, пока b пуст
+	select {
+	case v := <-out:
+		t.Fatalf("got %d while b is empty", v)
+	case <-time.After(20 * time.Millisecond):
+	}
+	b <- 10
+	for _, want := range []int{10, 1} {
+		if v := <-out; v != want {
+			t.Errorf("got %d, want %d", v, want)
+		}
+	}
+
+	// отмена закрывает выход
+	cancel()
+	for range out {
+	}
+	if Interleave[int](context.Background()) != nil {
+		t.Error("Interleave without inputs returned a channel")
+	}
+}
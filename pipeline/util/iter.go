@@ -0,0 +1,74 @@
+package util
+
+import (
+	"context"
+	"iter"
+)
+
+// Iter возвращает итератор по значениям канала ch для range-over-func. Итерация завершается при
+// закрытии ch или отмене ctx. Выход из цикла (break) не останавливает отправителя: чтобы он не
+// заблокировался навсегда, вызывающий код отменяет ctx или иначе сообщает источнику об остановке.
+func Iter[T any](ctx context.Context, ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case v, ok := <-ch:
+				if !ok || !yield(v) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Iter2 возвращает итератор по значениям канала ch и ошибкам канала errs: значение отдаётся
+// парой (v, nil), ошибка — парой (нулевое значение, err). Итерация завершается, когда закрыты
+// оба канала, или при отмене ctx; nil-канал считается закрытым. Выход из цикла аналогичен Iter.
+func Iter2[T any](ctx context.Context, ch <-chan T, errs <-chan error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for ch != nil || errs != nil {
+			select {
+			case v, ok := <-ch:
+				if !ok {
+					ch = nil
+					continue
+				}
+				if !yield(v, nil) {
+					return
+				}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				var zero T
+				if !yield(zero, err) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// FromSeq запускает горутину, отправляющую значения seq в возвращаемый канал с буфером buf, и
+// закрывает канал после окончания seq. При отмене ctx значения перестают запрашиваться у seq
+// (итерация прерывается на ближайшем yield) и канал закрывается. Паники seq не перехватываются:
+// для учёта политики паник нод используется pipeline.SourceFromSeq.
+func FromSeq[T any](ctx context.Context, seq iter.Seq[T], buf int) <-chan T {
+	out := make(chan T, max(buf, 0))
+	spawn(ctx, func() {
+		defer close(out)
+		for v := range seq {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingSeq бесконечная последовательность 0, 1, 2...; pulled считает выданные значения,
+// stopped отмечает, что потребитель прервал итерацию
+func countingSeq(pulled *atomic.Int64, stopped *atomic.Bool) func(yield func(int) bool) {
+	return func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			pulled.Add(1)
+			if !yield(i) {
+				stopped.Store(true)
+				return
+			}
+		}
+	}
+}
+
+func TestFromSeq(t *testing.T) {
+	for _, buf := range []int{0, 2} {
+		ch := FromSeq(context.Background(), slices.Values(ints(5)), buf)
+		var got []int
+		for v := range ch {
+			got = append(got, v)
+		}
+		if !slices.Equal(got, ints(5)) {
+			t.Errorf("buf %d: got %v, want %v", buf, got, ints(5))
+		}
+	}
+}
+
+func TestFromSeqCancel(t *testing.T) {
+	for _, buf := range []int{0, 2} {
+		var wg sync.WaitGroup
+		var pulled atomic.Int64
+		var stopped atomic.Bool
+		ctx, cancel := context.WithCancel(WithWaitGroup(context.Background(), &wg))
+		ch := FromSeq(ctx, countingSeq(&pulled, &stopped), buf)
+
+		var got []int
+		for v := range ch {
+			got = append(got, v)
+			if len(got) == 3 {
+				break
+			}
+		}
+		cancel()
+		// после отмены горутина прерывает итерацию seq и закрывает канал
+		waitOrFail(t, &wg, "FromSeq goroutine")
+		for range ch {
+		}
+		if !slices.Equal(got, ints(3)) {
+			t.Errorf("buf %d: got %v, want %v", buf, got, ints(3))
+		}
+		if !stopped.Load() {
+			t.Errorf("buf %d: seq was not stopped", buf)
+		}
+		// прочитанные, буферизованные и одно ожидающее отправки значение
+		if p := pulled.Load(); p > int64(len(got)+buf+1) {
+			t.Errorf("buf %d: %d values pulled after reading %d", buf, p, len(got))
+		}
+	}
+}
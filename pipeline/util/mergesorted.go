@@ -0,0 +1,154 @@
+package util
+
+import (
+	"context"
+	"time"
+)
+
+// MergeSorted объединяет входы, каждый из которых упорядочен по less, в один упорядоченный канал
+// (k-way слияние). Из каждого входа удерживается ровно одно ожидающее значение, и отправляется
+// наименьшее из них (при равенстве — из входа с меньшим индексом). Пока незакрытый вход пуст,
+// слияние ждёт его: порядок важнее задержки. Закрытые входы выбывают из слияния, nil-каналы
+// пропускаются; выходной канал закрывается после закрытия всех входов или отмены ctx.
+// Если входных каналов 0, возвращает nil.
+func MergeSorted[T any](ctx context.Context, less func(a, b T) bool, inputs ...<-chan T) <-chan T {
+	return MergeSortedMaxWait(ctx, less, 0, nil, inputs...)
+}
+
+// MergeSortedMaxWait объединяет входы так же, как MergeSorted, но ждёт пустой вход не дольше
+// maxWait (maxWait <= 0 — без ограничения). По истечении maxWait вход считается отставшим, и
+// значения отправляются без него, пока он не выдаст значение. Значение отставшего входа может
+// оказаться меньше уже отправленного: такое нарушение порядка отмечается вызовом late(v) перед
+// отправкой v (late может быть nil), значение при этом не отбрасывается.
+func MergeSortedMaxWait[T any](ctx context.Context, less func(a, b T) bool, maxWait time.Duration, late func(T),
+	inputs ...<-chan T) <-chan T {
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	heads := make([]mergeHead[T], len(inputs))
+	for i, ch := range inputs {
+		heads[i].ch = ch
+	}
+
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		var last T
+		emitted := false
+		for {
+			if !fillHeads(ctx, heads, maxWait) {
+				return
+			}
+
+			best := -1
+			for i := range heads {
+				if heads[i].ok && (best == -1 || less(heads[i].val, heads[best].val)) {
+					best = i
+				}
+			}
+			if best == -1 {
+				return
+			}
+
+			v := heads[best].val
+			heads[best].ok = false
+			if emitted && less(v, last) {
+				if late != nil {
+					late(v)
+				}
+			} else {
+				last = v
+			}
+			emitted = true
+
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// mergeHead ожидающее значение входа MergeSorted
+type mergeHead[T any] struct {
+	// ch канал входа, nil после закрытия
+	ch  <-chan T
+	val T
+	ok  bool
+	// stalled вход пуст дольше maxWait и не ожидается, пока не выдаст значение
+	stalled bool
+}
+
+// receive учитывает результат чтения из входа
+func (h *mergeHead[T]) receive(v T, ok bool) {
+	if !ok {
+		h.ch = nil
+		return
+	}
+	h.val, h.ok, h.stalled = v, true, false
+}
+
+// fillHeads получает по значению из каждого открытого входа без ожидающего значения. Пока есть
+// хотя бы одно ожидающее значение, пустые входы ждутся не дольше maxWait (общего для всех входов),
+// после чего помечаются отставшими; без ожидающих значений входы ждутся без ограничения.
+// Возвращает false при отмене ctx.
+func fillHeads[T any](ctx context.Context, heads []mergeHead[T], maxWait time.Duration) bool {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	expired := false
+	for i := range heads {
+		h := &heads[i]
+		if h.ch == nil || h.ok {
+			continue
+		}
+
+		select {
+		case v, ok := <-h.ch:
+			h.receive(v, ok)
+			continue
+		default:
+		}
+
+		var timeout <-chan time.Time
+		if maxWait > 0 && pending(heads) {
+			if h.stalled || expired {
+				h.stalled = true
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(maxWait)
+			}
+			timeout = timer.C
+		}
+
+		select {
+		case v, ok := <-h.ch:
+			h.receive(v, ok)
+		case <-timeout:
+			expired, h.stalled = true, true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// pending сообщает, есть ли ожидающее значение хотя бы у одного входа
+func pending[T any](heads []mergeHead[T]) bool {
+	for i := range heads {
+		if heads[i].ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package util
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sortedItem значение упорядоченного входа: ключ и номер входа
+type sortedItem struct {
+	key, input int
+}
+
+func TestMergeSortedProperty(t *testing.T) {
+	n := int64(300)
+	if testing.Short() {
+		n = 30
+	}
+	for seed := int64(0); seed < n; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		inputs := make([]<-chan sortedItem, rng.Intn(8))
+		var want []int
+		for i := range inputs {
+			keys := make([]int, rng.Intn(30))
+			for k := range keys {
+				keys[k] = rng.Intn(50)
+			}
+			slices.Sort(keys)
+			want = append(want, keys...)
+
+			ch := make(chan sortedItem, rng.Intn(3))
+			inputs[i] = ch
+			prng := rand.New(rand.NewSource(rng.Int63()))
+			go func() {
+				defer close(ch)
+				for _, k := range keys {
+					pause(prng)
+					ch <- sortedItem{key: k, input: i}
+				}
+			}()
+		}
+		slices.Sort(want)
+
+		merged := MergeSorted(context.Background(), func(a, b sortedItem) bool { return a.key < b.key }, inputs...)
+		if len(inputs) == 0 {
+			if merged != nil {
+				t.Fatalf("seed %d: MergeSorted without inputs returned a channel", seed)
+			}
+			continue
+		}
+		var got []int
+		var prev sortedItem
+		for v := range merged {
+			// при равных ключах первым отправляется значение входа с меньшим индексом
+			if len(got) > 0 && v.key == prev.key && v.input < prev.input {
+				t.Fatalf("seed %d: tie %v after %v", seed, v, prev)
+			}
+			got = append(got, v.key)
+			prev = v
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("seed %d: merged %v, want %v", seed, got, want)
+		}
+	}
+}
+
+func TestMergeSortedNilAndCancel(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	a, b := make(chan int, 3), make(chan int, 3)
+	a <- 1
+	a <- 4
+	b <- 2
+	close(a)
+	close(b)
+	var got []int
+	for v := range MergeSorted(context.Background(), less, nil, a, nil, b) {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{1, 2, 4}) {
+		t.Errorf("merged %v, want [1 2 4]", got)
+	}
+
+	// открытый пустой вход ждётся без ограничения, отмена закрывает выход
+	ctx, cancel := context.WithCancel(context.Background())
+	open := make(chan int)
+	merged := MergeSorted(ctx, less, open)
+	cancel()
+	select {
+	case _, ok := <-merged:
+		if ok {
+			t.Error("value after cancel")
+		}
+	case <-time.After(harnessTimeout):
+		t.Fatal("output not closed after cancel")
+	}
+}
+
+func TestMergeSortedMaxWait(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	fast, stalled := make(chan int), make(chan int)
+	var mu sync.Mutex
+	var late []int
+	merged := MergeSortedMaxWait(context.Background(), less, 10*time.Millisecond, func(v int) {
+		mu.Lock()
+		defer mu.Unlock()
+		late = append(late, v)
+	}, fast, stalled)
+
+	// отставший вход не задерживает значения остальных дольше maxWait
+	go func() {
+		for _, v := range []int{1, 2, 3} {
+			fast <- v
+		}
+	}()
+	var got []int
+	for range 3 {
+		select {
+		case v := <-merged:
+			got = append(got, v)
+		case <-time.After(harnessTimeout):
+			t.Fatalf("merge waited for the stalled input, got %v", got)
+		}
+	}
+
+	// значение отставшего входа меньше отправленных: отправляется с отметкой late. Без ожидающих
+	// значений слияние ждёт каждый открытый вход, поэтому fast закрывается первым
+	close(fast)
+	stalled <- 0
+	close(stalled)
+	for v := range merged {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{1, 2, 3, 0}) {
+		t.Errorf("merged %v, want [1 2 3 0]", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(late, []int{0}) {
+		t.Errorf("late = %v, want [0]", late)
+	}
+}
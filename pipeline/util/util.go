@@ -2,23 +2,82 @@ package util
 
 import (
 	"context"
+	"slices"
 	"sync"
+	"sync/atomic"
 )
 
+// dropCounterKey ключ счётчика отброшенных значений в контексте (WithDropCounter)
+type dropCounterKey struct{}
+
+// WithDropCounter возвращает контекст, с которым FanIn, FanInTree, FanOut, FanOutStrict и
+// FanOutWeighted учитывают в dropped значения, принятые из входов и отброшенные из-за отмены ctx.
+// Для распределителей, вход которых закрыт, выполняется равенство: принято = доставлено + dropped.
+func WithDropCounter(ctx context.Context, dropped *atomic.Uint64) context.Context {
+	return context.WithValue(ctx, dropCounterKey{}, dropped)
+}
+
+// dropCounter возвращает счётчик контекста (WithDropCounter) или nil
+func dropCounter(ctx context.Context) *atomic.Uint64 {
+	dropped, _ := ctx.Value(dropCounterKey{}).(*atomic.Uint64)
+	return dropped
+}
+
+// drop учитывает n отброшенных значений в счётчике dropped, если он задан
+func drop(dropped *atomic.Uint64, n uint64) {
+	if dropped != nil {
+		dropped.Add(n)
+	}
+}
+
 // FanIn объединяет несколько каналов входа в один выходной канал. Выходной
 // канал закрывается автоматически после того, как все входные каналы закрыты.
 // Если входных каналов 0, возвращает nil. Буфер выходного канала равен количеству входов.
+// nil-каналы среди входов пропускаются, а не блокируют закрытие выходного канала.
+// При отмене ctx значение, уже прочитанное из входа, отбрасывается (учитывается WithDropCounter),
+// оставшиеся во входах значения не читаются.
 func FanIn[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
-	l := len(inputs)
-	if l == 0 {
+	return merge(ctx, len(inputs), inputs)
+}
+
+// FanInTree объединяет каналы входа так же, как FanIn, но через дерево промежуточных слияний
+// не более чем по branching каналов (минимум 2), что снижает конкуренцию за один канал при большом
+// количестве входов. Буфер выходного канала равен количеству каналов верхнего уровня (не больше
+// branching), промежуточные каналы не буферизованы. При len(inputs) <= branching равносилен FanIn.
+func FanInTree[T any](ctx context.Context, branching int, inputs ...<-chan T) <-chan T {
+	branching = max(branching, 2)
+	for len(inputs) > branching {
+		level := make([]<-chan T, 0, (len(inputs)+branching-1)/branching)
+		for start := 0; start < len(inputs); start += branching {
+			level = append(level, merge(ctx, 0, inputs[start:min(start+branching, len(inputs))]))
+		}
+		inputs = level
+	}
+	return FanIn(ctx, inputs...)
+}
+
+// merge объединяет inputs в канал с буфером buf. nil-каналы пропускаются: если все входы nil,
+// возвращается закрытый канал.
+func merge[T any](ctx context.Context, buf int, inputs []<-chan T) <-chan T {
+	if len(inputs) == 0 {
 		return nil
 	}
 
-	out := make(chan T, l)
+	if slices.Contains(inputs, nil) {
+		inputs = slices.DeleteFunc(slices.Clone(inputs), func(ch <-chan T) bool { return ch == nil })
+		if len(inputs) == 0 {
+			out := make(chan T)
+			close(out)
+			return out
+		}
+	}
+
+	out := make(chan T, buf)
+	dropped := dropCounter(ctx)
 	var wg sync.WaitGroup
-	for _, input := range inputs {
+	for _, ch := range inputs {
 		wg.Add(1)
-		go func(ch <-chan T) {
+		spawn(ctx, func() {
 			defer wg.Done()
 
 			for {
@@ -30,6 +89,7 @@ func FanIn[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
 					select {
 					case out <- val:
 					case <-ctx.Done():
+						drop(dropped, 1)
 						return
 					}
 
@@ -37,21 +97,24 @@ func FanIn[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
 					return
 				}
 			}
-		}(input)
+		})
 	}
 
-	go func() {
+	spawn(ctx, func() {
 		wg.Wait()
 		close(out)
-	}()
+	})
 
 	return out
 }
 
 // FanOut распределяет значения из входного канала по нескольким выходным каналам в
 // round-robin режиме (поочерёдно). Если канал блокируется, переходит к следующему.
-// Если контекст отменён, распределение прекращается. Выходные каналы закрываются
-// автоматически после закрытия входного канала. Если выходных каналов 0, возвращает nil.
+// Если контекст отменён, распределение прекращается: уже принятые значения (прочитанное и
+// оставшиеся в буфере входа) без блокировки передаются в выходы со свободным местом, остальные
+// отбрасываются и учитываются WithDropCounter. После отмены выходы закрываются сразу, а вход больше
+// не читается: отправитель должен прекращать отправку по отмене ctx. Без отмены выходные
+// каналы закрываются автоматически после закрытия входного канала. Если выходных каналов 0, возвращает nil.
 // Буфер входного канала равен количеству выходов.
 func FanOut[T any](ctx context.Context, outputs ...chan<- T) chan<- T {
 	l := len(outputs)
@@ -60,12 +123,9 @@ func FanOut[T any](ctx context.Context, outputs ...chan<- T) chan<- T {
 	}
 
 	out := make(chan T, l)
-	go func() {
-		defer func() {
-			for _, output := range outputs {
-				close(output)
-			}
-		}()
+	dropped := dropCounter(ctx)
+	spawn(ctx, func() {
+		defer closeOutputs(out, outputs, dropped)
 
 		currChanIdx := 0
 		for {
@@ -93,14 +153,244 @@ func FanOut[T any](ctx context.Context, outputs ...chan<- T) chan<- T {
 					case outputs[currChanIdx] <- val:
 						currChanIdx = (currChanIdx + 1) % l
 					case <-ctx.Done():
+						salvage(out, &val, anyFree(outputs), dropped)
 						return
 					}
 				}
 			case <-ctx.Done():
+				salvage(out, nil, anyFree(outputs), dropped)
 				return
 			}
 		}
-	}()
+	})
 
 	return out
 }
+
+// Closed сообщает, что все каналы chs закрыты. Используется в обработчиках, читающих несколько
+// каналов через select: по соглашению обработчик обнуляет канал после получения признака закрытия
+// (ch = nil), чтобы select больше не выбирал его, и завершается, когда Closed возвращает true.
+func Closed[T any](chs ...<-chan T) bool {
+	for _, ch := range chs {
+		if ch != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Discard возвращает канал, значения из которого читаются и отбрасываются, пока он не будет закрыт
+// или не будет отменён ctx. Подходит как выход, результат которого не нужен.
+func Discard[T any](ctx context.Context) chan<- T {
+	ch := make(chan T)
+	spawn(ctx, func() {
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return ch
+}
+
+// FanOutStrict распределяет значения из входного канала по выходным каналам строго по очереди:
+// k-е значение всегда отправляется в выход k%n, при заполненном выходе распределение блокируется.
+// В отличие от FanOut, медленный выход замедляет все остальные, зато номер выхода однозначно
+// определяется порядковым номером значения. Закрытие и отмена аналогичны FanOut, но при отмене
+// принятые значения передаются только в очередной выход: первое значение, для которого в нём нет
+// места, и все последующие отбрасываются, поэтому порядок выходов не нарушается.
+func FanOutStrict[T any](ctx context.Context, outputs ...chan<- T) chan<- T {
+	l := len(outputs)
+	if l == 0 {
+		return nil
+	}
+
+	out := make(chan T, l)
+	dropped := dropCounter(ctx)
+	spawn(ctx, func() {
+		defer closeOutputs(out, outputs, dropped)
+
+		currChanIdx := 0
+		for {
+			select {
+			case val, ok := <-out:
+				if !ok {
+					return
+				}
+
+				select {
+				case outputs[currChanIdx] <- val:
+					currChanIdx = (currChanIdx + 1) % l
+				case <-ctx.Done():
+					salvage(out, &val, inTurn(outputs, &currChanIdx), dropped)
+					return
+				}
+			case <-ctx.Done():
+				salvage(out, nil, inTurn(outputs, &currChanIdx), dropped)
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// FanOutWeighted распределяет значения из входного канала по выходным каналам пропорционально
+// весам weights (по одному весу на выход) методом плавного взвешенного round-robin: выходы
+// чередуются, и пачка значений не уходит целиком в выход с наибольшим весом. Если выбранный выход
+// заполнен, значение отправляется в другой свободный выход с положительным весом, затем в свободный
+// выход с нулевым весом (резерв, используемый только при заполненных остальных); если свободных нет,
+// распределение блокируется на выбранном выходе. Закрытие и отмена аналогичны FanOut.
+// Паникует, если количество весов не совпадает с количеством выходов или нет положительных весов.
+func FanOutWeighted[T any](ctx context.Context, weights []int, outputs ...chan<- T) chan<- T {
+	l := len(outputs)
+	if l == 0 {
+		return nil
+	}
+
+	if len(weights) != l {
+		panic("mismatch weights")
+	}
+
+	total := 0
+	for _, w := range weights {
+		total += max(w, 0)
+	}
+	if total == 0 {
+		panic("no positive weights")
+	}
+
+	out := make(chan T, l)
+	dropped := dropCounter(ctx)
+	spawn(ctx, func() {
+		defer closeOutputs(out, outputs, dropped)
+
+		current := make([]int, l)
+		for {
+			select {
+			case val, ok := <-out:
+				if !ok {
+					return
+				}
+
+				best := -1
+				for i, w := range weights {
+					if w <= 0 {
+						continue
+					}
+					current[i] += w
+					if best == -1 || current[i] > current[best] {
+						best = i
+					}
+				}
+				current[best] -= total
+
+				if trySend(outputs, best, val, func(i int) bool { return weights[i] > 0 }) ||
+					trySend(outputs, best, val, func(i int) bool { return weights[i] <= 0 }) {
+					continue
+				}
+
+				select {
+				case outputs[best] <- val:
+				case <-ctx.Done():
+					salvage(out, &val, anyFree(outputs), dropped)
+					return
+				}
+			case <-ctx.Done():
+				salvage(out, nil, anyFree(outputs), dropped)
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// salvage при отмене контекста без блокировки передаёт send значение val (если задано), уже
+// прочитанное из in, и значения, оставшиеся в буфере in, чтобы принятые значения не терялись внутри
+// распределителя. На первом значении, которое send не принял, останавливается: оно учитывается в
+// dropped, а остальные отбрасывает closeOutputs.
+func salvage[T any](in chan T, val *T, send func(T) bool, dropped *atomic.Uint64) {
+	if val != nil && !send(*val) {
+		drop(dropped, 1)
+		return
+	}
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				return
+			}
+			if !send(v) {
+				drop(dropped, 1)
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// anyFree отправляет значение salvage в любой выход со свободным местом
+func anyFree[T any](outputs []chan<- T) func(T) bool {
+	return func(v T) bool {
+		return trySend(outputs, 0, v, func(int) bool { return true })
+	}
+}
+
+// inTurn отправляет значение salvage только в очередной выход *curr, сохраняя порядок
+// FanOutStrict: если он заполнен, значение не отправляется
+func inTurn[T any](outputs []chan<- T, curr *int) func(T) bool {
+	return func(v T) bool {
+		select {
+		case outputs[*curr] <- v:
+			*curr = (*curr + 1) % len(outputs)
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// closeOutputs закрывает выходы распределителя и без блокировки вычитывает значения, оставшиеся в
+// буфере in, учитывая их в dropped. Закрытия in после отмены не ждёт: обработчик может завершиться
+// по отмене, не закрыв свой выход, и ожидание заблокировало бы остановку узла.
+func closeOutputs[T any](in chan T, outputs []chan<- T, dropped *atomic.Uint64) {
+	for _, output := range outputs {
+		close(output)
+	}
+	for {
+		select {
+		case _, ok := <-in:
+			if !ok {
+				return
+			}
+			drop(dropped, 1)
+		default:
+			return
+		}
+	}
+}
+
+// trySend пытается без блокировки отправить значение в выходы, удовлетворяющие eligible,
+// начиная с выхода start. Возвращает true, если значение отправлено.
+func trySend[T any](outputs []chan<- T, start int, val T, eligible func(i int) bool) bool {
+	l := len(outputs)
+	for k := 0; k < l; k++ {
+		i := (start + k) % l
+		if !eligible(i) {
+			continue
+		}
+		select {
+		case outputs[i] <- val:
+			return true
+		default:
+		}
+	}
+	return false
+}
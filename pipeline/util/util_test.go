@@ -0,0 +1,288 @@
+package util
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// distribute отправляет values в распределитель in и возвращает значения, полученные выходами
+// outputs после закрытия in
+func distribute(in chan<- int, values []int, outputs []chan int) [][]int {
+	for _, v := range values {
+		in <- v
+	}
+	close(in)
+	got := make([][]int, len(outputs))
+	for i, out := range outputs {
+		for v := range out {
+			got[i] = append(got[i], v)
+		}
+	}
+	return got
+}
+
+// bufferedOutputs создаёт n выходов с буферами size
+func bufferedOutputs(n, size int) ([]chan int, []chan<- int) {
+	outputs := make([]chan int, n)
+	writeOnly := make([]chan<- int, n)
+	for i := range outputs {
+		outputs[i] = make(chan int, size)
+		writeOnly[i] = outputs[i]
+	}
+	return outputs, writeOnly
+}
+
+func TestFanOutWeightedDistribution(t *testing.T) {
+	const items = 10000
+	tests := []struct {
+		name    string
+		weights []int
+	}{
+		// два мощных исполнителя и четыре слабых
+		{"heterogeneous", []int{2, 2, 1, 1, 1, 1}},
+		{"skewed", []int{5, 1}},
+		{"equal", []int{1, 1, 1}},
+		{"with standby", []int{3, 0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total := 0
+			for _, w := range tt.weights {
+				total += w
+			}
+			// буферы вмещают все значения: выходы не заполняются, и распределение определяется весами
+			outputs, writeOnly := bufferedOutputs(len(tt.weights), items)
+			got := distribute(FanOutWeighted(context.Background(), tt.weights, writeOnly...), ints(items), outputs)
+
+			for i, w := range tt.weights {
+				want := items * w / total
+				if d := len(got[i]) - want; d < -1 || d > 1 {
+					t.Errorf("output %d (weight %d) got %d items, want %d", i, w, len(got[i]), want)
+				}
+				// плавность: за один цикл из total значений выход получает ровно w значений, а не пачку подряд
+				for k := w; k < len(got[i]); k++ {
+					if got[i][k]-got[i][k-w] < total-1 {
+						t.Fatalf("output %d: burst %v within one cycle of %d", i, got[i][k-w:k+1], total)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFanOutWeightedStandby(t *testing.T) {
+	// основной выход вмещает 5 значений и не читается, пока распределение не закончится: резерв
+	// получает только значения, для которых в основном выходе нет места
+	primary, standby := make(chan int, 5), make(chan int, 100)
+	in := FanOutWeighted(context.Background(), []int{1, 0}, primary, standby)
+	for v := range 10 {
+		in <- v
+	}
+	close(in)
+	var got [2][]int
+	for v := range standby {
+		got[1] = append(got[1], v)
+	}
+	for v := range primary {
+		got[0] = append(got[0], v)
+	}
+	if !slices.Equal(got[0], ints(5)) {
+		t.Errorf("primary got %v, want [0 1 2 3 4]", got[0])
+	}
+	if !slices.Equal(got[1], []int{5, 6, 7, 8, 9}) {
+		t.Errorf("standby got %v, want [5 6 7 8 9]", got[1])
+	}
+}
+
+func TestFanOutWeightedPanics(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+	}{
+		{"mismatch", []int{1}},
+		{"no positive", []int{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("FanOutWeighted did not panic")
+				}
+			}()
+			_, writeOnly := bufferedOutputs(2, 0)
+			FanOutWeighted(context.Background(), tt.weights, writeOnly...)
+		})
+	}
+}
+
+// ints возвращает числа 0..n-1
+func ints(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+// BenchmarkFanIn512 пропускная способность слияния 512 производителей в один канал: плоским FanIn
+// и деревом FanInTree с разным ветвлением. Дерево снижает конкуренцию за выходной канал и
+// выигрывает только на нескольких ядрах (-cpu); на одном ядре каждый уровень — лишняя пересылка.
+func BenchmarkFanIn512(b *testing.B) {
+	const producers = 512
+	for _, bc := range []struct {
+		name      string
+		branching int
+	}{
+		{"flat", 0},
+		{"tree 8", 8},
+		{"tree 32", 32},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			inputs := make([]<-chan int, producers)
+			for i := range inputs {
+				ch := make(chan int)
+				inputs[i] = ch
+				go func() {
+					defer close(ch)
+					for k := i; k < b.N; k += producers {
+						ch <- k
+					}
+				}()
+			}
+
+			b.ResetTimer()
+			var merged <-chan int
+			if bc.branching == 0 {
+				merged = FanIn(context.Background(), inputs...)
+			} else {
+				merged = FanInTree(context.Background(), bc.branching, inputs...)
+			}
+			for range merged {
+			}
+		})
+	}
+}
+
+func TestFanOutCancelWithoutClose(t *testing.T) {
+	tests := []struct {
+		name   string
+		fanOut func(ctx context.Context, outputs ...chan<- int) chan<- int
+	}{
+		{"round robin", FanOut[int]},
+		{"strict", FanOutStrict[int]},
+		{"weighted", func(ctx context.Context, outputs ...chan<- int) chan<- int {
+			return FanOutWeighted(ctx, []int{2, 1}, outputs...)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			var dropped atomic.Uint64
+			ctx, cancel := context.WithCancel(WithDropCounter(WithWaitGroup(context.Background(), &wg), &dropped))
+			// выходы не читаются: второе значение остаётся в буфере входа до отмены
+			outputs, writeOnly := bufferedOutputs(2, 0)
+			in := tt.fanOut(ctx, writeOnly...)
+			in <- 1
+			in <- 2
+			cancel()
+
+			// отправитель завершился по отмене, не закрыв вход: распределитель всё равно завершается
+			waitOrFail(t, &wg, "distributor")
+			for i, out := range outputs {
+				if _, ok := <-out; ok {
+					t.Errorf("output %d: value after cancel", i)
+				}
+			}
+			if d := dropped.Load(); d != 2 {
+				t.Errorf("dropped %d, want 2", d)
+			}
+		})
+	}
+}
+
+func TestFanOutStrictOrder(t *testing.T) {
+	const items = 60
+	tests := []struct {
+		name    string
+		buffers []int
+		// slow выход, читатель которого делает паузу после каждого значения (-1 — нет такого)
+		slow int
+	}{
+		{"unbuffered", []int{0, 0, 0}, -1},
+		{"buffered", []int{items, items}, -1},
+		// FanOut отдал бы значения медленного выхода соседям, FanOutStrict ждёт его
+		{"slow output", []int{0, 4, 4}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := len(tt.buffers)
+			writeOnly := make([]chan<- int, n)
+			got := make([][]int, n)
+			var wg sync.WaitGroup
+			for i, size := range tt.buffers {
+				out := make(chan int, size)
+				writeOnly[i] = out
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for v := range out {
+						got[i] = append(got[i], v)
+						if i == tt.slow {
+							time.Sleep(time.Millisecond)
+						}
+					}
+				}()
+			}
+			in := FanOutStrict(context.Background(), writeOnly...)
+			for v := range items {
+				in <- v
+			}
+			close(in)
+			waitOrFail(t, &wg, "readers")
+
+			// k-е значение всегда в выходе k%n
+			for i := range n {
+				var want []int
+				for v := i; v < items; v += n {
+					want = append(want, v)
+				}
+				if !slices.Equal(got[i], want) {
+					t.Errorf("output %d got %v, want %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestFanOutStrictCancel(t *testing.T) {
+	var wg sync.WaitGroup
+	var dropped atomic.Uint64
+	ctx, cancel := context.WithCancel(WithDropCounter(WithWaitGroup(context.Background(), &wg), &dropped))
+	// выходы не читаются, выход 0 вмещает одно значение, выход 1 — пять: 0 и 1 доставлены,
+	// распределитель держит 2 для заполненного выхода 0, а 3 и 4 ждут в буфере входа
+	outputs := []chan int{make(chan int, 1), make(chan int, 5)}
+	in := FanOutStrict(ctx, outputs[0], outputs[1])
+	for v := range 5 {
+		in <- v
+	}
+	cancel()
+	waitOrFail(t, &wg, "distributor")
+
+	// при отмене значения не уходят в свободный выход 1 вне очереди: 2, 3 и 4 отброшены
+	for i, out := range outputs {
+		var got []int
+		for v := range out {
+			got = append(got, v)
+		}
+		if !slices.Equal(got, []int{i}) {
+			t.Errorf("output %d got %v, want [%d]", i, got, i)
+		}
+	}
+	if d := dropped.Load(); d != 3 {
+		t.Errorf("dropped %d, want 3", d)
+	}
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"context"
+	"sync"
+)
+
+// waitGroupKey ключ WaitGroup в контексте (WithWaitGroup)
+type waitGroupKey struct{}
+
+// WithWaitGroup возвращает контекст, с которым горутины функций пакета (FanIn, FanOut, FromSeq,
+// MergeSorted и других) учитываются в wg: после возврата wg.Wait() ни одна из них не работает.
+// Узлы передают такой контекст обработчикам, поэтому пайплайн дожидается и горутин, запущенных
+// функциями пакета внутри обработчиков.
+func WithWaitGroup(ctx context.Context, wg *sync.WaitGroup) context.Context {
+	return context.WithValue(ctx, waitGroupKey{}, wg)
+}
+
+// spawn запускает f в горутине, учитывая её в WaitGroup контекста (WithWaitGroup), если он задан
+func spawn(ctx context.Context, f func()) {
+	wg, _ := ctx.Value(waitGroupKey{}).(*sync.WaitGroup)
+	if wg == nil {
+		go f()
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f()
+	}()
+}